@@ -148,3 +148,95 @@ func TestAzureFullURL(t *testing.T) {
 		})
 	}
 }
+
+func TestAzureRouting(t *testing.T) {
+	cases := []struct {
+		Name   string
+		Style  AzurePathStyle
+		Suffix string
+		Model  string
+		Expect string
+	}{
+		{
+			"Embeddings",
+			AzurePathStyleDeployments,
+			"/embeddings",
+			"text-embedding-3-small",
+			"https://example.com/openai/deployments/text-embedding-3-small/embeddings?api-version=2023-05-15",
+		},
+		{
+			"FineTuningJobEventsWithQuery",
+			AzurePathStyleDeployments,
+			"/fine_tuning/jobs/ftjob-1/events?limit=10",
+			"",
+			"https://example.com/openai/fine_tuning/jobs/ftjob-1/events?limit=10&api-version=2023-05-15",
+		},
+		{
+			"FileContent",
+			AzurePathStyleDeployments,
+			"/files/file-1/content",
+			"",
+			"https://example.com/openai/files/file-1/content?api-version=2023-05-15",
+		},
+		{
+			"Batches",
+			AzurePathStyleDeployments,
+			"/batches",
+			"",
+			"https://example.com/openai/batches?api-version=2023-05-15",
+		},
+		{
+			"VectorStoreFiles",
+			AzurePathStyleDeployments,
+			"/vector_stores/vs-1/files",
+			"",
+			"https://example.com/openai/vector_stores/vs-1/files?api-version=2023-05-15",
+		},
+		{
+			"LegacyFineTunesNotDeploymentScoped",
+			AzurePathStyleDeployments,
+			"/fine-tunes/ft-1/events",
+			"",
+			"https://example.com/openai/fine-tunes/ft-1/events?api-version=2023-05-15",
+		},
+		{
+			"V1ChatCompletions",
+			AzurePathStyleV1,
+			"/chat/completions",
+			"gpt-4o",
+			"https://example.com/openai/v1/chat/completions?api-version=2023-05-15",
+		},
+		{
+			"V1FilesWithQuery",
+			AzurePathStyleV1,
+			"/files?purpose=fine-tune",
+			"",
+			"https://example.com/openai/v1/files?purpose=fine-tune&api-version=2023-05-15",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			config := DefaultAzureConfig("dummy", "https://example.com/")
+			config.AzurePathStyle = c.Style
+			cli := NewClientWithConfig(config)
+			var actual string
+			if c.Model != "" {
+				actual = cli.fullURL(c.Suffix, c.Model)
+			} else {
+				actual = cli.fullURL(c.Suffix)
+			}
+			if actual != c.Expect {
+				t.Errorf("Expected %s, got %s", c.Expect, actual)
+			}
+		})
+	}
+
+	v1 := NewClientWithConfig(DefaultAzureV1Config("dummy", "https://example.com"))
+	if actual := v1.fullURL("/chat/completions", "gpt-4o"); actual != "https://example.com/openai/v1/chat/completions" {
+		t.Errorf("Expected no api-version for the v1 config, got %s", actual)
+	}
+	if route := AzureRouteForPath("/unknown/endpoint"); route != AzureRouteResource {
+		t.Errorf("Expected unknown endpoints to be routed per resource, got %v", route)
+	}
+}
@@ -26,6 +26,7 @@ type Assistant struct {
 	Metadata     map[string]any  `json:"metadata,omitempty"`
 
 	httpHeader
+	rawJSON
 }
 
 type AssistantToolType string
@@ -83,6 +84,7 @@ type AssistantsList struct {
 	FirstID    *string     `json:"first_id"`
 	HasMore    bool        `json:"has_more"`
 	httpHeader
+	rawJSON
 }
 
 type AssistantDeleteResponse struct {
@@ -91,6 +93,7 @@ type AssistantDeleteResponse struct {
 	Deleted bool   `json:"deleted"`
 
 	httpHeader
+	rawJSON
 }
 
 type AssistantFile struct {
@@ -100,6 +103,7 @@ type AssistantFile struct {
 	AssistantID string `json:"assistant_id"`
 
 	httpHeader
+	rawJSON
 }
 
 type AssistantFileRequest struct {
@@ -110,12 +114,17 @@ type AssistantFilesList struct {
 	AssistantFiles []AssistantFile `json:"data"`
 
 	httpHeader
+	rawJSON
 }
 
 // CreateAssistant creates a new assistant.
-func (c *Client) CreateAssistant(ctx context.Context, request AssistantRequest) (response Assistant, err error) {
+func (c *Client) CreateAssistant(
+	ctx context.Context,
+	request AssistantRequest,
+	opts ...RequestOption,
+) (response Assistant, err error) {
 	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL(assistantsSuffix), withBody(request),
-		withBetaAssistantVersion(c.config.AssistantVersion))
+		withBetaAssistantVersion(c.config.AssistantVersion), withRequestOptions(opts))
 	if err != nil {
 		return
 	}
@@ -128,10 +137,11 @@ func (c *Client) CreateAssistant(ctx context.Context, request AssistantRequest)
 func (c *Client) RetrieveAssistant(
 	ctx context.Context,
 	assistantID string,
+	opts ...RequestOption,
 ) (response Assistant, err error) {
 	urlSuffix := fmt.Sprintf("%s/%s", assistantsSuffix, assistantID)
 	req, err := c.newRequest(ctx, http.MethodGet, c.fullURL(urlSuffix),
-		withBetaAssistantVersion(c.config.AssistantVersion))
+		withBetaAssistantVersion(c.config.AssistantVersion), withRequestOptions(opts))
 	if err != nil {
 		return
 	}
@@ -145,10 +155,11 @@ func (c *Client) ModifyAssistant(
 	ctx context.Context,
 	assistantID string,
 	request AssistantRequest,
+	opts ...RequestOption,
 ) (response Assistant, err error) {
 	urlSuffix := fmt.Sprintf("%s/%s", assistantsSuffix, assistantID)
 	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL(urlSuffix), withBody(request),
-		withBetaAssistantVersion(c.config.AssistantVersion))
+		withBetaAssistantVersion(c.config.AssistantVersion), withRequestOptions(opts))
 	if err != nil {
 		return
 	}
@@ -161,10 +172,11 @@ func (c *Client) ModifyAssistant(
 func (c *Client) DeleteAssistant(
 	ctx context.Context,
 	assistantID string,
+	opts ...RequestOption,
 ) (response AssistantDeleteResponse, err error) {
 	urlSuffix := fmt.Sprintf("%s/%s", assistantsSuffix, assistantID)
 	req, err := c.newRequest(ctx, http.MethodDelete, c.fullURL(urlSuffix),
-		withBetaAssistantVersion(c.config.AssistantVersion))
+		withBetaAssistantVersion(c.config.AssistantVersion), withRequestOptions(opts))
 	if err != nil {
 		return
 	}
@@ -180,6 +192,7 @@ func (c *Client) ListAssistants(
 	order *string,
 	after *string,
 	before *string,
+	opts ...RequestOption,
 ) (response AssistantsList, err error) {
 	urlValues := url.Values{}
 	if limit != nil {
@@ -202,7 +215,7 @@ func (c *Client) ListAssistants(
 
 	urlSuffix := fmt.Sprintf("%s%s", assistantsSuffix, encodedValues)
 	req, err := c.newRequest(ctx, http.MethodGet, c.fullURL(urlSuffix),
-		withBetaAssistantVersion(c.config.AssistantVersion))
+		withBetaAssistantVersion(c.config.AssistantVersion), withRequestOptions(opts))
 	if err != nil {
 		return
 	}
@@ -216,10 +229,11 @@ func (c *Client) CreateAssistantFile(
 	ctx context.Context,
 	assistantID string,
 	request AssistantFileRequest,
+	opts ...RequestOption,
 ) (response AssistantFile, err error) {
 	urlSuffix := fmt.Sprintf("%s/%s%s", assistantsSuffix, assistantID, assistantsFilesSuffix)
 	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL(urlSuffix), withBody(request),
-		withBetaAssistantVersion(c.config.AssistantVersion))
+		withBetaAssistantVersion(c.config.AssistantVersion), withRequestOptions(opts))
 	if err != nil {
 		return
 	}
@@ -233,10 +247,11 @@ func (c *Client) RetrieveAssistantFile(
 	ctx context.Context,
 	assistantID string,
 	fileID string,
+	opts ...RequestOption,
 ) (response AssistantFile, err error) {
 	urlSuffix := fmt.Sprintf("%s/%s%s/%s", assistantsSuffix, assistantID, assistantsFilesSuffix, fileID)
 	req, err := c.newRequest(ctx, http.MethodGet, c.fullURL(urlSuffix),
-		withBetaAssistantVersion(c.config.AssistantVersion))
+		withBetaAssistantVersion(c.config.AssistantVersion), withRequestOptions(opts))
 	if err != nil {
 		return
 	}
@@ -250,10 +265,11 @@ func (c *Client) DeleteAssistantFile(
 	ctx context.Context,
 	assistantID string,
 	fileID string,
+	opts ...RequestOption,
 ) (err error) {
 	urlSuffix := fmt.Sprintf("%s/%s%s/%s", assistantsSuffix, assistantID, assistantsFilesSuffix, fileID)
 	req, err := c.newRequest(ctx, http.MethodDelete, c.fullURL(urlSuffix),
-		withBetaAssistantVersion(c.config.AssistantVersion))
+		withBetaAssistantVersion(c.config.AssistantVersion), withRequestOptions(opts))
 	if err != nil {
 		return
 	}
@@ -270,6 +286,7 @@ func (c *Client) ListAssistantFiles(
 	order *string,
 	after *string,
 	before *string,
+	opts ...RequestOption,
 ) (response AssistantFilesList, err error) {
 	urlValues := url.Values{}
 	if limit != nil {
@@ -292,7 +309,7 @@ func (c *Client) ListAssistantFiles(
 
 	urlSuffix := fmt.Sprintf("%s/%s%s%s", assistantsSuffix, assistantID, assistantsFilesSuffix, encodedValues)
 	req, err := c.newRequest(ctx, http.MethodGet, c.fullURL(urlSuffix),
-		withBetaAssistantVersion(c.config.AssistantVersion))
+		withBetaAssistantVersion(c.config.AssistantVersion), withRequestOptions(opts))
 	if err != nil {
 		return
 	}
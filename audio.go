@@ -3,6 +3,7 @@ package openai
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -14,6 +15,10 @@ import (
 // Whisper Defines the models provided by OpenAI to use when processing audio with OpenAI.
 const (
 	Whisper1 = "whisper-1"
+
+	GPT4oTranscribe        = "gpt-4o-transcribe"
+	GPT4oMiniTranscribe    = "gpt-4o-mini-transcribe"
+	GPT4oTranscribeDiarize = "gpt-4o-transcribe-diarize"
 )
 
 // Response formats; Whisper uses AudioResponseFormatJSON by default.
@@ -25,8 +30,59 @@ const (
 	AudioResponseFormatSRT         AudioResponseFormat = "srt"
 	AudioResponseFormatVerboseJSON AudioResponseFormat = "verbose_json"
 	AudioResponseFormatVTT         AudioResponseFormat = "vtt"
+	// AudioResponseFormatDiarizedJSON labels segments with their speakers. Use
+	// CreateDiarizedTranscription to get them; CreateTranscription returns
+	// the text and the segments without their speakers.
+	AudioResponseFormatDiarizedJSON AudioResponseFormat = "diarized_json"
+)
+
+// TranscriptionTimestampGranularity is the level at which a verbose_json
+// transcription is timed.
+type TranscriptionTimestampGranularity string
+
+const (
+	TranscriptionTimestampGranularityWord    TranscriptionTimestampGranularity = "word"
+	TranscriptionTimestampGranularitySegment TranscriptionTimestampGranularity = "segment"
 )
 
+// TranscriptionInclude is additional information to include in a
+// transcription.
+type TranscriptionInclude string
+
+// TranscriptionIncludeLogprobs returns the log probability of each token of a
+// json transcription. Whisper models do not support it.
+const TranscriptionIncludeLogprobs TranscriptionInclude = "logprobs"
+
+type TranscriptionChunkingType string
+
+const (
+	TranscriptionChunkingAuto      TranscriptionChunkingType = "auto"
+	TranscriptionChunkingServerVAD TranscriptionChunkingType = "server_vad"
+)
+
+// TranscriptionChunkingStrategy controls how audio is cut into chunks before
+// it is transcribed. With TranscriptionChunkingAuto the server normalizes the
+// loudness and uses voice activity detection with its own settings; with
+// TranscriptionChunkingServerVAD the settings below apply.
+type TranscriptionChunkingStrategy struct {
+	Type TranscriptionChunkingType `json:"type"`
+	// PrefixPaddingMs is the audio kept before detected speech.
+	PrefixPaddingMs int `json:"prefix_padding_ms,omitempty"`
+	// SilenceDurationMs is the silence that ends a chunk.
+	SilenceDurationMs int `json:"silence_duration_ms,omitempty"`
+	// Threshold is the sensitivity of voice activity detection, from 0 to 1.
+	Threshold float64 `json:"threshold,omitempty"`
+}
+
+// formValue returns the strategy as it is sent in a multipart form.
+func (s TranscriptionChunkingStrategy) formValue() (string, error) {
+	if s.Type == TranscriptionChunkingAuto {
+		return string(s.Type), nil
+	}
+	data, err := json.Marshal(s)
+	return string(data), err
+}
+
 // AudioRequest represents a request structure for audio API.
 // ResponseFormat is not supported for now. We only return JSON text, which may be sufficient.
 type AudioRequest struct {
@@ -42,29 +98,83 @@ type AudioRequest struct {
 	Temperature float32
 	Language    string // For translation, just do not use it. It seems "en" works, not confirmed...
 	Format      AudioResponseFormat
+
+	// TimestampGranularities requires AudioResponseFormatVerboseJSON. Segment
+	// timestamps are returned by default; word timestamps add latency.
+	TimestampGranularities []TranscriptionTimestampGranularity
+	Include                []TranscriptionInclude
+	// ChunkingStrategy is required by diarization of audio longer than 30
+	// seconds.
+	ChunkingStrategy *TranscriptionChunkingStrategy
+	// KnownSpeakerNames and KnownSpeakerReferences, data URLs of short audio
+	// samples of each speaker, let diarization label segments with names.
+	KnownSpeakerNames      []string
+	KnownSpeakerReferences []string
+	// Stream is set by CreateTranscriptionStream.
+	Stream bool
+}
+
+// TranscriptionSegment is a segment of a verbose_json transcription. Times
+// are in seconds.
+type TranscriptionSegment struct {
+	ID               int     `json:"id"`
+	Seek             int     `json:"seek"`
+	Start            float64 `json:"start"`
+	End              float64 `json:"end"`
+	Text             string  `json:"text"`
+	Tokens           []int   `json:"tokens"`
+	Temperature      float64 `json:"temperature"`
+	AvgLogprob       float64 `json:"avg_logprob"`
+	CompressionRatio float64 `json:"compression_ratio"`
+	NoSpeechProb     float64 `json:"no_speech_prob"`
+	Transient        bool    `json:"transient"`
+}
+
+// TranscriptionWord is a word of a verbose_json transcription requested with
+// TranscriptionTimestampGranularityWord. Times are in seconds.
+type TranscriptionWord struct {
+	Word  string  `json:"word"`
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+}
+
+// TranscriptionLogprob is the log probability of a token of a transcription.
+type TranscriptionLogprob struct {
+	Token   string  `json:"token"`
+	Logprob float64 `json:"logprob"`
+	Bytes   []int   `json:"bytes,omitempty"`
+}
+
+// TranscriptionUsage is billed by tokens for gpt-4o transcription models, and
+// by the seconds of audio for Whisper.
+type TranscriptionUsage struct {
+	// Type is "tokens" or "duration".
+	Type              string                          `json:"type"`
+	InputTokens       int                             `json:"input_tokens,omitempty"`
+	InputTokenDetails *TranscriptionInputTokenDetails `json:"input_token_details,omitempty"`
+	OutputTokens      int                             `json:"output_tokens,omitempty"`
+	TotalTokens       int                             `json:"total_tokens,omitempty"`
+	Seconds           float64                         `json:"seconds,omitempty"`
+}
+
+type TranscriptionInputTokenDetails struct {
+	TextTokens  int `json:"text_tokens"`
+	AudioTokens int `json:"audio_tokens"`
 }
 
 // AudioResponse represents a response structure for audio API.
 type AudioResponse struct {
-	Task     string  `json:"task"`
-	Language string  `json:"language"`
-	Duration float64 `json:"duration"`
-	Segments []struct {
-		ID               int     `json:"id"`
-		Seek             int     `json:"seek"`
-		Start            float64 `json:"start"`
-		End              float64 `json:"end"`
-		Text             string  `json:"text"`
-		Tokens           []int   `json:"tokens"`
-		Temperature      float64 `json:"temperature"`
-		AvgLogprob       float64 `json:"avg_logprob"`
-		CompressionRatio float64 `json:"compression_ratio"`
-		NoSpeechProb     float64 `json:"no_speech_prob"`
-		Transient        bool    `json:"transient"`
-	} `json:"segments"`
-	Text string `json:"text"`
+	Task     string                 `json:"task"`
+	Language string                 `json:"language"`
+	Duration float64                `json:"duration"`
+	Segments []TranscriptionSegment `json:"segments"`
+	Words    []TranscriptionWord    `json:"words,omitempty"`
+	Logprobs []TranscriptionLogprob `json:"logprobs,omitempty"`
+	Usage    *TranscriptionUsage    `json:"usage,omitempty"`
+	Text     string                 `json:"text"`
 
 	httpHeader
+	rawJSON
 }
 
 type audioTextResponse struct {
@@ -84,16 +194,18 @@ func (r *audioTextResponse) ToAudioResponse() AudioResponse {
 func (c *Client) CreateTranscription(
 	ctx context.Context,
 	request AudioRequest,
+	opts ...RequestOption,
 ) (response AudioResponse, err error) {
-	return c.callAudioAPI(ctx, request, "transcriptions")
+	return c.callAudioAPI(ctx, request, "transcriptions", opts)
 }
 
 // CreateTranslation — API call to translate audio into English.
 func (c *Client) CreateTranslation(
 	ctx context.Context,
 	request AudioRequest,
+	opts ...RequestOption,
 ) (response AudioResponse, err error) {
-	return c.callAudioAPI(ctx, request, "translations")
+	return c.callAudioAPI(ctx, request, "translations", opts)
 }
 
 // callAudioAPI — API call to an audio endpoint.
@@ -101,24 +213,22 @@ func (c *Client) callAudioAPI(
 	ctx context.Context,
 	request AudioRequest,
 	endpointSuffix string,
+	opts []RequestOption,
 ) (response AudioResponse, err error) {
-	var formBody bytes.Buffer
-	builder := c.createFormBuilder(&formBody)
-
-	if err = audioMultipartForm(request, builder); err != nil {
-		return AudioResponse{}, err
-	}
-
-	urlSuffix := fmt.Sprintf("/audio/%s", endpointSuffix)
-	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL(urlSuffix, request.Model),
-		withBody(&formBody), withContentType(builder.FormDataContentType()))
+	request.Stream = false
+	req, err := c.newAudioRequest(ctx, request, endpointSuffix, opts)
 	if err != nil {
 		return AudioResponse{}, err
 	}
 
-	if request.HasJSONResponse() {
+	switch {
+	case request.Format == AudioResponseFormatDiarizedJSON:
+		var diarized DiarizedTranscription
+		err = c.sendRequest(req, &diarized)
+		response = diarized.ToAudioResponse()
+	case request.HasJSONResponse():
 		err = c.sendRequest(req, &response)
-	} else {
+	default:
 		var textResponse audioTextResponse
 		err = c.sendRequest(req, &textResponse)
 		response = textResponse.ToAudioResponse()
@@ -129,9 +239,32 @@ func (c *Client) callAudioAPI(
 	return
 }
 
+// newAudioRequest builds the multipart request to an audio endpoint.
+func (c *Client) newAudioRequest(
+	ctx context.Context,
+	request AudioRequest,
+	endpointSuffix string,
+	opts []RequestOption,
+) (*http.Request, error) {
+	var formBody bytes.Buffer
+	builder := c.createFormBuilder(&formBody)
+
+	if err := audioMultipartForm(request, builder); err != nil {
+		return nil, err
+	}
+
+	urlSuffix := fmt.Sprintf("/audio/%s", endpointSuffix)
+	return c.newRequest(ctx, http.MethodPost, c.fullURL(urlSuffix, request.Model),
+		withBody(&formBody), withContentType(builder.FormDataContentType()), withRequestOptions(opts))
+}
+
 // HasJSONResponse returns true if the response format is JSON.
 func (r AudioRequest) HasJSONResponse() bool {
-	return r.Format == "" || r.Format == AudioResponseFormatJSON || r.Format == AudioResponseFormatVerboseJSON
+	switch r.Format {
+	case "", AudioResponseFormatJSON, AudioResponseFormatVerboseJSON, AudioResponseFormatDiarizedJSON:
+		return true
+	}
+	return false
 }
 
 // audioMultipartForm creates a form with audio file contents and the name of the model to use for
@@ -179,10 +312,61 @@ func audioMultipartForm(request AudioRequest, b utils.FormBuilder) error {
 		}
 	}
 
+	if err = writeAudioOptionFields(request, b); err != nil {
+		return err
+	}
+
 	// Close the multipart writer
 	return b.Close()
 }
 
+// writeAudioOptionFields writes the form fields of the options only some
+// models support, if provided. Lists are sent as repeated fields named with
+// a trailing [].
+func writeAudioOptionFields(request AudioRequest, b utils.FormBuilder) error {
+	lists := []struct {
+		name   string
+		values []string
+	}{
+		{"timestamp_granularities[]", stringSlice(request.TimestampGranularities)},
+		{"include[]", stringSlice(request.Include)},
+		{"known_speaker_names[]", request.KnownSpeakerNames},
+		{"known_speaker_references[]", request.KnownSpeakerReferences},
+	}
+	for _, list := range lists {
+		for _, value := range list.values {
+			if err := b.WriteField(list.name, value); err != nil {
+				return fmt.Errorf("writing %s: %w", list.name, err)
+			}
+		}
+	}
+
+	if request.ChunkingStrategy != nil {
+		value, err := request.ChunkingStrategy.formValue()
+		if err != nil {
+			return fmt.Errorf("encoding chunking strategy: %w", err)
+		}
+		if err = b.WriteField("chunking_strategy", value); err != nil {
+			return fmt.Errorf("writing chunking strategy: %w", err)
+		}
+	}
+
+	if request.Stream {
+		if err := b.WriteField("stream", "true"); err != nil {
+			return fmt.Errorf("writing stream: %w", err)
+		}
+	}
+	return nil
+}
+
+func stringSlice[T ~string](values []T) []string {
+	strs := make([]string, len(values))
+	for i, v := range values {
+		strs[i] = string(v)
+	}
+	return strs
+}
+
 // createFileField creates the "file" form field from either an existing file or by using the reader.
 func createFileField(request AudioRequest, b utils.FormBuilder) error {
 	if request.Reader != nil {
@@ -26,7 +26,7 @@ func TestAudio(t *testing.T) {
 
 	testcases := []struct {
 		name     string
-		createFn func(context.Context, openai.AudioRequest) (openai.AudioResponse, error)
+		createFn func(context.Context, openai.AudioRequest, ...openai.RequestOption) (openai.AudioResponse, error)
 	}{
 		{
 			"transcribe",
@@ -76,7 +76,7 @@ func TestAudioWithOptionalArgs(t *testing.T) {
 
 	testcases := []struct {
 		name     string
-		createFn func(context.Context, openai.AudioRequest) (openai.AudioResponse, error)
+		createFn func(context.Context, openai.AudioRequest, ...openai.RequestOption) (openai.AudioResponse, error)
 	}{
 		{
 			"transcribe",
@@ -160,3 +160,165 @@ func handleAudioEndpoint(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 }
+
+func TestTranscriptionOptions(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	var form *multipart.Form
+	server.RegisterHandler("/v1/audio/transcriptions", func(w http.ResponseWriter, r *http.Request) {
+		checks.NoError(t, r.ParseMultipartForm(1<<20))
+		form = r.MultipartForm
+		w.Write([]byte(`{"task": "transcribe", "language": "english", "duration": 1.5, "text": "Hello there",
+			"segments": [{"id": 0, "start": 0, "end": 1.5, "text": "Hello there"}],
+			"words": [{"word": "Hello", "start": 0, "end": 0.6}, {"word": "there", "start": 0.7, "end": 1.5}],
+			"usage": {"type": "duration", "seconds": 2}}`))
+	})
+
+	resp, err := client.CreateTranscription(context.Background(), openai.AudioRequest{
+		Model:    openai.Whisper1,
+		FilePath: "hello.wav",
+		Reader:   strings.NewReader("RIFF"),
+		Format:   openai.AudioResponseFormatVerboseJSON,
+		TimestampGranularities: []openai.TranscriptionTimestampGranularity{
+			openai.TranscriptionTimestampGranularityWord,
+			openai.TranscriptionTimestampGranularitySegment,
+		},
+		ChunkingStrategy: &openai.TranscriptionChunkingStrategy{
+			Type:              openai.TranscriptionChunkingServerVAD,
+			SilenceDurationMs: 500,
+		},
+	})
+	checks.NoError(t, err)
+	if granularities := form.Value["timestamp_granularities[]"]; len(granularities) != 2 || granularities[0] != "word" {
+		t.Fatalf("unexpected timestamp granularities %v", granularities)
+	}
+	if strategy := form.Value["chunking_strategy"]; len(strategy) != 1 ||
+		strategy[0] != `{"type":"server_vad","silence_duration_ms":500}` {
+		t.Fatalf("unexpected chunking strategy %v", strategy)
+	}
+	if len(resp.Words) != 2 || resp.Words[1].Word != "there" || resp.Words[1].End != 1.5 {
+		t.Fatalf("unexpected words %+v", resp.Words)
+	}
+	if resp.Segments[0].Text != "Hello there" || resp.Usage.Seconds != 2 {
+		t.Fatalf("unexpected transcription %+v", resp)
+	}
+
+	server.RegisterHandler("/v1/audio/transcriptions", func(w http.ResponseWriter, r *http.Request) {
+		checks.NoError(t, r.ParseMultipartForm(1<<20))
+		form = r.MultipartForm
+		w.Write([]byte(`{"text": "Hi", "logprobs": [{"token": "Hi", "logprob": -0.25, "bytes": [72, 105]}],
+			"usage": {"type": "tokens", "input_tokens": 10, "input_token_details": {"audio_tokens": 10},
+			"output_tokens": 1, "total_tokens": 11}}`))
+	})
+	resp, err = client.CreateTranscription(context.Background(), openai.AudioRequest{
+		Model:            openai.GPT4oMiniTranscribe,
+		FilePath:         "hi.wav",
+		Reader:           strings.NewReader("RIFF"),
+		Include:          []openai.TranscriptionInclude{openai.TranscriptionIncludeLogprobs},
+		ChunkingStrategy: &openai.TranscriptionChunkingStrategy{Type: openai.TranscriptionChunkingAuto},
+	})
+	checks.NoError(t, err)
+	if form.Value["include[]"][0] != "logprobs" || form.Value["chunking_strategy"][0] != "auto" {
+		t.Fatalf("unexpected form %v", form.Value)
+	}
+	if len(resp.Logprobs) != 1 || resp.Logprobs[0].Logprob != -0.25 || resp.Usage.InputTokenDetails.AudioTokens != 10 {
+		t.Fatalf("unexpected transcription %+v", resp)
+	}
+}
+
+func TestDiarizedTranscription(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	var form *multipart.Form
+	server.RegisterHandler("/v1/audio/transcriptions", func(w http.ResponseWriter, r *http.Request) {
+		checks.NoError(t, r.ParseMultipartForm(1<<20))
+		form = r.MultipartForm
+		w.Write([]byte(`{"task": "transcribe", "duration": 4, "text": "Hi. Hello. Bye.", "segments": [
+			{"type": "transcript.text.segment", "id": "seg_0", "start": 0, "end": 1, "text": "Hi.", "speaker": "agent"},
+			{"type": "transcript.text.segment", "id": "seg_1", "start": 1, "end": 3, "text": "Hello.", "speaker": "A"},
+			{"type": "transcript.text.segment", "id": "seg_2", "start": 3, "end": 4, "text": "Bye.", "speaker": "agent"}
+		]}`))
+	})
+	request := openai.AudioRequest{
+		Model:                  openai.GPT4oTranscribeDiarize,
+		FilePath:               "call.wav",
+		Reader:                 strings.NewReader("RIFF"),
+		KnownSpeakerNames:      []string{"agent"},
+		KnownSpeakerReferences: []string{"data:audio/wav;base64,UklGRg=="},
+	}
+
+	transcription, err := client.CreateDiarizedTranscription(context.Background(), request)
+	checks.NoError(t, err)
+	if form.Value["response_format"][0] != "diarized_json" || form.Value["known_speaker_names[]"][0] != "agent" {
+		t.Fatalf("unexpected form %v", form.Value)
+	}
+	if speakers := transcription.Speakers(); len(speakers) != 2 || speakers[0] != "agent" || speakers[1] != "A" {
+		t.Fatalf("unexpected speakers %v", speakers)
+	}
+	if segment := transcription.Segments[1]; segment.ID != "seg_1" || segment.End != 3 {
+		t.Fatalf("unexpected segment %+v", segment)
+	}
+
+	request.Reader = strings.NewReader("RIFF")
+	request.Format = openai.AudioResponseFormatDiarizedJSON
+	resp, err := client.CreateTranscription(context.Background(), request)
+	checks.NoError(t, err)
+	if resp.Text != "Hi. Hello. Bye." || resp.Duration != 4 || len(resp.Segments) != 3 {
+		t.Fatalf("unexpected transcription %+v", resp)
+	}
+	if segment := resp.Segments[1]; segment.ID != 1 || segment.Start != 1 || segment.End != 3 || segment.Text != "Hello." {
+		t.Fatalf("unexpected segment %+v", segment)
+	}
+}
+
+func TestCreateTranscriptionStream(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	var form *multipart.Form
+	server.RegisterHandler("/v1/audio/transcriptions", func(w http.ResponseWriter, r *http.Request) {
+		checks.NoError(t, r.ParseMultipartForm(1<<20))
+		form = r.MultipartForm
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, event := range []string{
+			`{"type": "transcript.text.delta", "delta": "Hello", "logprobs": [{"token": "Hello", "logprob": -0.1}]}`,
+			`{"type": "transcript.text.delta", "delta": " there"}`,
+			`{"type": "transcript.text.done", "text": "Hello there.", "usage": {"type": "tokens", "total_tokens": 7}}`,
+		} {
+			w.Write([]byte("data: " + event + "\n\n"))
+		}
+	})
+	ctx := context.Background()
+
+	_, err := client.CreateTranscriptionStream(ctx, openai.AudioRequest{Model: openai.Whisper1})
+	checks.ErrorIs(t, err, openai.ErrTranscriptionStreamUnsupported)
+
+	stream, err := client.CreateTranscriptionStream(ctx, openai.AudioRequest{
+		Model:    openai.GPT4oTranscribe,
+		FilePath: "hello.wav",
+		Reader:   strings.NewReader("RIFF"),
+		Include:  []openai.TranscriptionInclude{openai.TranscriptionIncludeLogprobs},
+	})
+	checks.NoError(t, err)
+	defer stream.Close()
+
+	var deltas []string
+	for {
+		event, recvErr := stream.Recv()
+		if errors.Is(recvErr, io.EOF) {
+			break
+		}
+		checks.NoError(t, recvErr)
+		if event.Type == openai.TranscriptionStreamEventTextDelta {
+			deltas = append(deltas, event.Delta)
+			if !stream.Done() && !strings.HasSuffix(stream.Text(), event.Delta) {
+				t.Fatalf("expected the text so far to end with the delta, got %q", stream.Text())
+			}
+		}
+	}
+	if form.Value["stream"][0] != "true" || len(deltas) != 2 {
+		t.Fatalf("unexpected form %v or deltas %v", form.Value, deltas)
+	}
+	if !stream.Done() || stream.Text() != "Hello there." || stream.Usage().TotalTokens != 7 {
+		t.Fatalf("unexpected transcription %q %+v", stream.Text(), stream.Usage())
+	}
+}
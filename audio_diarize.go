@@ -0,0 +1,76 @@
+package openai
+
+import "context"
+
+// DiarizedSegment is a segment of a diarized transcription, spoken by one
+// speaker. Times are in seconds.
+type DiarizedSegment struct {
+	ID    string  `json:"id"`
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Text  string  `json:"text"`
+	// Speaker is a name from AudioRequest.KnownSpeakerNames, or a label such
+	// as "A" for speakers that are not known.
+	Speaker string `json:"speaker"`
+}
+
+// DiarizedTranscription is a transcription in the diarized_json format.
+type DiarizedTranscription struct {
+	Task     string              `json:"task"`
+	Duration float64             `json:"duration"`
+	Text     string              `json:"text"`
+	Segments []DiarizedSegment   `json:"segments"`
+	Usage    *TranscriptionUsage `json:"usage,omitempty"`
+
+	httpHeader
+	rawJSON
+}
+
+// Speakers returns the speakers in the order they first speak.
+func (t DiarizedTranscription) Speakers() []string {
+	var speakers []string
+	seen := make(map[string]bool)
+	for _, segment := range t.Segments {
+		if !seen[segment.Speaker] {
+			seen[segment.Speaker] = true
+			speakers = append(speakers, segment.Speaker)
+		}
+	}
+	return speakers
+}
+
+// ToAudioResponse returns the transcription without its speakers. Segment IDs
+// become their position in the transcription, since AudioResponse numbers
+// its segments.
+func (t DiarizedTranscription) ToAudioResponse() AudioResponse {
+	segments := make([]TranscriptionSegment, len(t.Segments))
+	for i, segment := range t.Segments {
+		segments[i] = TranscriptionSegment{ID: i, Start: segment.Start, End: segment.End, Text: segment.Text}
+	}
+	return AudioResponse{
+		Task:       t.Task,
+		Duration:   t.Duration,
+		Segments:   segments,
+		Text:       t.Text,
+		Usage:      t.Usage,
+		httpHeader: t.httpHeader,
+	}
+}
+
+// CreateDiarizedTranscription — API call to transcribe audio with the
+// segments labelled by who speaks them. The model must support diarization,
+// e.g. GPT4oTranscribeDiarize.
+func (c *Client) CreateDiarizedTranscription(
+	ctx context.Context,
+	request AudioRequest,
+	opts ...RequestOption,
+) (response DiarizedTranscription, err error) {
+	request.Format = AudioResponseFormatDiarizedJSON
+	request.Stream = false
+	req, err := c.newAudioRequest(ctx, request, "transcriptions", opts)
+	if err != nil {
+		return
+	}
+	err = c.sendRequest(req, &response)
+	return
+}
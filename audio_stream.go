@@ -0,0 +1,116 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+var ErrTranscriptionStreamUnsupported = errors.New("whisper-1 cannot stream transcriptions")
+
+// Types of TranscriptionStreamEvent.
+const (
+	TranscriptionStreamEventTextDelta = "transcript.text.delta"
+	TranscriptionStreamEventTextDone  = "transcript.text.done"
+	// TranscriptionStreamEventTextSegment is sent by diarization models when
+	// a speaker's segment is complete.
+	TranscriptionStreamEventTextSegment = "transcript.text.segment"
+)
+
+// TranscriptionStreamEvent is an event of a transcription stream.
+type TranscriptionStreamEvent struct {
+	Type string `json:"type"`
+	// Delta is the text added by a transcript.text.delta event.
+	Delta string `json:"delta,omitempty"`
+	// Text is the whole transcription in a transcript.text.done event, or the
+	// text of a segment.
+	Text string `json:"text,omitempty"`
+	// Logprobs are set if they were requested with
+	// TranscriptionIncludeLogprobs.
+	Logprobs []TranscriptionLogprob `json:"logprobs,omitempty"`
+	// Usage is set by the transcript.text.done event.
+	Usage *TranscriptionUsage `json:"usage,omitempty"`
+
+	// ID, Start, End and Speaker are set by transcript.text.segment events.
+	ID      string  `json:"id,omitempty"`
+	Start   float64 `json:"start,omitempty"`
+	End     float64 `json:"end,omitempty"`
+	Speaker string  `json:"speaker,omitempty"`
+}
+
+// Segment returns the segment of a transcript.text.segment event.
+func (e TranscriptionStreamEvent) Segment() DiarizedSegment {
+	return DiarizedSegment{ID: e.ID, Start: e.Start, End: e.End, Text: e.Text, Speaker: e.Speaker}
+}
+
+// TranscriptionStream is a transcription streamed as server-sent events.
+type TranscriptionStream struct {
+	*streamReader[TranscriptionStreamEvent]
+
+	text  strings.Builder
+	done  bool
+	usage *TranscriptionUsage
+}
+
+// CreateTranscriptionStream — API call to transcribe audio and stream the
+// text as it is transcribed. Whisper1 does not support streaming, and
+// Format must be AudioResponseFormatJSON or AudioResponseFormatText, if set.
+func (c *Client) CreateTranscriptionStream(
+	ctx context.Context,
+	request AudioRequest,
+	opts ...RequestOption,
+) (stream *TranscriptionStream, err error) {
+	if request.Model == Whisper1 {
+		err = ErrTranscriptionStreamUnsupported
+		return
+	}
+	request.Stream = true
+	req, err := c.newAudioRequest(ctx, request, "transcriptions", opts)
+	if err != nil {
+		return
+	}
+
+	resp, err := sendRequestStream[TranscriptionStreamEvent](c, req)
+	if err != nil {
+		return
+	}
+	resp.timer.timeouts, _ = c.streamOptions(opts)
+	stream = &TranscriptionStream{
+		streamReader: resp,
+	}
+	return
+}
+
+// Recv returns the next event, or io.EOF after the last one.
+func (stream *TranscriptionStream) Recv() (event TranscriptionStreamEvent, err error) {
+	event, err = stream.streamReader.Recv()
+	if err != nil {
+		return
+	}
+	switch event.Type {
+	case TranscriptionStreamEventTextDelta:
+		stream.text.WriteString(event.Delta)
+	case TranscriptionStreamEventTextDone:
+		stream.text.Reset()
+		stream.text.WriteString(event.Text)
+		stream.done = true
+		stream.usage = event.Usage
+	}
+	return
+}
+
+// Text returns the text transcribed so far, or the whole transcription once
+// the transcript.text.done event has been received.
+func (stream *TranscriptionStream) Text() string {
+	return stream.text.String()
+}
+
+// Done reports whether the transcript.text.done event has been received.
+func (stream *TranscriptionStream) Done() bool {
+	return stream.done
+}
+
+// Usage returns the usage once the transcription is done, or nil.
+func (stream *TranscriptionStream) Usage() *TranscriptionUsage {
+	return stream.usage
+}
@@ -24,6 +24,13 @@ func TestAudioWithFailingFormBuilder(t *testing.T) {
 		Temperature: 0.5,
 		Language:    "en",
 		Format:      AudioResponseFormatSRT,
+
+		TimestampGranularities: []TranscriptionTimestampGranularity{TranscriptionTimestampGranularityWord},
+		Include:                []TranscriptionInclude{TranscriptionIncludeLogprobs},
+		ChunkingStrategy:       &TranscriptionChunkingStrategy{Type: TranscriptionChunkingAuto},
+		KnownSpeakerNames:      []string{"agent"},
+		KnownSpeakerReferences: []string{"data:audio/wav;base64,UklGRg=="},
+		Stream:                 true,
 	}
 
 	mockFailedErr := fmt.Errorf("mock form builder fail")
@@ -47,7 +54,11 @@ func TestAudioWithFailingFormBuilder(t *testing.T) {
 		return nil
 	}
 
-	failOn := []string{"model", "prompt", "temperature", "language", "response_format"}
+	failOn := []string{
+		"model", "prompt", "temperature", "language", "response_format",
+		"timestamp_granularities[]", "include[]", "known_speaker_names[]", "known_speaker_references[]",
+		"chunking_strategy", "stream",
+	}
 	for _, failingField := range failOn {
 		failForField = failingField
 		mockFailedErr = fmt.Errorf("mock form builder fail on field %s", failingField)
@@ -0,0 +1,116 @@
+package openai
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// AzurePathStyle selects how Azure OpenAI request URLs are built.
+type AzurePathStyle string
+
+const (
+	// AzurePathStyleDeployments is the dated API scheme. Model endpoints are
+	// served under /openai/deployments/{deployment}, everything else under
+	// /openai, and every request carries ?api-version=ClientConfig.APIVersion.
+	AzurePathStyleDeployments AzurePathStyle = ""
+	// AzurePathStyleV1 is the v1 GA scheme, where every endpoint is served under
+	// /openai/v1 as on api.openai.com and the deployment is selected by the
+	// model field of the request body. api-version is only sent when
+	// ClientConfig.APIVersion is set, e.g. to "preview".
+	AzurePathStyleV1 AzurePathStyle = "v1"
+)
+
+const azureV1Prefix = "v1"
+
+// AzureRoute says whether Azure serves an endpoint per deployment or per
+// resource in the dated API scheme.
+type AzureRoute int
+
+const (
+	// AzureRouteResource endpoints are served at /openai/{path}.
+	AzureRouteResource AzureRoute = iota
+	// AzureRouteDeployment endpoints are served at
+	// /openai/deployments/{deployment}/{path}.
+	AzureRouteDeployment
+)
+
+// azureRoutes maps the first path segments of every endpoint to its Azure
+// route. Paths are matched by the longest prefix ending at a segment boundary.
+var azureRoutes = map[string]AzureRoute{
+	"/chat/completions":     AzureRouteDeployment,
+	"/completions":          AzureRouteDeployment,
+	"/embeddings":           AzureRouteDeployment,
+	"/edits":                AzureRouteDeployment,
+	"/moderations":          AzureRouteDeployment,
+	"/images/generations":   AzureRouteDeployment,
+	"/images/edits":         AzureRouteDeployment,
+	"/images/variations":    AzureRouteDeployment,
+	"/audio/speech":         AzureRouteDeployment,
+	"/audio/transcriptions": AzureRouteDeployment,
+	"/audio/translations":   AzureRouteDeployment,
+	"/engines":              AzureRouteDeployment,
+
+	"/models":        AzureRouteResource,
+	"/files":         AzureRouteResource,
+	"/fine_tuning":   AzureRouteResource,
+	"/fine-tunes":    AzureRouteResource,
+	"/assistants":    AzureRouteResource,
+	"/threads":       AzureRouteResource,
+	"/batches":       AzureRouteResource,
+	"/vector_stores": AzureRouteResource,
+	"/uploads":       AzureRouteResource,
+	"/responses":     AzureRouteResource,
+}
+
+// AzureRouteForPath returns the Azure route of an API path such as
+// "/chat/completions" or "/files/file-abc/content". Unknown paths are routed
+// per resource.
+func AzureRouteForPath(path string) AzureRoute {
+	for prefix := path; prefix != ""; {
+		if route, ok := azureRoutes[prefix]; ok {
+			return route
+		}
+		idx := strings.LastIndexByte(prefix, '/')
+		if idx < 0 {
+			break
+		}
+		prefix = prefix[:idx]
+	}
+	return AzureRouteResource
+}
+
+// azureFullURL builds the Azure URL for an API suffix, which may carry its
+// own query string.
+func (c *Client) azureFullURL(suffix string, args ...any) string {
+	baseURL := strings.TrimRight(c.config.BaseURL, "/")
+	path, rawQuery, _ := strings.Cut(suffix, "?")
+
+	var prefix string
+	switch {
+	case c.config.AzurePathStyle == AzurePathStyleV1:
+		prefix = fmt.Sprintf("%s/%s/%s", baseURL, azureAPIPrefix, azureV1Prefix)
+	case AzureRouteForPath(path) == AzureRouteDeployment:
+		azureDeploymentName := "UNKNOWN"
+		if len(args) > 0 {
+			model, ok := args[0].(string)
+			if ok {
+				azureDeploymentName = c.config.GetAzureDeploymentByModel(model)
+			}
+		}
+		prefix = fmt.Sprintf("%s/%s/%s/%s", baseURL, azureAPIPrefix, azureDeploymentsPrefix, azureDeploymentName)
+	default:
+		prefix = fmt.Sprintf("%s/%s", baseURL, azureAPIPrefix)
+	}
+
+	if c.config.APIVersion != "" {
+		if rawQuery != "" {
+			rawQuery += "&"
+		}
+		rawQuery += "api-version=" + url.QueryEscape(c.config.APIVersion)
+	}
+	if rawQuery == "" {
+		return prefix + path
+	}
+	return prefix + path + "?" + rawQuery
+}
@@ -0,0 +1,498 @@
+package openai
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1" //nolint:gosec // x5t is defined as the SHA-1 thumbprint of the certificate
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// AzureCognitiveServicesScope is the OAuth scope for Azure OpenAI.
+	AzureCognitiveServicesScope = "https://cognitiveservices.azure.com/.default"
+	// AzurePublicCloudAuthorityHost is the Microsoft Entra ID authority for the
+	// public cloud.
+	AzurePublicCloudAuthorityHost = "https://login.microsoftonline.com/"
+
+	azureIMDSEndpoint   = "http://169.254.169.254/metadata/identity/oauth2/token"
+	azureIMDSAPIVersion = "2018-02-01"
+	azureJWTBearerType  = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+	azureAssertionTTL   = 10 * time.Minute
+
+	defaultTokenRefreshBefore = 5 * time.Minute
+	// tokenFetchTimeout bounds a token fetch, which runs on its own context
+	// since several callers may be waiting for it.
+	tokenFetchTimeout = time.Minute
+	// tokenRefreshRetryInterval is how long a failed background refresh waits
+	// before the next one, while the current token is still valid.
+	tokenRefreshRetryInterval = 10 * time.Second
+)
+
+var (
+	ErrAzureTokenRequest         = errors.New("azure token request failed")
+	ErrAzureCredentialIncomplete = errors.New("azure credential is missing required settings")
+	ErrUnsupportedPrivateKey     = errors.New("certificate private key must be an RSA key")
+)
+
+// AccessToken is an OAuth access token and its expiry.
+type AccessToken struct {
+	Token     string
+	ExpiresOn time.Time
+}
+
+// TokenSource fetches a fresh access token on every call.
+type TokenSource interface {
+	FetchToken(ctx context.Context) (AccessToken, error)
+}
+
+// CachedTokenProvider caches the tokens of a TokenSource. Once a token is
+// within the refresh window of expiring, callers keep receiving it while a single
+// background refresh fetches its replacement; callers only block when there is
+// no unexpired token. A failed background refresh is retried after ten
+// seconds at the earliest.
+type CachedTokenProvider struct {
+	source        TokenSource
+	refreshBefore time.Duration
+	now           func() time.Time
+
+	mu         sync.Mutex
+	token      AccessToken
+	refreshAt  time.Time
+	refreshing bool
+	fetching   *tokenFetch
+}
+
+// tokenFetch is a fetch that callers without an unexpired token wait for.
+// token and err are set before done is closed.
+type tokenFetch struct {
+	done  chan struct{}
+	token AccessToken
+	err   error
+}
+
+// NewCachedTokenProvider caches tokens from source, refreshing them
+// refreshBefore their expiry, but no earlier than halfway through their
+// lifetime. A zero refreshBefore defaults to five minutes.
+func NewCachedTokenProvider(source TokenSource, refreshBefore time.Duration) *CachedTokenProvider {
+	if refreshBefore <= 0 {
+		refreshBefore = defaultTokenRefreshBefore
+	}
+	return &CachedTokenProvider{source: source, refreshBefore: refreshBefore, now: time.Now}
+}
+
+func (p *CachedTokenProvider) Token(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	now := p.now()
+	if p.token.Token != "" && now.Before(p.token.ExpiresOn) {
+		token := p.token.Token
+		if !p.refreshing && p.fetching == nil && !now.Before(p.refreshAt) {
+			p.refreshing = true
+			go p.refresh()
+		}
+		p.mu.Unlock()
+		return token, nil
+	}
+
+	// The fetch is shared, so it must not be canceled with the caller that
+	// happened to start it.
+	if p.fetching == nil {
+		p.fetching = &tokenFetch{done: make(chan struct{})}
+		go p.fetch(p.fetching)
+	}
+	fetching := p.fetching
+	p.mu.Unlock()
+
+	select {
+	case <-fetching.done:
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+	if fetching.err != nil {
+		return "", fetching.err
+	}
+	return fetching.token.Token, nil
+}
+
+// fetch fetches a token for the callers waiting on f.
+func (p *CachedTokenProvider) fetch(f *tokenFetch) {
+	ctx, cancel := context.WithTimeout(context.Background(), tokenFetchTimeout)
+	defer cancel()
+	f.token, f.err = p.source.FetchToken(ctx)
+
+	p.mu.Lock()
+	if f.err == nil {
+		p.setToken(f.token)
+	}
+	p.fetching = nil
+	p.mu.Unlock()
+	close(f.done)
+}
+
+// refresh replaces the cached token in the background. A failed refresh keeps
+// the current token; a call after tokenRefreshRetryInterval retries.
+func (p *CachedTokenProvider) refresh() {
+	ctx, cancel := context.WithTimeout(context.Background(), tokenFetchTimeout)
+	defer cancel()
+	token, err := p.source.FetchToken(ctx)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err == nil {
+		p.setToken(token)
+	} else {
+		p.refreshAt = p.now().Add(tokenRefreshRetryInterval)
+	}
+	p.refreshing = false
+}
+
+// setToken caches token. The refresh lead is capped at half the token's
+// lifetime, so a short-lived token is not refreshed on every call. It must be
+// called with p.mu held.
+func (p *CachedTokenProvider) setToken(token AccessToken) {
+	lead := p.refreshBefore
+	if half := token.ExpiresOn.Sub(p.now()) / 2; lead > half {
+		lead = half
+	}
+	p.token = token
+	p.refreshAt = token.ExpiresOn.Add(-lead)
+}
+
+// ClientSecretCredential authenticates a Microsoft Entra ID application with a
+// client secret using the OAuth client credentials flow.
+type ClientSecretCredential struct {
+	TenantID     string
+	ClientID     string
+	ClientSecret string
+	// Scope defaults to AzureCognitiveServicesScope.
+	Scope string
+	// AuthorityHost defaults to AzurePublicCloudAuthorityHost.
+	AuthorityHost string
+	HTTPClient    *http.Client
+}
+
+func (c ClientSecretCredential) FetchToken(ctx context.Context) (AccessToken, error) {
+	if c.TenantID == "" || c.ClientID == "" || c.ClientSecret == "" {
+		return AccessToken{}, ErrAzureCredentialIncomplete
+	}
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {c.ClientID},
+		"client_secret": {c.ClientSecret},
+		"scope":         {scopeOrDefault(c.Scope)},
+	}
+	return requestEntraToken(ctx, c.HTTPClient, entraTokenURL(c.AuthorityHost, c.TenantID), form)
+}
+
+// ClientCertificateCredential authenticates a Microsoft Entra ID application
+// with a certificate, sending a signed client assertion in the client
+// credentials flow. Use ParseCertificatePEM to load a PEM bundle.
+type ClientCertificateCredential struct {
+	TenantID    string
+	ClientID    string
+	Certificate *x509.Certificate
+	PrivateKey  *rsa.PrivateKey
+	// Scope defaults to AzureCognitiveServicesScope.
+	Scope string
+	// AuthorityHost defaults to AzurePublicCloudAuthorityHost.
+	AuthorityHost string
+	HTTPClient    *http.Client
+}
+
+func (c ClientCertificateCredential) FetchToken(ctx context.Context) (AccessToken, error) {
+	if c.TenantID == "" || c.ClientID == "" || c.Certificate == nil || c.PrivateKey == nil {
+		return AccessToken{}, ErrAzureCredentialIncomplete
+	}
+	tokenURL := entraTokenURL(c.AuthorityHost, c.TenantID)
+	assertion, err := c.clientAssertion(tokenURL, time.Now())
+	if err != nil {
+		return AccessToken{}, err
+	}
+	form := url.Values{
+		"grant_type":            {"client_credentials"},
+		"client_id":             {c.ClientID},
+		"client_assertion_type": {azureJWTBearerType},
+		"client_assertion":      {assertion},
+		"scope":                 {scopeOrDefault(c.Scope)},
+	}
+	return requestEntraToken(ctx, c.HTTPClient, tokenURL, form)
+}
+
+// clientAssertion builds the RS256 JWT described at
+// https://learn.microsoft.com/en-us/entra/identity-platform/certificate-credentials
+func (c ClientCertificateCredential) clientAssertion(audience string, now time.Time) (string, error) {
+	thumbprint := sha1.Sum(c.Certificate.Raw) //nolint:gosec // required by the x5t header
+	header, err := json.Marshal(map[string]string{
+		"alg": "RS256",
+		"typ": "JWT",
+		"x5t": base64.RawURLEncoding.EncodeToString(thumbprint[:]),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	jti := make([]byte, 16)
+	if _, err = rand.Read(jti); err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]any{
+		"aud": audience,
+		"iss": c.ClientID,
+		"sub": c.ClientID,
+		"jti": hex.EncodeToString(jti),
+		"iat": now.Unix(),
+		"nbf": now.Unix(),
+		"exp": now.Add(azureAssertionTTL).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, c.PrivateKey, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// ParseCertificatePEM returns the first certificate and RSA private key in a
+// PEM bundle, as exported for Entra ID certificate credentials.
+func ParseCertificatePEM(data []byte) (*x509.Certificate, *rsa.PrivateKey, error) {
+	var (
+		cert *x509.Certificate
+		key  *rsa.PrivateKey
+	)
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		switch block.Type {
+		case "CERTIFICATE":
+			if cert != nil {
+				continue
+			}
+			parsed, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, nil, err
+			}
+			cert = parsed
+		case "RSA PRIVATE KEY":
+			parsed, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+			if err != nil {
+				return nil, nil, err
+			}
+			key = parsed
+		case "PRIVATE KEY":
+			parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+			if err != nil {
+				return nil, nil, err
+			}
+			rsaKey, ok := parsed.(*rsa.PrivateKey)
+			if !ok {
+				return nil, nil, ErrUnsupportedPrivateKey
+			}
+			key = rsaKey
+		}
+	}
+	if cert == nil || key == nil {
+		return nil, nil, fmt.Errorf("%w: PEM data must contain a certificate and a private key",
+			ErrAzureCredentialIncomplete)
+	}
+	return cert, key, nil
+}
+
+// WorkloadIdentityCredential authenticates with a federated token, such as the
+// service account token projected by Azure Workload Identity on Kubernetes.
+// The token file is re-read on every fetch because it is rotated.
+type WorkloadIdentityCredential struct {
+	TenantID      string
+	ClientID      string
+	TokenFilePath string
+	// Scope defaults to AzureCognitiveServicesScope.
+	Scope string
+	// AuthorityHost defaults to AzurePublicCloudAuthorityHost.
+	AuthorityHost string
+	HTTPClient    *http.Client
+}
+
+// NewWorkloadIdentityCredentialFromEnv reads the settings injected by the
+// Azure Workload Identity webhook: AZURE_TENANT_ID, AZURE_CLIENT_ID,
+// AZURE_FEDERATED_TOKEN_FILE and optionally AZURE_AUTHORITY_HOST.
+func NewWorkloadIdentityCredentialFromEnv() (WorkloadIdentityCredential, error) {
+	c := WorkloadIdentityCredential{
+		TenantID:      os.Getenv("AZURE_TENANT_ID"),
+		ClientID:      os.Getenv("AZURE_CLIENT_ID"),
+		TokenFilePath: os.Getenv("AZURE_FEDERATED_TOKEN_FILE"),
+		AuthorityHost: os.Getenv("AZURE_AUTHORITY_HOST"),
+	}
+	if c.TenantID == "" || c.ClientID == "" || c.TokenFilePath == "" {
+		return WorkloadIdentityCredential{}, ErrAzureCredentialIncomplete
+	}
+	return c, nil
+}
+
+func (c WorkloadIdentityCredential) FetchToken(ctx context.Context) (AccessToken, error) {
+	if c.TenantID == "" || c.ClientID == "" || c.TokenFilePath == "" {
+		return AccessToken{}, ErrAzureCredentialIncomplete
+	}
+	assertion, err := os.ReadFile(c.TokenFilePath)
+	if err != nil {
+		return AccessToken{}, fmt.Errorf("reading federated token: %w", err)
+	}
+	form := url.Values{
+		"grant_type":            {"client_credentials"},
+		"client_id":             {c.ClientID},
+		"client_assertion_type": {azureJWTBearerType},
+		"client_assertion":      {strings.TrimSpace(string(assertion))},
+		"scope":                 {scopeOrDefault(c.Scope)},
+	}
+	return requestEntraToken(ctx, c.HTTPClient, entraTokenURL(c.AuthorityHost, c.TenantID), form)
+}
+
+// ManagedIdentityCredential fetches tokens for the managed identity of the
+// Azure VM, container or App Service the process runs on from the instance
+// metadata service (IMDS).
+type ManagedIdentityCredential struct {
+	// ClientID selects a user-assigned identity. Empty uses the system-assigned
+	// identity.
+	ClientID string
+	// Resource defaults to the resource of AzureCognitiveServicesScope.
+	Resource string
+	// Endpoint defaults to the IMDS token endpoint.
+	Endpoint   string
+	HTTPClient *http.Client
+}
+
+func (c ManagedIdentityCredential) FetchToken(ctx context.Context) (AccessToken, error) {
+	endpoint := c.Endpoint
+	if endpoint == "" {
+		endpoint = azureIMDSEndpoint
+	}
+	resource := c.Resource
+	if resource == "" {
+		resource = strings.TrimSuffix(AzureCognitiveServicesScope, ".default")
+	}
+	query := url.Values{
+		"api-version": {azureIMDSAPIVersion},
+		"resource":    {resource},
+	}
+	if c.ClientID != "" {
+		query.Set("client_id", c.ClientID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"?"+query.Encode(), nil)
+	if err != nil {
+		return AccessToken{}, err
+	}
+	req.Header.Set("Metadata", "true")
+	return doEntraTokenRequest(httpClientOrDefault(c.HTTPClient), req)
+}
+
+// entraTokenResponse covers both the Entra ID v2 token endpoint, which returns
+// expires_in as a number, and IMDS, which returns expires_in and expires_on as
+// strings.
+type entraTokenResponse struct {
+	AccessToken      string          `json:"access_token"`
+	ExpiresIn        json.RawMessage `json:"expires_in"`
+	ExpiresOn        json.RawMessage `json:"expires_on"`
+	Error            string          `json:"error"`
+	ErrorDescription string          `json:"error_description"`
+}
+
+func (r entraTokenResponse) expiry(now time.Time) (time.Time, error) {
+	if seconds, ok := parseJSONInt(r.ExpiresOn); ok {
+		return time.Unix(seconds, 0), nil
+	}
+	if seconds, ok := parseJSONInt(r.ExpiresIn); ok {
+		return now.Add(time.Duration(seconds) * time.Second), nil
+	}
+	return time.Time{}, fmt.Errorf("%w: response has no token expiry", ErrAzureTokenRequest)
+}
+
+// parseJSONInt parses a JSON number or a string containing one.
+func parseJSONInt(raw json.RawMessage) (int64, bool) {
+	s := strings.Trim(string(raw), `"`)
+	if s == "" {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	return n, err == nil
+}
+
+func requestEntraToken(
+	ctx context.Context,
+	client *http.Client,
+	tokenURL string,
+	form url.Values,
+) (AccessToken, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return AccessToken{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return doEntraTokenRequest(httpClientOrDefault(client), req)
+}
+
+func doEntraTokenRequest(client *http.Client, req *http.Request) (AccessToken, error) {
+	req.Header.Set("Accept", "application/json")
+	now := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return AccessToken{}, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return AccessToken{}, err
+	}
+	var token entraTokenResponse
+	if err = json.Unmarshal(body, &token); err != nil {
+		return AccessToken{}, fmt.Errorf("%w: status %d: %s", ErrAzureTokenRequest, resp.StatusCode, body)
+	}
+	if resp.StatusCode != http.StatusOK || token.AccessToken == "" {
+		return AccessToken{}, fmt.Errorf("%w: status %d: %s: %s",
+			ErrAzureTokenRequest, resp.StatusCode, token.Error, token.ErrorDescription)
+	}
+	expiresOn, err := token.expiry(now)
+	if err != nil {
+		return AccessToken{}, err
+	}
+	return AccessToken{Token: token.AccessToken, ExpiresOn: expiresOn}, nil
+}
+
+func entraTokenURL(authorityHost, tenantID string) string {
+	if authorityHost == "" {
+		authorityHost = AzurePublicCloudAuthorityHost
+	}
+	return strings.TrimSuffix(authorityHost, "/") + "/" + url.PathEscape(tenantID) + "/oauth2/v2.0/token"
+}
+
+func scopeOrDefault(scope string) string {
+	if scope == "" {
+		return AzureCognitiveServicesScope
+	}
+	return scope
+}
+
+func httpClientOrDefault(client *http.Client) *http.Client {
+	if client == nil {
+		return http.DefaultClient
+	}
+	return client
+}
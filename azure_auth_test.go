@@ -0,0 +1,444 @@
+package openai_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gradientlabs-ai/go-openai"
+	"github.com/gradientlabs-ai/go-openai/internal/test"
+	"github.com/gradientlabs-ai/go-openai/internal/test/checks"
+)
+
+const (
+	testTenantID = "test-tenant"
+	testClientID = "test-client"
+)
+
+// tokenServer stands in for the Entra ID token endpoint and IMDS. check
+// validates each request; it returns an error to reject it.
+type tokenServer struct {
+	*httptest.Server
+	requests int32
+}
+
+func newTokenServer(t *testing.T, check func(r *http.Request) error) *tokenServer {
+	t.Helper()
+	ts := &tokenServer{}
+	ts.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&ts.requests, 1)
+		w.Header().Set("Content-Type", "application/json")
+		if err := check(r); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, `{"error":"invalid_request","error_description":%q}`, err.Error())
+			return
+		}
+		if r.Method == http.MethodGet {
+			// IMDS returns string-typed expiry fields.
+			expiresOn := time.Now().Add(time.Hour).Unix()
+			fmt.Fprintf(w, `{"access_token":%q,"expires_in":"3600","expires_on":"%d","token_type":"Bearer"}`,
+				test.GetTestToken(), expiresOn)
+			return
+		}
+		fmt.Fprintf(w, `{"access_token":%q,"expires_in":3600,"token_type":"Bearer"}`, test.GetTestToken())
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func checkTokenForm(r *http.Request, expected map[string]string) error {
+	if r.URL.Path != "/"+testTenantID+"/oauth2/v2.0/token" {
+		return fmt.Errorf("unexpected path %s", r.URL.Path)
+	}
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+	for k, v := range expected {
+		if got := r.PostForm.Get(k); got != v {
+			return fmt.Errorf("%s: got %q, expected %q", k, got, v)
+		}
+	}
+	return nil
+}
+
+func TestAzureADClientSecretCredential(t *testing.T) {
+	tokens := newTokenServer(t, func(r *http.Request) error {
+		return checkTokenForm(r, map[string]string{
+			"grant_type":    "client_credentials",
+			"client_id":     testClientID,
+			"client_secret": "secret",
+			"scope":         openai.AzureCognitiveServicesScope,
+		})
+	})
+
+	server := test.NewTestServer()
+	server.RegisterHandler("/openai/models", handleListModelsEndpoint)
+	ts := server.OpenAITestServer()
+	ts.Start()
+	defer ts.Close()
+
+	provider := openai.NewCachedTokenProvider(openai.ClientSecretCredential{
+		TenantID:      testTenantID,
+		ClientID:      testClientID,
+		ClientSecret:  "secret",
+		AuthorityHost: tokens.URL,
+	}, 0)
+	client := openai.NewClientWithConfig(openai.DefaultAzureADConfig(provider, ts.URL))
+	for i := 0; i < 3; i++ {
+		_, err := client.ListModels(context.Background())
+		checks.NoError(t, err, "ListModels error")
+	}
+	if n := atomic.LoadInt32(&tokens.requests); n != 1 {
+		t.Errorf("expected the token to be fetched once, got %d requests", n)
+	}
+}
+
+func TestAzureADTokenErrorFailsRequest(t *testing.T) {
+	tokens := newTokenServer(t, func(*http.Request) error { return errors.New("bad secret") })
+	client := openai.NewClientWithConfig(openai.DefaultAzureADConfig(
+		openai.NewCachedTokenProvider(openai.ClientSecretCredential{
+			TenantID:      testTenantID,
+			ClientID:      testClientID,
+			ClientSecret:  "wrong",
+			AuthorityHost: tokens.URL,
+		}, 0),
+		"https://dummylab.openai.azure.com/",
+	))
+	_, err := client.ListModels(context.Background())
+	checks.ErrorIs(t, err, openai.ErrAzureTokenRequest, "token failure should fail the request")
+	if !strings.Contains(err.Error(), "bad secret") {
+		t.Errorf("expected the error description in %q", err)
+	}
+
+	_, err = openai.ClientSecretCredential{TenantID: testTenantID}.FetchToken(context.Background())
+	checks.ErrorIs(t, err, openai.ErrAzureCredentialIncomplete, "missing client id and secret")
+}
+
+func generateTestCertificate(t *testing.T) []byte {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	checks.NoError(t, err, "GenerateKey error")
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	checks.NoError(t, err, "CreateCertificate error")
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	checks.NoError(t, err, "MarshalPKCS8PrivateKey error")
+	return append(
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})...,
+	)
+}
+
+func verifyClientAssertion(assertion string, cert *x509.Certificate, audience string) error {
+	parts := strings.Split(assertion, ".")
+	if len(parts) != 3 {
+		return errors.New("assertion is not a JWT")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return err
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	publicKey, _ := cert.PublicKey.(*rsa.PublicKey)
+	if err = rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, digest[:], signature); err != nil {
+		return err
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return err
+	}
+	var claims struct {
+		Aud string `json:"aud"`
+		Iss string `json:"iss"`
+		Sub string `json:"sub"`
+		Exp int64  `json:"exp"`
+	}
+	if err = json.Unmarshal(payload, &claims); err != nil {
+		return err
+	}
+	if claims.Aud != audience || claims.Iss != testClientID || claims.Sub != testClientID {
+		return fmt.Errorf("unexpected claims %+v", claims)
+	}
+	if time.Unix(claims.Exp, 0).Before(time.Now()) {
+		return errors.New("assertion already expired")
+	}
+	return nil
+}
+
+func TestAzureADClientCertificateCredential(t *testing.T) {
+	cert, key, err := openai.ParseCertificatePEM(generateTestCertificate(t))
+	checks.NoError(t, err, "ParseCertificatePEM error")
+
+	var tokenURL string
+	tokens := newTokenServer(t, func(r *http.Request) error {
+		err := checkTokenForm(r, map[string]string{
+			"grant_type":            "client_credentials",
+			"client_id":             testClientID,
+			"client_assertion_type": "urn:ietf:params:oauth:client-assertion-type:jwt-bearer",
+		})
+		if err != nil {
+			return err
+		}
+		return verifyClientAssertion(r.PostForm.Get("client_assertion"), cert, tokenURL)
+	})
+	tokenURL = tokens.URL + "/" + testTenantID + "/oauth2/v2.0/token"
+
+	token, err := openai.ClientCertificateCredential{
+		TenantID:      testTenantID,
+		ClientID:      testClientID,
+		Certificate:   cert,
+		PrivateKey:    key,
+		AuthorityHost: tokens.URL,
+	}.FetchToken(context.Background())
+	checks.NoError(t, err, "FetchToken error")
+	if token.Token != test.GetTestToken() || time.Until(token.ExpiresOn) < 59*time.Minute {
+		t.Errorf("unexpected token %+v", token)
+	}
+
+	_, _, err = openai.ParseCertificatePEM([]byte("not pem"))
+	checks.ErrorIs(t, err, openai.ErrAzureCredentialIncomplete, "no certificate in PEM")
+}
+
+func TestAzureADWorkloadIdentityCredential(t *testing.T) {
+	dir, cleanup := test.CreateTestDirectory(t)
+	defer cleanup()
+	tokenFile := filepath.Join(dir, "token")
+	checks.NoError(t, os.WriteFile(tokenFile, []byte("federated-token\n"), 0o600), "WriteFile error")
+
+	tokens := newTokenServer(t, func(r *http.Request) error {
+		return checkTokenForm(r, map[string]string{
+			"client_id":             testClientID,
+			"client_assertion_type": "urn:ietf:params:oauth:client-assertion-type:jwt-bearer",
+			"client_assertion":      "federated-token",
+		})
+	})
+
+	t.Setenv("AZURE_TENANT_ID", testTenantID)
+	t.Setenv("AZURE_CLIENT_ID", testClientID)
+	t.Setenv("AZURE_FEDERATED_TOKEN_FILE", tokenFile)
+	t.Setenv("AZURE_AUTHORITY_HOST", tokens.URL)
+	credential, err := openai.NewWorkloadIdentityCredentialFromEnv()
+	checks.NoError(t, err, "NewWorkloadIdentityCredentialFromEnv error")
+
+	token, err := credential.FetchToken(context.Background())
+	checks.NoError(t, err, "FetchToken error")
+	if token.Token != test.GetTestToken() {
+		t.Errorf("unexpected token %+v", token)
+	}
+
+	t.Setenv("AZURE_FEDERATED_TOKEN_FILE", "")
+	_, err = openai.NewWorkloadIdentityCredentialFromEnv()
+	checks.ErrorIs(t, err, openai.ErrAzureCredentialIncomplete, "missing token file")
+}
+
+func TestAzureADManagedIdentityCredential(t *testing.T) {
+	tokens := newTokenServer(t, func(r *http.Request) error {
+		query := r.URL.Query()
+		switch {
+		case r.Header.Get("Metadata") != "true":
+			return errors.New("missing Metadata header")
+		case query.Get("resource") != "https://cognitiveservices.azure.com/":
+			return fmt.Errorf("unexpected resource %q", query.Get("resource"))
+		case query.Get("client_id") != testClientID:
+			return fmt.Errorf("unexpected client_id %q", query.Get("client_id"))
+		}
+		return nil
+	})
+
+	token, err := openai.ManagedIdentityCredential{
+		ClientID: testClientID,
+		Endpoint: tokens.URL + "/metadata/identity/oauth2/token",
+	}.FetchToken(context.Background())
+	checks.NoError(t, err, "FetchToken error")
+	if token.Token != test.GetTestToken() || time.Until(token.ExpiresOn) < 59*time.Minute {
+		t.Errorf("unexpected token %+v", token)
+	}
+}
+
+// countingTokenSource issues numbered tokens that expire after ttl. If
+// failAfter is set, every fetch after the first failAfter fails.
+type countingTokenSource struct {
+	mu        sync.Mutex
+	fetches   int
+	ttl       time.Duration
+	failAfter int
+	fetched   chan struct{}
+}
+
+func (s *countingTokenSource) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.fetches
+}
+
+func (s *countingTokenSource) FetchToken(context.Context) (openai.AccessToken, error) {
+	s.mu.Lock()
+	s.fetches++
+	n := s.fetches
+	s.mu.Unlock()
+	select {
+	case s.fetched <- struct{}{}:
+	default:
+	}
+	if s.failAfter > 0 && n > s.failAfter {
+		return openai.AccessToken{}, errors.New("token endpoint unavailable")
+	}
+	return openai.AccessToken{Token: fmt.Sprintf("token-%d", n), ExpiresOn: time.Now().Add(s.ttl)}, nil
+}
+
+func TestCachedTokenProviderRefresh(t *testing.T) {
+	ctx := context.Background()
+
+	// A token far from expiry is reused.
+	source := &countingTokenSource{ttl: time.Hour}
+	provider := openai.NewCachedTokenProvider(source, time.Minute)
+	for i := 0; i < 3; i++ {
+		token, err := provider.Token(ctx)
+		checks.NoError(t, err, "Token error")
+		if token != "token-1" {
+			t.Fatalf("expected the cached token, got %q", token)
+		}
+	}
+
+	// A refresh lead longer than the token's lifetime is capped at half of
+	// it, rather than refreshing on every call.
+	source = &countingTokenSource{ttl: time.Hour}
+	provider = openai.NewCachedTokenProvider(source, 2*time.Hour)
+	for i := 0; i < 3; i++ {
+		_, err := provider.Token(ctx)
+		checks.NoError(t, err, "Token error")
+	}
+	time.Sleep(10 * time.Millisecond)
+	if n := source.count(); n != 1 {
+		t.Errorf("expected one fetch with a capped refresh lead, got %d", n)
+	}
+
+	// A token inside the refresh window is still returned while a background
+	// refresh replaces it.
+	source = &countingTokenSource{ttl: 200 * time.Millisecond, fetched: make(chan struct{}, 2)}
+	provider = openai.NewCachedTokenProvider(source, time.Minute)
+	token, err := provider.Token(ctx)
+	checks.NoError(t, err, "Token error")
+	<-source.fetched
+	if token != "token-1" {
+		t.Fatalf("expected the first token, got %q", token)
+	}
+	time.Sleep(120 * time.Millisecond)
+	token, err = provider.Token(ctx)
+	checks.NoError(t, err, "Token error")
+	if token != "token-1" {
+		t.Fatalf("expected the current token during refresh, got %q", token)
+	}
+	select {
+	case <-source.fetched:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected a proactive refresh")
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for token != "token-2" && time.Now().Before(deadline) {
+		token, err = provider.Token(ctx)
+		checks.NoError(t, err, "Token error")
+		time.Sleep(time.Millisecond)
+	}
+	if token != "token-2" {
+		t.Errorf("expected the refreshed token, got %q", token)
+	}
+}
+
+func TestCachedTokenProviderFailedRefreshBacksOff(t *testing.T) {
+	ctx := context.Background()
+	source := &countingTokenSource{ttl: 200 * time.Millisecond, failAfter: 1, fetched: make(chan struct{}, 1)}
+	provider := openai.NewCachedTokenProvider(source, time.Minute)
+	_, err := provider.Token(ctx)
+	checks.NoError(t, err, "Token error")
+	<-source.fetched
+
+	time.Sleep(120 * time.Millisecond)
+	_, err = provider.Token(ctx)
+	checks.NoError(t, err, "Token error")
+	select {
+	case <-source.fetched:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected a proactive refresh")
+	}
+
+	// The refresh failed; the current token is still served without
+	// refreshing again on every call.
+	for i := 0; i < 10; i++ {
+		token, err := provider.Token(ctx)
+		checks.NoError(t, err, "Token error")
+		if token != "token-1" {
+			t.Fatalf("expected the current token, got %q", token)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if n := source.count(); n != 2 {
+		t.Errorf("expected no refresh right after a failed one, got %d fetches", n)
+	}
+}
+
+// blockingTokenSource returns a token once release is closed, or fails with
+// the error of the context it was given.
+type blockingTokenSource struct {
+	release chan struct{}
+	started chan struct{}
+}
+
+func (s *blockingTokenSource) FetchToken(ctx context.Context) (openai.AccessToken, error) {
+	close(s.started)
+	select {
+	case <-s.release:
+		return openai.AccessToken{Token: "shared", ExpiresOn: time.Now().Add(time.Hour)}, nil
+	case <-ctx.Done():
+		return openai.AccessToken{}, ctx.Err()
+	}
+}
+
+func TestCachedTokenProviderFetchOutlivesCanceledCaller(t *testing.T) {
+	source := &blockingTokenSource{release: make(chan struct{}), started: make(chan struct{})}
+	provider := openai.NewCachedTokenProvider(source, time.Minute)
+
+	first, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := provider.Token(first)
+		firstErr <- err
+	}()
+	<-source.started
+	second := make(chan string, 1)
+	go func() {
+		token, _ := provider.Token(context.Background())
+		second <- token
+	}()
+
+	cancel()
+	checks.ErrorIs(t, <-firstErr, context.Canceled, "the canceled caller should stop waiting")
+	close(source.release)
+	if token := <-second; token != "shared" {
+		t.Fatalf("expected the other caller to get the fetched token, got %q", token)
+	}
+}
@@ -0,0 +1,176 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// BatchEndpoint is an endpoint the requests of a batch are sent to.
+type BatchEndpoint string
+
+const (
+	BatchEndpointChatCompletions BatchEndpoint = "/v1/chat/completions"
+	BatchEndpointCompletions     BatchEndpoint = "/v1/completions"
+	BatchEndpointEmbeddings      BatchEndpoint = "/v1/embeddings"
+)
+
+// BatchCompletionWindow24h is the only completion window the API accepts.
+const BatchCompletionWindow24h = "24h"
+
+// Batch statuses.
+const (
+	BatchStatusValidating = "validating"
+	BatchStatusFailed     = "failed"
+	BatchStatusInProgress = "in_progress"
+	BatchStatusFinalizing = "finalizing"
+	BatchStatusCompleted  = "completed"
+	BatchStatusExpired    = "expired"
+	BatchStatusCancelling = "cancelling"
+	BatchStatusCancelled  = "cancelled"
+)
+
+// CreateBatchRequest creates a batch from an uploaded JSONL file of requests.
+// Upload the file with the purpose PurposeBatch.
+type CreateBatchRequest struct {
+	InputFileID      string         `json:"input_file_id"`
+	Endpoint         BatchEndpoint  `json:"endpoint"`
+	CompletionWindow string         `json:"completion_window"`
+	Metadata         map[string]any `json:"metadata,omitempty"`
+}
+
+// Batch is a batch of requests processed asynchronously.
+type Batch struct {
+	ID               string             `json:"id"`
+	Object           string             `json:"object"`
+	Endpoint         BatchEndpoint      `json:"endpoint"`
+	Errors           *BatchErrors       `json:"errors"`
+	InputFileID      string             `json:"input_file_id"`
+	CompletionWindow string             `json:"completion_window"`
+	Status           string             `json:"status"`
+	OutputFileID     *string            `json:"output_file_id"`
+	ErrorFileID      *string            `json:"error_file_id"`
+	CreatedAt        int64              `json:"created_at"`
+	InProgressAt     *int64             `json:"in_progress_at"`
+	ExpiresAt        *int64             `json:"expires_at"`
+	FinalizingAt     *int64             `json:"finalizing_at"`
+	CompletedAt      *int64             `json:"completed_at"`
+	FailedAt         *int64             `json:"failed_at"`
+	ExpiredAt        *int64             `json:"expired_at"`
+	CancellingAt     *int64             `json:"cancelling_at"`
+	CancelledAt      *int64             `json:"cancelled_at"`
+	RequestCounts    BatchRequestCounts `json:"request_counts"`
+	Metadata         map[string]any     `json:"metadata"`
+
+	httpHeader
+	rawJSON
+}
+
+type BatchErrors struct {
+	Object string       `json:"object"`
+	Data   []BatchError `json:"data"`
+}
+
+type BatchError struct {
+	Code    string  `json:"code"`
+	Message string  `json:"message"`
+	Param   *string `json:"param"`
+	Line    *int    `json:"line"`
+}
+
+type BatchRequestCounts struct {
+	Total     int `json:"total"`
+	Completed int `json:"completed"`
+	Failed    int `json:"failed"`
+}
+
+// Done reports whether the batch has stopped changing.
+func (b Batch) Done() bool {
+	switch b.Status {
+	case BatchStatusFailed, BatchStatusCompleted, BatchStatusExpired, BatchStatusCancelled:
+		return true
+	}
+	return false
+}
+
+// BatchList is a page of batches.
+type BatchList struct {
+	Object  string  `json:"object"`
+	Data    []Batch `json:"data"`
+	FirstID *string `json:"first_id"`
+	LastID  *string `json:"last_id"`
+	HasMore bool    `json:"has_more"`
+
+	httpHeader
+	rawJSON
+}
+
+// CreateBatch creates a batch. The completion window defaults to 24h.
+func (c *Client) CreateBatch(
+	ctx context.Context,
+	request CreateBatchRequest,
+	opts ...RequestOption,
+) (response Batch, err error) {
+	if request.CompletionWindow == "" {
+		request.CompletionWindow = BatchCompletionWindow24h
+	}
+	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL("/batches"), withBody(request), withRequestOptions(opts))
+	if err != nil {
+		return
+	}
+
+	err = c.sendRequest(req, &response)
+	return
+}
+
+// RetrieveBatch retrieves a batch.
+func (c *Client) RetrieveBatch(ctx context.Context, batchID string, opts ...RequestOption) (response Batch, err error) {
+	req, err := c.newRequest(ctx, http.MethodGet, c.fullURL("/batches/"+batchID), withRequestOptions(opts))
+	if err != nil {
+		return
+	}
+
+	err = c.sendRequest(req, &response)
+	return
+}
+
+// CancelBatch cancels a batch that is in progress.
+func (c *Client) CancelBatch(ctx context.Context, batchID string, opts ...RequestOption) (response Batch, err error) {
+	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL("/batches/"+batchID+"/cancel"), withRequestOptions(opts))
+	if err != nil {
+		return
+	}
+
+	err = c.sendRequest(req, &response)
+	return
+}
+
+// ListBatch lists batches, newest first. after is the ID of the last batch
+// of the previous page. Nil parameters use the API's defaults.
+func (c *Client) ListBatch(
+	ctx context.Context,
+	after *string,
+	limit *int,
+	opts ...RequestOption,
+) (response BatchList, err error) {
+	urlValues := url.Values{}
+	if after != nil {
+		urlValues.Add("after", *after)
+	}
+	if limit != nil {
+		urlValues.Add("limit", fmt.Sprintf("%d", *limit))
+	}
+	encodedValues := ""
+	if len(urlValues) > 0 {
+		encodedValues = "?" + urlValues.Encode()
+	}
+
+	req, err := c.newRequest(ctx, http.MethodGet, c.fullURL("/batches"+encodedValues), withRequestOptions(opts))
+	if err != nil {
+		return
+	}
+
+	err = c.sendRequest(req, &response)
+	return
+}
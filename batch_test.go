@@ -0,0 +1,100 @@
+package openai_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gradientlabs-ai/go-openai"
+	"github.com/gradientlabs-ai/go-openai/internal/test/checks"
+)
+
+const testBatchID = "batch_abc123"
+
+// TestBatch Tests the batch endpoints of the API using the mocked server.
+func TestBatch(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	server.RegisterHandler(
+		"/v1/batches",
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet {
+				if r.URL.Query().Get("limit") != "2" {
+					t.Errorf("expected the limit to be sent, got %q", r.URL.RawQuery)
+				}
+				resBytes, _ := json.Marshal(openai.BatchList{Object: "list", Data: []openai.Batch{{ID: testBatchID}}})
+				fmt.Fprintln(w, string(resBytes))
+				return
+			}
+			var request openai.CreateBatchRequest
+			if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if request.CompletionWindow != openai.BatchCompletionWindow24h {
+				t.Errorf("expected the default completion window, got %q", request.CompletionWindow)
+			}
+			resBytes, _ := json.Marshal(openai.Batch{
+				ID:               testBatchID,
+				Object:           "batch",
+				Endpoint:         request.Endpoint,
+				InputFileID:      request.InputFileID,
+				CompletionWindow: request.CompletionWindow,
+				Status:           openai.BatchStatusValidating,
+			})
+			fmt.Fprintln(w, string(resBytes))
+		},
+	)
+	server.RegisterHandler(
+		"/v1/batches/"+testBatchID,
+		func(w http.ResponseWriter, _ *http.Request) {
+			outputFileID := "file-output"
+			resBytes, _ := json.Marshal(openai.Batch{
+				ID:            testBatchID,
+				Status:        openai.BatchStatusCompleted,
+				OutputFileID:  &outputFileID,
+				RequestCounts: openai.BatchRequestCounts{Total: 2, Completed: 2},
+			})
+			fmt.Fprintln(w, string(resBytes))
+		},
+	)
+	server.RegisterHandler(
+		"/v1/batches/"+testBatchID+"/cancel",
+		func(w http.ResponseWriter, _ *http.Request) {
+			resBytes, _ := json.Marshal(openai.Batch{ID: testBatchID, Status: openai.BatchStatusCancelling})
+			fmt.Fprintln(w, string(resBytes))
+		},
+	)
+
+	ctx := context.Background()
+
+	batch, err := client.CreateBatch(ctx, openai.CreateBatchRequest{
+		InputFileID: "file-input",
+		Endpoint:    openai.BatchEndpointChatCompletions,
+	})
+	checks.NoError(t, err, "CreateBatch error")
+	if batch.ID != testBatchID || batch.Done() {
+		t.Fatalf("unexpected batch %+v", batch)
+	}
+
+	batch, err = client.RetrieveBatch(ctx, testBatchID)
+	checks.NoError(t, err, "RetrieveBatch error")
+	if !batch.Done() || batch.OutputFileID == nil || batch.RequestCounts.Completed != 2 {
+		t.Fatalf("unexpected batch %+v", batch)
+	}
+
+	batch, err = client.CancelBatch(ctx, testBatchID)
+	checks.NoError(t, err, "CancelBatch error")
+	if batch.Status != openai.BatchStatusCancelling {
+		t.Fatalf("unexpected status %q", batch.Status)
+	}
+
+	limit := 2
+	list, err := client.ListBatch(ctx, nil, &limit)
+	checks.NoError(t, err, "ListBatch error")
+	if len(list.Data) != 1 {
+		t.Fatalf("expected one batch, got %d", len(list.Data))
+	}
+}
@@ -0,0 +1,427 @@
+package openai
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+var ErrCacheStoreCapacity = errors.New("cache store capacity must be greater than zero")
+
+// CacheStore is a pluggable key/value store backing the response cache.
+// Implementations must be safe for concurrent use.
+type CacheStore interface {
+	// Get returns the value stored under key. The boolean reports whether a
+	// live (non-expired) entry was found.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores value under key. A zero ttl means the entry never expires.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete removes the entry stored under key, if any.
+	Delete(ctx context.Context, key string) error
+}
+
+// ResponseCacheConfig configures exact-match caching of chat completion and
+// embedding responses. Requests are keyed by a canonical hash of the HTTP
+// method, URL, headers and JSON body, so only byte-for-byte identical requests
+// (after normalizing key order and whitespace) share an entry. This is
+// intended for deterministic workloads such as eval reruns that pin Seed and
+// temperature.
+//
+// The credential headers are left out of the key, so that refreshed Entra ID
+// tokens and keys rotated by a KeyPool keep hitting the cache. Clients are
+// told apart by their organization and other headers, by Namespace, and for a
+// static API key by a fingerprint of that key.
+type ResponseCacheConfig struct {
+	Store CacheStore
+	// TTL is how long entries stay valid. Zero means entries never expire.
+	TTL time.Duration
+	// Namespace, if set, is the identity entries are keyed by instead of the
+	// API key fingerprint, e.g. to share a Store between clients that should
+	// not see each other's responses but authenticate with a TokenProvider.
+	Namespace string
+}
+
+// CacheControl adjusts the behaviour of the response cache for a single call.
+// Attach it to the request context with WithCacheControl.
+type CacheControl struct {
+	// NoCache skips the cache lookup; the fresh response is still stored.
+	NoCache bool
+	// NoStore prevents the response from being written to the cache.
+	NoStore bool
+	// TTL overrides ResponseCacheConfig.TTL when non-zero.
+	TTL time.Duration
+}
+
+type cacheControlContextKey struct{}
+
+// WithCacheControl returns a copy of ctx carrying per-call cache settings.
+func WithCacheControl(ctx context.Context, cc CacheControl) context.Context {
+	return context.WithValue(ctx, cacheControlContextKey{}, cc)
+}
+
+func cacheControlFromContext(ctx context.Context) CacheControl {
+	cc, _ := ctx.Value(cacheControlContextKey{}).(CacheControl)
+	return cc
+}
+
+type cacheKeyContextKey struct{}
+
+// cachedResponse is the serialized form of a successful response kept in the
+// CacheStore. Body holds the raw bytes returned by the server, which for
+// streaming requests are the complete server-sent events payload.
+type cachedResponse struct {
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+}
+
+// responseCacheKey returns the canonical cache key for a request body sent to
+// url with method and header by the client identity. The body is marshaled
+// and re-encoded through a generic value so that map ordering and formatting
+// do not affect the key.
+func responseCacheKey(method, url, identity string, header http.Header, body any) (string, error) {
+	raw, err := json.Marshal(body)
+	if err != nil {
+		return "", err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var generic any
+	if err = decoder.Decode(&generic); err != nil {
+		return "", err
+	}
+	canonical, err := json.Marshal(generic)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	h.Write([]byte(method))
+	h.Write([]byte{' '})
+	h.Write([]byte(url))
+	h.Write([]byte{'\n'})
+	h.Write([]byte(identity))
+	h.Write([]byte{'\n'})
+	writeCacheKeyHeader(h, header)
+	h.Write(canonical)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// writeCacheKeyHeader writes header to h in a canonical order.
+func writeCacheKeyHeader(h io.Writer, header http.Header) {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range header[name] {
+			fmt.Fprintf(h, "%s: %s\n", http.CanonicalHeaderKey(name), value)
+		}
+	}
+	h.Write([]byte{'\n'})
+}
+
+// withResponseCacheKey attaches the cache key for req to its context so that
+// sendRequest and sendRequestStream can consult the cache. It is a no-op when
+// the client has no cache configured.
+func (c *Client) withResponseCacheKey(req *http.Request, body any) (*http.Request, error) {
+	if c.config.ResponseCache == nil || c.config.ResponseCache.Store == nil {
+		return req, nil
+	}
+	key, err := responseCacheKey(req.Method, req.URL.String(), c.cacheIdentity(), c.cacheKeyHeader(req.Header), body)
+	if err != nil {
+		return nil, err
+	}
+	return req.WithContext(context.WithValue(req.Context(), cacheKeyContextKey{}, key)), nil
+}
+
+// cacheIdentity returns who the client's cache entries belong to: the
+// configured namespace, or a fingerprint of a static API key. Tokens from a
+// TokenProvider change on every refresh or rotation, so they are not used.
+func (c *Client) cacheIdentity() string {
+	if namespace := c.config.ResponseCache.Namespace; namespace != "" {
+		return "namespace " + namespace
+	}
+	if c.config.TokenProvider == nil && c.config.authToken != "" {
+		fingerprint := sha256.Sum256([]byte(c.config.authToken))
+		return "key " + hex.EncodeToString(fingerprint[:])
+	}
+	return ""
+}
+
+// cacheKeyHeader returns the headers of a request that go into its cache key.
+// The credentials are left out, as cacheIdentity stands for them, and so is
+// the idempotency key, as it names a call rather than who makes it.
+func (c *Client) cacheKeyHeader(header http.Header) http.Header {
+	skip := []string{"Authorization", AzureAPIKeyHeader, IdempotencyKeyHeader}
+	if provider := c.config.Provider; provider != nil && provider.AuthHeader != "" {
+		skip = append(skip, provider.AuthHeader)
+	}
+	keyed := header.Clone()
+	for _, name := range skip {
+		keyed.Del(name)
+	}
+	return keyed
+}
+
+func requestCacheKey(req *http.Request) string {
+	key, _ := req.Context().Value(cacheKeyContextKey{}).(string)
+	return key
+}
+
+// lookupResponseCache returns the cached response for key. Store errors and
+// undecodable entries are treated as misses so that a broken cache never fails
+// an otherwise valid request.
+func (c *Client) lookupResponseCache(ctx context.Context, key string) (*cachedResponse, bool) {
+	if key == "" || cacheControlFromContext(ctx).NoCache {
+		return nil, false
+	}
+	raw, ok, err := c.config.ResponseCache.Store.Get(ctx, key)
+	if err != nil || !ok {
+		return nil, false
+	}
+	var entry cachedResponse
+	if err = json.Unmarshal(raw, &entry); err != nil {
+		return nil, false
+	}
+	return &entry, true
+}
+
+func (c *Client) storeResponseCache(ctx context.Context, key string, header http.Header, body []byte) {
+	cc := cacheControlFromContext(ctx)
+	if key == "" || cc.NoStore {
+		return
+	}
+	raw, err := json.Marshal(cachedResponse{Header: header, Body: body})
+	if err != nil {
+		return
+	}
+	ttl := c.config.ResponseCache.TTL
+	if cc.TTL != 0 {
+		ttl = cc.TTL
+	}
+	_ = c.config.ResponseCache.Store.Set(ctx, key, raw, ttl)
+}
+
+// cacheRecordingBody tees everything read from a streaming response body and
+// stores it in the cache on Close once the stream has completed.
+type cacheRecordingBody struct {
+	io.ReadCloser
+
+	client *Client
+	ctx    context.Context
+	key    string
+	header http.Header
+
+	// mu guards the recording, since the body may be closed while a Read
+	// is blocked.
+	mu       sync.Mutex
+	buf      bytes.Buffer
+	complete bool
+}
+
+func (b *cacheRecordingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf.Write(p[:n])
+	if errors.Is(err, io.EOF) {
+		b.complete = true
+	}
+	return n, err
+}
+
+// Close stores the recorded stream when it was fully consumed without an
+// in-band error event, so partial or failed streams are never replayed.
+func (b *cacheRecordingBody) Close() error {
+	b.mu.Lock()
+	recorded := b.buf.Bytes()
+	done := bytes.Contains(recorded, []byte("data: [DONE]"))
+	if (done || b.complete) && !bytes.Contains(recorded, errorPrefix) {
+		b.client.storeResponseCache(b.ctx, b.key, b.header, recorded)
+	}
+	b.mu.Unlock()
+	return b.ReadCloser.Close()
+}
+
+// MemoryCacheStore is an in-memory CacheStore that evicts the least recently
+// used entry once its capacity is reached.
+type MemoryCacheStore struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List
+}
+
+type memoryCacheEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// NewMemoryCacheStore creates an LRU CacheStore holding at most capacity entries.
+func NewMemoryCacheStore(capacity int) (*MemoryCacheStore, error) {
+	if capacity <= 0 {
+		return nil, ErrCacheStoreCapacity
+	}
+	return &MemoryCacheStore{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}, nil
+}
+
+func (s *MemoryCacheStore) Get(_ context.Context, key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elem, ok := s.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	entry, _ := elem.Value.(*memoryCacheEntry)
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		s.order.Remove(elem)
+		delete(s.entries, key)
+		return nil, false, nil
+	}
+	s.order.MoveToFront(elem)
+	return entry.value, true, nil
+}
+
+func (s *MemoryCacheStore) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := &memoryCacheEntry{key: key, value: value}
+	if ttl > 0 {
+		entry.expiresAt = time.Now().Add(ttl)
+	}
+	if elem, ok := s.entries[key]; ok {
+		elem.Value = entry
+		s.order.MoveToFront(elem)
+		return nil
+	}
+	s.entries[key] = s.order.PushFront(entry)
+	for s.order.Len() > s.capacity {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		evicted, _ := oldest.Value.(*memoryCacheEntry)
+		delete(s.entries, evicted.key)
+	}
+	return nil
+}
+
+func (s *MemoryCacheStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, ok := s.entries[key]; ok {
+		s.order.Remove(elem)
+		delete(s.entries, key)
+	}
+	return nil
+}
+
+// Len returns the number of entries currently held, including expired entries
+// that have not been evicted yet.
+func (s *MemoryCacheStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.order.Len()
+}
+
+// DiskCacheStore is a CacheStore that keeps one file per entry in a directory.
+// Entries survive process restarts, which makes it suitable for eval reruns.
+type DiskCacheStore struct {
+	dir string
+}
+
+type diskCacheEntry struct {
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+	Value     []byte    `json:"value"`
+}
+
+// NewDiskCacheStore creates a DiskCacheStore rooted at dir, creating the
+// directory if it does not exist.
+func NewDiskCacheStore(dir string) (*DiskCacheStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &DiskCacheStore{dir: dir}, nil
+}
+
+func (s *DiskCacheStore) path(key string) string {
+	// Keys are hashed so callers can use arbitrary strings without worrying
+	// about path separators.
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (s *DiskCacheStore) Get(_ context.Context, key string) ([]byte, bool, error) {
+	raw, err := os.ReadFile(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	var entry diskCacheEntry
+	if err = json.Unmarshal(raw, &entry); err != nil {
+		return nil, false, err
+	}
+	if !entry.ExpiresAt.IsZero() && time.Now().After(entry.ExpiresAt) {
+		_ = os.Remove(s.path(key))
+		return nil, false, nil
+	}
+	return entry.Value, true, nil
+}
+
+func (s *DiskCacheStore) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	entry := diskCacheEntry{Value: value}
+	if ttl > 0 {
+		entry.ExpiresAt = time.Now().Add(ttl)
+	}
+	raw, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so concurrent readers never observe a
+	// partially written entry.
+	tmp, err := os.CreateTemp(s.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(raw); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path(key))
+}
+
+func (s *DiskCacheStore) Delete(_ context.Context, key string) error {
+	err := os.Remove(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,349 @@
+package openai_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gradientlabs-ai/go-openai"
+	"github.com/gradientlabs-ai/go-openai/internal/test"
+	"github.com/gradientlabs-ai/go-openai/internal/test/checks"
+)
+
+func setupCachedTestServer(t *testing.T) (client *openai.Client, server *test.ServerTest, teardown func()) {
+	t.Helper()
+	server = test.NewTestServer()
+	ts := server.OpenAITestServer()
+	ts.Start()
+	teardown = ts.Close
+
+	store, err := openai.NewMemoryCacheStore(16)
+	checks.NoError(t, err, "NewMemoryCacheStore error")
+
+	config := openai.DefaultConfig(test.GetTestToken())
+	config.BaseURL = ts.URL + "/v1"
+	config.ResponseCache = &openai.ResponseCacheConfig{Store: store}
+	client = openai.NewClientWithConfig(config)
+	return
+}
+
+func TestMemoryCacheStoreEvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	store, err := openai.NewMemoryCacheStore(2)
+	checks.NoError(t, err, "NewMemoryCacheStore error")
+
+	checks.NoError(t, store.Set(ctx, "a", []byte("1"), 0))
+	checks.NoError(t, store.Set(ctx, "b", []byte("2"), 0))
+	// Touch "a" so that "b" becomes the eviction candidate.
+	if _, ok, _ := store.Get(ctx, "a"); !ok {
+		t.Fatal("expected entry a to be present")
+	}
+	checks.NoError(t, store.Set(ctx, "c", []byte("3"), 0))
+
+	if _, ok, _ := store.Get(ctx, "b"); ok {
+		t.Error("expected entry b to be evicted")
+	}
+	if v, ok, _ := store.Get(ctx, "a"); !ok || string(v) != "1" {
+		t.Errorf("expected entry a to survive, got %q, %v", v, ok)
+	}
+	if store.Len() != 2 {
+		t.Errorf("expected 2 entries, got %d", store.Len())
+	}
+
+	checks.NoError(t, store.Delete(ctx, "a"))
+	if _, ok, _ := store.Get(ctx, "a"); ok {
+		t.Error("expected entry a to be deleted")
+	}
+}
+
+func TestMemoryCacheStoreTTL(t *testing.T) {
+	ctx := context.Background()
+	store, err := openai.NewMemoryCacheStore(1)
+	checks.NoError(t, err, "NewMemoryCacheStore error")
+
+	checks.NoError(t, store.Set(ctx, "a", []byte("1"), 10*time.Millisecond))
+	time.Sleep(20 * time.Millisecond)
+	if _, ok, _ := store.Get(ctx, "a"); ok {
+		t.Error("expected entry to expire")
+	}
+
+	_, err = openai.NewMemoryCacheStore(0)
+	checks.ErrorIs(t, err, openai.ErrCacheStoreCapacity, "zero capacity should be rejected")
+}
+
+func TestDiskCacheStore(t *testing.T) {
+	ctx := context.Background()
+	dir, cleanup := test.CreateTestDirectory(t)
+	defer cleanup()
+
+	store, err := openai.NewDiskCacheStore(dir)
+	checks.NoError(t, err, "NewDiskCacheStore error")
+
+	checks.NoError(t, store.Set(ctx, "some/key", []byte("value"), 0))
+
+	reopened, err := openai.NewDiskCacheStore(dir)
+	checks.NoError(t, err, "NewDiskCacheStore error")
+	v, ok, err := reopened.Get(ctx, "some/key")
+	checks.NoError(t, err, "Get error")
+	if !ok || string(v) != "value" {
+		t.Fatalf("expected persisted value, got %q, %v", v, ok)
+	}
+
+	checks.NoError(t, store.Set(ctx, "expiring", []byte("value"), time.Millisecond))
+	time.Sleep(5 * time.Millisecond)
+	if _, ok, _ = store.Get(ctx, "expiring"); ok {
+		t.Error("expected entry to expire")
+	}
+
+	checks.NoError(t, store.Delete(ctx, "some/key"))
+	checks.NoError(t, store.Delete(ctx, "some/key"), "deleting a missing key should succeed")
+	if _, ok, _ = store.Get(ctx, "some/key"); ok {
+		t.Error("expected entry to be deleted")
+	}
+}
+
+func TestChatCompletionResponseCache(t *testing.T) {
+	client, server, teardown := setupCachedTestServer(t)
+	defer teardown()
+
+	calls := 0
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		w.Header().Set("x-request-id", fmt.Sprintf("req-%d", calls))
+		resBytes, _ := json.Marshal(openai.ChatCompletionResponse{
+			ID:      fmt.Sprintf("chatcmpl-%d", calls),
+			Choices: []openai.ChatCompletionChoice{{Message: openai.ChatCompletionMessage{Content: "hi"}}},
+		})
+		fmt.Fprintln(w, string(resBytes))
+	})
+
+	seed := 42
+	request := openai.ChatCompletionRequest{
+		Model:    openai.GPT4oMini,
+		Seed:     &seed,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hello!"}},
+	}
+	ctx := context.Background()
+
+	first, err := client.CreateChatCompletion(ctx, request)
+	checks.NoError(t, err, "CreateChatCompletion error")
+	second, err := client.CreateChatCompletion(ctx, request)
+	checks.NoError(t, err, "CreateChatCompletion error")
+
+	if calls != 1 {
+		t.Fatalf("expected 1 upstream call, got %d", calls)
+	}
+	if first.ID != second.ID || second.GetRequestID() != "req-1" {
+		t.Errorf("cached response differs: %s/%s, request id %q", first.ID, second.ID, second.GetRequestID())
+	}
+
+	_, err = client.CreateChatCompletion(openai.WithCacheControl(ctx, openai.CacheControl{NoCache: true}), request)
+	checks.NoError(t, err, "CreateChatCompletion error")
+	if calls != 2 {
+		t.Fatalf("expected NoCache to reach the server, got %d calls", calls)
+	}
+
+	request.Messages[0].Content = "Hello again!"
+	_, err = client.CreateChatCompletion(openai.WithCacheControl(ctx, openai.CacheControl{NoStore: true}), request)
+	checks.NoError(t, err, "CreateChatCompletion error")
+	_, err = client.CreateChatCompletion(ctx, request)
+	checks.NoError(t, err, "CreateChatCompletion error")
+	if calls != 4 {
+		t.Fatalf("expected NoStore response not to be cached, got %d calls", calls)
+	}
+}
+
+func TestResponseCacheKeyIncludesHeaders(t *testing.T) {
+	server := test.NewTestServer()
+	ts := server.OpenAITestServer()
+	ts.Start()
+	defer ts.Close()
+	calls := 0
+	server.RegisterHandler("/v1/embeddings", func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		fmt.Fprintf(w, `{"object": "list", "data": [{"embedding": [%d], "index": 0}]}`, calls)
+	})
+
+	store, err := openai.NewMemoryCacheStore(16)
+	checks.NoError(t, err, "NewMemoryCacheStore error")
+	newClient := func(orgID string) *openai.Client {
+		config := openai.DefaultConfig(test.GetTestToken())
+		config.BaseURL = ts.URL + "/v1"
+		config.OrgID = orgID
+		config.ResponseCache = &openai.ResponseCacheConfig{Store: store}
+		return openai.NewClientWithConfig(config)
+	}
+	ctx := context.Background()
+	request := openai.EmbeddingRequestStrings{Input: []string{"hi"}, Model: openai.SmallEmbedding3}
+
+	_, err = newClient("org-1").CreateEmbeddings(ctx, request)
+	checks.NoError(t, err, "CreateEmbeddings error")
+	_, err = newClient("org-1").CreateEmbeddings(ctx, request, openai.WithIdempotencyKey("call-2"))
+	checks.NoError(t, err, "CreateEmbeddings error")
+	if calls != 1 {
+		t.Fatalf("expected the same credentials to share the cache, got %d calls", calls)
+	}
+	_, err = newClient("org-2").CreateEmbeddings(ctx, request)
+	checks.NoError(t, err, "CreateEmbeddings error")
+	_, err = newClient("org-1").CreateEmbeddings(ctx, request, openai.WithExtraHeader("OpenAI-Project", "p"))
+	checks.NoError(t, err, "CreateEmbeddings error")
+	if calls != 3 {
+		t.Fatalf("expected another organization or header not to hit the cache, got %d calls", calls)
+	}
+}
+
+func TestResponseCacheKeyIgnoresRefreshedTokens(t *testing.T) {
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		fmt.Fprintf(w, `{"object": "list", "data": [{"embedding": [%d], "index": 0}]}`, calls)
+	}))
+	defer ts.Close()
+
+	store, err := openai.NewMemoryCacheStore(16)
+	checks.NoError(t, err, "NewMemoryCacheStore error")
+	tokens := 0
+	newClient := func(apiKey, namespace string, refreshing bool) *openai.Client {
+		config := openai.DefaultConfig(apiKey)
+		config.BaseURL = ts.URL + "/v1"
+		config.ResponseCache = &openai.ResponseCacheConfig{Store: store, Namespace: namespace}
+		if refreshing {
+			// Every request gets a new token, as after each Entra ID refresh.
+			config.TokenProvider = openai.TokenProviderFunc(func(context.Context) (string, error) {
+				tokens++
+				return fmt.Sprintf("token-%d", tokens), nil
+			})
+		}
+		return openai.NewClientWithConfig(config)
+	}
+	ctx := context.Background()
+	request := openai.EmbeddingRequestStrings{Input: []string{"hi"}, Model: openai.SmallEmbedding3}
+	embed := func(client *openai.Client) {
+		_, embedErr := client.CreateEmbeddings(ctx, request)
+		checks.NoError(t, embedErr, "CreateEmbeddings error")
+	}
+
+	refreshing := newClient("", "", true)
+	embed(refreshing)
+	embed(refreshing)
+	if calls != 1 {
+		t.Fatalf("expected a refreshed token to hit the cache, got %d calls", calls)
+	}
+	embed(newClient("", "evals", true))
+	embed(newClient("", "evals", true))
+	if calls != 2 {
+		t.Fatalf("expected a namespace to get its own entries, got %d calls", calls)
+	}
+	embed(newClient("key-1", "", false))
+	embed(newClient("key-1", "", false))
+	embed(newClient("key-2", "", false))
+	if calls != 4 {
+		t.Fatalf("expected static keys to be told apart by fingerprint, got %d calls", calls)
+	}
+}
+
+func TestChatCompletionResponseCacheSkipsErrors(t *testing.T) {
+	client, server, teardown := setupCachedTestServer(t)
+	defer teardown()
+
+	calls := 0
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintln(w, `{"error":{"message":"boom","type":"server_error"}}`)
+	})
+
+	request := openai.ChatCompletionRequest{
+		Model:    openai.GPT4oMini,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hello!"}},
+	}
+	for i := 0; i < 2; i++ {
+		_, err := client.CreateChatCompletion(context.Background(), request)
+		checks.HasError(t, err, "expected server error")
+	}
+	if calls != 2 {
+		t.Fatalf("expected errors not to be cached, got %d calls", calls)
+	}
+}
+
+func TestChatCompletionStreamResponseCache(t *testing.T) {
+	client, server, teardown := setupCachedTestServer(t)
+	defer teardown()
+
+	calls := 0
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("x-ratelimit-remaining-requests", "9")
+		for _, content := range []string{"Hel", "lo"} {
+			//nolint:lll
+			fmt.Fprintf(w, `data: {"id":"1","object":"chat.completion.chunk","model":"gpt-4o-mini","choices":[{"index":0,"delta":{"content":%q}}]}`+"\n\n", content)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	})
+
+	request := openai.ChatCompletionRequest{
+		Model:    openai.GPT4oMini,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hello!"}},
+	}
+	readAll := func() (string, int) {
+		stream, err := client.CreateChatCompletionStream(context.Background(), request)
+		checks.NoError(t, err, "CreateChatCompletionStream error")
+		defer stream.Close()
+
+		var content string
+		for {
+			chunk, recvErr := stream.Recv()
+			if errors.Is(recvErr, io.EOF) {
+				break
+			}
+			checks.NoError(t, recvErr, "Recv error")
+			content += chunk.Choices[0].Delta.Content
+		}
+		return content, stream.GetRateLimitHeaders().RemainingRequests
+	}
+
+	first, _ := readAll()
+	second, remaining := readAll()
+	if calls != 1 {
+		t.Fatalf("expected stream to be replayed from cache, got %d calls", calls)
+	}
+	if first != "Hello" || second != "Hello" {
+		t.Errorf("unexpected replayed content %q / %q", first, second)
+	}
+	if remaining != 9 {
+		t.Errorf("expected replayed headers, got remaining requests %d", remaining)
+	}
+}
+
+func TestEmbeddingsResponseCache(t *testing.T) {
+	client, server, teardown := setupCachedTestServer(t)
+	defer teardown()
+
+	calls := 0
+	server.RegisterHandler("/v1/embeddings", func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		resBytes, _ := json.Marshal(openai.EmbeddingResponse{
+			Data: []openai.Embedding{{Embedding: []float32{0.1, 0.2}}},
+		})
+		fmt.Fprintln(w, string(resBytes))
+	})
+
+	request := openai.EmbeddingRequestStrings{Input: []string{"hello"}, Model: openai.SmallEmbedding3}
+	for i := 0; i < 3; i++ {
+		res, err := client.CreateEmbeddings(context.Background(), request)
+		checks.NoError(t, err, "CreateEmbeddings error")
+		if len(res.Data) != 1 || len(res.Data[0].Embedding) != 2 {
+			t.Fatalf("unexpected embeddings response %+v", res)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("expected 1 upstream call, got %d", calls)
+	}
+}
@@ -42,11 +42,111 @@ type Violence struct {
 	Severity string `json:"severity,omitempty"`
 }
 
+type JailBreak struct {
+	Filtered bool `json:"filtered"`
+	Detected bool `json:"detected"`
+}
+type IndirectAttack struct {
+	Filtered bool `json:"filtered"`
+	Detected bool `json:"detected"`
+}
+type Profanity struct {
+	Filtered bool `json:"filtered"`
+	Detected bool `json:"detected"`
+}
+type ProtectedMaterial struct {
+	Filtered bool                       `json:"filtered"`
+	Detected bool                       `json:"detected"`
+	Citation *ProtectedMaterialCitation `json:"citation,omitempty"`
+}
+type ProtectedMaterialCitation struct {
+	URL     string `json:"URL,omitempty"`
+	License string `json:"license,omitempty"`
+}
+
+// ContentFilterBlocklists reports matches against custom blocklists. Older API
+// versions send a bare array of blocklist results, newer ones an object with
+// details; both decode into this type.
+type ContentFilterBlocklists struct {
+	Filtered bool                     `json:"filtered"`
+	Details  []ContentFilterBlocklist `json:"details,omitempty"`
+}
+type ContentFilterBlocklist struct {
+	Filtered bool   `json:"filtered"`
+	ID       string `json:"id"`
+}
+
+func (b *ContentFilterBlocklists) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '[' {
+		var details []ContentFilterBlocklist
+		if err := json.Unmarshal(data, &details); err != nil {
+			return err
+		}
+		b.Details = details
+		b.Filtered = false
+		for _, d := range details {
+			b.Filtered = b.Filtered || d.Filtered
+		}
+		return nil
+	}
+	type alias ContentFilterBlocklists
+	return json.Unmarshal(data, (*alias)(b))
+}
+
+// ContentFilterError is set when Azure could not run the content filters.
+type ContentFilterError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ContentFilterResults are the Azure OpenAI content filter annotations of a
+// prompt or completion. They are only returned by Azure.
 type ContentFilterResults struct {
-	Hate     Hate     `json:"hate,omitempty"`
-	SelfHarm SelfHarm `json:"self_harm,omitempty"`
-	Sexual   Sexual   `json:"sexual,omitempty"`
-	Violence Violence `json:"violence,omitempty"`
+	Hate                  Hate                     `json:"hate,omitempty"`
+	SelfHarm              SelfHarm                 `json:"self_harm,omitempty"`
+	Sexual                Sexual                   `json:"sexual,omitempty"`
+	Violence              Violence                 `json:"violence,omitempty"`
+	JailBreak             JailBreak                `json:"jailbreak,omitempty"`
+	IndirectAttack        IndirectAttack           `json:"indirect_attack,omitempty"`
+	Profanity             Profanity                `json:"profanity,omitempty"`
+	ProtectedMaterialText ProtectedMaterial        `json:"protected_material_text,omitempty"`
+	ProtectedMaterialCode ProtectedMaterial        `json:"protected_material_code,omitempty"`
+	CustomBlocklists      *ContentFilterBlocklists `json:"custom_blocklists,omitempty"`
+	Error                 *ContentFilterError      `json:"error,omitempty"`
+}
+
+// Filtered reports whether any content filter category filtered the content.
+func (r ContentFilterResults) Filtered() bool {
+	return r.Hate.Filtered || r.SelfHarm.Filtered || r.Sexual.Filtered || r.Violence.Filtered ||
+		r.JailBreak.Filtered || r.IndirectAttack.Filtered || r.Profanity.Filtered ||
+		r.ProtectedMaterialText.Filtered || r.ProtectedMaterialCode.Filtered ||
+		(r.CustomBlocklists != nil && r.CustomBlocklists.Filtered)
+}
+
+// FilteredCategories returns the names of the categories that filtered the
+// content, as they appear in the JSON.
+func (r ContentFilterResults) FilteredCategories() []string {
+	var categories []string
+	for _, category := range []struct {
+		name     string
+		filtered bool
+	}{
+		{"hate", r.Hate.Filtered},
+		{"self_harm", r.SelfHarm.Filtered},
+		{"sexual", r.Sexual.Filtered},
+		{"violence", r.Violence.Filtered},
+		{"jailbreak", r.JailBreak.Filtered},
+		{"indirect_attack", r.IndirectAttack.Filtered},
+		{"profanity", r.Profanity.Filtered},
+		{"protected_material_text", r.ProtectedMaterialText.Filtered},
+		{"protected_material_code", r.ProtectedMaterialCode.Filtered},
+		{"custom_blocklists", r.CustomBlocklists != nil && r.CustomBlocklists.Filtered},
+	} {
+		if category.filtered {
+			categories = append(categories, category.name)
+		}
+	}
+	return categories
 }
 
 type PromptAnnotation struct {
@@ -82,16 +182,18 @@ type ChatMessageFileData struct {
 type ChatMessagePartType string
 
 const (
-	ChatMessagePartTypeText     ChatMessagePartType = "text"
-	ChatMessagePartTypeImageURL ChatMessagePartType = "image_url"
-	ChatMessagePartTypeFile     ChatMessagePartType = "file"
+	ChatMessagePartTypeText       ChatMessagePartType = "text"
+	ChatMessagePartTypeImageURL   ChatMessagePartType = "image_url"
+	ChatMessagePartTypeFile       ChatMessagePartType = "file"
+	ChatMessagePartTypeInputAudio ChatMessagePartType = "input_audio"
 )
 
 type ChatMessagePart struct {
-	Type     ChatMessagePartType  `json:"type,omitempty"`
-	Text     string               `json:"text,omitempty"`
-	ImageURL *ChatMessageImageURL `json:"image_url,omitempty"`
-	File     *ChatMessageFileData `json:"file,omitempty"`
+	Type       ChatMessagePartType    `json:"type,omitempty"`
+	Text       string                 `json:"text,omitempty"`
+	ImageURL   *ChatMessageImageURL   `json:"image_url,omitempty"`
+	File       *ChatMessageFileData   `json:"file,omitempty"`
+	InputAudio *ChatMessageInputAudio `json:"input_audio,omitempty"`
 }
 
 type ChatCompletionMessage struct {
@@ -119,6 +221,10 @@ type ChatCompletionMessage struct {
 
 	// Reasoning contains model reasoning from certain Cerebras / Groq models with reasoning enabled.
 	Reasoning string `json:"reasoning,omitempty"`
+
+	// Audio is the audio of an assistant message when audio output was requested. Use WithAudioReference to
+	// send the message back in a later turn.
+	Audio *ChatCompletionAudio `json:"audio,omitempty"`
 }
 
 func (m ChatCompletionMessage) MarshalJSON() ([]byte, error) {
@@ -127,28 +233,30 @@ func (m ChatCompletionMessage) MarshalJSON() ([]byte, error) {
 	}
 	if len(m.MultiContent) > 0 {
 		msg := struct {
-			Role             string            `json:"role"`
-			Content          string            `json:"-"`
-			MultiContent     []ChatMessagePart `json:"content,omitempty"`
-			Name             string            `json:"name,omitempty"`
-			FunctionCall     *FunctionCall     `json:"function_call,omitempty"`
-			ToolCalls        []ToolCall        `json:"tool_calls,omitempty"`
-			ToolCallID       string            `json:"tool_call_id,omitempty"`
-			ReasoningContent string            `json:"reasoning_content,omitempty"`
-			Reasoning        string            `json:"reasoning,omitempty"`
+			Role             string               `json:"role"`
+			Content          string               `json:"-"`
+			MultiContent     []ChatMessagePart    `json:"content,omitempty"`
+			Name             string               `json:"name,omitempty"`
+			FunctionCall     *FunctionCall        `json:"function_call,omitempty"`
+			ToolCalls        []ToolCall           `json:"tool_calls,omitempty"`
+			ToolCallID       string               `json:"tool_call_id,omitempty"`
+			ReasoningContent string               `json:"reasoning_content,omitempty"`
+			Reasoning        string               `json:"reasoning,omitempty"`
+			Audio            *ChatCompletionAudio `json:"audio,omitempty"`
 		}(m)
 		return json.Marshal(msg)
 	}
 	msg := struct {
-		Role             string            `json:"role"`
-		Content          string            `json:"content"`
-		MultiContent     []ChatMessagePart `json:"-"`
-		Name             string            `json:"name,omitempty"`
-		FunctionCall     *FunctionCall     `json:"function_call,omitempty"`
-		ToolCalls        []ToolCall        `json:"tool_calls,omitempty"`
-		ToolCallID       string            `json:"tool_call_id,omitempty"`
-		ReasoningContent string            `json:"reasoning_content,omitempty"`
-		Reasoning        string            `json:"reasoning,omitempty"`
+		Role             string               `json:"role"`
+		Content          string               `json:"content"`
+		MultiContent     []ChatMessagePart    `json:"-"`
+		Name             string               `json:"name,omitempty"`
+		FunctionCall     *FunctionCall        `json:"function_call,omitempty"`
+		ToolCalls        []ToolCall           `json:"tool_calls,omitempty"`
+		ToolCallID       string               `json:"tool_call_id,omitempty"`
+		ReasoningContent string               `json:"reasoning_content,omitempty"`
+		Reasoning        string               `json:"reasoning,omitempty"`
+		Audio            *ChatCompletionAudio `json:"audio,omitempty"`
 	}(m)
 	return json.Marshal(msg)
 }
@@ -158,12 +266,13 @@ func (m *ChatCompletionMessage) UnmarshalJSON(bs []byte) error {
 		Role             string `json:"role"`
 		Content          string `json:"content"`
 		MultiContent     []ChatMessagePart
-		Name             string        `json:"name,omitempty"`
-		FunctionCall     *FunctionCall `json:"function_call,omitempty"`
-		ToolCalls        []ToolCall    `json:"tool_calls,omitempty"`
-		ToolCallID       string        `json:"tool_call_id,omitempty"`
-		ReasoningContent string        `json:"reasoning_content,omitempty"`
-		Reasoning        string        `json:"reasoning,omitempty"`
+		Name             string               `json:"name,omitempty"`
+		FunctionCall     *FunctionCall        `json:"function_call,omitempty"`
+		ToolCalls        []ToolCall           `json:"tool_calls,omitempty"`
+		ToolCallID       string               `json:"tool_call_id,omitempty"`
+		ReasoningContent string               `json:"reasoning_content,omitempty"`
+		Reasoning        string               `json:"reasoning,omitempty"`
+		Audio            *ChatCompletionAudio `json:"audio,omitempty"`
 	}{}
 	if err := json.Unmarshal(bs, &msg); err == nil {
 		*m = ChatCompletionMessage(msg)
@@ -172,13 +281,14 @@ func (m *ChatCompletionMessage) UnmarshalJSON(bs []byte) error {
 	multiMsg := struct {
 		Role             string `json:"role"`
 		Content          string
-		MultiContent     []ChatMessagePart `json:"content"`
-		Name             string            `json:"name,omitempty"`
-		FunctionCall     *FunctionCall     `json:"function_call,omitempty"`
-		ToolCalls        []ToolCall        `json:"tool_calls,omitempty"`
-		ToolCallID       string            `json:"tool_call_id,omitempty"`
-		ReasoningContent string            `json:"reasoning_content,omitempty"`
-		Reasoning        string            `json:"reasoning,omitempty"`
+		MultiContent     []ChatMessagePart    `json:"content"`
+		Name             string               `json:"name,omitempty"`
+		FunctionCall     *FunctionCall        `json:"function_call,omitempty"`
+		ToolCalls        []ToolCall           `json:"tool_calls,omitempty"`
+		ToolCallID       string               `json:"tool_call_id,omitempty"`
+		ReasoningContent string               `json:"reasoning_content,omitempty"`
+		Reasoning        string               `json:"reasoning,omitempty"`
+		Audio            *ChatCompletionAudio `json:"audio,omitempty"`
 	}{}
 	if err := json.Unmarshal(bs, &multiMsg); err != nil {
 		return err
@@ -266,12 +376,35 @@ type ChatCompletionRequest struct {
 	ReasoningEffort string         `json:"reasoning_effort,omitempty"`
 	// Verbosity constrains the verbosity of the model's response. Lower values will result in more concise responses, while higher values will result in more verbose responses.
 	Verbosity string `json:"verbosity,omitempty"`
+	// Modalities are the kinds of output to generate, text by default. Audio output needs Audio set too.
+	Modalities []ChatCompletionModality `json:"modalities,omitempty"`
+	// Audio sets the voice and format of audio output.
+	Audio *ChatCompletionAudioOptions `json:"audio,omitempty"`
 	// Specifies the latency tier to use for processing the request.
 	ServiceTier ServiceTier `json:"service_tier,omitempty"`
-	// ExtraBody allows passing provider-specific parameters that aren't part of the standard OpenAI API.
-	// For example, Google's Gemini API uses this for thinking_config:
-	// ExtraBody: map[string]any{"google": map[string]any{"thinking_config": map[string]any{"include_thoughts": true}}}
-	ExtraBody map[string]any `json:"extra_body,omitempty"`
+	// Store asks OpenAI to keep the completion for use in evals and distillation.
+	Store bool `json:"store,omitempty"`
+	// Metadata is up to 16 key-value pairs attached to the completion, used to filter stored completions.
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// ExtraBody holds provider-specific parameters that aren't part of the standard OpenAI API.
+	// They are merged into the top level of the request JSON, overriding fields of the same name,
+	// like extra_body in the Python SDK.
+	//
+	// Breaking change: ExtraBody used to be sent as a literal extra_body field. Clients whose
+	// ProviderProfile sets ExtraBodyField, such as ProviderGemini, still nest it that way, so
+	// ExtraBody: map[string]any{"google": map[string]any{"thinking_config": ...}} reaches Gemini
+	// as extra_body.google. Other clients of a backend that reads extra_body must now nest it
+	// themselves: ExtraBody: map[string]any{"extra_body": map[string]any{"google": ...}}.
+	ExtraBody map[string]any `json:"-"`
+}
+
+func (r ChatCompletionRequest) MarshalJSON() ([]byte, error) {
+	type chatCompletionRequest ChatCompletionRequest
+	data, err := json.Marshal(chatCompletionRequest(r))
+	if err != nil {
+		return nil, err
+	}
+	return mergeJSONFields(data, r.ExtraBody)
 }
 
 type StreamOptions struct {
@@ -377,6 +510,8 @@ type ChatCompletionChoice struct {
 	// null: API response still in progress or incomplete
 	FinishReason FinishReason `json:"finish_reason"`
 	LogProbs     *LogProbs    `json:"logprobs,omitempty"`
+	// ContentFilterResults is only returned by Azure OpenAI.
+	ContentFilterResults ContentFilterResults `json:"content_filter_results,omitempty"`
 }
 
 // ChatCompletionResponse represents a response structure for chat completion API.
@@ -389,14 +524,18 @@ type ChatCompletionResponse struct {
 	Usage             Usage                  `json:"usage"`
 	SystemFingerprint string                 `json:"system_fingerprint"`
 	ServiceTier       ServiceTier            `json:"service_tier,omitempty"`
+	// PromptFilterResults is only returned by Azure OpenAI.
+	PromptFilterResults []PromptFilterResult `json:"prompt_filter_results,omitempty"`
 
 	httpHeader
+	rawJSON
 }
 
 // CreateChatCompletion — API call to Create a completion for the chat message.
 func (c *Client) CreateChatCompletion(
 	ctx context.Context,
 	request ChatCompletionRequest,
+	opts ...RequestOption,
 ) (response ChatCompletionResponse, err error) {
 	if request.Stream {
 		err = ErrChatCompletionStreamNotSupported
@@ -409,11 +548,24 @@ func (c *Client) CreateChatCompletion(
 		return
 	}
 
-	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL(urlSuffix, request.Model), withBody(request))
-	if err != nil {
-		return
+	response, err = callWithPolicies(ctx, c.policies, policyCall[ChatCompletionResponse]{
+		endpoint: urlSuffix,
+		model:    request.Model,
+		hedge:    true,
+		do: func(ctx context.Context) (response ChatCompletionResponse, err error) {
+			req, err := c.newRequest(ctx, http.MethodPost, c.fullURL(urlSuffix, request.Model), withBody(request),
+				withResponseCache(), withRequestOptions(opts))
+			if err != nil {
+				return
+			}
+			err = c.sendRequest(req, &response)
+			return
+		},
+	})
+	if err == nil && c.config.Provider != nil {
+		for i := range response.Choices {
+			c.config.Provider.normalizeMessageReasoning(&response.Choices[i].Message)
+		}
 	}
-
-	err = c.sendRequest(req, &response)
 	return
 }
@@ -0,0 +1,220 @@
+package openai
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"strings"
+	"time"
+)
+
+// ChatCompletionModality is a kind of output a chat completion can generate.
+type ChatCompletionModality string
+
+const (
+	ChatCompletionModalityText  ChatCompletionModality = "text"
+	ChatCompletionModalityAudio ChatCompletionModality = "audio"
+)
+
+// ChatCompletionAudioFormat is the format of audio output. Streamed audio must
+// be pcm16.
+type ChatCompletionAudioFormat string
+
+const (
+	ChatCompletionAudioFormatWAV   ChatCompletionAudioFormat = "wav"
+	ChatCompletionAudioFormatMP3   ChatCompletionAudioFormat = "mp3"
+	ChatCompletionAudioFormatFLAC  ChatCompletionAudioFormat = "flac"
+	ChatCompletionAudioFormatOpus  ChatCompletionAudioFormat = "opus"
+	ChatCompletionAudioFormatAAC   ChatCompletionAudioFormat = "aac"
+	ChatCompletionAudioFormatPCM16 ChatCompletionAudioFormat = "pcm16"
+)
+
+// ChatAudioPCM16SampleRate is the sample rate of pcm16 audio output, which is
+// 16-bit little-endian mono.
+const ChatAudioPCM16SampleRate = 24000
+
+// ChatCompletionAudioOptions sets up audio output, requested by including
+// ChatCompletionModalityAudio in the request's Modalities.
+type ChatCompletionAudioOptions struct {
+	Voice  SpeechVoice               `json:"voice"`
+	Format ChatCompletionAudioFormat `json:"format"`
+}
+
+// InputAudioFormat is the format of audio in an input_audio message part.
+type InputAudioFormat string
+
+const (
+	InputAudioFormatWAV InputAudioFormat = "wav"
+	InputAudioFormatMP3 InputAudioFormat = "mp3"
+)
+
+type ChatMessageInputAudio struct {
+	// Data is the base64-encoded audio.
+	Data   string           `json:"data"`
+	Format InputAudioFormat `json:"format"`
+}
+
+// NewInputAudioPart returns a message part with audio for the model to
+// listen to.
+func NewInputAudioPart(audio []byte, format InputAudioFormat) ChatMessagePart {
+	return ChatMessagePart{
+		Type:       ChatMessagePartTypeInputAudio,
+		InputAudio: &ChatMessageInputAudio{Data: base64.StdEncoding.EncodeToString(audio), Format: format},
+	}
+}
+
+// ChatCompletionAudio is audio generated by the model. In streamed deltas
+// each field holds the next chunk, if any. In a request, only ID is sent,
+// to refer to the audio of an earlier turn.
+type ChatCompletionAudio struct {
+	ID string `json:"id,omitempty"`
+	// Data is the base64-encoded audio in the requested format.
+	Data string `json:"data,omitempty"`
+	// ExpiresAt is the Unix time after which the audio can no longer be
+	// referred to by ID.
+	ExpiresAt  int64  `json:"expires_at,omitempty"`
+	Transcript string `json:"transcript,omitempty"`
+}
+
+// Bytes decodes the audio.
+func (a *ChatCompletionAudio) Bytes() ([]byte, error) {
+	return base64.StdEncoding.DecodeString(a.Data)
+}
+
+// WriteTo writes the decoded audio to w.
+func (a *ChatCompletionAudio) WriteTo(w io.Writer) (int64, error) {
+	data, err := a.Bytes()
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(data)
+	return int64(n), err
+}
+
+// WriteWAV writes audio requested in the pcm16 format to w as a WAV file.
+func (a *ChatCompletionAudio) WriteWAV(w io.Writer) error {
+	pcm, err := a.Bytes()
+	if err != nil {
+		return err
+	}
+	return WriteWAV(w, pcm, ChatAudioPCM16SampleRate)
+}
+
+// WithAudioReference returns the message as it should be sent back in a
+// later turn: with its audio referred to by ID rather than sent again, or,
+// once the audio has expired, replaced by its transcript.
+func (m ChatCompletionMessage) WithAudioReference() ChatCompletionMessage {
+	if m.Audio == nil {
+		return m
+	}
+	if m.Audio.ExpiresAt != 0 && time.Now().Unix() >= m.Audio.ExpiresAt {
+		if m.Content == "" && m.MultiContent == nil {
+			m.Content = m.Audio.Transcript
+		}
+		m.Audio = nil
+		return m
+	}
+	m.Audio = &ChatCompletionAudio{ID: m.Audio.ID}
+	return m
+}
+
+// WriteWAV writes pcm, 16-bit little-endian mono samples at sampleRate, as a
+// WAV file. Use ChatAudioPCM16SampleRate for pcm16 audio output.
+func WriteWAV(w io.Writer, pcm []byte, sampleRate int) error {
+	const (
+		channels      = 1
+		bitsPerSample = 16
+	)
+	var header bytes.Buffer
+	header.WriteString("RIFF")
+	for _, v := range []any{
+		uint32(36 + len(pcm)),
+		[]byte("WAVEfmt "),
+		uint32(16), // The size of the fmt chunk.
+		uint16(1),  // PCM.
+		uint16(channels),
+		uint32(sampleRate),
+		uint32(sampleRate * channels * bitsPerSample / 8),
+		uint16(channels * bitsPerSample / 8),
+		uint16(bitsPerSample),
+		[]byte("data"),
+		uint32(len(pcm)),
+	} {
+		_ = binary.Write(&header, binary.LittleEndian, v)
+	}
+	if _, err := w.Write(header.Bytes()); err != nil {
+		return err
+	}
+	_, err := w.Write(pcm)
+	return err
+}
+
+// ChatCompletionAudioCollector assembles the audio streamed in the deltas of
+// a chat completion stream:
+//
+//	collector := &openai.ChatCompletionAudioCollector{W: speaker}
+//	for {
+//		response, err := stream.Recv()
+//		...
+//		if err := collector.Add(response.Choices[0].Delta.Audio); err != nil {
+//			...
+//		}
+//	}
+//	history = append(history, collector.Message())
+type ChatCompletionAudioCollector struct {
+	// W, if set, receives the audio as it arrives, so that playback can start
+	// before the response is complete. The audio is then not kept.
+	W io.Writer
+
+	id         string
+	expiresAt  int64
+	transcript strings.Builder
+	data       bytes.Buffer
+}
+
+// Add adds the chunk of audio in a delta, which may be nil.
+func (c *ChatCompletionAudioCollector) Add(delta *ChatCompletionAudio) error {
+	if delta == nil {
+		return nil
+	}
+	if delta.ID != "" {
+		c.id = delta.ID
+	}
+	if delta.ExpiresAt != 0 {
+		c.expiresAt = delta.ExpiresAt
+	}
+	c.transcript.WriteString(delta.Transcript)
+	if delta.Data == "" {
+		return nil
+	}
+	chunk, err := delta.Bytes()
+	if err != nil {
+		return err
+	}
+	if c.W != nil {
+		_, err = c.W.Write(chunk)
+		return err
+	}
+	c.data.Write(chunk)
+	return nil
+}
+
+// Audio returns the audio collected so far. Data is empty if it was written
+// to W.
+func (c *ChatCompletionAudioCollector) Audio() ChatCompletionAudio {
+	audio := ChatCompletionAudio{ID: c.id, ExpiresAt: c.expiresAt, Transcript: c.transcript.String()}
+	if c.data.Len() > 0 {
+		audio.Data = base64.StdEncoding.EncodeToString(c.data.Bytes())
+	}
+	return audio
+}
+
+// Message returns the assistant message to add to the conversation, with the
+// audio referred to by ID.
+func (c *ChatCompletionAudioCollector) Message() ChatCompletionMessage {
+	return ChatCompletionMessage{
+		Role:  ChatMessageRoleAssistant,
+		Audio: &ChatCompletionAudio{ID: c.id},
+	}
+}
@@ -0,0 +1,139 @@
+package openai_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gradientlabs-ai/go-openai"
+	"github.com/gradientlabs-ai/go-openai/internal/test/checks"
+)
+
+var pcm16Output = &openai.ChatCompletionAudioOptions{
+	Voice:  openai.VoiceAlloy,
+	Format: openai.ChatCompletionAudioFormatPCM16,
+}
+
+func TestChatCompletionAudio(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	pcm := []byte{1, 0, 2, 0, 3, 0}
+	var request map[string]any
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		checks.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		resp := openai.ChatCompletionResponse{Choices: []openai.ChatCompletionChoice{{
+			Message: openai.ChatCompletionMessage{
+				Role: openai.ChatMessageRoleAssistant,
+				Audio: &openai.ChatCompletionAudio{
+					ID:         "audio_1",
+					Data:       base64.StdEncoding.EncodeToString(pcm),
+					ExpiresAt:  time.Now().Add(time.Hour).Unix(),
+					Transcript: "Hello there",
+				},
+			},
+		}}}
+		checks.NoError(t, json.NewEncoder(w).Encode(resp))
+	})
+
+	resp, err := client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{
+		Model:      openai.GPT4oAudioPreview,
+		Modalities: []openai.ChatCompletionModality{openai.ChatCompletionModalityText, openai.ChatCompletionModalityAudio},
+		Audio:      pcm16Output,
+		Messages: []openai.ChatCompletionMessage{{
+			Role: openai.ChatMessageRoleUser,
+			MultiContent: []openai.ChatMessagePart{
+				openai.NewTextPart("What is said here?"),
+				openai.NewInputAudioPart([]byte("RIFF"), openai.InputAudioFormatWAV),
+			},
+		}},
+	})
+	checks.NoError(t, err)
+	if request["audio"].(map[string]any)["voice"] != "alloy" || len(request["modalities"].([]any)) != 2 {
+		t.Fatalf("unexpected audio options %v", request)
+	}
+	part := request["messages"].([]any)[0].(map[string]any)["content"].([]any)[1].(map[string]any)
+	if part["type"] != "input_audio" || part["input_audio"].(map[string]any)["data"] != "UklGRg==" {
+		t.Fatalf("unexpected audio part %v", part)
+	}
+
+	message := resp.Choices[0].Message
+	var wav bytes.Buffer
+	checks.NoError(t, message.Audio.WriteWAV(&wav))
+	if !bytes.HasPrefix(wav.Bytes(), []byte("RIFF")) || !bytes.HasSuffix(wav.Bytes(), pcm) || wav.Len() != 44+len(pcm) {
+		t.Fatalf("unexpected WAV file %q", wav.Bytes())
+	}
+	if rate := binary.LittleEndian.Uint32(wav.Bytes()[24:28]); rate != openai.ChatAudioPCM16SampleRate {
+		t.Fatalf("unexpected sample rate %d", rate)
+	}
+
+	data, err := json.Marshal(message.WithAudioReference())
+	checks.NoError(t, err)
+	if !strings.Contains(string(data), `"audio":{"id":"audio_1"}`) {
+		t.Fatalf("expected the audio to be referred to by ID, got %s", data)
+	}
+	message.Audio.ExpiresAt = time.Now().Add(-time.Minute).Unix()
+	if expired := message.WithAudioReference(); expired.Audio != nil || expired.Content != "Hello there" {
+		t.Fatalf("expected expired audio to be replaced by its transcript, got %+v", expired)
+	}
+}
+
+func TestChatCompletionAudioStream(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, delta := range []string{
+			`{"role": "assistant", "audio": {"id": "audio_2", "transcript": "Hi"}}`,
+			`{"audio": {"data": "AQI=", "transcript": " there"}}`,
+			`{"audio": {"data": "AwQ=", "expires_at": 1700000000}}`,
+		} {
+			w.Write([]byte(`data: {"id": "1", "choices": [{"index": 0, "delta": ` + delta + "}]}\n\n"))
+		}
+		w.Write([]byte("data: [DONE]\n\n"))
+	})
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), openai.ChatCompletionRequest{
+		Model:      openai.GPT4oAudioPreview,
+		Modalities: []openai.ChatCompletionModality{openai.ChatCompletionModalityText, openai.ChatCompletionModalityAudio},
+		Audio:      pcm16Output,
+		Messages:   []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hi"}},
+	})
+	checks.NoError(t, err)
+	defer stream.Close()
+
+	var played bytes.Buffer
+	collector := &openai.ChatCompletionAudioCollector{W: &played}
+	for {
+		resp, recvErr := stream.Recv()
+		if errors.Is(recvErr, io.EOF) {
+			break
+		}
+		checks.NoError(t, recvErr)
+		checks.NoError(t, collector.Add(resp.Choices[0].Delta.Audio))
+	}
+	if !bytes.Equal(played.Bytes(), []byte{1, 2, 3, 4}) {
+		t.Fatalf("unexpected audio %v", played.Bytes())
+	}
+	audio := collector.Audio()
+	if audio.ID != "audio_2" || audio.Transcript != "Hi there" || audio.ExpiresAt != 1700000000 || audio.Data != "" {
+		t.Fatalf("unexpected collected audio %+v", audio)
+	}
+	if message := collector.Message(); message.Role != openai.ChatMessageRoleAssistant || message.Audio.ID != "audio_2" {
+		t.Fatalf("unexpected message %+v", message)
+	}
+
+	kept := &openai.ChatCompletionAudioCollector{}
+	checks.NoError(t, kept.Add(&openai.ChatCompletionAudio{Data: "AQI="}))
+	checks.NoError(t, kept.Add(&openai.ChatCompletionAudio{Data: "AwQ="}))
+	if audio = kept.Audio(); audio.Data != base64.StdEncoding.EncodeToString([]byte{1, 2, 3, 4}) {
+		t.Fatalf("expected the audio to be kept, got %+v", audio)
+	}
+}
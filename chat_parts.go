@@ -0,0 +1,429 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif" // Registers the GIF decoder for image.Decode.
+	"image/jpeg"
+	"image/png"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	ErrImageTooLarge        = errors.New("image cannot be shrunk to fit the size limit")
+	ErrFileTooLargeToInline = errors.New("file is too large to send inline and there is no client to upload it with")
+	ErrDownloadTooLarge     = errors.New("downloaded content is larger than the builder accepts")
+)
+
+const (
+	// DefaultMaxInlineFileBytes is the largest file ChatMessagePartBuilder
+	// sends inline unless told otherwise.
+	DefaultMaxInlineFileBytes = 8 << 20
+	// DefaultMaxImagePixels is the largest image, in pixels, that
+	// ChatMessagePartBuilder decodes unless told otherwise.
+	DefaultMaxImagePixels = 50_000_000
+
+	// maxUploadFileBytes is the largest file the Files API accepts.
+	maxUploadFileBytes = 512 << 20
+	// maxChatImageBytes is the largest image the API accepts in a message.
+	maxChatImageBytes = 20 << 20
+)
+
+// NewTextPart returns a text message part.
+func NewTextPart(text string) ChatMessagePart {
+	return ChatMessagePart{Type: ChatMessagePartTypeText, Text: text}
+}
+
+// NewImageURLPart returns an image part for an image the API fetches from
+// url, which may also be a data URL.
+func NewImageURLPart(url string, detail ImageURLDetail) ChatMessagePart {
+	return ChatMessagePart{Type: ChatMessagePartTypeImageURL, ImageURL: &ChatMessageImageURL{URL: url, Detail: detail}}
+}
+
+// ChatMessagePartBuilder builds message parts from content, working out its
+// MIME type from the content itself and, failing that, its file name. PNG,
+// JPEG, GIF and WebP images become image_url parts with a data URL, WAV and
+// MP3 audio input_audio parts, and anything else a file part, sent inline or
+// uploaded depending on its size.
+// The zero value sends everything inline and unchanged.
+type ChatMessagePartBuilder struct {
+	// Client uploads files larger than MaxInlineFileBytes. Without it they
+	// are an error.
+	Client *Client
+	// Detail is the detail images are sent with.
+	Detail ImageURLDetail
+	// ResizeImages downscales images to the largest size the model looks at
+	// for Detail: 512x512 for low detail, and otherwise 2048x2048 with the
+	// shorter side at most 768. Larger images only cost bandwidth.
+	ResizeImages bool
+	// MaxImageBytes, if positive, re-encodes larger images as JPEG, lowering
+	// the quality and then the size until they fit. Transparency is lost.
+	MaxImageBytes int
+	// MaxImagePixels is the largest image that is decoded to resize or
+	// re-encode it; larger ones are an error. It defaults to
+	// DefaultMaxImagePixels.
+	MaxImagePixels int
+	// MaxInlineFileBytes is the largest file sent as a data URL, before base64
+	// encoding. It defaults to DefaultMaxInlineFileBytes.
+	MaxInlineFileBytes int
+	// UploadPurpose is the purpose files are uploaded with. It defaults to
+	// PurposeUserData.
+	UploadPurpose PurposeType
+	// HTTPClient downloads the content of URLs for FromURL. It defaults to
+	// http.DefaultClient. Downloads larger than the builder could send, inline
+	// or uploaded, are an error.
+	HTTPClient *http.Client
+}
+
+// FromBytes builds a part from data. filename names file parts and helps
+// to tell the type of content that can't be recognised from its bytes.
+func (b ChatMessagePartBuilder) FromBytes(ctx context.Context, data []byte, filename string) (ChatMessagePart, error) {
+	return b.build(ctx, data, filename, detectMIMEType(data, filename))
+}
+
+// FromReader builds a part from everything read from r.
+func (b ChatMessagePartBuilder) FromReader(ctx context.Context, r io.Reader, filename string) (ChatMessagePart, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return ChatMessagePart{}, err
+	}
+	return b.FromBytes(ctx, data, filename)
+}
+
+// FromFile builds a part from a local file.
+func (b ChatMessagePartBuilder) FromFile(ctx context.Context, name string) (ChatMessagePart, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return ChatMessagePart{}, err
+	}
+	return b.FromBytes(ctx, data, filepath.Base(name))
+}
+
+// FromURL builds a part from a data URL or an http or https URL. Images whose
+// URL ends in an image extension are left for the API to fetch unless they
+// need resizing or a size limit applies; everything else is downloaded,
+// since the API only fetches images.
+func (b ChatMessagePartBuilder) FromURL(ctx context.Context, rawURL string) (ChatMessagePart, error) {
+	if strings.HasPrefix(rawURL, "data:") {
+		mimeType, data, err := decodeDataURL(rawURL)
+		if err != nil {
+			return ChatMessagePart{}, err
+		}
+		if mimeType == "" {
+			mimeType = detectMIMEType(data, "")
+		}
+		return b.build(ctx, data, "", mimeType)
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ChatMessagePart{}, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return ChatMessagePart{}, fmt.Errorf("unsupported URL scheme %q", u.Scheme)
+	}
+	if isChatImageType(mediaType(mime.TypeByExtension(path.Ext(u.Path)))) && !b.ResizeImages && b.MaxImageBytes <= 0 {
+		return NewImageURLPart(rawURL, b.Detail), nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return ChatMessagePart{}, err
+	}
+	client := b.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return ChatMessagePart{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		return ChatMessagePart{}, fmt.Errorf("download %s: %s", rawURL, resp.Status)
+	}
+	filename := path.Base(u.Path)
+	if filename == "/" || filename == "." {
+		filename = ""
+	}
+	mimeType := mediaType(resp.Header.Get("Content-Type"))
+	limit := b.downloadLimit(mimeType)
+	data, err := io.ReadAll(io.LimitReader(resp.Body, int64(limit)+1))
+	if err != nil {
+		return ChatMessagePart{}, err
+	}
+	if len(data) > limit {
+		return ChatMessagePart{}, fmt.Errorf("%w: %s is over %d bytes", ErrDownloadTooLarge, rawURL, limit)
+	}
+	if mimeType == "" || mimeType == "application/octet-stream" {
+		mimeType = detectMIMEType(data, filename)
+	}
+	return b.build(ctx, data, filename, mimeType)
+}
+
+// downloadLimit is the most FromURL reads of content of mimeType: files
+// larger than the inline limit can only be uploaded, and images may be
+// shrunk to fit.
+func (b ChatMessagePartBuilder) downloadLimit(mimeType string) int {
+	limit := b.maxInlineFileBytes()
+	if b.Client != nil {
+		limit = maxUploadFileBytes
+	}
+	if isChatImageType(mimeType) {
+		limit = maxInt(limit, maxInt(b.MaxImageBytes, maxChatImageBytes))
+	}
+	return limit
+}
+
+func (b ChatMessagePartBuilder) maxInlineFileBytes() int {
+	if b.MaxInlineFileBytes <= 0 {
+		return DefaultMaxInlineFileBytes
+	}
+	return b.MaxInlineFileBytes
+}
+
+func (b ChatMessagePartBuilder) build(ctx context.Context, data []byte, filename, mimeType string) (
+	ChatMessagePart, error) {
+	if isChatImageType(mimeType) {
+		data, mimeType, err := b.fitImage(data, mimeType)
+		if err != nil {
+			return ChatMessagePart{}, err
+		}
+		return NewImageURLPart(dataURL(mimeType, data), b.Detail), nil
+	}
+	switch mimeType {
+	case "audio/wav", "audio/wave", "audio/x-wav":
+		return NewInputAudioPart(data, InputAudioFormatWAV), nil
+	case "audio/mpeg", "audio/mp3":
+		return NewInputAudioPart(data, InputAudioFormatMP3), nil
+	}
+
+	if filename == "" {
+		filename = "file"
+		if extensions, _ := mime.ExtensionsByType(mimeType); len(extensions) > 0 {
+			filename += extensions[0]
+		}
+	}
+	if len(data) <= b.maxInlineFileBytes() {
+		return ChatMessagePart{
+			Type: ChatMessagePartTypeFile,
+			File: &ChatMessageFileData{FileData: dataURL(mimeType, data), Filename: filename},
+		}, nil
+	}
+	if b.Client == nil {
+		return ChatMessagePart{}, fmt.Errorf("%w: %s is %d bytes", ErrFileTooLargeToInline, filename, len(data))
+	}
+	purpose := b.UploadPurpose
+	if purpose == "" {
+		purpose = PurposeUserData
+	}
+	file, err := b.Client.CreateFileBytes(ctx, FileBytesRequest{Name: filename, Bytes: data, Purpose: purpose})
+	if err != nil {
+		return ChatMessagePart{}, err
+	}
+	return ChatMessagePart{Type: ChatMessagePartTypeFile, File: &ChatMessageFileData{FileID: file.ID}}, nil
+}
+
+// fitImage resizes and re-encodes an image as the builder asks, and returns
+// it unchanged if nothing needs doing.
+func (b ChatMessagePartBuilder) fitImage(data []byte, mimeType string) ([]byte, string, error) {
+	fits := b.MaxImageBytes <= 0 || len(data) <= b.MaxImageBytes
+	if fits && !b.ResizeImages {
+		return data, mimeType, nil
+	}
+	img, err := b.decodeImage(data)
+	if errors.Is(err, ErrImageTooLarge) {
+		return nil, "", err
+	}
+	if err != nil {
+		// WebP has no decoder in the standard library; send it as it is if
+		// it may be.
+		if fits {
+			return data, mimeType, nil
+		}
+		return nil, "", fmt.Errorf("%w: %v", ErrImageTooLarge, err)
+	}
+
+	if b.ResizeImages {
+		bounds := img.Bounds()
+		if width, height := imageSizeForDetail(bounds.Dx(), bounds.Dy(), b.Detail); width < bounds.Dx() {
+			img = scaleImage(img, width, height)
+			var buf bytes.Buffer
+			if mimeType == "image/jpeg" {
+				err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90})
+			} else {
+				mimeType = "image/png"
+				err = png.Encode(&buf, img)
+			}
+			if err != nil {
+				return nil, "", err
+			}
+			data = buf.Bytes()
+		}
+	}
+	if b.MaxImageBytes <= 0 || len(data) <= b.MaxImageBytes {
+		return data, mimeType, nil
+	}
+
+	// Lower the quality first, as that keeps detail the model can use, and
+	// then the size.
+	img = flattenImage(img)
+	for {
+		for _, quality := range []int{85, 70, 55} {
+			var buf bytes.Buffer
+			if err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+				return nil, "", err
+			}
+			if buf.Len() <= b.MaxImageBytes {
+				return buf.Bytes(), "image/jpeg", nil
+			}
+		}
+		bounds := img.Bounds()
+		width, height := bounds.Dx()*3/4, bounds.Dy()*3/4
+		if width < 16 || height < 16 {
+			return nil, "", fmt.Errorf("%w of %d bytes", ErrImageTooLarge, b.MaxImageBytes)
+		}
+		img = scaleImage(img, width, height)
+	}
+}
+
+// decodeImage decodes data, checking its size against MaxImagePixels first so
+// that a small file can't claim a huge image.
+func (b ChatMessagePartBuilder) decodeImage(data []byte) (image.Image, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	budget := int64(b.MaxImagePixels)
+	if budget <= 0 {
+		budget = DefaultMaxImagePixels
+	}
+	if pixels := int64(config.Width) * int64(config.Height); pixels > budget {
+		return nil, fmt.Errorf("%w: %dx%d is over %d pixels", ErrImageTooLarge, config.Width, config.Height, budget)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	return img, err
+}
+
+// imageSizeForDetail returns the size an image is scaled down to before the
+// model sees it at detail.
+func imageSizeForDetail(width, height int, detail ImageURLDetail) (int, int) {
+	longest, shortest := width, height
+	if height > width {
+		longest, shortest = height, width
+	}
+	scale := 1.0
+	fit := func(limit float64, side int) {
+		if s := limit / float64(side); s < scale {
+			scale = s
+		}
+	}
+	if detail == ImageURLDetailLow {
+		fit(512, longest)
+	} else {
+		fit(2048, longest)
+		fit(768, shortest)
+	}
+	return maxInt(1, int(math.Round(float64(width)*scale))), maxInt(1, int(math.Round(float64(height)*scale)))
+}
+
+// scaleImage downscales src to width x height, averaging the source pixels
+// that make up each destination pixel.
+func scaleImage(src image.Image, width, height int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	bounds := src.Bounds()
+	for y := 0; y < height; y++ {
+		y0 := bounds.Min.Y + y*bounds.Dy()/height
+		y1 := maxInt(y0+1, bounds.Min.Y+(y+1)*bounds.Dy()/height)
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*bounds.Dx()/width
+			x1 := maxInt(x0+1, bounds.Min.X+(x+1)*bounds.Dx()/width)
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(cr), g+uint64(cg), b+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			dst.Set(x, y, color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(b / n), A: uint16(a / n)})
+		}
+	}
+	return dst
+}
+
+// flattenImage draws img over white, as JPEG has no transparency.
+func flattenImage(img image.Image) *image.RGBA {
+	bounds := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, bounds.Min, draw.Over)
+	return dst
+}
+
+// detectMIMEType sniffs the type of data, falling back on the extension of
+// filename for content that sniffs as plain text or unknown binary, such as
+// CSV or Markdown.
+func detectMIMEType(data []byte, filename string) string {
+	sniffed := mediaType(http.DetectContentType(data))
+	if sniffed == "application/octet-stream" || sniffed == "text/plain" {
+		if byExtension := mediaType(mime.TypeByExtension(path.Ext(filename))); byExtension != "" {
+			return byExtension
+		}
+	}
+	return sniffed
+}
+
+func isChatImageType(mimeType string) bool {
+	switch mimeType {
+	case "image/png", "image/jpeg", "image/gif", "image/webp":
+		return true
+	default:
+		return false
+	}
+}
+
+// mediaType strips the parameters, such as the charset, from a MIME type.
+func mediaType(mimeType string) string {
+	mediaType, _, _ := strings.Cut(mimeType, ";")
+	return strings.TrimSpace(strings.ToLower(mediaType))
+}
+
+func dataURL(mimeType string, data []byte) string {
+	return "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data)
+}
+
+// decodeDataURL returns the MIME type and content of a data URL.
+func decodeDataURL(rawURL string) (string, []byte, error) {
+	header, content, ok := strings.Cut(strings.TrimPrefix(rawURL, "data:"), ",")
+	if !ok {
+		return "", nil, errors.New("invalid data URL")
+	}
+	if strings.HasSuffix(header, ";base64") {
+		data, err := base64.StdEncoding.DecodeString(content)
+		return mediaType(strings.TrimSuffix(header, ";base64")), data, err
+	}
+	text, err := url.PathUnescape(content)
+	return mediaType(header), []byte(text), err
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
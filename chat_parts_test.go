@@ -0,0 +1,189 @@
+package openai_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"image"
+	"image/png"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gradientlabs-ai/go-openai"
+	"github.com/gradientlabs-ai/go-openai/internal/test/checks"
+)
+
+// noisyPNG returns a PNG of random pixels, which compresses badly.
+func noisyPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	random := rand.New(rand.NewSource(1))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(random.Intn(256))
+	}
+	var buf bytes.Buffer
+	checks.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+// decodeImagePart decodes the data URL of an image part.
+func decodeImagePart(t *testing.T, part openai.ChatMessagePart) (image.Image, string, int) {
+	t.Helper()
+	if part.Type != openai.ChatMessagePartTypeImageURL || part.ImageURL == nil {
+		t.Fatalf("expected an image part, got %+v", part)
+	}
+	header, content, _ := strings.Cut(strings.TrimPrefix(part.ImageURL.URL, "data:"), ";base64,")
+	data, err := base64.StdEncoding.DecodeString(content)
+	checks.NoError(t, err)
+	img, _, err := image.Decode(bytes.NewReader(data))
+	checks.NoError(t, err)
+	return img, header, len(data)
+}
+
+func TestChatMessagePartBuilderImages(t *testing.T) {
+	ctx := context.Background()
+	data := noisyPNG(t, 1024, 800)
+
+	// The zero value sends images as they are.
+	part, err := openai.ChatMessagePartBuilder{}.FromBytes(ctx, data, "")
+	checks.NoError(t, err)
+	if part.ImageURL.URL != "data:image/png;base64,"+base64.StdEncoding.EncodeToString(data) {
+		t.Fatal("expected the image to be sent unchanged")
+	}
+
+	builder := openai.ChatMessagePartBuilder{Detail: openai.ImageURLDetailLow, ResizeImages: true}
+	part, err = builder.FromBytes(ctx, data, "photo.png")
+	checks.NoError(t, err)
+	img, mimeType, _ := decodeImagePart(t, part)
+	if size := img.Bounds().Size(); size != image.Pt(512, 400) || mimeType != "image/png" {
+		t.Fatalf("expected a 512x400 PNG, got %v %s", size, mimeType)
+	}
+	if part.ImageURL.Detail != openai.ImageURLDetailLow {
+		t.Fatalf("unexpected detail %q", part.ImageURL.Detail)
+	}
+
+	builder = openai.ChatMessagePartBuilder{Detail: openai.ImageURLDetailHigh, ResizeImages: true}
+	part, err = builder.FromBytes(ctx, data, "photo.png")
+	checks.NoError(t, err)
+	if img, _, _ = decodeImagePart(t, part); img.Bounds().Size() != image.Pt(983, 768) {
+		t.Fatalf("expected the shorter side to be 768, got %v", img.Bounds().Size())
+	}
+
+	builder = openai.ChatMessagePartBuilder{MaxImageBytes: 50_000}
+	part, err = builder.FromBytes(ctx, data, "photo.png")
+	checks.NoError(t, err)
+	if _, mimeType, size := decodeImagePart(t, part); mimeType != "image/jpeg" || size > 50_000 {
+		t.Fatalf("expected a JPEG of at most 50000 bytes, got %s of %d", mimeType, size)
+	}
+
+	// Images over the pixel budget are not decoded.
+	builder = openai.ChatMessagePartBuilder{ResizeImages: true, MaxImagePixels: 1024 * 799}
+	_, err = builder.FromBytes(ctx, data, "photo.png")
+	checks.ErrorIs(t, err, openai.ErrImageTooLarge)
+
+	builder = openai.ChatMessagePartBuilder{MaxImageBytes: 10}
+	_, err = builder.FromBytes(ctx, []byte("RIFF\x00\x00\x00\x00WEBPVP8 not really webp"), "")
+	checks.ErrorIs(t, err, openai.ErrImageTooLarge)
+}
+
+func TestChatMessagePartBuilderFiles(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	var uploaded string
+	server.RegisterHandler("/v1/files", func(w http.ResponseWriter, r *http.Request) {
+		checks.NoError(t, r.ParseMultipartForm(1<<20))
+		uploaded = r.FormValue("purpose")
+		w.Write([]byte(`{"id": "file-123", "object": "file"}`))
+	})
+	ctx := context.Background()
+	pdf := []byte("%PDF-1.4 a small document")
+
+	path := filepath.Join(t.TempDir(), "table.csv")
+	checks.NoError(t, os.WriteFile(path, []byte("a,b\n1,2\n"), 0o600))
+	part, err := openai.ChatMessagePartBuilder{}.FromFile(ctx, path)
+	checks.NoError(t, err)
+	if part.File == nil || part.File.Filename != "table.csv" || !strings.HasPrefix(part.File.FileData, "data:text/csv;") {
+		t.Fatalf("expected the type of a CSV to come from its extension, got %+v", part)
+	}
+
+	part, err = openai.ChatMessagePartBuilder{}.FromReader(ctx, bytes.NewReader(pdf), "")
+	checks.NoError(t, err)
+	if part.File.Filename != "file.pdf" || !strings.HasPrefix(part.File.FileData, "data:application/pdf;base64,") {
+		t.Fatalf("unexpected inline file %+v", part.File)
+	}
+
+	part, err = openai.ChatMessagePartBuilder{}.FromBytes(ctx, []byte("RIFF\x24\x00\x00\x00WAVEfmt "), "")
+	checks.NoError(t, err)
+	if part.InputAudio == nil || part.InputAudio.Format != openai.InputAudioFormatWAV {
+		t.Fatalf("expected WAV audio to be an input_audio part, got %+v", part)
+	}
+
+	_, err = openai.ChatMessagePartBuilder{MaxInlineFileBytes: 10}.FromBytes(ctx, pdf, "report.pdf")
+	checks.ErrorIs(t, err, openai.ErrFileTooLargeToInline)
+	part, err = openai.ChatMessagePartBuilder{Client: client, MaxInlineFileBytes: 10}.FromBytes(ctx, pdf, "report.pdf")
+	checks.NoError(t, err)
+	if part.File.FileID != "file-123" || part.File.FileData != "" || uploaded != string(openai.PurposeUserData) {
+		t.Fatalf("expected the file to be uploaded, got %+v with purpose %q", part.File, uploaded)
+	}
+}
+
+func TestChatMessagePartBuilderURLs(t *testing.T) {
+	ctx := context.Background()
+	pages := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/report":
+			w.Write([]byte("%PDF-1.4 report"))
+		case "/large":
+			w.Write(bytes.Repeat([]byte("x"), 1<<10))
+		case "/pixel.png":
+			w.Write(noisyPNG(t, 4, 4))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer pages.Close()
+
+	part, err := openai.ChatMessagePartBuilder{}.FromURL(ctx, "https://example.com/cat.jpg?size=large")
+	checks.NoError(t, err)
+	if part.ImageURL == nil || part.ImageURL.URL != "https://example.com/cat.jpg?size=large" {
+		t.Fatalf("expected the API to fetch the image, got %+v", part)
+	}
+
+	part, err = openai.ChatMessagePartBuilder{}.FromURL(ctx, pages.URL+"/report")
+	checks.NoError(t, err)
+	if part.File == nil || part.File.Filename != "report" ||
+		!strings.HasPrefix(part.File.FileData, "data:application/pdf") {
+		t.Fatalf("expected the document to be downloaded, got %+v", part)
+	}
+
+	// An image that has to fit a limit is downloaded to check it.
+	part, err = openai.ChatMessagePartBuilder{MaxImageBytes: 1 << 20}.FromURL(ctx, pages.URL+"/pixel.png")
+	checks.NoError(t, err)
+	if !strings.HasPrefix(part.ImageURL.URL, "data:image/png;base64,") {
+		t.Fatalf("expected an inline image, got %+v", part)
+	}
+
+	part, err = openai.ChatMessagePartBuilder{}.FromURL(ctx, "data:,hello%20there")
+	checks.NoError(t, err)
+	if part.File == nil || part.File.FileData != "data:text/plain;base64,aGVsbG8gdGhlcmU=" {
+		t.Fatalf("unexpected part from a data URL %+v", part)
+	}
+
+	// Content larger than could be sent is not read to the end.
+	_, err = openai.ChatMessagePartBuilder{MaxInlineFileBytes: 100}.FromURL(ctx, pages.URL+"/large")
+	checks.ErrorIs(t, err, openai.ErrDownloadTooLarge)
+
+	_, err = openai.ChatMessagePartBuilder{}.FromURL(ctx, pages.URL+"/missing")
+	checks.HasError(t, err, "a missing document must be an error")
+	_, err = openai.ChatMessagePartBuilder{}.FromURL(ctx, "ftp://example.com/file.pdf")
+	checks.HasError(t, err, "only http and https URLs can be downloaded")
+
+	if part := openai.NewTextPart("hi"); part.Type != openai.ChatMessagePartTypeText || part.Text != "hi" {
+		t.Fatalf("unexpected text part %+v", part)
+	}
+}
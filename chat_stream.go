@@ -2,6 +2,8 @@ package openai
 
 import (
 	"context"
+	"encoding/json"
+	"io"
 	"net/http"
 )
 
@@ -10,6 +12,14 @@ type ChatCompletionStreamChoiceDelta struct {
 	Role         string        `json:"role,omitempty"`
 	FunctionCall *FunctionCall `json:"function_call,omitempty"`
 	ToolCalls    []ToolCall    `json:"tool_calls,omitempty"`
+
+	// ReasoningContent and Reasoning carry streamed reasoning text, see
+	// ChatCompletionMessage.
+	ReasoningContent string `json:"reasoning_content,omitempty"`
+	Reasoning        string `json:"reasoning,omitempty"`
+
+	// Audio carries a chunk of streamed audio output. Gather the chunks with a ChatCompletionAudioCollector.
+	Audio *ChatCompletionAudio `json:"audio,omitempty"`
 }
 
 type ChatCompletionStreamChoice struct {
@@ -24,6 +34,26 @@ type PromptFilterResult struct {
 	ContentFilterResults ContentFilterResults `json:"content_filter_results,omitempty"`
 }
 
+// UnmarshalJSON also accepts prompt_index, which Azure sends in place of index.
+func (r *PromptFilterResult) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Index                *int                 `json:"index"`
+		PromptIndex          *int                 `json:"prompt_index"`
+		ContentFilterResults ContentFilterResults `json:"content_filter_results"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*r = PromptFilterResult{ContentFilterResults: raw.ContentFilterResults}
+	switch {
+	case raw.Index != nil:
+		r.Index = *raw.Index
+	case raw.PromptIndex != nil:
+		r.Index = *raw.PromptIndex
+	}
+	return nil
+}
+
 type ChatCompletionStreamResponse struct {
 	ID                  string                       `json:"id"`
 	Object              string                       `json:"object"`
@@ -37,12 +67,52 @@ type ChatCompletionStreamResponse struct {
 	// When present, it contains a null value except for the last chunk which contains the token usage statistics
 	// for the entire request.
 	Usage *Usage `json:"usage,omitempty"`
+
+	rawJSON
 }
 
 // ChatCompletionStream
 // Note: Perhaps it is more elegant to abstract Stream using generics.
 type ChatCompletionStream struct {
 	*streamReader[ChatCompletionStreamResponse]
+
+	provider *ProviderProfile
+	// cancel, if set, releases the context the stream was opened with.
+	cancel context.CancelFunc
+	// fallback, if set, finishes the stream without streaming when it is
+	// cut off.
+	fallback *streamFallback
+}
+
+// Close closes the response body.
+func (stream *ChatCompletionStream) Close() error {
+	err := stream.streamReader.Close()
+	if stream.cancel != nil {
+		stream.cancel()
+	}
+	return err
+}
+
+// Recv returns the next chunk of the stream, or io.EOF once it is done.
+func (stream *ChatCompletionStream) Recv() (response ChatCompletionStreamResponse, err error) {
+	if stream.fallback != nil && stream.fallback.interruption != nil {
+		return response, io.EOF
+	}
+	response, err = stream.streamReader.Recv()
+	if stream.fallback != nil {
+		if err == nil {
+			stream.fallback.observe(response)
+		} else if stream.fallback.applies(err, stream.isFinished) {
+			_ = stream.streamReader.Close()
+			return stream.fallback.complete(err)
+		}
+	}
+	if err == nil && stream.provider != nil {
+		for i := range response.Choices {
+			stream.provider.normalizeDeltaReasoning(&response.Choices[i].Delta)
+		}
+	}
+	return
 }
 
 // CreateChatCompletionStream — API call to create a chat completion w/ streaming
@@ -52,6 +122,7 @@ type ChatCompletionStream struct {
 func (c *Client) CreateChatCompletionStream(
 	ctx context.Context,
 	request ChatCompletionRequest,
+	opts ...RequestOption,
 ) (stream *ChatCompletionStream, err error) {
 	urlSuffix := chatCompletionsSuffix
 	if !checkEndpointSupportsModel(urlSuffix, request.Model) {
@@ -60,17 +131,36 @@ func (c *Client) CreateChatCompletionStream(
 	}
 
 	request.Stream = true
-	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL(urlSuffix, request.Model), withBody(request))
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := sendRequestStream[ChatCompletionStreamResponse](c, req)
-	if err != nil {
-		return
-	}
-	stream = &ChatCompletionStream{
-		streamReader: resp,
-	}
-	return
+	return callWithPolicies(ctx, c.policies, policyCall[*ChatCompletionStream]{
+		endpoint: urlSuffix,
+		model:    request.Model,
+		hedge:    true,
+		do: func(ctx context.Context) (*ChatCompletionStream, error) {
+			req, err := c.newRequest(ctx, http.MethodPost, c.fullURL(urlSuffix, request.Model), withBody(request),
+				withResponseCache(), withRequestOptions(opts))
+			if err != nil {
+				return nil, err
+			}
+			resp, err := sendRequestStream[ChatCompletionStreamResponse](c, req)
+			if err != nil {
+				return nil, err
+			}
+			stream := &ChatCompletionStream{
+				streamReader: resp,
+				provider:     c.config.Provider,
+			}
+			timeouts, fallback := c.streamOptions(opts)
+			resp.timer.timeouts = timeouts
+			if fallback {
+				stream.fallback = &streamFallback{ctx: ctx, client: c, request: request, opts: opts}
+			}
+			return stream, nil
+		},
+		keep: func(stream *ChatCompletionStream, cancel context.CancelFunc) {
+			stream.cancel = cancel
+		},
+		discard: func(stream *ChatCompletionStream) {
+			_ = stream.Close()
+		},
+	})
 }
@@ -297,6 +297,83 @@ func TestAzureChatCompletions(t *testing.T) {
 	checks.NoError(t, err, "CreateAzureChatCompletion error")
 }
 
+func TestAzureChatCompletionsContentFilterResults(t *testing.T) {
+	client, server, teardown := setupAzureTestServer()
+	defer teardown()
+	server.RegisterHandler("/openai/deployments/*", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `{
+			"id": "chatcmpl-1",
+			"object": "chat.completion",
+			"choices": [{
+				"index": 0,
+				"finish_reason": "content_filter",
+				"message": {"role": "assistant", "content": ""},
+				"content_filter_results": {
+					"hate": {"filtered": false, "severity": "safe"},
+					"violence": {"filtered": true, "severity": "high"},
+					"protected_material_code": {
+						"filtered": false,
+						"detected": true,
+						"citation": {"URL": "https://example.com/repo", "license": "MIT"}
+					}
+				}
+			}],
+			"prompt_filter_results": [{
+				"prompt_index": 1,
+				"content_filter_results": {
+					"jailbreak": {"filtered": false, "detected": false},
+					"custom_blocklists": [{"filtered": true, "id": "blocklist-1"}]
+				}
+			}]
+		}`)
+	})
+
+	resp, err := client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{
+		Model:    openai.GPT4o,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hello!"}},
+	})
+	checks.NoError(t, err, "CreateChatCompletion error")
+
+	completion := resp.Choices[0].ContentFilterResults
+	if !completion.Violence.Filtered || completion.Violence.Severity != "high" || !completion.Filtered() {
+		t.Errorf("expected the violence filter to be reported: %+v", completion)
+	}
+	citation := completion.ProtectedMaterialCode.Citation
+	if !completion.ProtectedMaterialCode.Detected || citation == nil || citation.License != "MIT" {
+		t.Errorf("expected protected material to be reported: %+v", completion.ProtectedMaterialCode)
+	}
+
+	if len(resp.PromptFilterResults) != 1 {
+		t.Fatalf("expected one prompt filter result, got %+v", resp.PromptFilterResults)
+	}
+	if resp.PromptFilterResults[0].Index != 1 {
+		t.Errorf("expected prompt_index to be decoded, got %d", resp.PromptFilterResults[0].Index)
+	}
+	prompt := resp.PromptFilterResults[0].ContentFilterResults
+	blocklists := prompt.CustomBlocklists
+	if blocklists == nil || !blocklists.Filtered ||
+		len(blocklists.Details) != 1 || blocklists.Details[0].ID != "blocklist-1" {
+		t.Errorf("expected the blocklist array to be decoded: %+v", blocklists)
+	}
+	if !prompt.Filtered() {
+		t.Error("expected the prompt to be reported as filtered")
+	}
+}
+
+func TestContentFilterBlocklistsObject(t *testing.T) {
+	var results openai.ContentFilterResults
+	data := `{"custom_blocklists": {"filtered": false, "details": [{"filtered": false, "id": "b"}]}}`
+	err := json.Unmarshal([]byte(data), &results)
+	checks.NoError(t, err, "Unmarshal error")
+	blocklists := results.CustomBlocklists
+	if blocklists == nil || blocklists.Filtered || len(blocklists.Details) != 1 {
+		t.Errorf("unexpected blocklists %+v", blocklists)
+	}
+	if results.Filtered() {
+		t.Error("expected nothing to be filtered")
+	}
+}
+
 func TestMultipartChatCompletions(t *testing.T) {
 	client, server, teardown := setupAzureTestServer()
 	defer teardown()
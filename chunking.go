@@ -0,0 +1,719 @@
+package openai
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	ErrInvalidChunkSize        = errors.New("chunk size must be greater than zero and larger than the overlap")
+	ErrUnsupportedCodeLanguage = errors.New("unsupported code language")
+)
+
+// TextChunk is a piece of a larger text. Start and End are byte offsets into
+// the original text, so Text == original[Start:End].
+type TextChunk struct {
+	Text   string
+	Start  int
+	End    int
+	Tokens int
+	// Metadata describes where the chunk came from, e.g. the Markdown heading
+	// path or the code symbols it contains. RAGPipeline copies it onto the
+	// indexed records.
+	Metadata map[string]string
+}
+
+// Chunker splits text into chunks suitable for embedding.
+type Chunker interface {
+	Chunk(text string) ([]TextChunk, error)
+}
+
+// ChunkTexts returns the text of each chunk, in order.
+func ChunkTexts(chunks []TextChunk) []string {
+	texts := make([]string, len(chunks))
+	for i, c := range chunks {
+		texts[i] = c.Text
+	}
+	return texts
+}
+
+// EmbeddingRequestFromChunks builds an embeddings request with one input per
+// chunk. Embedding.Index in the response matches the chunk's position.
+func EmbeddingRequestFromChunks(model EmbeddingModel, chunks []TextChunk) EmbeddingRequestStrings {
+	return EmbeddingRequestStrings{Input: ChunkTexts(chunks), Model: model}
+}
+
+// Metadata keys set by the chunkers in this package.
+const (
+	ChunkMetadataHeading  = "heading"
+	ChunkMetadataLanguage = "language"
+	ChunkMetadataSymbols  = "symbols"
+)
+
+type textSpan struct {
+	start, end int
+}
+
+// chunkSizer holds the size settings shared by all chunkers and implements
+// the splitting and merging steps they have in common.
+type chunkSizer struct {
+	maxTokens int
+	overlap   int
+	tokenizer Tokenizer
+}
+
+func newChunkSizer(maxTokens, overlap int, tokenizer Tokenizer) (chunkSizer, error) {
+	if maxTokens <= 0 || overlap < 0 || overlap >= maxTokens {
+		return chunkSizer{}, ErrInvalidChunkSize
+	}
+	if tokenizer == nil {
+		tokenizer = ApproximateTokenizer{}
+	}
+	return chunkSizer{maxTokens: maxTokens, overlap: overlap, tokenizer: tokenizer}, nil
+}
+
+func (s chunkSizer) count(text string, from, to int) int {
+	return s.tokenizer.CountTokens(text[from:to])
+}
+
+func (s chunkSizer) fits(text string, span textSpan) bool {
+	return s.count(text, span.start, span.end) <= s.maxTokens
+}
+
+// hardSplit cuts span at rune boundaries into the longest pieces that fit.
+func (s chunkSizer) hardSplit(text string, span textSpan) []textSpan {
+	var out []textSpan
+	for start := span.start; start < span.end; {
+		lo, hi := start, span.end
+		for lo < hi {
+			mid := runeBoundary(text, (lo+hi+1)/2)
+			if mid <= lo {
+				mid = nextRuneBoundary(text, lo)
+				if mid > hi || s.count(text, start, mid) > s.maxTokens {
+					break
+				}
+				lo = mid
+				continue
+			}
+			if s.count(text, start, mid) <= s.maxTokens {
+				lo = mid
+			} else {
+				hi = mid - 1
+			}
+		}
+		end := runeBoundary(text, lo)
+		if end <= start {
+			// Always make progress, even if a single rune exceeds the limit.
+			end = nextRuneBoundary(text, start)
+		}
+		out = append(out, textSpan{start: start, end: end})
+		start = end
+	}
+	return out
+}
+
+// recursiveSplit splits span on the first separator that occurs in it and
+// recurses into pieces that are still too large with the remaining separators,
+// falling back to hardSplit. Separators stay attached to the preceding piece so
+// that the pieces are contiguous.
+func (s chunkSizer) recursiveSplit(text string, span textSpan, separators []string) []textSpan {
+	if s.fits(text, span) {
+		return []textSpan{span}
+	}
+	if len(separators) == 0 || separators[0] == "" {
+		return s.hardSplit(text, span)
+	}
+
+	pieces := splitSpanAfter(text, span, separators[0])
+	if len(pieces) == 1 {
+		return s.recursiveSplit(text, span, separators[1:])
+	}
+	var out []textSpan
+	for _, piece := range pieces {
+		out = append(out, s.recursiveSplit(text, piece, separators[1:])...)
+	}
+	return out
+}
+
+// merge greedily packs consecutive spans into chunks of at most maxTokens.
+// Each chunk after the first starts with trailing spans of the previous chunk
+// totalling at most overlap tokens. Chunk boundaries are trimmed of whitespace.
+func (s chunkSizer) merge(text string, spans []textSpan) []TextChunk {
+	var chunks []TextChunk
+	for i := 0; i < len(spans); {
+		j := i
+		for j+1 < len(spans) && s.count(text, spans[i].start, spans[j+1].end) <= s.maxTokens {
+			j++
+		}
+		if chunk, ok := s.newChunk(text, spans[i].start, spans[j].end); ok {
+			chunks = append(chunks, chunk)
+		}
+		if j == len(spans)-1 {
+			break
+		}
+
+		next := j + 1
+		for next-1 > i && s.count(text, spans[next-1].start, spans[j].end) <= s.overlap {
+			next--
+		}
+		i = next
+	}
+	return chunks
+}
+
+func (s chunkSizer) newChunk(text string, start, end int) (TextChunk, bool) {
+	for start < end {
+		r, size := utf8.DecodeRuneInString(text[start:end])
+		if !unicode.IsSpace(r) {
+			break
+		}
+		start += size
+	}
+	for end > start {
+		r, size := utf8.DecodeLastRuneInString(text[start:end])
+		if !unicode.IsSpace(r) {
+			break
+		}
+		end -= size
+	}
+	if start == end {
+		return TextChunk{}, false
+	}
+	return TextChunk{
+		Text:   text[start:end],
+		Start:  start,
+		End:    end,
+		Tokens: s.count(text, start, end),
+	}, true
+}
+
+// splitSpanAfter splits span after every occurrence of sep.
+func splitSpanAfter(text string, span textSpan, sep string) []textSpan {
+	var out []textSpan
+	start := span.start
+	for {
+		idx := strings.Index(text[start:span.end], sep)
+		if idx < 0 {
+			break
+		}
+		end := start + idx + len(sep)
+		out = append(out, textSpan{start: start, end: end})
+		start = end
+	}
+	if start < span.end {
+		out = append(out, textSpan{start: start, end: span.end})
+	}
+	return out
+}
+
+// runeBoundary returns the largest index <= i that starts a rune in text.
+func runeBoundary(text string, i int) int {
+	for i > 0 && i < len(text) && !utf8.RuneStart(text[i]) {
+		i--
+	}
+	return i
+}
+
+func nextRuneBoundary(text string, i int) int {
+	if i >= len(text) {
+		return len(text)
+	}
+	_, size := utf8.DecodeRuneInString(text[i:])
+	return i + size
+}
+
+// TokenWindowChunker splits text on whitespace into windows of at most
+// MaxTokens tokens, with consecutive windows sharing about Overlap tokens.
+// Words longer than MaxTokens are split at rune boundaries.
+type TokenWindowChunker struct {
+	MaxTokens int
+	Overlap   int
+	// Tokenizer defaults to ApproximateTokenizer.
+	Tokenizer Tokenizer
+}
+
+func (c TokenWindowChunker) Chunk(text string) ([]TextChunk, error) {
+	sizer, err := newChunkSizer(c.MaxTokens, c.Overlap, c.Tokenizer)
+	if err != nil {
+		return nil, err
+	}
+	var spans []textSpan
+	for _, word := range splitWords(text) {
+		spans = append(spans, sizer.recursiveSplit(text, word, nil)...)
+	}
+	return sizer.merge(text, spans), nil
+}
+
+// splitWords returns the byte ranges of the whitespace-separated words in text.
+func splitWords(text string) []textSpan {
+	var (
+		words []textSpan
+		start = -1
+	)
+	for i, r := range text {
+		if unicode.IsSpace(r) {
+			if start >= 0 {
+				words = append(words, textSpan{start: start, end: i})
+				start = -1
+			}
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		words = append(words, textSpan{start: start, end: len(text)})
+	}
+	return words
+}
+
+// DefaultChunkSeparators are the separators RecursiveCharacterChunker tries in
+// order: paragraphs, lines, sentences, words and finally single characters.
+var DefaultChunkSeparators = []string{"\n\n", "\n", ". ", " ", ""}
+
+// RecursiveCharacterChunker splits text on the first separator that occurs in
+// it, recursing into pieces that are still larger than MaxTokens with the next
+// separator, then packs the pieces back into chunks of at most MaxTokens with
+// about Overlap tokens shared between consecutive chunks.
+type RecursiveCharacterChunker struct {
+	MaxTokens int
+	Overlap   int
+	// Tokenizer defaults to ApproximateTokenizer.
+	Tokenizer Tokenizer
+	// Separators defaults to DefaultChunkSeparators.
+	Separators []string
+}
+
+func (c RecursiveCharacterChunker) Chunk(text string) ([]TextChunk, error) {
+	sizer, err := newChunkSizer(c.MaxTokens, c.Overlap, c.Tokenizer)
+	if err != nil {
+		return nil, err
+	}
+	separators := c.Separators
+	if separators == nil {
+		separators = DefaultChunkSeparators
+	}
+	spans := sizer.recursiveSplit(text, textSpan{start: 0, end: len(text)}, separators)
+	return sizer.merge(text, spans), nil
+}
+
+// SentenceChunker packs whole sentences into chunks of at most MaxTokens, with
+// about Overlap tokens of trailing sentences repeated at the start of the next
+// chunk. Sentences longer than MaxTokens are split between words.
+type SentenceChunker struct {
+	MaxTokens int
+	Overlap   int
+	// Tokenizer defaults to ApproximateTokenizer.
+	Tokenizer Tokenizer
+}
+
+func (c SentenceChunker) Chunk(text string) ([]TextChunk, error) {
+	sizer, err := newChunkSizer(c.MaxTokens, c.Overlap, c.Tokenizer)
+	if err != nil {
+		return nil, err
+	}
+	var spans []textSpan
+	for _, sentence := range splitSentences(text) {
+		spans = append(spans, sizer.recursiveSplit(text, sentence, []string{" ", ""})...)
+	}
+	return sizer.merge(text, spans), nil
+}
+
+// splitSentences returns contiguous spans each ending after a sentence
+// terminator (., !, ? or …, plus closing quotes and brackets) that is followed
+// by whitespace and an upper-case letter, digit or opening quote, or after a
+// blank line. The heuristic avoids splitting on most abbreviations.
+func splitSentences(text string) []textSpan {
+	var (
+		spans []textSpan
+		start int
+	)
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		end := i + size
+		switch {
+		case r == '\n' && strings.HasPrefix(text[end:], "\n"):
+			spans = append(spans, textSpan{start: start, end: end})
+			start = end
+		case strings.ContainsRune(".!?…", r):
+			for end < len(text) {
+				closer, closerSize := utf8.DecodeRuneInString(text[end:])
+				if !strings.ContainsRune(`"')]”’»`, closer) {
+					break
+				}
+				end += closerSize
+			}
+			if isSentenceStart(text[end:]) {
+				spans = append(spans, textSpan{start: start, end: end})
+				start = end
+			}
+		}
+		i = end
+	}
+	if start < len(text) {
+		spans = append(spans, textSpan{start: start, end: len(text)})
+	}
+	return spans
+}
+
+// isSentenceStart reports whether rest begins with whitespace followed by the
+// start of a new sentence, or is empty.
+func isSentenceStart(rest string) bool {
+	trimmed := strings.TrimLeftFunc(rest, unicode.IsSpace)
+	if trimmed == rest && rest != "" {
+		return false
+	}
+	if trimmed == "" {
+		return true
+	}
+	r, _ := utf8.DecodeRuneInString(trimmed)
+	return unicode.IsUpper(r) || unicode.IsDigit(r) || strings.ContainsRune(`"'“‘«([`, r)
+}
+
+// MarkdownChunker splits Markdown into sections at ATX headings (# to ######),
+// ignoring headings inside fenced code blocks. Each section becomes its own
+// chunk, or several chunks split recursively if it is longer than MaxTokens.
+// Every chunk records its heading path, e.g. "Install > Linux", under
+// ChunkMetadataHeading.
+type MarkdownChunker struct {
+	MaxTokens int
+	Overlap   int
+	// Tokenizer defaults to ApproximateTokenizer.
+	Tokenizer Tokenizer
+}
+
+func (c MarkdownChunker) Chunk(text string) ([]TextChunk, error) {
+	sizer, err := newChunkSizer(c.MaxTokens, c.Overlap, c.Tokenizer)
+	if err != nil {
+		return nil, err
+	}
+
+	type section struct {
+		span    textSpan
+		heading string
+	}
+	var (
+		sections []section
+		headings []string
+		current  = section{}
+		fence    string
+	)
+	for _, line := range splitSpanAfter(text, textSpan{start: 0, end: len(text)}, "\n") {
+		content := strings.TrimSpace(text[line.start:line.end])
+		if fence != "" {
+			if strings.HasPrefix(content, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(content, "```") || strings.HasPrefix(content, "~~~") {
+			fence = content[:3]
+			continue
+		}
+		level, title := markdownHeading(text[line.start:line.end])
+		if level == 0 {
+			continue
+		}
+		if line.start > current.span.start {
+			current.span.end = line.start
+			sections = append(sections, current)
+		}
+		if level <= len(headings) {
+			headings = headings[:level-1]
+		}
+		for len(headings) < level-1 {
+			headings = append(headings, "")
+		}
+		headings = append(headings, title)
+		current = section{span: textSpan{start: line.start}, heading: joinHeadings(headings)}
+	}
+	current.span.end = len(text)
+	sections = append(sections, current)
+
+	var chunks []TextChunk
+	for _, sec := range sections {
+		spans := sizer.recursiveSplit(text, sec.span, DefaultChunkSeparators)
+		for _, chunk := range sizer.merge(text, spans) {
+			if sec.heading != "" {
+				chunk.Metadata = map[string]string{ChunkMetadataHeading: sec.heading}
+			}
+			chunks = append(chunks, chunk)
+		}
+	}
+	return chunks, nil
+}
+
+// markdownHeading returns the level and title of an ATX heading line, or zero
+// if line is not a heading.
+func markdownHeading(line string) (int, string) {
+	trimmed := strings.TrimRight(line, "\r\n")
+	if strings.HasPrefix(trimmed, "    ") || strings.HasPrefix(trimmed, "\t") {
+		return 0, ""
+	}
+	trimmed = strings.TrimLeft(trimmed, " ")
+	level := 0
+	for level < len(trimmed) && trimmed[level] == '#' {
+		level++
+	}
+	const maxHeadingLevel = 6
+	if level == 0 || level > maxHeadingLevel {
+		return 0, ""
+	}
+	rest := trimmed[level:]
+	if rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+		return 0, ""
+	}
+	title := strings.TrimSpace(strings.TrimRight(strings.TrimSpace(rest), "#"))
+	return level, title
+}
+
+func joinHeadings(headings []string) string {
+	var parts []string
+	for _, h := range headings {
+		if h != "" {
+			parts = append(parts, h)
+		}
+	}
+	return strings.Join(parts, " > ")
+}
+
+// CodeLanguage selects the parser used by CodeChunker.
+type CodeLanguage string
+
+const (
+	CodeLanguageGo     CodeLanguage = "go"
+	CodeLanguagePython CodeLanguage = "python"
+)
+
+// codeSeparators split a declaration that is too large on blank lines, then
+// lines, then words.
+var codeSeparators = []string{"\n\n", "\n", " ", ""}
+
+// CodeChunker splits source code at top-level declarations (functions, types,
+// classes, ...) including their doc comments and decorators. Consecutive small
+// declarations are packed together up to MaxTokens; larger ones are split
+// between lines. Chunks record the language and the names of the declarations
+// that start in them under ChunkMetadataLanguage and ChunkMetadataSymbols.
+type CodeChunker struct {
+	Language  CodeLanguage
+	MaxTokens int
+	Overlap   int
+	// Tokenizer defaults to ApproximateTokenizer.
+	Tokenizer Tokenizer
+}
+
+type codeUnit struct {
+	span textSpan
+	name string
+}
+
+func (c CodeChunker) Chunk(text string) ([]TextChunk, error) {
+	sizer, err := newChunkSizer(c.MaxTokens, c.Overlap, c.Tokenizer)
+	if err != nil {
+		return nil, err
+	}
+
+	var units []codeUnit
+	switch c.Language {
+	case CodeLanguageGo:
+		units = goCodeUnits(text)
+	case CodeLanguagePython:
+		units = pythonCodeUnits(text)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedCodeLanguage, c.Language)
+	}
+
+	var spans []textSpan
+	for _, unit := range units {
+		spans = append(spans, sizer.recursiveSplit(text, unit.span, codeSeparators)...)
+	}
+	chunks := sizer.merge(text, spans)
+	for i := range chunks {
+		var symbols []string
+		for _, unit := range units {
+			if unit.name != "" && unit.span.start < chunks[i].End && unit.span.end > chunks[i].Start &&
+				unit.span.start >= chunks[i].Start-leadingSpace(text, unit.span) {
+				symbols = append(symbols, unit.name)
+			}
+		}
+		chunks[i].Metadata = map[string]string{ChunkMetadataLanguage: string(c.Language)}
+		if len(symbols) > 0 {
+			chunks[i].Metadata[ChunkMetadataSymbols] = strings.Join(symbols, ",")
+		}
+	}
+	return chunks, nil
+}
+
+// leadingSpace returns the number of whitespace bytes at the start of span.
+func leadingSpace(text string, span textSpan) int {
+	s := text[span.start:span.end]
+	return len(s) - len(strings.TrimLeftFunc(s, unicode.IsSpace))
+}
+
+// goCodeUnits splits Go source into the package clause and imports followed by
+// one unit per top-level declaration. Units are contiguous and cover the whole
+// text. Source that does not parse is split with the line-based heuristic.
+func goCodeUnits(text string) []codeUnit {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", text, parser.ParseComments)
+	if err != nil {
+		return lineBasedCodeUnits(text, goDeclName)
+	}
+
+	var boundaries []codeUnit
+	for _, decl := range file.Decls {
+		start := decl.Pos()
+		var name string
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Doc != nil {
+				start = d.Doc.Pos()
+			}
+			name = d.Name.Name
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				name = receiverTypeName(d.Recv.List[0].Type) + "." + name
+			}
+		case *ast.GenDecl:
+			if d.Doc != nil {
+				start = d.Doc.Pos()
+			}
+			if d.Tok == token.IMPORT {
+				continue
+			}
+			name = genDeclName(d)
+		}
+		boundaries = append(boundaries, codeUnit{
+			span: textSpan{start: lineStart(text, fset.Position(start).Offset)},
+			name: name,
+		})
+	}
+	return contiguousCodeUnits(text, boundaries)
+}
+
+func receiverTypeName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiverTypeName(t.X)
+	case *ast.Ident:
+		return t.Name
+	case *ast.IndexExpr:
+		return receiverTypeName(t.X)
+	default:
+		return ""
+	}
+}
+
+func genDeclName(d *ast.GenDecl) string {
+	var names []string
+	for _, spec := range d.Specs {
+		switch s := spec.(type) {
+		case *ast.TypeSpec:
+			names = append(names, s.Name.Name)
+		case *ast.ValueSpec:
+			for _, n := range s.Names {
+				names = append(names, n.Name)
+			}
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// goDeclName recognizes top-level Go declarations for the line-based fallback.
+func goDeclName(line string) (string, bool) {
+	for _, keyword := range []string{"func ", "type ", "var ", "const "} {
+		if strings.HasPrefix(line, keyword) {
+			rest := strings.TrimPrefix(line, keyword)
+			if keyword == "func " && strings.HasPrefix(rest, "(") {
+				if idx := strings.Index(rest, ")"); idx >= 0 {
+					rest = strings.TrimSpace(rest[idx+1:])
+				}
+			}
+			return identifierPrefix(rest), true
+		}
+	}
+	return "", false
+}
+
+// pythonCodeUnits splits Python source at unindented def, async def and class
+// statements. Decorators and comments directly above a declaration belong to it.
+func pythonCodeUnits(text string) []codeUnit {
+	return lineBasedCodeUnits(text, func(line string) (string, bool) {
+		for _, keyword := range []string{"def ", "async def ", "class "} {
+			if strings.HasPrefix(line, keyword) {
+				return identifierPrefix(strings.TrimPrefix(line, keyword)), true
+			}
+		}
+		return "", false
+	})
+}
+
+// lineBasedCodeUnits starts a new unit at every unindented line recognized by
+// declName, pulling in the decorator and comment lines directly above it.
+func lineBasedCodeUnits(text string, declName func(line string) (string, bool)) []codeUnit {
+	lines := splitSpanAfter(text, textSpan{start: 0, end: len(text)}, "\n")
+	var boundaries []codeUnit
+	for i, line := range lines {
+		name, ok := declName(text[line.start:line.end])
+		if !ok {
+			continue
+		}
+		start := i
+		for start > 0 {
+			prev := text[lines[start-1].start:lines[start-1].end]
+			if !strings.HasPrefix(prev, "@") && !strings.HasPrefix(prev, "#") && !strings.HasPrefix(prev, "//") {
+				break
+			}
+			start--
+		}
+		boundaries = append(boundaries, codeUnit{span: textSpan{start: lines[start].start}, name: name})
+	}
+	return contiguousCodeUnits(text, boundaries)
+}
+
+// contiguousCodeUnits turns declaration start offsets into contiguous units
+// covering text, with any preamble before the first declaration as its own
+// unnamed unit.
+func contiguousCodeUnits(text string, boundaries []codeUnit) []codeUnit {
+	var units []codeUnit
+	if len(boundaries) == 0 || boundaries[0].span.start > 0 {
+		end := len(text)
+		if len(boundaries) > 0 {
+			end = boundaries[0].span.start
+		}
+		units = append(units, codeUnit{span: textSpan{start: 0, end: end}})
+	}
+	for i, b := range boundaries {
+		end := len(text)
+		if i+1 < len(boundaries) {
+			end = boundaries[i+1].span.start
+		}
+		if end > b.span.start {
+			units = append(units, codeUnit{span: textSpan{start: b.span.start, end: end}, name: b.name})
+		}
+	}
+	return units
+}
+
+func lineStart(text string, offset int) int {
+	if offset > len(text) {
+		offset = len(text)
+	}
+	return strings.LastIndexByte(text[:offset], '\n') + 1
+}
+
+func identifierPrefix(s string) string {
+	end := strings.IndexFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+	if end < 0 {
+		return s
+	}
+	return s[:end]
+}
@@ -0,0 +1,213 @@
+package openai_test
+
+import (
+	"testing"
+
+	"github.com/gradientlabs-ai/go-openai"
+	"github.com/gradientlabs-ai/go-openai/internal/test/checks"
+)
+
+func TestTokenWindowChunker(t *testing.T) {
+	text := "alpha beta  gamma\ndelta epsilon"
+	chunker := openai.TokenWindowChunker{
+		MaxTokens: 3,
+		Overlap:   1,
+		Tokenizer: wordTokenizer{},
+	}
+	chunks, err := chunker.Chunk(text)
+	checks.NoError(t, err, "Chunk error")
+
+	expected := []string{"alpha beta  gamma", "gamma\ndelta epsilon"}
+	if len(chunks) != len(expected) {
+		t.Fatalf("expected %d chunks, got %+v", len(expected), chunks)
+	}
+	for i, chunk := range chunks {
+		if chunk.Text != expected[i] {
+			t.Errorf("chunk %d: got %q, expected %q", i, chunk.Text, expected[i])
+		}
+		if text[chunk.Start:chunk.End] != chunk.Text {
+			t.Errorf("chunk %d offsets [%d:%d] do not match its text", i, chunk.Start, chunk.End)
+		}
+		if chunk.Tokens != 3 {
+			t.Errorf("chunk %d: expected 3 tokens, got %d", i, chunk.Tokens)
+		}
+	}
+
+	_, err = openai.TokenWindowChunker{MaxTokens: 2, Overlap: 2}.Chunk(text)
+	checks.ErrorIs(t, err, openai.ErrInvalidChunkSize, "overlap must be smaller than the chunk size")
+}
+
+func checkChunkOffsets(
+	t *testing.T,
+	text string,
+	chunks []openai.TextChunk,
+	maxTokens int,
+	tokenizer openai.Tokenizer,
+) {
+	t.Helper()
+	for i, chunk := range chunks {
+		if text[chunk.Start:chunk.End] != chunk.Text {
+			t.Errorf("chunk %d offsets [%d:%d] do not match its text", i, chunk.Start, chunk.End)
+		}
+		if got := tokenizer.CountTokens(chunk.Text); got > maxTokens || got != chunk.Tokens {
+			t.Errorf("chunk %d: %d tokens (reported %d), max %d", i, got, chunk.Tokens, maxTokens)
+		}
+	}
+}
+
+func checkChunkTexts(t *testing.T, got []openai.TextChunk, expected []string) {
+	t.Helper()
+	texts := openai.ChunkTexts(got)
+	if len(texts) != len(expected) {
+		t.Fatalf("expected %d chunks %q, got %d %q", len(expected), expected, len(texts), texts)
+	}
+	for i := range texts {
+		if texts[i] != expected[i] {
+			t.Errorf("chunk %d: got %q, expected %q", i, texts[i], expected[i])
+		}
+	}
+}
+
+func TestTokenWindowChunkerLongWord(t *testing.T) {
+	text := "short averyveryverylongword end"
+	tokenizer := openai.ApproximateTokenizer{}
+	chunks, err := openai.TokenWindowChunker{MaxTokens: 2}.Chunk(text)
+	checks.NoError(t, err, "Chunk error")
+	checkChunkOffsets(t, text, chunks, 2, tokenizer)
+	checkChunkTexts(t, chunks, []string{"short", "averyver", "yverylon", "gword", "end"})
+}
+
+func TestRecursiveCharacterChunker(t *testing.T) {
+	text := "one two three.\n\nfour five six seven eight nine.\nten eleven."
+	chunker := openai.RecursiveCharacterChunker{MaxTokens: 4, Tokenizer: wordTokenizer{}}
+	chunks, err := chunker.Chunk(text)
+	checks.NoError(t, err, "Chunk error")
+	checkChunkOffsets(t, text, chunks, 4, wordTokenizer{})
+	checkChunkTexts(t, chunks, []string{
+		"one two three.\n\nfour",
+		"five six seven eight",
+		"nine.\nten eleven.",
+	})
+
+	chunker.Overlap = 1
+	chunks, err = chunker.Chunk(text)
+	checks.NoError(t, err, "Chunk error")
+	checkChunkOffsets(t, text, chunks, 4, wordTokenizer{})
+	checkChunkTexts(t, chunks, []string{
+		"one two three.\n\nfour",
+		"four five six seven",
+		"seven eight nine.",
+		"nine.\nten eleven.",
+	})
+}
+
+func TestSentenceChunker(t *testing.T) {
+	text := "Dr. Smith arrived, e.g. early. He sat down! Was it late? \"Yes,\" she said.\n\nnew para"
+	chunker := openai.SentenceChunker{MaxTokens: 6, Overlap: 3, Tokenizer: wordTokenizer{}}
+	chunks, err := chunker.Chunk(text)
+	checks.NoError(t, err, "Chunk error")
+	checkChunkOffsets(t, text, chunks, 6, wordTokenizer{})
+	checkChunkTexts(t, chunks, []string{
+		"Dr. Smith arrived, e.g. early.",
+		"He sat down! Was it late?",
+		"Was it late? \"Yes,\" she said.",
+		"\"Yes,\" she said.\n\nnew para",
+	})
+}
+
+func TestSentenceChunkerCurlyQuotes(t *testing.T) {
+	text := "He said “Stop.” Then he left. «Bien.» Voilà."
+	chunker := openai.SentenceChunker{MaxTokens: 3, Tokenizer: wordTokenizer{}}
+	chunks, err := chunker.Chunk(text)
+	checks.NoError(t, err, "Chunk error")
+	checkChunkOffsets(t, text, chunks, 3, wordTokenizer{})
+	checkChunkTexts(t, chunks, []string{
+		"He said “Stop.”",
+		"Then he left.",
+		"«Bien.» Voilà.",
+	})
+}
+
+func TestMarkdownChunker(t *testing.T) {
+	text := "Intro text.\n\n# Install\n\nRun it.\n\n## Linux\n\nUse apt.\n\n```sh\n# not a heading\napt install x\n```\n" +
+		"\n### Notes\nOne.\n# Usage\nCall it.\n"
+	chunks, err := openai.MarkdownChunker{MaxTokens: 50}.Chunk(text)
+	checks.NoError(t, err, "Chunk error")
+	checkChunkOffsets(t, text, chunks, 50, openai.ApproximateTokenizer{})
+	checkChunkTexts(t, chunks, []string{
+		"Intro text.",
+		"# Install\n\nRun it.",
+		"## Linux\n\nUse apt.\n\n```sh\n# not a heading\napt install x\n```",
+		"### Notes\nOne.",
+		"# Usage\nCall it.",
+	})
+	headings := []string{"", "Install", "Install > Linux", "Install > Linux > Notes", "Usage"}
+	for i, chunk := range chunks {
+		if got := chunk.Metadata[openai.ChunkMetadataHeading]; got != headings[i] {
+			t.Errorf("chunk %d: heading %q, expected %q", i, got, headings[i])
+		}
+	}
+}
+
+func TestCodeChunkerGo(t *testing.T) {
+	text := `package demo
+
+import "fmt"
+
+// Greeter greets.
+type Greeter struct{}
+
+// Hello says hello.
+func (g *Greeter) Hello() {
+	fmt.Println("hello")
+}
+
+func Bye() {}
+`
+	chunks, err := openai.CodeChunker{Language: openai.CodeLanguageGo, MaxTokens: 20}.Chunk(text)
+	checks.NoError(t, err, "Chunk error")
+	checkChunkOffsets(t, text, chunks, 20, openai.ApproximateTokenizer{})
+	checkChunkTexts(t, chunks, []string{
+		"package demo\n\nimport \"fmt\"\n\n// Greeter greets.\ntype Greeter struct{}",
+		"// Hello says hello.\nfunc (g *Greeter) Hello() {\n\tfmt.Println(\"hello\")\n}",
+		"func Bye() {}",
+	})
+	symbols := []string{"Greeter", "Greeter.Hello", "Bye"}
+	for i, chunk := range chunks {
+		if chunk.Metadata[openai.ChunkMetadataLanguage] != "go" {
+			t.Errorf("chunk %d: unexpected metadata %v", i, chunk.Metadata)
+		}
+		if got := chunk.Metadata[openai.ChunkMetadataSymbols]; got != symbols[i] {
+			t.Errorf("chunk %d: symbols %q, expected %q", i, got, symbols[i])
+		}
+	}
+}
+
+func TestCodeChunkerPython(t *testing.T) {
+	text := "import os\n\n@decorator\ndef first(x):\n    return x\n\nclass Second:\n    def method(self):\n" +
+		"        pass\n\nasync def third():\n    pass\n"
+	chunks, err := openai.CodeChunker{Language: openai.CodeLanguagePython, MaxTokens: 20}.Chunk(text)
+	checks.NoError(t, err, "Chunk error")
+	checkChunkOffsets(t, text, chunks, 20, openai.ApproximateTokenizer{})
+	checkChunkTexts(t, chunks, []string{
+		"import os\n\n@decorator\ndef first(x):\n    return x",
+		"class Second:\n    def method(self):\n        pass\n\nasync def third():\n    pass",
+	})
+	symbols := []string{"first", "Second,third"}
+	for i, chunk := range chunks {
+		if got := chunk.Metadata[openai.ChunkMetadataSymbols]; got != symbols[i] {
+			t.Errorf("chunk %d: symbols %q, expected %q", i, got, symbols[i])
+		}
+	}
+
+	_, err = openai.CodeChunker{Language: "cobol", MaxTokens: 10}.Chunk(text)
+	checks.ErrorIs(t, err, openai.ErrUnsupportedCodeLanguage, "unknown language")
+}
+
+func TestEmbeddingRequestFromChunks(t *testing.T) {
+	chunks := []openai.TextChunk{{Text: "a"}, {Text: "b"}}
+	req := openai.EmbeddingRequestFromChunks(openai.SmallEmbedding3, chunks)
+	if req.Model != openai.SmallEmbedding3 || len(req.Input) != 2 || req.Input[0] != "a" || req.Input[1] != "b" {
+		t.Errorf("unexpected request %+v", req)
+	}
+}
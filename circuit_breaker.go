@@ -0,0 +1,169 @@
+package openai
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned when a call is refused because the circuit
+// breaker guarding it is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitState is the state of a circuit breaker.
+type CircuitState int
+
+const (
+	// CircuitClosed lets every call through.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen refuses calls until CircuitBreakerConfig.OpenTimeout has
+	// passed.
+	CircuitOpen
+	// CircuitHalfOpen lets a single probe call through at a time. Successful
+	// probes close the circuit, a failed one opens it again.
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+const (
+	defaultCircuitFailureThreshold = 5
+	defaultCircuitOpenTimeout      = 30 * time.Second
+)
+
+// CircuitBreakerConfig configures a circuit breaker.
+type CircuitBreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures that opens the
+	// circuit. Zero means 5.
+	FailureThreshold int
+	// OpenTimeout is how long the circuit stays open before probing. Zero
+	// means 30 seconds.
+	OpenTimeout time.Duration
+	// HalfOpenProbes is the number of successful probes needed to close the
+	// circuit again. Zero means 1.
+	HalfOpenProbes int
+}
+
+func (c CircuitBreakerConfig) withDefaults() CircuitBreakerConfig {
+	if c.FailureThreshold <= 0 {
+		c.FailureThreshold = defaultCircuitFailureThreshold
+	}
+	if c.OpenTimeout <= 0 {
+		c.OpenTimeout = defaultCircuitOpenTimeout
+	}
+	if c.HalfOpenProbes <= 0 {
+		c.HalfOpenProbes = 1
+	}
+	return c
+}
+
+// callOutcome is what a guarded call reports back to its breaker.
+type callOutcome int
+
+const (
+	callSucceeded callOutcome = iota
+	callFailed
+	// callIgnored is for calls that say nothing about the health of the
+	// backend, such as invalid requests or calls canceled by the caller.
+	callIgnored
+)
+
+type circuitBreaker struct {
+	config        CircuitBreakerConfig
+	onStateChange func(from, to CircuitState)
+
+	mu        sync.Mutex
+	state     CircuitState
+	failures  int
+	successes int
+	openedAt  time.Time
+	probing   bool
+}
+
+func newCircuitBreaker(config CircuitBreakerConfig, onStateChange func(from, to CircuitState)) *circuitBreaker {
+	return &circuitBreaker{config: config.withDefaults(), onStateChange: onStateChange}
+}
+
+// allow reports whether a call may proceed and whether that call is the
+// half-open probe. Every allowed call must be followed by exactly one call to
+// record, passing the probe flag back.
+func (b *circuitBreaker) allow() (allowed, probe bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case CircuitOpen:
+		if time.Since(b.openedAt) < b.config.OpenTimeout {
+			return false, false
+		}
+		b.setState(CircuitHalfOpen)
+		fallthrough
+	case CircuitHalfOpen:
+		if b.probing {
+			return false, false
+		}
+		b.probing = true
+		return true, true
+	}
+	return true, false
+}
+
+// record reports the outcome of an allowed call. Only the probe itself may
+// close or reopen a half-open circuit; a call admitted earlier, while the
+// circuit was closed, that finishes during the probe leaves it alone.
+func (b *circuitBreaker) record(probe bool, outcome callOutcome) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if probe {
+		b.probing = false
+	}
+	wasProbe := probe && b.state == CircuitHalfOpen
+	switch outcome {
+	case callSucceeded:
+		b.failures = 0
+		if !wasProbe {
+			return
+		}
+		b.successes++
+		if b.successes >= b.config.HalfOpenProbes {
+			b.setState(CircuitClosed)
+		}
+	case callFailed:
+		b.failures++
+		if wasProbe || (b.state == CircuitClosed && b.failures >= b.config.FailureThreshold) {
+			b.openedAt = time.Now()
+			b.setState(CircuitOpen)
+		}
+	case callIgnored:
+	}
+}
+
+func (b *circuitBreaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// setState must be called with b.mu held.
+func (b *circuitBreaker) setState(state CircuitState) {
+	if b.state == state {
+		return
+	}
+	from := b.state
+	b.state = state
+	b.failures = 0
+	b.successes = 0
+	if b.onStateChange != nil {
+		b.onStateChange(from, state)
+	}
+}
@@ -0,0 +1,38 @@
+package openai
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerIgnoresStaleCallsDuringProbe(t *testing.T) {
+	b := newCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 1, OpenTimeout: time.Millisecond}, nil)
+
+	// Two calls admitted while closed; the second fails and opens the circuit.
+	_, slowProbe := b.allow()
+	_, failedProbe := b.allow()
+	b.record(failedProbe, callFailed)
+	if state := b.State(); state != CircuitOpen {
+		t.Fatalf("expected an open breaker, got %s", state)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	allowed, probe := b.allow()
+	if !allowed || !probe {
+		t.Fatalf("expected the probe to be admitted, got allowed=%v probe=%v", allowed, probe)
+	}
+
+	// The slow call finishing during the probe must not decide its outcome.
+	b.record(slowProbe, callSucceeded)
+	if state := b.State(); state != CircuitHalfOpen {
+		t.Errorf("a call admitted before the probe closed the breaker: %s", state)
+	}
+	if allowed, _ := b.allow(); allowed {
+		t.Error("a second probe was admitted while the first is in flight")
+	}
+
+	b.record(probe, callSucceeded)
+	if state := b.State(); state != CircuitClosed {
+		t.Errorf("expected the probe to close the breaker, got %s", state)
+	}
+}
@@ -2,6 +2,7 @@ package openai
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -9,7 +10,9 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"strings"
+	"time"
 
 	utils "github.com/gradientlabs-ai/go-openai/internal"
 )
@@ -20,6 +23,8 @@ type Client struct {
 
 	requestBuilder    utils.RequestBuilder
 	createFormBuilder func(io.Writer) utils.FormBuilder
+
+	policies *clientPolicies
 }
 
 type Response interface {
@@ -64,6 +69,7 @@ func NewClientWithConfig(config ClientConfig) *Client {
 		createFormBuilder: func(body io.Writer) utils.FormBuilder {
 			return utils.NewFormBuilder(body)
 		},
+		policies: newClientPolicies(config),
 	}
 }
 
@@ -77,8 +83,18 @@ func NewOrgClient(authToken, org string) *Client {
 }
 
 type requestOptions struct {
-	body   any
-	header http.Header
+	body      any
+	header    http.Header
+	cacheable bool
+
+	// Set by the exported RequestOption values.
+	extraBody   map[string]any
+	extraHeader http.Header
+	extraQuery  url.Values
+	timeout     time.Duration
+
+	streamTimeouts *streamTimeouts
+	streamFallback bool
 }
 
 type requestOption func(*requestOptions)
@@ -95,6 +111,14 @@ func withContentType(contentType string) requestOption {
 	}
 }
 
+// withResponseCache marks the request as eligible for the response cache
+// configured in ClientConfig.ResponseCache.
+func withResponseCache() requestOption {
+	return func(args *requestOptions) {
+		args.cacheable = true
+	}
+}
+
 func withBetaAssistantVersion(version string) requestOption {
 	return func(args *requestOptions) {
 		args.header.Set("OpenAI-Beta", fmt.Sprintf("assistants=%s", version))
@@ -104,17 +128,43 @@ func withBetaAssistantVersion(version string) requestOption {
 func (c *Client) newRequest(ctx context.Context, method, url string, setters ...requestOption) (*http.Request, error) {
 	// Default Options
 	args := &requestOptions{
-		body:   nil,
-		header: make(http.Header),
+		body:        nil,
+		header:      make(http.Header),
+		extraHeader: make(http.Header),
 	}
 	for _, setter := range setters {
 		setter(args)
 	}
+	if provider := c.config.Provider; provider != nil && args.body != nil {
+		args.body = provider.nestExtraBody(args.body)
+		if _, isReader := args.body.(io.Reader); !isReader && provider.rewritesRequests(url) {
+			body, err := provider.rewriteRequestBody(args.body)
+			if err != nil {
+				return nil, err
+			}
+			args.body = body
+		}
+	}
+	if err := args.applyExtraBody(); err != nil {
+		return nil, err
+	}
+	ctx = withTimeout(ctx, args.timeout)
 	req, err := c.requestBuilder.Build(ctx, method, url, args.body, args.header)
 	if err != nil {
+		releaseRequest(ctx)
 		return nil, err
 	}
-	c.setCommonHeaders(req)
+	if err = c.setCommonHeaders(req); err != nil {
+		releaseRequest(ctx)
+		return nil, err
+	}
+	args.applyExtras(req)
+	if args.cacheable {
+		if req, err = c.withResponseCacheKey(req, args.body); err != nil {
+			releaseRequest(ctx)
+			return nil, err
+		}
+	}
 	return req, nil
 }
 
@@ -128,7 +178,16 @@ func (c *Client) sendRequest(req *http.Request, v Response) error {
 		req.Header.Set("Content-Type", "application/json")
 	}
 
-	res, err := c.config.HTTPClient.Do(req)
+	cacheKey := requestCacheKey(req)
+	if cached, ok := c.lookupResponseCache(req.Context(), cacheKey); ok {
+		releaseRequest(req.Context())
+		if v != nil {
+			v.SetHeader(cached.Header)
+		}
+		return decodeResponse(bytes.NewReader(cached.Body), v)
+	}
+
+	res, err := c.doRequest(req)
 	if err != nil {
 		return err
 	}
@@ -143,16 +202,41 @@ func (c *Client) sendRequest(req *http.Request, v Response) error {
 		v.SetHeader(res.Header)
 	}
 
+	if cacheKey != "" {
+		body, readErr := io.ReadAll(res.Body)
+		if readErr != nil {
+			return readErr
+		}
+		c.storeResponseCache(req.Context(), cacheKey, res.Header, body)
+		return decodeResponse(bytes.NewReader(body), v)
+	}
+
 	return decodeResponse(res.Body, v)
 }
 
+// doRequest sends req and reports the response to the token provider if it
+// implements ResponseObserver.
+func (c *Client) doRequest(req *http.Request) (*http.Response, error) {
+	resp, err := c.config.HTTPClient.Do(req)
+	if err != nil {
+		releaseRequest(req.Context())
+		return nil, err
+	}
+	resp.Body = &releasingBody{ReadCloser: resp.Body, ctx: req.Context()}
+	if observer, ok := c.config.TokenProvider.(ResponseObserver); ok {
+		observer.ObserveResponse(c.requestToken(req), resp)
+	}
+	return resp, nil
+}
+
 func (c *Client) sendRequestRaw(req *http.Request) (response RawResponse, err error) {
-	resp, err := c.config.HTTPClient.Do(req) //nolint:bodyclose // body should be closed by outer function
+	resp, err := c.doRequest(req) //nolint:bodyclose // body should be closed by outer function
 	if err != nil {
 		return
 	}
 
 	if isFailureStatusCode(resp) {
+		defer resp.Body.Close()
 		err = c.handleErrorResp(resp)
 		return
 	}
@@ -163,40 +247,93 @@ func (c *Client) sendRequestRaw(req *http.Request) (response RawResponse, err er
 }
 
 func sendRequestStream[T streamable](client *Client, req *http.Request) (*streamReader[T], error) {
-	req.Header.Set("Content-Type", "application/json")
+	// Streamed transcriptions are sent as multipart/form-data.
+	if req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
 	req.Header.Set("Accept", "text/event-stream")
 	req.Header.Set("Cache-Control", "no-cache")
 	req.Header.Set("Connection", "keep-alive")
 
-	resp, err := client.config.HTTPClient.Do(req) //nolint:bodyclose // body is closed in stream.Close()
+	cacheKey := requestCacheKey(req)
+	if cached, ok := client.lookupResponseCache(req.Context(), cacheKey); ok {
+		releaseRequest(req.Context())
+		return newStreamReader[T](client, &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     cached.Header,
+			Body:       io.NopCloser(bytes.NewReader(cached.Body)),
+		}), nil
+	}
+
+	resp, err := client.doRequest(req) //nolint:bodyclose // body is closed in stream.Close()
 	if err != nil {
 		return new(streamReader[T]), err
 	}
 	if isFailureStatusCode(resp) {
+		defer resp.Body.Close()
 		return new(streamReader[T]), client.handleErrorResp(resp)
 	}
+	if cacheKey != "" {
+		resp.Body = &cacheRecordingBody{
+			ReadCloser: resp.Body,
+			client:     client,
+			ctx:        req.Context(),
+			key:        cacheKey,
+			header:     resp.Header,
+		}
+	}
+	return newStreamReader[T](client, resp), nil
+}
+
+func newStreamReader[T streamable](client *Client, resp *http.Response) *streamReader[T] {
 	return &streamReader[T]{
 		emptyMessagesLimit: client.config.EmptyMessagesLimit,
 		reader:             bufio.NewReader(resp.Body),
 		response:           resp,
 		errAccumulator:     utils.NewErrorAccumulator(),
 		unmarshaler:        &utils.JSONUnmarshaler{},
+		timer:              readTimer{started: time.Now()},
 		httpHeader:         httpHeader(resp.Header),
-	}, nil
+	}
 }
 
-func (c *Client) setCommonHeaders(req *http.Request) {
+func (c *Client) setCommonHeaders(req *http.Request) error {
+	token := c.config.authToken
+	if c.config.TokenProvider != nil {
+		var err error
+		token, err = c.config.TokenProvider.Token(req.Context())
+		if err != nil {
+			return fmt.Errorf("fetching auth token: %w", err)
+		}
+	}
+
 	// https://learn.microsoft.com/en-us/azure/cognitive-services/openai/reference#authentication
-	// Azure API Key authentication
-	if c.config.APIType == APITypeAzure {
-		req.Header.Set(AzureAPIKeyHeader, c.config.authToken)
-	} else if c.config.authToken != "" {
+	switch {
+	case c.config.APIType == APITypeAzure:
+		// Azure API Key authentication
+		req.Header.Set(AzureAPIKeyHeader, token)
+	case token != "" && c.config.Provider != nil && c.config.Provider.AuthHeader != "":
+		req.Header.Set(c.config.Provider.AuthHeader, c.config.Provider.authHeaderValue(token))
+	case token != "":
 		// OpenAI or Azure AD authentication
-		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.config.authToken))
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
 	}
 	if c.config.OrgID != "" {
 		req.Header.Set("OpenAI-Organization", c.config.OrgID)
 	}
+	return nil
+}
+
+// requestToken returns the credential setCommonHeaders put on req.
+func (c *Client) requestToken(req *http.Request) string {
+	if c.config.APIType == APITypeAzure {
+		return req.Header.Get(AzureAPIKeyHeader)
+	}
+	if provider := c.config.Provider; provider != nil && provider.AuthHeader != "" {
+		value := req.Header.Get(provider.AuthHeader)
+		return strings.TrimPrefix(value, provider.AuthScheme+" ")
+	}
+	return strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
 }
 
 func isFailureStatusCode(resp *http.Response) bool {
@@ -213,6 +350,12 @@ func decodeResponse(body io.Reader, v any) error {
 		return decodeString(body, o)
 	case *audioTextResponse:
 		return decodeString(body, &o.Text)
+	case rawJSONSetter:
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return err
+		}
+		return unmarshalResponse(data, v, json.Unmarshal)
 	default:
 		return json.NewDecoder(body).Decode(v)
 	}
@@ -232,24 +375,7 @@ func decodeString(body io.Reader, output *string) error {
 func (c *Client) fullURL(suffix string, args ...any) string {
 	// /openai/deployments/{model}/chat/completions?api-version={api_version}
 	if c.config.APIType == APITypeAzure || c.config.APIType == APITypeAzureAD {
-		baseURL := c.config.BaseURL
-		baseURL = strings.TrimRight(baseURL, "/")
-		// if suffix is /models change to {endpoint}/openai/models?api-version=2022-12-01
-		// https://learn.microsoft.com/en-us/rest/api/cognitiveservices/azureopenaistable/models/list?tabs=HTTP
-		if containsSubstr([]string{"/models", "/assistants", "/threads", "/files"}, suffix) {
-			return fmt.Sprintf("%s/%s%s?api-version=%s", baseURL, azureAPIPrefix, suffix, c.config.APIVersion)
-		}
-		azureDeploymentName := "UNKNOWN"
-		if len(args) > 0 {
-			model, ok := args[0].(string)
-			if ok {
-				azureDeploymentName = c.config.GetAzureDeploymentByModel(model)
-			}
-		}
-		return fmt.Sprintf("%s/%s/%s/%s%s?api-version=%s",
-			baseURL, azureAPIPrefix, azureDeploymentsPrefix,
-			azureDeploymentName, suffix, c.config.APIVersion,
-		)
+		return c.azureFullURL(suffix, args...)
 	}
 
 	// c.config.APIType == APITypeOpenAI || c.config.APIType == ""
@@ -268,6 +394,14 @@ func (c *Client) handleErrorResp(resp *http.Response) error {
 
 	log.Printf("error response body: %s", string(bodyBytes))
 
+	if c.config.Provider != nil && c.config.Provider.ParseError != nil {
+		if apiErr := c.config.Provider.ParseError(bodyBytes); apiErr != nil {
+			apiErr.HTTPStatusCode = resp.StatusCode
+			apiErr.httpHeader = httpHeader(resp.Header)
+			return apiErr
+		}
+	}
+
 	var errRes ErrorResponse
 	err = json.Unmarshal(bodyBytes, &errRes)
 	if err != nil {
@@ -302,12 +436,3 @@ func (c *Client) handleErrorResp(resp *http.Response) error {
 	errRes.Error.httpHeader = httpHeader(resp.Header)
 	return errRes.Error
 }
-
-func containsSubstr(s []string, e string) bool {
-	for _, v := range s {
-		if strings.Contains(e, v) {
-			return true
-		}
-	}
-	return false
-}
@@ -0,0 +1,45 @@
+// Command openai-mock serves the openaitest fake of the OpenAI API, for
+// integration tests that can't run it in-process. Point a client at
+// http://<addr>/v1 and script it with -rules or the /_mock endpoints.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/gradientlabs-ai/go-openai/openaitest"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:8080", "address to listen on")
+	rulesPath := flag.String("rules", "", "JSON file of rules to load at start")
+	apiKey := flag.String("api-key", "", "API key clients must send; empty accepts any")
+	reply := flag.String("default-reply", "", "reply to chat completions that match no rule")
+	flag.Parse()
+
+	fake := openaitest.New()
+	fake.APIKey = *apiKey
+	if *reply != "" {
+		fake.DefaultReply = *reply
+	}
+	if *rulesPath != "" {
+		file, err := os.Open(*rulesPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		rules, err := openaitest.LoadRules(file)
+		file.Close()
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, rule := range rules {
+			fake.On(rule)
+		}
+		log.Printf("loaded %d rules from %s", len(rules), *rulesPath)
+	}
+
+	log.Printf("serving the OpenAI API fake on http://%s/v1", *addr)
+	log.Fatal(http.ListenAndServe(*addr, fake)) //nolint:gosec // a test server needs no timeouts
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/gradientlabs-ai/go-openai"
+)
+
+func runTranscribe(ctx context.Context, c *cli, args []string) error {
+	flags := c.newFlagSet("transcribe", "<audio file>")
+	model := flags.String("model", openai.Whisper1, "transcription model")
+	language := flags.String("language", "", "language of the audio as ISO-639-1, e.g. en")
+	prompt := flags.String("prompt", "", "text to guide the transcription's style")
+	format := flags.String("format", string(openai.AudioResponseFormatText),
+		"output format: json, text, srt, vtt or verbose_json")
+	output := flags.String("o", "-", "output file")
+	if err := c.parse(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errUsage
+	}
+	resp, err := c.client.CreateTranscription(ctx, openai.AudioRequest{
+		Model:    *model,
+		FilePath: flags.Arg(0),
+		Prompt:   *prompt,
+		Language: *language,
+		Format:   openai.AudioResponseFormat(*format),
+	})
+	if err != nil {
+		return err
+	}
+
+	w, err := c.create(*output)
+	if err != nil {
+		return err
+	}
+	if c.json {
+		err = writeJSON(w, resp)
+	} else {
+		_, err = fmt.Fprintln(w, resp.Text)
+	}
+	if closeErr := w.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func runSpeak(ctx context.Context, c *cli, args []string) error {
+	flags := c.newFlagSet("speak", "[text | -]")
+	model := flags.String("model", string(openai.TTSModel1), "speech model")
+	voice := flags.String("voice", string(openai.VoiceAlloy), "voice")
+	format := flags.String("format", string(openai.SpeechResponseFormatMp3),
+		"audio format: mp3, opus, aac, flac, wav or pcm")
+	speed := flags.Float64("speed", 0, "speed from 0.25 to 4 (default 1)")
+	instructions := flags.String("instructions", "", "how to speak, e.g. the tone or accent (not all models)")
+	output := flags.String("o", "", "output file (default speech.<format>; - for stdout)")
+	if err := c.parse(flags, args); err != nil {
+		return err
+	}
+	input, err := c.readInput(flags.Args())
+	if err != nil {
+		return err
+	}
+	path := *output
+	if path == "" {
+		path = "speech." + *format
+	}
+
+	audio, err := c.client.CreateSpeech(ctx, openai.CreateSpeechRequest{
+		Model:          openai.SpeechModel(*model),
+		Input:          input,
+		Voice:          openai.SpeechVoice(*voice),
+		ResponseFormat: openai.SpeechResponseFormat(*format),
+		Speed:          *speed,
+		Instructions:   *instructions,
+	})
+	if err != nil {
+		return err
+	}
+	defer audio.Close()
+	n, err := c.writeTo(path, audio)
+	if err != nil {
+		return err
+	}
+	if path != "-" {
+		return c.print(map[string]any{"path": path, "bytes": n}, func(w io.Writer) {
+			fmt.Fprintf(w, "wrote %d bytes to %s\n", n, path)
+		})
+	}
+	return nil
+}
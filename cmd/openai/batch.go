@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"time"
+
+	"github.com/gradientlabs-ai/go-openai"
+)
+
+func runBatch(ctx context.Context, c *cli, args []string) error {
+	return c.subcommand(ctx, "batch", args, map[string]func(context.Context, []string) error{
+		"submit":   c.submitBatch,
+		"status":   c.batchStatus,
+		"download": c.downloadBatch,
+		"cancel":   c.cancelBatch,
+		"list":     c.listBatches,
+	})
+}
+
+func (c *cli) submitBatch(ctx context.Context, args []string) error {
+	flags := c.newFlagSet("batch submit", "<requests.jsonl>")
+	endpoint := flags.String("endpoint", string(openai.BatchEndpointChatCompletions), "endpoint the requests are sent to")
+	wait := flags.Bool("wait", false, "wait for the batch to finish")
+	interval := flags.Duration("interval", 30*time.Second, "polling interval with -wait")
+	if err := c.parse(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errUsage
+	}
+	file, err := c.client.CreateFile(ctx, openai.FileRequest{
+		FileName: filepath.Base(flags.Arg(0)),
+		FilePath: flags.Arg(0),
+		Purpose:  string(openai.PurposeBatch),
+	})
+	if err != nil {
+		return fmt.Errorf("uploading %s: %w", flags.Arg(0), err)
+	}
+	batch, err := c.client.CreateBatch(ctx, openai.CreateBatchRequest{
+		InputFileID: file.ID,
+		Endpoint:    openai.BatchEndpoint(*endpoint),
+	})
+	if err != nil {
+		return err
+	}
+	if *wait {
+		if batch, err = c.waitBatch(ctx, batch, *interval); err != nil {
+			return err
+		}
+	}
+	return c.printBatch(batch)
+}
+
+func (c *cli) batchStatus(ctx context.Context, args []string) error {
+	flags := c.newFlagSet("batch status", "<batch id>")
+	wait := flags.Bool("wait", false, "wait for the batch to finish")
+	interval := flags.Duration("interval", 30*time.Second, "polling interval with -wait")
+	if err := c.parse(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errUsage
+	}
+	batch, err := c.client.RetrieveBatch(ctx, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if *wait {
+		if batch, err = c.waitBatch(ctx, batch, *interval); err != nil {
+			return err
+		}
+	}
+	return c.printBatch(batch)
+}
+
+// waitBatch polls a batch until it is done, reporting progress on stderr.
+func (c *cli) waitBatch(ctx context.Context, batch openai.Batch, interval time.Duration) (openai.Batch, error) {
+	for !batch.Done() {
+		fmt.Fprintf(c.stderr, "%s: %s, %d/%d requests done\n",
+			batch.ID, batch.Status, batch.RequestCounts.Completed+batch.RequestCounts.Failed, batch.RequestCounts.Total)
+		select {
+		case <-ctx.Done():
+			return batch, ctx.Err()
+		case <-time.After(interval):
+		}
+		var err error
+		if batch, err = c.client.RetrieveBatch(ctx, batch.ID); err != nil {
+			return batch, err
+		}
+	}
+	return batch, nil
+}
+
+func (c *cli) downloadBatch(ctx context.Context, args []string) error {
+	flags := c.newFlagSet("batch download", "<batch id>")
+	output := flags.String("o", "-", "output file")
+	errorsOnly := flags.Bool("errors", false, "download the error file instead of the output file")
+	if err := c.parse(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errUsage
+	}
+	batch, err := c.client.RetrieveBatch(ctx, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	fileID := batch.OutputFileID
+	if *errorsOnly {
+		fileID = batch.ErrorFileID
+	}
+	if fileID == nil || *fileID == "" {
+		if !batch.Done() {
+			return fmt.Errorf("batch %s is %s", batch.ID, batch.Status)
+		}
+		return errors.New("the batch has no such file")
+	}
+	return c.download(ctx, *fileID, *output)
+}
+
+func (c *cli) cancelBatch(ctx context.Context, args []string) error {
+	flags := c.newFlagSet("batch cancel", "<batch id>")
+	if err := c.parse(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errUsage
+	}
+	batch, err := c.client.CancelBatch(ctx, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	return c.printBatch(batch)
+}
+
+func (c *cli) listBatches(ctx context.Context, args []string) error {
+	flags := c.newFlagSet("batch list", "")
+	limit := flags.Int("limit", 20, "number of batches")
+	if err := c.parse(flags, args); err != nil {
+		return err
+	}
+	list, err := c.client.ListBatch(ctx, nil, limit)
+	if err != nil {
+		return err
+	}
+	return c.print(list.Data, func(w io.Writer) {
+		for _, batch := range list.Data {
+			printBatchLine(w, batch)
+		}
+	})
+}
+
+func (c *cli) printBatch(batch openai.Batch) error {
+	return c.print(batch, func(w io.Writer) { printBatchLine(w, batch) })
+}
+
+func printBatchLine(w io.Writer, batch openai.Batch) {
+	counts := batch.RequestCounts
+	fmt.Fprintf(w, "%s  %s  %s  %d/%d done, %d failed", batch.ID, batch.Status, batch.Endpoint,
+		counts.Completed, counts.Total, counts.Failed)
+	if batch.OutputFileID != nil && *batch.OutputFileID != "" {
+		fmt.Fprintf(w, "  output %s", *batch.OutputFileID)
+	}
+	if batch.ErrorFileID != nil && *batch.ErrorFileID != "" {
+		fmt.Fprintf(w, "  errors %s", *batch.ErrorFileID)
+	}
+	fmt.Fprintln(w)
+}
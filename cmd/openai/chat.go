@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gradientlabs-ai/go-openai"
+)
+
+func runChat(ctx context.Context, c *cli, args []string) error {
+	flags := c.newFlagSet("chat", "[prompt | -]")
+	model := flags.String("model", openai.GPT4oMini, "model")
+	system := flags.String("system", "", "system message")
+	temperature := flags.Float64("temperature", -1, "sampling temperature (default the model's)")
+	maxTokens := flags.Int("max-tokens", 0, "maximum tokens in the answer")
+	noStream := flags.Bool("no-stream", false, "wait for the whole answer instead of streaming it")
+	if err := c.parse(flags, args); err != nil {
+		return err
+	}
+	prompt, err := c.readInput(flags.Args())
+	if err != nil {
+		return err
+	}
+
+	request := openai.ChatCompletionRequest{Model: *model, MaxCompletionTokens: *maxTokens}
+	if *temperature >= 0 {
+		request.Temperature = float32(*temperature)
+	}
+	if *system != "" {
+		request.Messages = append(request.Messages, openai.ChatCompletionMessage{
+			Role:    openai.ChatMessageRoleSystem,
+			Content: *system,
+		})
+	}
+	request.Messages = append(request.Messages, openai.ChatCompletionMessage{
+		Role:    openai.ChatMessageRoleUser,
+		Content: prompt,
+	})
+
+	// JSON output is the whole response, so it is never streamed.
+	if *noStream || c.json {
+		resp, chatErr := c.client.CreateChatCompletion(ctx, request)
+		if chatErr != nil {
+			return chatErr
+		}
+		return c.print(resp, func(w io.Writer) {
+			for _, choice := range resp.Choices {
+				fmt.Fprintln(w, choice.Message.Content)
+				printToolCalls(w, choice.Message.ToolCalls)
+			}
+		})
+	}
+
+	stream, err := c.client.CreateChatCompletionStream(ctx, request)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+	var toolCalls []openai.ToolCall
+	for {
+		chunk, recvErr := stream.Recv()
+		if errors.Is(recvErr, io.EOF) {
+			break
+		}
+		if recvErr != nil {
+			fmt.Fprintln(c.stdout)
+			return recvErr
+		}
+		for _, choice := range chunk.Choices {
+			fmt.Fprint(c.stdout, choice.Delta.Content)
+			toolCalls = mergeToolCalls(toolCalls, choice.Delta.ToolCalls)
+		}
+	}
+	fmt.Fprintln(c.stdout)
+	printToolCalls(c.stdout, toolCalls)
+	return nil
+}
+
+// mergeToolCalls adds streamed tool call deltas to the calls assembled so far.
+func mergeToolCalls(calls, deltas []openai.ToolCall) []openai.ToolCall {
+	for _, delta := range deltas {
+		index := len(calls)
+		if delta.Index != nil {
+			index = *delta.Index
+		}
+		for len(calls) <= index {
+			calls = append(calls, openai.ToolCall{})
+		}
+		call := &calls[index]
+		if delta.ID != "" {
+			call.ID = delta.ID
+		}
+		call.Function.Name += delta.Function.Name
+		call.Function.Arguments += delta.Function.Arguments
+	}
+	return calls
+}
+
+func printToolCalls(w io.Writer, calls []openai.ToolCall) {
+	for _, call := range calls {
+		fmt.Fprintf(w, "tool call %s: %s(%s)\n", call.ID, call.Function.Name, strings.TrimSpace(call.Function.Arguments))
+	}
+}
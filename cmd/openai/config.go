@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/gradientlabs-ai/go-openai"
+)
+
+// profilesFile is the file of named client configurations described in the
+// command documentation.
+type profilesFile struct {
+	Default  string             `json:"default"`
+	Profiles map[string]profile `json:"profiles"`
+}
+
+type profile struct {
+	// APIType is "openai", "azure" for dated deployment URLs, or "azure-v1"
+	// for the Azure v1 API. Empty means openai.
+	APIType string `json:"api_type"`
+	BaseURL string `json:"base_url"`
+	// APIKey is the key itself. Prefer APIKeyEnv, which names an environment
+	// variable read on every request.
+	APIKey     string `json:"api_key"`
+	APIKeyEnv  string `json:"api_key_env"`
+	APIVersion string `json:"api_version"`
+	OrgID      string `json:"org_id"`
+}
+
+const (
+	configEnv  = "OPENAI_CONFIG"
+	profileEnv = "OPENAI_PROFILE"
+)
+
+func loadClientConfig(path, name string) (openai.ClientConfig, error) {
+	explicit := path != "" || os.Getenv(configEnv) != ""
+	if path == "" {
+		path = os.Getenv(configEnv)
+	}
+	if path == "" {
+		dir, err := os.UserConfigDir()
+		if err == nil {
+			path = filepath.Join(dir, "openai", "config.json")
+		}
+	}
+	if name == "" {
+		name = os.Getenv(profileEnv)
+	}
+
+	var file profilesFile
+	if path != "" {
+		data, err := os.ReadFile(path)
+		switch {
+		case errors.Is(err, os.ErrNotExist) && !explicit:
+		case err != nil:
+			return openai.ClientConfig{}, err
+		default:
+			if err = json.Unmarshal(data, &file); err != nil {
+				return openai.ClientConfig{}, fmt.Errorf("reading %s: %w", path, err)
+			}
+		}
+	}
+
+	if name == "" {
+		name = file.Default
+	}
+	if name == "" && len(file.Profiles) == 0 {
+		return profile{BaseURL: os.Getenv("OPENAI_BASE_URL")}.clientConfig()
+	}
+	if name == "" {
+		name = "default"
+	}
+	p, ok := file.Profiles[name]
+	if !ok {
+		return openai.ClientConfig{}, fmt.Errorf("no profile %q in %s", name, path)
+	}
+	return p.clientConfig()
+}
+
+func (p profile) clientConfig() (openai.ClientConfig, error) {
+	var config openai.ClientConfig
+	switch p.APIType {
+	case "", "openai":
+		config = openai.DefaultConfig(p.APIKey)
+		if p.BaseURL != "" {
+			config.BaseURL = p.BaseURL
+		}
+	case "azure":
+		config = openai.DefaultAzureConfig(p.APIKey, p.BaseURL)
+		if p.APIVersion != "" {
+			config.APIVersion = p.APIVersion
+		}
+	case "azure-v1":
+		config = openai.DefaultAzureV1Config(p.APIKey, p.BaseURL)
+	default:
+		return config, fmt.Errorf("unknown api_type %q", p.APIType)
+	}
+	if config.APIType != openai.APITypeOpenAI && p.BaseURL == "" {
+		return config, errors.New("an Azure profile needs a base_url")
+	}
+	if p.APIKey == "" {
+		config.TokenProvider = openai.EnvTokenProvider{Name: p.APIKeyEnv}
+	}
+	config.OrgID = p.OrgID
+	return config, nil
+}
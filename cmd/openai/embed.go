@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/gradientlabs-ai/go-openai"
+)
+
+func runEmbed(ctx context.Context, c *cli, args []string) error {
+	flags := c.newFlagSet("embed", "[text... | -]")
+	model := flags.String("model", string(openai.SmallEmbedding3), "embedding model")
+	dimensions := flags.Int("dimensions", 0, "embedding size (default the model's)")
+	format := flags.String("format", "json", "output format: json or npy")
+	output := flags.String("o", "-", "output file")
+	if err := c.parse(flags, args); err != nil {
+		return err
+	}
+	if *format != "json" && *format != "npy" {
+		return fmt.Errorf("unknown format %q", *format)
+	}
+	inputs, err := c.embeddingInputs(flags.Args())
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.client.CreateEmbeddingsBatched(ctx, openai.EmbeddingRequestStrings{
+		Input:      inputs,
+		Model:      openai.EmbeddingModel(*model),
+		Dimensions: *dimensions,
+	}, openai.EmbeddingBatchOptions{})
+	if err != nil {
+		return err
+	}
+	vectors := make([][]float32, len(resp.Data))
+	for _, embedding := range resp.Data {
+		vectors[embedding.Index] = embedding.Embedding
+	}
+
+	w, err := c.create(*output)
+	if err != nil {
+		return err
+	}
+	if *format == "npy" {
+		err = writeNPY(w, vectors)
+	} else {
+		err = writeEmbeddingsJSON(w, inputs, vectors)
+	}
+	if closeErr := w.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// embeddingInputs returns the arguments, or the non-empty lines of stdin if
+// there are none or the only one is "-".
+func (c *cli) embeddingInputs(args []string) ([]string, error) {
+	if len(args) > 0 && !(len(args) == 1 && args[0] == "-") {
+		return args, nil
+	}
+	var inputs []string
+	scanner := bufio.NewScanner(c.stdin)
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			inputs = append(inputs, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading stdin: %w", err)
+	}
+	if len(inputs) == 0 {
+		return nil, errors.New("no input: pass texts as arguments or lines on stdin")
+	}
+	return inputs, nil
+}
+
+func writeEmbeddingsJSON(w io.Writer, inputs []string, vectors [][]float32) error {
+	type embedding struct {
+		Input     string    `json:"input"`
+		Embedding []float32 `json:"embedding"`
+	}
+	embeddings := make([]embedding, len(vectors))
+	for i, vector := range vectors {
+		embeddings[i] = embedding{Input: inputs[i], Embedding: vector}
+	}
+	return json.NewEncoder(w).Encode(embeddings)
+}
+
+// writeNPY writes vectors as a two-dimensional little-endian float32 array
+// in the NumPy .npy format, version 1.0.
+func writeNPY(w io.Writer, vectors [][]float32) error {
+	columns := 0
+	if len(vectors) > 0 {
+		columns = len(vectors[0])
+	}
+	for _, vector := range vectors {
+		if len(vector) != columns {
+			return errors.New("embeddings have different sizes")
+		}
+	}
+
+	header := fmt.Sprintf("{'descr': '<f4', 'fortran_order': False, 'shape': (%d, %d), }", len(vectors), columns)
+	// The magic string, version, header length and header are padded with
+	// spaces to a multiple of 64 bytes, and the header ends with a newline.
+	const preamble = 10
+	padding := 64 - (preamble+len(header)+1)%64
+	if padding == 64 {
+		padding = 0
+	}
+	header += strings.Repeat(" ", padding) + "\n"
+
+	bw := bufio.NewWriter(w)
+	bw.WriteString("\x93NUMPY\x01\x00")
+	_ = binary.Write(bw, binary.LittleEndian, uint16(len(header)))
+	bw.WriteString(header)
+	var buf [4]byte
+	for _, vector := range vectors {
+		for _, value := range vector {
+			binary.LittleEndian.PutUint32(buf[:], math.Float32bits(value))
+			bw.Write(buf[:])
+		}
+	}
+	return bw.Flush()
+}
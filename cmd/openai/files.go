@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gradientlabs-ai/go-openai"
+)
+
+func runFiles(ctx context.Context, c *cli, args []string) error {
+	return c.subcommand(ctx, "files", args, map[string]func(context.Context, []string) error{
+		"upload":   c.uploadFile,
+		"list":     c.listFiles,
+		"download": c.downloadFile,
+		"delete":   c.deleteFile,
+	})
+}
+
+func (c *cli) uploadFile(ctx context.Context, args []string) error {
+	flags := c.newFlagSet("files upload", "<path>")
+	purpose := flags.String("purpose", string(openai.PurposeAssistants), "file purpose, e.g. fine-tune or batch")
+	if err := c.parse(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errUsage
+	}
+	file, err := c.client.CreateFile(ctx, openai.FileRequest{
+		FileName: filepath.Base(flags.Arg(0)),
+		FilePath: flags.Arg(0),
+		Purpose:  *purpose,
+	})
+	if err != nil {
+		return err
+	}
+	return c.print(file, func(w io.Writer) { fmt.Fprintln(w, file.ID) })
+}
+
+func (c *cli) listFiles(ctx context.Context, args []string) error {
+	flags := c.newFlagSet("files list", "")
+	if err := c.parse(flags, args); err != nil {
+		return err
+	}
+	list, err := c.client.ListFiles(ctx)
+	if err != nil {
+		return err
+	}
+	sort.Slice(list.Files, func(i, j int) bool { return list.Files[i].CreatedAt > list.Files[j].CreatedAt })
+	return c.print(list.Files, func(w io.Writer) {
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tBYTES\tPURPOSE\tCREATED\tNAME")
+		for _, file := range list.Files {
+			fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\n",
+				file.ID, file.Bytes, file.Purpose, formatUnix(file.CreatedAt), file.FileName)
+		}
+		tw.Flush()
+	})
+}
+
+func (c *cli) downloadFile(ctx context.Context, args []string) error {
+	flags := c.newFlagSet("files download", "<file id>")
+	output := flags.String("o", "-", "output file")
+	if err := c.parse(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errUsage
+	}
+	return c.download(ctx, flags.Arg(0), *output)
+}
+
+// download writes the content of a file to path.
+func (c *cli) download(ctx context.Context, fileID, path string) error {
+	content, err := c.client.GetFileContent(ctx, fileID)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+	n, err := c.writeTo(path, content)
+	if err != nil {
+		return err
+	}
+	if path != "-" {
+		fmt.Fprintf(c.stderr, "wrote %d bytes to %s\n", n, path)
+	}
+	return nil
+}
+
+func (c *cli) deleteFile(ctx context.Context, args []string) error {
+	flags := c.newFlagSet("files delete", "<file id>...")
+	if err := c.parse(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errUsage
+	}
+	var errs []error
+	for _, id := range flags.Args() {
+		if err := c.client.DeleteFile(ctx, id); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", id, err))
+			continue
+		}
+		if !c.json {
+			fmt.Fprintln(c.stdout, "deleted", id)
+		}
+	}
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	if len(errs) > 1 {
+		return fmt.Errorf("%w (and %d more)", errs[0], len(errs)-1)
+	}
+	return nil
+}
+
+func runModels(ctx context.Context, c *cli, args []string) error {
+	flags := c.newFlagSet("models", "")
+	if err := c.parse(flags, args); err != nil {
+		return err
+	}
+	list, err := c.client.ListModels(ctx)
+	if err != nil {
+		return err
+	}
+	sort.Slice(list.Models, func(i, j int) bool { return list.Models[i].ID < list.Models[j].ID })
+	return c.print(list.Models, func(w io.Writer) {
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tOWNED BY")
+		for _, model := range list.Models {
+			fmt.Fprintf(tw, "%s\t%s\n", model.ID, model.OwnedBy)
+		}
+		tw.Flush()
+	})
+}
+
+func formatUnix(seconds int64) string {
+	if seconds == 0 {
+		return "-"
+	}
+	return time.Unix(seconds, 0).Format(time.RFC3339)
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/gradientlabs-ai/go-openai"
+)
+
+func runFineTune(ctx context.Context, c *cli, args []string) error {
+	return c.subcommand(ctx, "fine-tune", args, map[string]func(context.Context, []string) error{
+		"create": c.createFineTune,
+		"status": c.fineTuneStatus,
+		"events": c.fineTuneEvents,
+		"cancel": c.cancelFineTune,
+	})
+}
+
+func (c *cli) createFineTune(ctx context.Context, args []string) error {
+	flags := c.newFlagSet("fine-tune create", "")
+	model := flags.String("model", openai.GPT4oMini, "base model")
+	trainingFile := flags.String("training-file", "", "ID of the uploaded training file (required)")
+	validationFile := flags.String("validation-file", "", "ID of the uploaded validation file")
+	suffix := flags.String("suffix", "", "suffix of the fine-tuned model name")
+	epochs := flags.String("epochs", "", "number of epochs, or auto")
+	follow := flags.Bool("follow", false, "print the job's events until it finishes")
+	interval := flags.Duration("interval", 10*time.Second, "polling interval with -follow")
+	if err := c.parse(flags, args); err != nil {
+		return err
+	}
+	if *trainingFile == "" {
+		flags.Usage()
+		return errUsage
+	}
+	request := openai.FineTuningJobRequest{
+		TrainingFile:   *trainingFile,
+		ValidationFile: *validationFile,
+		Model:          *model,
+		Suffix:         *suffix,
+	}
+	if *epochs != "" {
+		var n any = *epochs
+		if value, err := strconv.Atoi(*epochs); err == nil {
+			n = value
+		}
+		request.Hyperparameters = &openai.Hyperparameters{Epochs: n}
+	}
+	job, err := c.client.CreateFineTuningJob(ctx, request)
+	if err != nil {
+		return err
+	}
+	if err = c.printFineTune(job); err != nil {
+		return err
+	}
+	if *follow {
+		return c.followFineTune(ctx, job.ID, *interval)
+	}
+	return nil
+}
+
+func (c *cli) fineTuneStatus(ctx context.Context, args []string) error {
+	flags := c.newFlagSet("fine-tune status", "<job id>")
+	if err := c.parse(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errUsage
+	}
+	job, err := c.client.RetrieveFineTuningJob(ctx, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	return c.printFineTune(job)
+}
+
+func (c *cli) cancelFineTune(ctx context.Context, args []string) error {
+	flags := c.newFlagSet("fine-tune cancel", "<job id>")
+	if err := c.parse(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errUsage
+	}
+	job, err := c.client.CancelFineTuningJob(ctx, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	return c.printFineTune(job)
+}
+
+func (c *cli) printFineTune(job openai.FineTuningJob) error {
+	return c.print(job, func(w io.Writer) {
+		fmt.Fprintf(w, "%s  %s  %s", job.ID, job.Status, job.Model)
+		if job.FineTunedModel != "" {
+			fmt.Fprintf(w, " -> %s", job.FineTunedModel)
+		}
+		fmt.Fprintln(w)
+	})
+}
+
+func (c *cli) fineTuneEvents(ctx context.Context, args []string) error {
+	flags := c.newFlagSet("fine-tune events", "<job id>")
+	follow := flags.Bool("follow", false, "keep printing events until the job finishes")
+	interval := flags.Duration("interval", 10*time.Second, "polling interval with -follow")
+	if err := c.parse(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errUsage
+	}
+	if *follow {
+		return c.followFineTune(ctx, flags.Arg(0), *interval)
+	}
+	return c.printNewEvents(ctx, flags.Arg(0), map[openai.FineTuneEvent]bool{})
+}
+
+// followFineTune prints the events of a job as they arrive until the job
+// succeeds, fails or is cancelled.
+func (c *cli) followFineTune(ctx context.Context, jobID string, interval time.Duration) error {
+	seen := make(map[openai.FineTuneEvent]bool)
+	for {
+		if err := c.printNewEvents(ctx, jobID, seen); err != nil {
+			return err
+		}
+		job, err := c.client.RetrieveFineTuningJob(ctx, jobID)
+		if err != nil {
+			return err
+		}
+		switch job.Status {
+		case "succeeded", "failed", "cancelled":
+			// Events logged while the job finished.
+			if err = c.printNewEvents(ctx, jobID, seen); err != nil {
+				return err
+			}
+			if !c.json {
+				fmt.Fprintf(c.stderr, "job %s %s\n", job.ID, job.Status)
+			}
+			if job.Status == "failed" {
+				return fmt.Errorf("fine-tuning job %s failed", job.ID)
+			}
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// printNewEvents prints the job's latest events that are not in seen, oldest
+// first, and adds them to seen. Events have no ID, so they are told apart by
+// their content.
+func (c *cli) printNewEvents(ctx context.Context, jobID string, seen map[openai.FineTuneEvent]bool) error {
+	list, err := c.client.ListFineTuningJobEvents(ctx, jobID, openai.ListFineTuningJobEventsWithLimit(100))
+	if err != nil {
+		return err
+	}
+	// The API lists the newest events first.
+	for i := len(list.Data) - 1; i >= 0; i-- {
+		event := list.Data[i]
+		if seen[event] {
+			continue
+		}
+		seen[event] = true
+		if err = c.print(event, func(w io.Writer) {
+			fmt.Fprintf(w, "%s  %-5s  %s\n", formatUnix(event.CreatedAt), event.Level, event.Message)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
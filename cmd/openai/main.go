@@ -0,0 +1,245 @@
+// Command openai runs common OpenAI API operations from the shell.
+//
+//	openai [-profile name] [-json] <command> [flags] [args]
+//
+// Commands:
+//
+//	chat        send a prompt, from the arguments or stdin, and stream the answer
+//	repl        chat interactively, with attachments, sessions and local tools
+//	embed       embed text, from the arguments or stdin lines, to JSON or NPY
+//	files       upload, list, download or delete files
+//	fine-tune   create, inspect, cancel or follow fine-tuning jobs
+//	batch       submit batches, check their status and download their results
+//	models      list models
+//	transcribe  transcribe an audio file
+//	speak       synthesize speech to an audio file
+//
+// With -json, results are printed as JSON for piping into other tools.
+//
+// The client is configured by a profile from $OPENAI_CONFIG, or
+// config.json in the openai directory of the user config directory:
+//
+//	{
+//	  "default": "openai",
+//	  "profiles": {
+//	    "openai": {"api_key_env": "OPENAI_API_KEY"},
+//	    "azure": {
+//	      "api_type": "azure",
+//	      "base_url": "https://my-resource.openai.azure.com",
+//	      "api_key_env": "AZURE_OPENAI_API_KEY",
+//	      "api_version": "2024-06-01"
+//	    }
+//	  }
+//	}
+//
+// api_type is openai, azure or azure-v1. A profile without api_key or
+// api_key_env reads $OPENAI_API_KEY. Without a file, the client talks to
+// OpenAI with $OPENAI_API_KEY, and $OPENAI_BASE_URL if set.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+
+	"github.com/gradientlabs-ai/go-openai"
+)
+
+// cli is the state shared by the commands.
+type cli struct {
+	client *openai.Client
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+	// json prints results as JSON instead of text.
+	json bool
+}
+
+type command struct {
+	summary string
+	run     func(ctx context.Context, c *cli, args []string) error
+}
+
+var commands = map[string]command{
+	"chat":       {"send a prompt and stream the answer", runChat},
+	"repl":       {"chat interactively", runREPL},
+	"embed":      {"embed text to JSON or NPY", runEmbed},
+	"files":      {"upload, list, download or delete files", runFiles},
+	"fine-tune":  {"create, inspect, cancel or follow fine-tuning jobs", runFineTune},
+	"batch":      {"submit batches, check their status and download results", runBatch},
+	"models":     {"list models", runModels},
+	"transcribe": {"transcribe an audio file", runTranscribe},
+	"speak":      {"synthesize speech to an audio file", runSpeak},
+}
+
+// errUsage is returned after a usage message has been printed.
+var errUsage = errors.New("usage")
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	err := run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+	stop()
+	switch {
+	case errors.Is(err, errUsage):
+		os.Exit(2)
+	case err != nil:
+		fmt.Fprintln(os.Stderr, "openai:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("openai", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	profileName := flags.String("profile", "", "configuration profile (default $OPENAI_PROFILE or the file's default)")
+	configPath := flags.String("config", "",
+		"profiles file (default $OPENAI_CONFIG or <user config dir>/openai/config.json)")
+	asJSON := flags.Bool("json", false, "print results as JSON")
+	flags.Usage = func() { usage(flags) }
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errUsage
+	}
+	cmd, ok := commands[flags.Arg(0)]
+	if !ok {
+		fmt.Fprintf(stderr, "openai: unknown command %q\n", flags.Arg(0))
+		flags.Usage()
+		return errUsage
+	}
+
+	config, err := loadClientConfig(*configPath, *profileName)
+	if err != nil {
+		return err
+	}
+	c := &cli{
+		client: openai.NewClientWithConfig(config),
+		stdin:  stdin,
+		stdout: stdout,
+		stderr: stderr,
+		json:   *asJSON,
+	}
+	return cmd.run(ctx, c, flags.Args()[1:])
+}
+
+func usage(flags *flag.FlagSet) {
+	w := flags.Output()
+	fmt.Fprintln(w, "usage: openai [flags] <command> [command flags] [args]")
+	fmt.Fprintln(w, "\nCommands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-11s %s\n", name, commands[name].summary)
+	}
+	fmt.Fprintln(w, "\nFlags:")
+	flags.PrintDefaults()
+}
+
+// newFlagSet returns the flag set of a command. Parse errors are reported
+// as errUsage after the usage has been printed.
+func (c *cli) newFlagSet(name, args string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(c.stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(c.stderr, "usage: openai %s [flags] %s\n", name, args)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+func (c *cli) parse(flags *flag.FlagSet, args []string) error {
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+	return nil
+}
+
+// subcommand dispatches to the subcommands of a command such as files.
+func (c *cli) subcommand(
+	ctx context.Context,
+	name string,
+	args []string,
+	subcommands map[string]func(context.Context, []string) error,
+) error {
+	names := make([]string, 0, len(subcommands))
+	for sub := range subcommands {
+		names = append(names, sub)
+	}
+	sort.Strings(names)
+	if len(args) == 0 || subcommands[args[0]] == nil {
+		fmt.Fprintf(c.stderr, "usage: openai %s <%s> [flags] [args]\n", name, strings.Join(names, "|"))
+		return errUsage
+	}
+	return subcommands[args[0]](ctx, args[1:])
+}
+
+// print writes v as indented JSON with -json, and calls text otherwise.
+func (c *cli) print(v any, text func(w io.Writer)) error {
+	if c.json {
+		return writeJSON(c.stdout, v)
+	}
+	text(c.stdout)
+	return nil
+}
+
+func writeJSON(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// readInput returns the arguments joined by spaces, or stdin if there are
+// none or the only one is "-".
+func (c *cli) readInput(args []string) (string, error) {
+	if len(args) > 0 && !(len(args) == 1 && args[0] == "-") {
+		return strings.Join(args, " "), nil
+	}
+	data, err := io.ReadAll(c.stdin)
+	if err != nil {
+		return "", fmt.Errorf("reading stdin: %w", err)
+	}
+	text := strings.TrimSpace(string(data))
+	if text == "" {
+		return "", errors.New("no input: pass it as arguments or on stdin")
+	}
+	return text, nil
+}
+
+// create opens path for writing, or returns stdout if path is "-".
+func (c *cli) create(path string) (io.WriteCloser, error) {
+	if path == "-" {
+		return nopWriteCloser{c.stdout}, nil
+	}
+	return os.Create(path)
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// writeTo copies r to path, or to stdout if path is "-".
+func (c *cli) writeTo(path string, r io.Reader) (int64, error) {
+	w, err := c.create(path)
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(w, r)
+	if closeErr := w.Close(); err == nil {
+		err = closeErr
+	}
+	return n, err
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gradientlabs-ai/go-openai"
+	"github.com/gradientlabs-ai/go-openai/internal/test/checks"
+	"github.com/gradientlabs-ai/go-openai/openaitest"
+)
+
+// startCLI starts a fake API and returns a function that runs the command
+// against it with stdin and returns its stdout.
+func startCLI(t *testing.T) (*openaitest.Server, func(stdin string, args ...string) (string, error)) {
+	t.Helper()
+	server := openaitest.NewServer()
+	t.Cleanup(server.Close)
+
+	config := filepath.Join(t.TempDir(), "config.json")
+	profiles := profilesFile{
+		Default: "mock",
+		Profiles: map[string]profile{
+			"mock": {BaseURL: server.URL + "/v1", APIKey: openaitest.DefaultAPIKey},
+		},
+	}
+	data, err := json.Marshal(profiles)
+	checks.NoError(t, err)
+	checks.NoError(t, os.WriteFile(config, data, 0o600))
+
+	return server, func(stdin string, args ...string) (string, error) {
+		var stdout, stderr bytes.Buffer
+		args = append([]string{"-config", config}, args...)
+		err := run(context.Background(), args, strings.NewReader(stdin), &stdout, &stderr)
+		return stdout.String(), err
+	}
+}
+
+func TestChat(t *testing.T) {
+	server, run := startCLI(t)
+	server.On(openaitest.Rule{Prompt: "ping", Reply: "pong and more"})
+
+	out, err := run("ping\n", "chat")
+	checks.NoError(t, err)
+	if out != "pong and more\n" {
+		t.Fatalf("unexpected streamed answer %q", out)
+	}
+	if requests := server.RequestsTo("/chat/completions"); len(requests) != 1 || !requests[0].Stream {
+		t.Fatalf("expected one streamed request, got %+v", requests)
+	}
+
+	out, err = run("", "-json", "chat", "-system", "be brief", "ping")
+	checks.NoError(t, err)
+	var resp openai.ChatCompletionResponse
+	checks.NoError(t, json.Unmarshal([]byte(out), &resp))
+	if resp.Choices[0].Message.Content != "pong and more" {
+		t.Fatalf("unexpected JSON answer %q", out)
+	}
+	request, err := server.RequestsTo("/chat/completions")[1].ChatCompletionRequest()
+	checks.NoError(t, err)
+	if len(request.Messages) != 2 || request.Messages[0].Content != "be brief" {
+		t.Fatalf("expected a system message, got %+v", request.Messages)
+	}
+}
+
+func TestEmbedNPY(t *testing.T) {
+	_, run := startCLI(t)
+
+	out, err := run("first\n\nsecond\n", "embed", "-dimensions", "4", "-format", "npy")
+	checks.NoError(t, err)
+	data := []byte(out)
+	if !bytes.HasPrefix(data, []byte("\x93NUMPY\x01\x00")) {
+		t.Fatalf("missing NPY magic: %q", data[:10])
+	}
+	headerLength := int(binary.LittleEndian.Uint16(data[8:10]))
+	if (10+headerLength)%64 != 0 {
+		t.Fatalf("header is not aligned to 64 bytes: %d", 10+headerLength)
+	}
+	header := string(data[10 : 10+headerLength])
+	if !strings.Contains(header, "'shape': (2, 4)") || !strings.HasSuffix(header, "\n") {
+		t.Fatalf("unexpected header %q", header)
+	}
+	if got := len(data) - 10 - headerLength; got != 2*4*4 {
+		t.Fatalf("expected 32 bytes of data, got %d", got)
+	}
+
+	out, err = run("", "embed", "-dimensions", "4", "hello")
+	checks.NoError(t, err)
+	var embeddings []struct {
+		Input     string    `json:"input"`
+		Embedding []float32 `json:"embedding"`
+	}
+	checks.NoError(t, json.Unmarshal([]byte(out), &embeddings))
+	if len(embeddings) != 1 || embeddings[0].Input != "hello" || len(embeddings[0].Embedding) != 4 {
+		t.Fatalf("unexpected embeddings %q", out)
+	}
+}
+
+func TestFiles(t *testing.T) {
+	_, run := startCLI(t)
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	checks.NoError(t, os.WriteFile(path, []byte("hello"), 0o600))
+
+	out, err := run("", "files", "upload", path)
+	checks.NoError(t, err)
+	id := strings.TrimSpace(out)
+
+	out, err = run("", "files", "list")
+	checks.NoError(t, err)
+	if !strings.Contains(out, id) || !strings.Contains(out, "notes.txt") {
+		t.Fatalf("expected the file to be listed, got %q", out)
+	}
+
+	out, err = run("", "files", "download", id)
+	checks.NoError(t, err)
+	if out != "hello" {
+		t.Fatalf("unexpected content %q", out)
+	}
+
+	_, err = run("", "files", "delete", id)
+	checks.NoError(t, err)
+	out, err = run("", "-json", "files", "list")
+	checks.NoError(t, err)
+	if strings.TrimSpace(out) != "[]" {
+		t.Fatalf("expected no files, got %q", out)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	server, run := startCLI(t)
+	server.On(openaitest.Rule{Reply: "done"})
+	path := filepath.Join(t.TempDir(), "requests.jsonl")
+	line := `{"custom_id": "1", "method": "POST", "url": "/v1/chat/completions",` +
+		` "body": {"model": "gpt-4o", "messages": [{"role": "user", "content": "hi"}]}}`
+	checks.NoError(t, os.WriteFile(path, []byte(line+"\n"), 0o600))
+
+	out, err := run("", "-json", "batch", "submit", "-wait", "-interval", "1ms", path)
+	checks.NoError(t, err)
+	var batch openai.Batch
+	checks.NoError(t, json.Unmarshal([]byte(out), &batch))
+	if batch.Status != openai.BatchStatusCompleted || batch.RequestCounts.Completed != 1 {
+		t.Fatalf("unexpected batch %q", out)
+	}
+
+	out, err = run("", "batch", "download", batch.ID)
+	checks.NoError(t, err)
+	if !strings.Contains(out, `"custom_id":"1"`) || !strings.Contains(out, "done") {
+		t.Fatalf("unexpected batch output %q", out)
+	}
+	_, err = run("", "batch", "download", "-errors", batch.ID)
+	checks.HasError(t, err, "the batch has no error file")
+}
+
+func TestModels(t *testing.T) {
+	_, run := startCLI(t)
+	out, err := run("", "models")
+	checks.NoError(t, err)
+	if !strings.Contains(out, openai.GPT4o) {
+		t.Fatalf("expected models to be listed, got %q", out)
+	}
+}
+
+func TestUsage(t *testing.T) {
+	_, run := startCLI(t)
+	for _, args := range [][]string{{}, {"nope"}, {"files"}, {"files", "download"}} {
+		_, err := run("", args...)
+		if !errors.Is(err, errUsage) {
+			t.Errorf("%v: expected a usage error, got %v", args, err)
+		}
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	checks.NoError(t, os.WriteFile(path, []byte(`{
+		"default": "azure",
+		"profiles": {
+			"azure": {"api_type": "azure", "base_url": "https://example.openai.azure.com", "api_key": "k"},
+			"broken": {"api_type": "azure"},
+			"odd": {"api_type": "bedrock"}
+		}
+	}`), 0o600))
+
+	config, err := loadClientConfig(path, "")
+	checks.NoError(t, err)
+	if config.APIType != openai.APITypeAzure || config.BaseURL != "https://example.openai.azure.com" {
+		t.Fatalf("unexpected config %+v", config)
+	}
+	for _, name := range []string{"broken", "odd", "missing"} {
+		_, err = loadClientConfig(path, name)
+		checks.HasError(t, err, name)
+	}
+	_, err = loadClientConfig(filepath.Join(t.TempDir(), "none.json"), "")
+	checks.HasError(t, err, "an explicit config file must exist")
+}
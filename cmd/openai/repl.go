@@ -0,0 +1,402 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gradientlabs-ai/go-openai"
+)
+
+// maxToolRounds bounds the tool calls answered for one message, so a model
+// that keeps calling tools can't loop forever.
+const maxToolRounds = 10
+
+const replHelp = `Type a message and press enter. End a line with \ to continue it on the
+next line, or wrap a block in """ lines.
+
+  /model [name]          show or switch the model
+  /system [text]         set the system prompt; without text, clear it
+  /reasoning [effort]    set reasoning effort: minimal, low, medium, high or off
+  /attach [path]         attach an image or file to the next message; without
+                         a path, list the attachments
+  /detach                drop the attachments
+  /tools [on|off]        let the model read files and run shell commands, each
+                         after confirmation
+  /price <in> <out>      set the model's price in dollars per million tokens
+  /usage                 show the tokens and cost of the session
+  /save <path>           save the session as JSON
+  /load <path>           load a saved session
+  /clear                 forget the conversation
+  /quit                  leave`
+
+// replSession is the state of a conversation, saved and loaded as JSON.
+type replSession struct {
+	Model           string                         `json:"model"`
+	System          string                         `json:"system,omitempty"`
+	ReasoningEffort string                         `json:"reasoning_effort,omitempty"`
+	Messages        []openai.ChatCompletionMessage `json:"messages"`
+	Usage           openai.Usage                   `json:"usage"`
+	Cost            float64                        `json:"cost"`
+}
+
+type repl struct {
+	*cli
+	in          *bufio.Reader
+	session     replSession
+	attachments []openai.ChatMessagePart
+	tools       bool
+	prices      map[string]modelPrice
+}
+
+func runREPL(ctx context.Context, c *cli, args []string) error {
+	flags := c.newFlagSet("repl", "")
+	model := flags.String("model", openai.GPT4oMini, "model")
+	system := flags.String("system", "", "system prompt")
+	tools := flags.Bool("tools", false, "let the model read files and run shell commands, each after confirmation")
+	session := flags.String("session", "", "session file to load")
+	if err := c.parse(flags, args); err != nil {
+		return err
+	}
+	r := &repl{
+		cli:     c,
+		in:      bufio.NewReader(c.stdin),
+		session: replSession{Model: *model, System: *system},
+		tools:   *tools,
+		prices:  defaultPrices(),
+	}
+	if *session != "" {
+		if err := r.load(*session); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(c.stderr, "Chatting with %s. Type /help for commands.\n", r.session.Model)
+
+	for {
+		input, err := r.readMessage()
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		if input = strings.TrimSpace(input); input != "" {
+			if r.handle(ctx, input) {
+				return ctx.Err()
+			}
+		}
+		if err != nil {
+			fmt.Fprintln(c.stderr)
+			return nil
+		}
+	}
+}
+
+// handle runs a command or sends a message, and reports whether to quit.
+func (r *repl) handle(ctx context.Context, input string) bool {
+	if strings.HasPrefix(input, "/") {
+		quit, err := r.command(ctx, input)
+		if err != nil {
+			fmt.Fprintln(r.stderr, "error:", err)
+		}
+		return quit
+	}
+	if err := r.turn(ctx, input); err != nil {
+		if ctx.Err() != nil {
+			return true
+		}
+		fmt.Fprintln(r.stderr, "error:", err)
+	}
+	return false
+}
+
+// readMessage reads one message, joining continued lines and """ blocks.
+func (r *repl) readMessage() (string, error) {
+	fmt.Fprint(r.stderr, "> ")
+	var lines []string
+	block := false
+	for {
+		line, err := r.in.ReadString('\n')
+		line = strings.TrimRight(line, "\r\n")
+		switch {
+		case strings.TrimSpace(line) == `"""`:
+			block = !block
+			if !block {
+				return strings.Join(lines, "\n"), err
+			}
+		case block:
+			lines = append(lines, line)
+		case strings.HasSuffix(line, `\`):
+			lines = append(lines, strings.TrimSuffix(line, `\`))
+		default:
+			lines = append(lines, line)
+			return strings.Join(lines, "\n"), err
+		}
+		if err != nil {
+			return strings.Join(lines, "\n"), err
+		}
+		fmt.Fprint(r.stderr, "... ")
+	}
+}
+
+// confirm asks a yes or no question and reports whether the answer was yes.
+func (r *repl) confirm(question string) bool {
+	fmt.Fprintf(r.stderr, "%s [y/N] ", question)
+	answer, _ := r.in.ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+//nolint:gocyclo // a flat table of commands reads best
+func (r *repl) command(ctx context.Context, input string) (quit bool, err error) {
+	name, arg := input, ""
+	if i := strings.IndexAny(input, " \t"); i >= 0 {
+		name, arg = input[:i], strings.TrimSpace(input[i+1:])
+	}
+	switch name {
+	case "/help":
+		fmt.Fprintln(r.stderr, replHelp)
+	case "/quit", "/exit":
+		return true, nil
+	case "/model":
+		if arg != "" {
+			r.session.Model = arg
+		}
+		fmt.Fprintln(r.stderr, "model:", r.session.Model)
+	case "/system":
+		r.session.System = arg
+		if arg == "" {
+			fmt.Fprintln(r.stderr, "system prompt cleared")
+		}
+	case "/reasoning":
+		switch arg {
+		case "", "off":
+			r.session.ReasoningEffort = ""
+		case "minimal", "low", "medium", "high":
+			r.session.ReasoningEffort = arg
+		default:
+			return false, fmt.Errorf("unknown reasoning effort %q", arg)
+		}
+		fmt.Fprintln(r.stderr, "reasoning effort:", valueOr(r.session.ReasoningEffort, "off"))
+	case "/attach":
+		if arg == "" {
+			for _, part := range r.attachments {
+				fmt.Fprintln(r.stderr, attachmentName(part))
+			}
+			return false, nil
+		}
+		part, attachErr := r.attachment(ctx, arg)
+		if attachErr != nil {
+			return false, attachErr
+		}
+		r.attachments = append(r.attachments, part)
+	case "/detach":
+		r.attachments = nil
+	case "/tools":
+		switch arg {
+		case "":
+			r.tools = !r.tools
+		case "on":
+			r.tools = true
+		case "off":
+			r.tools = false
+		default:
+			return false, fmt.Errorf("expected on or off, got %q", arg)
+		}
+		fmt.Fprintln(r.stderr, "tools:", map[bool]string{true: "on", false: "off"}[r.tools])
+	case "/price":
+		fields := strings.Fields(arg)
+		if len(fields) != 2 {
+			return false, errors.New("usage: /price <input $/1M tokens> <output $/1M tokens>")
+		}
+		input, inErr := strconv.ParseFloat(fields[0], 64)
+		output, outErr := strconv.ParseFloat(fields[1], 64)
+		if inErr != nil || outErr != nil {
+			return false, errors.New("prices must be numbers")
+		}
+		r.prices[r.session.Model] = modelPrice{Input: input, Output: output}
+	case "/usage":
+		usage := r.session.Usage
+		fmt.Fprintf(r.stderr, "%d prompt and %d completion tokens, $%.4f\n",
+			usage.PromptTokens, usage.CompletionTokens, r.session.Cost)
+	case "/save":
+		return false, r.save(arg)
+	case "/load":
+		return false, r.load(arg)
+	case "/clear":
+		r.session.Messages = nil
+		r.attachments = nil
+	default:
+		return false, fmt.Errorf("unknown command %s; type /help", name)
+	}
+	return false, nil
+}
+
+func (r *repl) save(path string) error {
+	if path == "" {
+		return errors.New("usage: /save <path>")
+	}
+	data, err := json.MarshalIndent(r.session, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
+
+func (r *repl) load(path string) error {
+	if path == "" {
+		return errors.New("usage: /load <path>")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var session replSession
+	if err = json.Unmarshal(data, &session); err != nil {
+		return fmt.Errorf("reading %s: %w", path, err)
+	}
+	if session.Model == "" {
+		session.Model = r.session.Model
+	}
+	r.session = session
+	fmt.Fprintf(r.stderr, "loaded %d messages\n", len(session.Messages))
+	return nil
+}
+
+// turn sends a message and streams the answer, answering tool calls until
+// the model replies with text.
+func (r *repl) turn(ctx context.Context, input string) error {
+	message := openai.ChatCompletionMessage{Role: openai.ChatMessageRoleUser, Content: input}
+	if len(r.attachments) > 0 {
+		message.Content = ""
+		message.MultiContent = append([]openai.ChatMessagePart{{Type: openai.ChatMessagePartTypeText, Text: input}},
+			r.attachments...)
+	}
+	// The message is only kept if it was answered, so a failed turn can be
+	// retried as it was.
+	messages := append(append([]openai.ChatCompletionMessage(nil), r.session.Messages...), message)
+
+	var turnUsage openai.Usage
+	for round := 0; ; round++ {
+		answer, usage, err := r.stream(ctx, messages)
+		turnUsage = addUsage(turnUsage, usage)
+		if err != nil {
+			return err
+		}
+		messages = append(messages, answer)
+		if len(answer.ToolCalls) == 0 {
+			break
+		}
+		if round == maxToolRounds {
+			return fmt.Errorf("stopped after %d rounds of tool calls", maxToolRounds)
+		}
+		for _, call := range answer.ToolCalls {
+			messages = append(messages, openai.ChatCompletionMessage{
+				Role:       openai.ChatMessageRoleTool,
+				ToolCallID: call.ID,
+				Content:    r.callTool(ctx, call),
+			})
+		}
+	}
+
+	r.session.Messages = messages
+	r.attachments = nil
+	r.session.Usage = addUsage(r.session.Usage, turnUsage)
+	cost, known := r.cost(turnUsage)
+	r.session.Cost += cost
+	status := fmt.Sprintf("[%d in, %d out tokens", turnUsage.PromptTokens, turnUsage.CompletionTokens)
+	if known {
+		status += fmt.Sprintf(", $%.4f; session $%.4f", cost, r.session.Cost)
+	}
+	fmt.Fprintln(r.stderr, status+"]")
+	return nil
+}
+
+// stream sends the conversation and prints the answer as it arrives.
+func (r *repl) stream(
+	ctx context.Context,
+	messages []openai.ChatCompletionMessage,
+) (openai.ChatCompletionMessage, openai.Usage, error) {
+	request := openai.ChatCompletionRequest{
+		Model:           r.session.Model,
+		ReasoningEffort: r.session.ReasoningEffort,
+		StreamOptions:   &openai.StreamOptions{IncludeUsage: true},
+	}
+	if r.session.System != "" {
+		request.Messages = append(request.Messages, openai.ChatCompletionMessage{
+			Role:    openai.ChatMessageRoleSystem,
+			Content: r.session.System,
+		})
+	}
+	request.Messages = append(request.Messages, messages...)
+	if r.tools {
+		request.Tools = localTools
+	}
+
+	answer := openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant}
+	var usage openai.Usage
+	stream, err := r.client.CreateChatCompletionStream(ctx, request)
+	if err != nil {
+		return answer, usage, err
+	}
+	defer stream.Close()
+	var content strings.Builder
+	for {
+		chunk, recvErr := stream.Recv()
+		if errors.Is(recvErr, io.EOF) {
+			break
+		}
+		if recvErr != nil {
+			fmt.Fprintln(r.stdout)
+			return answer, usage, recvErr
+		}
+		if chunk.Usage != nil {
+			usage = *chunk.Usage
+		}
+		for _, choice := range chunk.Choices {
+			fmt.Fprint(r.stdout, choice.Delta.Content)
+			content.WriteString(choice.Delta.Content)
+			answer.ToolCalls = mergeToolCalls(answer.ToolCalls, choice.Delta.ToolCalls)
+		}
+	}
+	if content.Len() > 0 {
+		fmt.Fprintln(r.stdout)
+	}
+	answer.Content = content.String()
+	for i := range answer.ToolCalls {
+		answer.ToolCalls[i].Type = openai.ToolTypeFunction
+	}
+	return answer, usage, nil
+}
+
+// attachment reads a file into a message part: images as image_url parts
+// and anything else as file parts, inlined as data URLs unless they are large
+// enough to be uploaded.
+func (r *repl) attachment(ctx context.Context, path string) (openai.ChatMessagePart, error) {
+	builder := openai.ChatMessagePartBuilder{Client: r.client, Detail: openai.ImageURLDetailAuto, ResizeImages: true}
+	return builder.FromFile(ctx, path)
+}
+
+func attachmentName(part openai.ChatMessagePart) string {
+	if part.File != nil {
+		return "file " + part.File.Filename
+	}
+	return "image"
+}
+
+func addUsage(a, b openai.Usage) openai.Usage {
+	a.PromptTokens += b.PromptTokens
+	a.CompletionTokens += b.CompletionTokens
+	a.TotalTokens += b.TotalTokens
+	return a
+}
+
+func valueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
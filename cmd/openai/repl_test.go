@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gradientlabs-ai/go-openai"
+	"github.com/gradientlabs-ai/go-openai/internal/test/checks"
+	"github.com/gradientlabs-ai/go-openai/openaitest"
+)
+
+// runREPLTest runs the REPL on input against server and returns its stdout
+// and stderr.
+func runREPLTest(t *testing.T, server *openaitest.Server, input string, args ...string) (string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	c := &cli{client: server.OpenAIClient(), stdin: strings.NewReader(input), stdout: &stdout, stderr: &stderr}
+	checks.NoError(t, runREPL(context.Background(), c, args))
+	return stdout.String(), stderr.String()
+}
+
+func lastChatRequest(t *testing.T, server *openaitest.Server) openai.ChatCompletionRequest {
+	t.Helper()
+	requests := server.RequestsTo("/chat/completions")
+	if len(requests) == 0 {
+		t.Fatal("no chat completion requests")
+	}
+	request, err := requests[len(requests)-1].ChatCompletionRequest()
+	checks.NoError(t, err)
+	return request
+}
+
+func TestREPLConversation(t *testing.T) {
+	server := openaitest.NewServer()
+	defer server.Close()
+	server.On(openaitest.Rule{Prompt: "^first$", Reply: "one"})
+	server.On(openaitest.Rule{Prompt: "again", Reply: "two"})
+	sessionPath := filepath.Join(t.TempDir(), "session.json")
+
+	input := "first\n/model gpt-4o\n/system be brief\nagain\\\ncontinued\n/save " + sessionPath + "\n/quit\nignored\n"
+	stdout, stderr := runREPLTest(t, server, input)
+	if stdout != "one\ntwo\n" {
+		t.Fatalf("unexpected answers %q", stdout)
+	}
+	if !strings.Contains(stderr, "tokens, $") {
+		t.Fatalf("expected the cost of priced models to be shown, got %q", stderr)
+	}
+	server.AssertRequestCount(t, "/chat/completions", 2)
+
+	request := lastChatRequest(t, server)
+	if request.Model != openai.GPT4o || len(request.Messages) != 4 {
+		t.Fatalf("unexpected request %+v", request)
+	}
+	if request.Messages[0].Content != "be brief" || request.Messages[3].Content != "again\ncontinued" {
+		t.Fatalf("unexpected messages %+v", request.Messages)
+	}
+
+	data, err := os.ReadFile(sessionPath)
+	checks.NoError(t, err)
+	var session replSession
+	checks.NoError(t, json.Unmarshal(data, &session))
+	if session.Model != openai.GPT4o || len(session.Messages) != 4 || session.Usage.TotalTokens == 0 {
+		t.Fatalf("unexpected saved session %+v", session)
+	}
+
+	// A loaded session carries on where it left off.
+	runREPLTest(t, server, "again\n", "-session", sessionPath)
+	if request = lastChatRequest(t, server); len(request.Messages) != 6 {
+		t.Fatalf("expected the loaded conversation to be sent, got %d messages", len(request.Messages))
+	}
+}
+
+func TestREPLTools(t *testing.T) {
+	server := openaitest.NewServer()
+	defer server.Close()
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	checks.NoError(t, os.WriteFile(path, []byte("secret notes"), 0o600))
+	arguments, err := json.Marshal(map[string]string{"path": path})
+	checks.NoError(t, err)
+	server.On(openaitest.Rule{Times: 1, ToolCalls: []openai.ToolCall{
+		{Function: openai.FunctionCall{Name: "read_file", Arguments: string(arguments)}},
+		{Function: openai.FunctionCall{Name: "run_shell", Arguments: `{"command": "echo hi"}`}},
+	}})
+	server.On(openaitest.Rule{Reply: "done"})
+
+	// The user lets the model read the file, and declines the command.
+	stdout, stderr := runREPLTest(t, server, "look around\ny\nn\n", "-tools")
+	if stdout != "done\n" {
+		t.Fatalf("unexpected answer %q", stdout)
+	}
+	if !strings.Contains(stderr, "Read "+path+"?") || !strings.Contains(stderr, "Run `echo hi`?") {
+		t.Fatalf("expected a confirmation prompt, got %q", stderr)
+	}
+
+	request := lastChatRequest(t, server)
+	if len(request.Tools) != len(localTools) {
+		t.Fatalf("expected the local tools to be offered, got %+v", request.Tools)
+	}
+	var results []string
+	for _, message := range request.Messages {
+		if message.Role == openai.ChatMessageRoleTool {
+			results = append(results, message.Content)
+		}
+	}
+	if len(results) != 2 || results[0] != "secret notes" || !strings.Contains(results[1], "declined") {
+		t.Fatalf("unexpected tool results %q", results)
+	}
+}
+
+func TestREPLAttachments(t *testing.T) {
+	server := openaitest.NewServer()
+	defer server.Close()
+	dir := t.TempDir()
+	image := filepath.Join(dir, "pixel.png")
+	checks.NoError(t, os.WriteFile(image, []byte("\x89PNG\r\n\x1a\n"), 0o600))
+	document := filepath.Join(dir, "report.pdf")
+	checks.NoError(t, os.WriteFile(document, []byte("%PDF-1.4"), 0o600))
+
+	runREPLTest(t, server, "/attach "+image+"\n/attach "+document+"\ndescribe these\nand now?\n")
+
+	requests := server.RequestsTo("/chat/completions")
+	first, err := requests[0].ChatCompletionRequest()
+	checks.NoError(t, err)
+	parts := first.Messages[0].MultiContent
+	if len(parts) != 3 || parts[0].Text != "describe these" {
+		t.Fatalf("unexpected parts %+v", parts)
+	}
+	if parts[1].ImageURL == nil || !strings.HasPrefix(parts[1].ImageURL.URL, "data:image/png;base64,") {
+		t.Fatalf("expected an inline image, got %+v", parts[1])
+	}
+	if parts[2].File == nil || parts[2].File.Filename != "report.pdf" ||
+		!strings.HasPrefix(parts[2].File.FileData, "data:application/pdf;base64,") {
+		t.Fatalf("expected an inline file, got %+v", parts[2])
+	}
+
+	// Attachments only go with the next message.
+	if second := lastChatRequest(t, server); second.Messages[2].Content != "and now?" {
+		t.Fatalf("expected a plain message, got %+v", second.Messages[2])
+	}
+}
+
+func TestREPLCost(t *testing.T) {
+	r := &repl{prices: defaultPrices()}
+	usage := openai.Usage{PromptTokens: 1_000_000, CompletionTokens: 1_000_000}
+
+	r.session.Model = "gpt-4o-mini-2024-07-18"
+	cost, ok := r.cost(usage)
+	if !ok || cost != 0.75 {
+		t.Fatalf("expected a dated snapshot to use its model's price, got %v %v", cost, ok)
+	}
+	r.session.Model = "my-model"
+	if _, ok = r.cost(usage); ok {
+		t.Fatal("expected an unknown model to have no price")
+	}
+}
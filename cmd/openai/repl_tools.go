@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/gradientlabs-ai/go-openai"
+)
+
+const (
+	// maxToolOutput caps what a tool returns to the model, in bytes.
+	maxToolOutput = 32 * 1024
+	shellTimeout  = time.Minute
+)
+
+// localTools are offered to the model when /tools is on.
+var localTools = []openai.Tool{
+	{
+		Type: openai.ToolTypeFunction,
+		Function: &openai.FunctionDefinition{
+			Name:        "read_file",
+			Description: "Read a text file on the user's machine after they confirm it.",
+			Parameters: json.RawMessage(`{"type": "object", "properties": {` +
+				`"path": {"type": "string", "description": "Path of the file"}}, "required": ["path"]}`),
+		},
+	},
+	{
+		Type: openai.ToolTypeFunction,
+		Function: &openai.FunctionDefinition{
+			Name:        "run_shell",
+			Description: "Run a shell command on the user's machine after they confirm it, and return its output.",
+			Parameters: json.RawMessage(`{"type": "object", "properties": {` +
+				`"command": {"type": "string", "description": "The command, run with sh -c"}}, "required": ["command"]}`),
+		},
+	},
+}
+
+// modelPrice is the price of a model in dollars per million tokens.
+type modelPrice struct {
+	Input  float64
+	Output float64
+}
+
+// defaultPrices are list prices at the time of writing. They go stale; set
+// the current ones with /price.
+func defaultPrices() map[string]modelPrice {
+	return map[string]modelPrice{
+		openai.GPT4o:        {Input: 2.5, Output: 10},
+		openai.GPT4oMini:    {Input: 0.15, Output: 0.6},
+		openai.GPT4Dot1:     {Input: 2, Output: 8},
+		openai.GPT4Dot1Mini: {Input: 0.4, Output: 1.6},
+		openai.GPT4Dot1Nano: {Input: 0.1, Output: 0.4},
+		openai.GPT5:         {Input: 1.25, Output: 10},
+		openai.GPT5Mini:     {Input: 0.25, Output: 2},
+	}
+}
+
+// cost returns the cost of usage with the current model, and reports false
+// if its price is unknown. Dated snapshots such as gpt-4o-2024-08-06 use the
+// price of the longest model name they start with.
+func (r *repl) cost(usage openai.Usage) (float64, bool) {
+	var (
+		price   modelPrice
+		matched string
+	)
+	for model, p := range r.prices {
+		if (r.session.Model == model || strings.HasPrefix(r.session.Model, model+"-")) && len(model) > len(matched) {
+			price, matched = p, model
+		}
+	}
+	if matched == "" {
+		return 0, false
+	}
+	return (float64(usage.PromptTokens)*price.Input + float64(usage.CompletionTokens)*price.Output) / 1e6, true
+}
+
+// callTool runs a local tool and returns its output for the model.
+func (r *repl) callTool(ctx context.Context, call openai.ToolCall) string {
+	var args struct {
+		Path    string `json:"path"`
+		Command string `json:"command"`
+	}
+	if err := json.Unmarshal([]byte(call.Function.Arguments), &args); err != nil {
+		return "error: invalid arguments: " + err.Error()
+	}
+	switch call.Function.Name {
+	case "read_file":
+		if !r.confirm(fmt.Sprintf("Read %s?", args.Path)) {
+			return "The user declined to share the file."
+		}
+		file, err := os.Open(args.Path)
+		if err != nil {
+			return "error: " + err.Error()
+		}
+		defer file.Close()
+		data, err := io.ReadAll(io.LimitReader(file, maxToolOutput+1))
+		if err != nil {
+			return "error: " + err.Error()
+		}
+		return truncate(string(data))
+	case "run_shell":
+		if !r.confirm(fmt.Sprintf("Run `%s`?", args.Command)) {
+			return "The user declined to run the command."
+		}
+		ctx, cancel := context.WithTimeout(ctx, shellTimeout)
+		defer cancel()
+		var output bytes.Buffer
+		cmd := exec.CommandContext(ctx, "sh", "-c", args.Command)
+		cmd.Stdout = &output
+		cmd.Stderr = &output
+		err := cmd.Run()
+		result := truncate(output.String())
+		if err != nil {
+			result += "\nerror: " + err.Error()
+		}
+		return result
+	default:
+		return "error: unknown tool " + call.Function.Name
+	}
+}
+
+func truncate(s string) string {
+	if len(s) > maxToolOutput {
+		return s[:maxToolOutput] + "\n[truncated]"
+	}
+	return s
+}
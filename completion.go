@@ -62,6 +62,8 @@ const (
 	GPT4oLatest           = "chatgpt-4o-latest"
 	GPT4oMini             = "gpt-4o-mini"
 	GPT4oMini20240718     = "gpt-4o-mini-2024-07-18"
+	GPT4oAudioPreview     = "gpt-4o-audio-preview"
+	GPT4oMiniAudioPreview = "gpt-4o-mini-audio-preview"
 	GPT4Turbo             = "gpt-4-turbo"
 	GPT4Turbo20240409     = "gpt-4-turbo-2024-04-09"
 	GPT4Turbo0125         = "gpt-4-0125-preview"
@@ -240,6 +242,8 @@ type CompletionChoice struct {
 	Index        int           `json:"index"`
 	FinishReason string        `json:"finish_reason"`
 	LogProbs     LogprobResult `json:"logprobs"`
+	// ContentFilterResults is only returned by Azure OpenAI.
+	ContentFilterResults ContentFilterResults `json:"content_filter_results,omitempty"`
 }
 
 // LogprobResult represents logprob result of Choice.
@@ -258,8 +262,11 @@ type CompletionResponse struct {
 	Model   string             `json:"model"`
 	Choices []CompletionChoice `json:"choices"`
 	Usage   Usage              `json:"usage"`
+	// PromptFilterResults is only returned by Azure OpenAI.
+	PromptFilterResults []PromptFilterResult `json:"prompt_filter_results,omitempty"`
 
 	httpHeader
+	rawJSON
 }
 
 // CreateCompletion — API call to create a completion. This is the main endpoint of the API. Returns new text as well
@@ -270,6 +277,7 @@ type CompletionResponse struct {
 func (c *Client) CreateCompletion(
 	ctx context.Context,
 	request CompletionRequest,
+	opts ...RequestOption,
 ) (response CompletionResponse, err error) {
 	if request.Stream {
 		err = ErrCompletionStreamNotSupported
@@ -287,11 +295,17 @@ func (c *Client) CreateCompletion(
 		return
 	}
 
-	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL(urlSuffix, request.Model), withBody(request))
-	if err != nil {
-		return
-	}
-
-	err = c.sendRequest(req, &response)
-	return
+	return callWithPolicies(ctx, c.policies, policyCall[CompletionResponse]{
+		endpoint: urlSuffix,
+		model:    request.Model,
+		do: func(ctx context.Context) (response CompletionResponse, err error) {
+			req, err := c.newRequest(ctx, http.MethodPost, c.fullURL(urlSuffix, request.Model), withBody(request),
+				withRequestOptions(opts))
+			if err != nil {
+				return
+			}
+			err = c.sendRequest(req, &response)
+			return
+		},
+	})
 }
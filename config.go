@@ -3,6 +3,7 @@ package openai
 import (
 	"net/http"
 	"regexp"
+	"time"
 )
 
 const (
@@ -32,12 +33,49 @@ type ClientConfig struct {
 	BaseURL              string
 	OrgID                string
 	APIType              APIType
-	APIVersion           string // required when APIType is APITypeAzure or APITypeAzureAD
+	APIVersion           string // required by the dated Azure path style (APITypeAzure or APITypeAzureAD)
 	AssistantVersion     string
 	AzureModelMapperFunc func(model string) string // replace model to azure deployment name func
 	HTTPClient           *http.Client
 
+	// Provider adapts requests, auth and error parsing to an OpenAI-compatible
+	// backend. Nil talks plain OpenAI.
+	Provider *ProviderProfile
+
+	// AzurePathStyle selects the dated deployments scheme or the v1 GA scheme
+	// for Azure request URLs.
+	AzurePathStyle AzurePathStyle
+
 	EmptyMessagesLimit uint
+
+	// StreamFirstTokenTimeout and StreamIdleTimeout bound how long a stream
+	// waits for its first chunk, counted from the response headers, and for
+	// each chunk after that. Recv fails with a *StreamTimeoutError when one is
+	// exceeded. Heartbeat comments don't count as chunks. Zero disables them.
+	StreamFirstTokenTimeout time.Duration
+	StreamIdleTimeout       time.Duration
+
+	// ResponseCache enables exact-match caching of chat completion and embedding
+	// responses, including replay of streamed chat completions. Nil disables it.
+	ResponseCache *ResponseCacheConfig
+
+	// TokenProvider supplies the credential for each request in place of the
+	// static auth token: an API key from an EnvTokenProvider, FileTokenProvider
+	// or KeyPool, or a CachedTokenProvider over a Microsoft Entra ID credential
+	// when APIType is APITypeAzureAD. With APITypeAzure it is sent as the
+	// api-key header, otherwise as a bearer token.
+	TokenProvider TokenProvider
+
+	// Hedging sends duplicate chat completion requests when the first one is
+	// slow and uses whichever answers first. Nil disables it.
+	Hedging *HedgingPolicy
+	// CircuitBreaker guards chat completion, completion and embedding calls
+	// with a breaker per endpoint and model. Nil disables it.
+	CircuitBreaker *CircuitBreakerPolicy
+
+	// SpeechModels are the text-to-speech models CreateSpeech accepts and the
+	// voices and options each supports. Nil uses DefaultSpeechModels.
+	SpeechModels *SpeechModelRegistry
 }
 
 func DefaultConfig(authToken string) ClientConfig {
@@ -71,6 +109,36 @@ func DefaultAzureConfig(apiKey, baseURL string) ClientConfig {
 	}
 }
 
+// DefaultConfigWithTokenProvider returns a config for the OpenAI API that takes
+// its API key from provider on every request, so keys can be rotated without
+// rebuilding the client.
+func DefaultConfigWithTokenProvider(provider TokenProvider) ClientConfig {
+	config := DefaultConfig("")
+	config.TokenProvider = provider
+	return config
+}
+
+// DefaultAzureV1Config returns a config for the Azure OpenAI v1 API, which is
+// served under {baseURL}/openai/v1 without an api-version. Pass the deployment
+// name as the request model.
+func DefaultAzureV1Config(apiKey, baseURL string) ClientConfig {
+	config := DefaultAzureConfig(apiKey, baseURL)
+	config.APIVersion = ""
+	config.AzurePathStyle = AzurePathStyleV1
+	config.AzureModelMapperFunc = nil
+	return config
+}
+
+// DefaultAzureADConfig returns a config for Azure OpenAI authenticated with
+// Microsoft Entra ID tokens from provider, e.g.
+// NewCachedTokenProvider(ManagedIdentityCredential{}, 0).
+func DefaultAzureADConfig(provider TokenProvider, baseURL string) ClientConfig {
+	config := DefaultAzureConfig("", baseURL)
+	config.APIType = APITypeAzureAD
+	config.TokenProvider = provider
+	return config
+}
+
 func (ClientConfig) String() string {
 	return "<OpenAI API ClientConfig>"
 }
@@ -0,0 +1,329 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultAPIKeyEnv            = "OPENAI_API_KEY"
+	defaultSecretTTL            = 15 * time.Minute
+	defaultUnauthorizedCooldown = 10 * time.Minute
+	defaultRateLimitCooldown    = 30 * time.Second
+)
+
+var (
+	ErrCredentialNotFound = errors.New("credential not found")
+	ErrKeyPoolEmpty       = errors.New("key pool needs at least one key")
+	ErrKeyPoolExhausted   = errors.New("all keys in the pool are quarantined or rate limited")
+)
+
+// TokenProvider supplies the credential sent with every request. When
+// ClientConfig.TokenProvider is set it is consulted by each request instead of
+// the static auth token, so implementations should be cheap to call; wrap a
+// slow TokenSource in a CachedTokenProvider.
+type TokenProvider interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// TokenProviderFunc adapts a function to a TokenProvider.
+type TokenProviderFunc func(ctx context.Context) (string, error)
+
+func (f TokenProviderFunc) Token(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+// ResponseObserver is implemented by token providers that want to know how the
+// API responded to requests made with their tokens. The client calls
+// ObserveResponse with the token used and the response, before the body is
+// read.
+type ResponseObserver interface {
+	ObserveResponse(token string, resp *http.Response)
+}
+
+// EnvTokenProvider reads the API key from an environment variable on every
+// request.
+type EnvTokenProvider struct {
+	// Name defaults to OPENAI_API_KEY.
+	Name string
+}
+
+func (p EnvTokenProvider) Token(context.Context) (string, error) {
+	name := p.Name
+	if name == "" {
+		name = defaultAPIKeyEnv
+	}
+	if token := strings.TrimSpace(os.Getenv(name)); token != "" {
+		return token, nil
+	}
+	return "", fmt.Errorf("%w: environment variable %s is empty", ErrCredentialNotFound, name)
+}
+
+// FileTokenProvider reads the API key from a file, such as a mounted
+// Kubernetes secret, and reloads it whenever the file changes.
+type FileTokenProvider struct {
+	path string
+
+	mu      sync.Mutex
+	modTime time.Time
+	size    int64
+	token   string
+}
+
+func NewFileTokenProvider(path string) *FileTokenProvider {
+	return &FileTokenProvider{path: path}
+}
+
+func (p *FileTokenProvider) Token(context.Context) (string, error) {
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrCredentialNotFound, err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.token != "" && info.ModTime().Equal(p.modTime) && info.Size() == p.size {
+		return p.token, nil
+	}
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrCredentialNotFound, err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("%w: %s is empty", ErrCredentialNotFound, p.path)
+	}
+	p.token, p.modTime, p.size = token, info.ModTime(), info.Size()
+	return token, nil
+}
+
+// NewSecretTokenProvider caches the API key returned by fetch, typically a
+// call to a secret manager, for ttl and refreshes it in the background shortly
+// before it expires. A zero ttl defaults to fifteen minutes.
+func NewSecretTokenProvider(fetch func(ctx context.Context) (string, error), ttl time.Duration) *CachedTokenProvider {
+	if ttl <= 0 {
+		ttl = defaultSecretTTL
+	}
+	const refreshFraction = 10
+	return NewCachedTokenProvider(secretTokenSource{fetch: fetch, ttl: ttl}, ttl/refreshFraction)
+}
+
+type secretTokenSource struct {
+	fetch func(ctx context.Context) (string, error)
+	ttl   time.Duration
+}
+
+func (s secretTokenSource) FetchToken(ctx context.Context) (AccessToken, error) {
+	token, err := s.fetch(ctx)
+	if err != nil {
+		return AccessToken{}, err
+	}
+	if token == "" {
+		return AccessToken{}, fmt.Errorf("%w: secret is empty", ErrCredentialNotFound)
+	}
+	return AccessToken{Token: token, ExpiresOn: time.Now().Add(s.ttl)}, nil
+}
+
+// KeyPoolOptions tunes how long a KeyPool benches failing keys.
+type KeyPoolOptions struct {
+	// UnauthorizedCooldown is how long a key that got a 401 is skipped.
+	// Defaults to ten minutes.
+	UnauthorizedCooldown time.Duration
+	// RateLimitCooldown is how long a key that got a 429 is skipped when the
+	// response carries neither Retry-After nor rate limit reset headers.
+	// Defaults to thirty seconds.
+	RateLimitCooldown time.Duration
+}
+
+// KeyPoolStatus is a snapshot of one key in a KeyPool.
+type KeyPoolStatus struct {
+	// Key is the API key with all but its last four characters masked.
+	Key      string
+	Requests int
+	// RateLimit holds the rate limit headers of the last response, if any.
+	RateLimit RateLimitHeaders
+	// LastStatusCode is the status code of the last response.
+	LastStatusCode int
+	// AvailableAt is when the key can be used again if it is quarantined or
+	// has no requests or tokens remaining, and zero otherwise.
+	AvailableAt time.Time
+}
+
+type pooledKey struct {
+	key            string
+	requests       int
+	rateLimit      RateLimitHeaders
+	lastStatusCode int
+	availableAt    time.Time
+}
+
+// KeyPool spreads requests round-robin across several API keys, for instance
+// keys of different projects. It implements ResponseObserver to track each
+// key's rate limit headers: keys that run out of requests or tokens are skipped
+// until their limit resets, and keys that get a 401 or 429 are quarantined for
+// a cool-down.
+type KeyPool struct {
+	options KeyPoolOptions
+	now     func() time.Time
+
+	mu   sync.Mutex
+	keys []*pooledKey
+	next int
+}
+
+func NewKeyPool(keys []string, options KeyPoolOptions) (*KeyPool, error) {
+	if len(keys) == 0 {
+		return nil, ErrKeyPoolEmpty
+	}
+	if options.UnauthorizedCooldown <= 0 {
+		options.UnauthorizedCooldown = defaultUnauthorizedCooldown
+	}
+	if options.RateLimitCooldown <= 0 {
+		options.RateLimitCooldown = defaultRateLimitCooldown
+	}
+	pool := &KeyPool{options: options, now: time.Now}
+	for _, key := range keys {
+		if key == "" {
+			return nil, fmt.Errorf("%w: empty key", ErrKeyPoolEmpty)
+		}
+		pool.keys = append(pool.keys, &pooledKey{key: key})
+	}
+	return pool, nil
+}
+
+// Token returns the next available key in round-robin order.
+func (p *KeyPool) Token(context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	var soonest time.Time
+	for i := 0; i < len(p.keys); i++ {
+		k := p.keys[(p.next+i)%len(p.keys)]
+		if now.Before(k.availableAt) {
+			if soonest.IsZero() || k.availableAt.Before(soonest) {
+				soonest = k.availableAt
+			}
+			continue
+		}
+		p.next = (p.next + i + 1) % len(p.keys)
+		k.requests++
+		return k.key, nil
+	}
+	return "", fmt.Errorf("%w: next key available in %s", ErrKeyPoolExhausted, soonest.Sub(now).Round(time.Second))
+}
+
+func (p *KeyPool) ObserveResponse(token string, resp *http.Response) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var k *pooledKey
+	for _, candidate := range p.keys {
+		if candidate.key == token {
+			k = candidate
+			break
+		}
+	}
+	if k == nil {
+		return
+	}
+
+	now := p.now()
+	k.lastStatusCode = resp.StatusCode
+	hasRateLimit := resp.Header.Get("x-ratelimit-limit-requests") != "" ||
+		resp.Header.Get("x-ratelimit-limit-tokens") != ""
+	if hasRateLimit {
+		k.rateLimit = newRateLimitHeaders(resp.Header)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		k.availableAt = now.Add(p.options.UnauthorizedCooldown)
+	case http.StatusTooManyRequests:
+		cooldown := p.options.RateLimitCooldown
+		if d, ok := retryAfter(resp.Header); ok {
+			cooldown = d
+		} else if d, ok := rateLimitReset(k.rateLimit, hasRateLimit); ok {
+			cooldown = d
+		}
+		k.availableAt = now.Add(cooldown)
+	default:
+		// Quarantines are not lifted early: this may be the response to a
+		// request that was in flight when the key was benched.
+		if d, ok := rateLimitReset(k.rateLimit, hasRateLimit); ok && now.Add(d).After(k.availableAt) {
+			k.availableAt = now.Add(d)
+		}
+	}
+}
+
+// Status returns a snapshot of every key in the pool, in the order given to
+// NewKeyPool.
+func (p *KeyPool) Status() []KeyPoolStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	statuses := make([]KeyPoolStatus, len(p.keys))
+	for i, k := range p.keys {
+		statuses[i] = KeyPoolStatus{
+			Key:            maskKey(k.key),
+			Requests:       k.requests,
+			RateLimit:      k.rateLimit,
+			LastStatusCode: k.lastStatusCode,
+		}
+		if now.Before(k.availableAt) {
+			statuses[i].AvailableAt = k.availableAt
+		}
+	}
+	return statuses
+}
+
+// rateLimitReset returns how long until an exhausted request or token budget
+// resets. It reports false if the key still has budget left.
+func rateLimitReset(limits RateLimitHeaders, ok bool) (time.Duration, bool) {
+	if !ok {
+		return 0, false
+	}
+	var wait time.Duration
+	if limits.LimitRequests > 0 && limits.RemainingRequests <= 0 {
+		if d, err := time.ParseDuration(limits.ResetRequests.String()); err == nil && d > wait {
+			wait = d
+		}
+	}
+	if limits.LimitTokens > 0 && limits.RemainingTokens <= 0 {
+		if d, err := time.ParseDuration(limits.ResetTokens.String()); err == nil && d > wait {
+			wait = d
+		}
+	}
+	return wait, wait > 0
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date.
+func retryAfter(h http.Header) (time.Duration, bool) {
+	value := h.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return time.Until(at), true
+	}
+	return 0, false
+}
+
+func maskKey(key string) string {
+	const visible = 4
+	if len(key) <= visible {
+		return strings.Repeat("*", len(key))
+	}
+	return strings.Repeat("*", len(key)-visible) + key[len(key)-visible:]
+}
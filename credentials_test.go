@@ -0,0 +1,183 @@
+package openai_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gradientlabs-ai/go-openai"
+	"github.com/gradientlabs-ai/go-openai/internal/test"
+	"github.com/gradientlabs-ai/go-openai/internal/test/checks"
+)
+
+// startModelsTestServer serves the list models endpoint under both the OpenAI
+// and Azure paths and returns its URL.
+func startModelsTestServer(t *testing.T, handler func(http.ResponseWriter, *http.Request)) string {
+	t.Helper()
+	server := test.NewTestServer()
+	server.RegisterHandler("/v1/models", handler)
+	server.RegisterHandler("/openai/models", handler)
+	ts := server.OpenAITestServer()
+	ts.Start()
+	t.Cleanup(ts.Close)
+	return ts.URL
+}
+
+func TestEnvTokenProviderRotation(t *testing.T) {
+	serverURL := startModelsTestServer(t, handleListModelsEndpoint)
+
+	config := openai.DefaultConfigWithTokenProvider(openai.EnvTokenProvider{Name: "TEST_OPENAI_KEY"})
+	config.BaseURL = serverURL + "/v1"
+	client := openai.NewClientWithConfig(config)
+
+	t.Setenv("TEST_OPENAI_KEY", test.GetTestToken())
+	_, err := client.ListModels(context.Background())
+	checks.NoError(t, err, "ListModels error")
+
+	t.Setenv("TEST_OPENAI_KEY", "rotated-away")
+	_, err = client.ListModels(context.Background())
+	checks.HasError(t, err, "the rotated key should be sent")
+
+	t.Setenv("TEST_OPENAI_KEY", "")
+	_, err = client.ListModels(context.Background())
+	checks.ErrorIs(t, err, openai.ErrCredentialNotFound, "empty variable")
+}
+
+func TestTokenProviderAzureAPIKey(t *testing.T) {
+	serverURL := startModelsTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" {
+			http.Error(w, "unexpected bearer token", http.StatusBadRequest)
+			return
+		}
+		handleListModelsEndpoint(w, r)
+	})
+
+	t.Setenv("TEST_AZURE_KEY", test.GetTestToken())
+	config := openai.DefaultAzureConfig("", serverURL)
+	config.TokenProvider = openai.EnvTokenProvider{Name: "TEST_AZURE_KEY"}
+	_, err := openai.NewClientWithConfig(config).ListModels(context.Background())
+	checks.NoError(t, err, "ListModels error")
+}
+
+func TestFileTokenProvider(t *testing.T) {
+	dir, cleanup := test.CreateTestDirectory(t)
+	defer cleanup()
+	path := filepath.Join(dir, "key")
+	provider := openai.NewFileTokenProvider(path)
+
+	_, err := provider.Token(context.Background())
+	checks.ErrorIs(t, err, openai.ErrCredentialNotFound, "missing file")
+
+	checks.NoError(t, os.WriteFile(path, []byte("first-key\n"), 0o600), "WriteFile error")
+	token, err := provider.Token(context.Background())
+	checks.NoError(t, err, "Token error")
+	if token != "first-key" {
+		t.Errorf("unexpected token %q", token)
+	}
+
+	checks.NoError(t, os.WriteFile(path, []byte("second-longer-key\n"), 0o600), "WriteFile error")
+	token, err = provider.Token(context.Background())
+	checks.NoError(t, err, "Token error")
+	if token != "second-longer-key" {
+		t.Errorf("expected the rotated key, got %q", token)
+	}
+}
+
+func TestSecretTokenProvider(t *testing.T) {
+	var fetches int32
+	provider := openai.NewSecretTokenProvider(func(context.Context) (string, error) {
+		atomic.AddInt32(&fetches, 1)
+		return "secret-key", nil
+	}, 0)
+	for i := 0; i < 3; i++ {
+		token, err := provider.Token(context.Background())
+		checks.NoError(t, err, "Token error")
+		if token != "secret-key" {
+			t.Errorf("unexpected token %q", token)
+		}
+	}
+	if n := atomic.LoadInt32(&fetches); n != 1 {
+		t.Errorf("expected one fetch, got %d", n)
+	}
+
+	failing := openai.NewSecretTokenProvider(func(context.Context) (string, error) {
+		return "", errors.New("vault sealed")
+	}, 0)
+	_, err := failing.Token(context.Background())
+	checks.HasError(t, err, "fetch errors should be returned")
+}
+
+func TestKeyPool(t *testing.T) {
+	_, err := openai.NewKeyPool(nil, openai.KeyPoolOptions{})
+	checks.ErrorIs(t, err, openai.ErrKeyPoolEmpty, "empty pool")
+
+	serverURL := startModelsTestServer(t, handleListModelsEndpoint)
+
+	// The test server only accepts the test token; every other key is
+	// rejected with a 401.
+	pool, err := openai.NewKeyPool([]string{test.GetTestToken(), "revoked-key"}, openai.KeyPoolOptions{})
+	checks.NoError(t, err, "NewKeyPool error")
+	config := openai.DefaultConfigWithTokenProvider(pool)
+	config.BaseURL = serverURL + "/v1"
+	client := openai.NewClientWithConfig(config)
+
+	_, err = client.ListModels(context.Background())
+	checks.NoError(t, err, "first key should work")
+	_, err = client.ListModels(context.Background())
+	checks.HasError(t, err, "second key is revoked")
+
+	// The revoked key is quarantined, so every following request uses the
+	// working key.
+	for i := 0; i < 3; i++ {
+		_, err = client.ListModels(context.Background())
+		checks.NoError(t, err, "ListModels error")
+	}
+
+	status := pool.Status()
+	if status[0].Requests != 4 || status[0].LastStatusCode != http.StatusOK || !status[0].AvailableAt.IsZero() {
+		t.Errorf("unexpected status for the working key: %+v", status[0])
+	}
+	if status[1].Requests != 1 || status[1].LastStatusCode != http.StatusUnauthorized || status[1].AvailableAt.IsZero() {
+		t.Errorf("expected the revoked key to be quarantined: %+v", status[1])
+	}
+	if !strings.HasSuffix(status[1].Key, "-key") || strings.Contains(status[1].Key, "revoked") {
+		t.Errorf("expected a masked key, got %q", status[1].Key)
+	}
+}
+
+func TestKeyPoolRateLimits(t *testing.T) {
+	pool, err := openai.NewKeyPool([]string{"key-a", "key-b"}, openai.KeyPoolOptions{})
+	checks.NoError(t, err, "NewKeyPool error")
+	ctx := context.Background()
+
+	// key-a reports an exhausted request budget.
+	exhausted := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}}
+	exhausted.Header.Set("x-ratelimit-limit-requests", "100")
+	exhausted.Header.Set("x-ratelimit-remaining-requests", "0")
+	exhausted.Header.Set("x-ratelimit-reset-requests", "1m")
+	pool.ObserveResponse("key-a", exhausted)
+
+	// key-b gets a 429 with Retry-After.
+	limited := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}
+	limited.Header.Set("Retry-After", "120")
+	pool.ObserveResponse("key-b", limited)
+
+	_, err = pool.Token(ctx)
+	checks.ErrorIs(t, err, openai.ErrKeyPoolExhausted, "every key is benched")
+	if !strings.Contains(err.Error(), "1m0s") {
+		t.Errorf("expected the earliest reset in %q", err)
+	}
+
+	status := pool.Status()
+	if status[0].RateLimit.RemainingRequests != 0 || status[0].RateLimit.LimitRequests != 100 {
+		t.Errorf("expected rate limit headers to be tracked: %+v", status[0])
+	}
+	if !status[1].AvailableAt.After(status[0].AvailableAt) {
+		t.Errorf("expected key-b to be benched longer than key-a: %+v", status)
+	}
+}
@@ -3,9 +3,7 @@ package openai
 import (
 	"context"
 	"encoding/base64"
-	"encoding/binary"
 	"errors"
-	"math"
 	"net/http"
 )
 
@@ -76,6 +74,7 @@ type EmbeddingResponse struct {
 	Usage  Usage          `json:"usage"`
 
 	httpHeader
+	rawJSON
 }
 
 type base64String string
@@ -86,13 +85,7 @@ func (b base64String) Decode() ([]float32, error) {
 		return nil, err
 	}
 
-	const sizeOfFloat32 = 4
-	floats := make([]float32, len(decodedData)/sizeOfFloat32)
-	for i := 0; i < len(floats); i++ {
-		floats[i] = math.Float32frombits(binary.LittleEndian.Uint32(decodedData[i*4 : (i+1)*4]))
-	}
-
-	return floats, nil
+	return decodeEmbeddingVector(decodedData), nil
 }
 
 // Base64Embedding is a container for base64 encoded embeddings.
@@ -110,6 +103,7 @@ type EmbeddingResponseBase64 struct {
 	Usage  Usage             `json:"usage"`
 
 	httpHeader
+	rawJSON
 }
 
 // ToEmbeddingResponse converts an embeddingResponseBase64 to an EmbeddingResponse.
@@ -239,24 +233,30 @@ func (r EmbeddingRequestTokens) Convert() EmbeddingRequest {
 func (c *Client) CreateEmbeddings(
 	ctx context.Context,
 	conv EmbeddingRequestConverter,
+	opts ...RequestOption,
 ) (res EmbeddingResponse, err error) {
 	baseReq := conv.Convert()
-	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL("/embeddings", string(baseReq.Model)), withBody(baseReq))
-	if err != nil {
-		return
-	}
-
-	if baseReq.EncodingFormat != EmbeddingEncodingFormatBase64 {
-		err = c.sendRequest(req, &res)
-		return
-	}
-
-	base64Response := &EmbeddingResponseBase64{}
-	err = c.sendRequest(req, base64Response)
-	if err != nil {
-		return
-	}
-
-	res, err = base64Response.ToEmbeddingResponse()
-	return
+	return callWithPolicies(ctx, c.policies, policyCall[EmbeddingResponse]{
+		endpoint: "/embeddings",
+		model:    string(baseReq.Model),
+		do: func(ctx context.Context) (res EmbeddingResponse, err error) {
+			req, err := c.newRequest(ctx, http.MethodPost, c.fullURL("/embeddings", string(baseReq.Model)),
+				withBody(baseReq), withResponseCache(), withRequestOptions(opts))
+			if err != nil {
+				return
+			}
+
+			if baseReq.EncodingFormat != EmbeddingEncodingFormatBase64 {
+				err = c.sendRequest(req, &res)
+				return
+			}
+
+			base64Response := &EmbeddingResponseBase64{}
+			err = c.sendRequest(req, base64Response)
+			if err != nil {
+				return
+			}
+			return base64Response.ToEmbeddingResponse()
+		},
+	})
 }
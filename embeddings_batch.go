@@ -0,0 +1,256 @@
+package openai
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultEmbeddingMaxInputs is the maximum number of inputs the embeddings
+	// endpoint accepts in a single request.
+	defaultEmbeddingMaxInputs = 2048
+	// defaultEmbeddingMaxRequestTokens is the maximum total number of tokens
+	// summed across all inputs in a single request.
+	defaultEmbeddingMaxRequestTokens = 300000
+	// defaultEmbeddingMaxInputTokens is the maximum length of a single input.
+	defaultEmbeddingMaxInputTokens = 8192
+	defaultEmbeddingConcurrency    = 4
+)
+
+var ErrEmbeddingInputTooLong = errors.New("embedding input exceeds the maximum number of tokens")
+
+// EmbeddingBatchOptions controls how CreateEmbeddingsBatched splits and caches
+// a large embeddings request. Zero values select the limits documented by
+// OpenAI for the embeddings endpoint.
+type EmbeddingBatchOptions struct {
+	// MaxInputsPerRequest caps the number of inputs sent in one request.
+	MaxInputsPerRequest int
+	// MaxTokensPerRequest caps the total number of tokens sent in one request.
+	MaxTokensPerRequest int
+	// MaxTokensPerInput caps the length of a single input. Longer inputs fail
+	// with ErrEmbeddingInputTooLong instead of being sent.
+	MaxTokensPerInput int
+	// Concurrency bounds the number of requests in flight at once.
+	Concurrency int
+	// Tokenizer counts input tokens. Defaults to one token per character,
+	// which overcounts English but never undercounts CJK text or code the way
+	// four characters per token does; set a real tokenizer to pack batches
+	// tighter.
+	Tokenizer Tokenizer
+
+	// Cache, when set, stores embeddings keyed by a hash of the model,
+	// dimensions and input text so unchanged texts are not embedded again.
+	Cache CacheStore
+	// CacheTTL is how long cached embeddings stay valid. Zero means forever.
+	CacheTTL time.Duration
+}
+
+func (o EmbeddingBatchOptions) withDefaults() EmbeddingBatchOptions {
+	if o.MaxInputsPerRequest <= 0 {
+		o.MaxInputsPerRequest = defaultEmbeddingMaxInputs
+	}
+	if o.MaxTokensPerRequest <= 0 {
+		o.MaxTokensPerRequest = defaultEmbeddingMaxRequestTokens
+	}
+	if o.MaxTokensPerInput <= 0 {
+		o.MaxTokensPerInput = defaultEmbeddingMaxInputTokens
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = defaultEmbeddingConcurrency
+	}
+	if o.Tokenizer == nil {
+		o.Tokenizer = ApproximateTokenizer{CharsPerToken: 1}
+	}
+	return o
+}
+
+// embeddingBatch is a contiguous group of inputs sent in one request. Positions
+// holds the index of each input within the caller's original slice.
+type embeddingBatch struct {
+	Inputs    []string
+	Positions []int
+}
+
+// splitEmbeddingBatches groups the inputs at positions into batches that stay
+// within the configured input count and token limits, preserving order.
+func splitEmbeddingBatches(input []string, positions []int, opts EmbeddingBatchOptions) ([]embeddingBatch, error) {
+	var (
+		batches []embeddingBatch
+		current embeddingBatch
+		tokens  int
+	)
+	for _, pos := range positions {
+		n := opts.Tokenizer.CountTokens(input[pos])
+		if n > opts.MaxTokensPerInput || n > opts.MaxTokensPerRequest {
+			return nil, fmt.Errorf("%w: input %d has %d tokens", ErrEmbeddingInputTooLong, pos, n)
+		}
+		if len(current.Inputs) > 0 &&
+			(len(current.Inputs) >= opts.MaxInputsPerRequest || tokens+n > opts.MaxTokensPerRequest) {
+			batches = append(batches, current)
+			current, tokens = embeddingBatch{}, 0
+		}
+		current.Inputs = append(current.Inputs, input[pos])
+		current.Positions = append(current.Positions, pos)
+		tokens += n
+	}
+	if len(current.Inputs) > 0 {
+		batches = append(batches, current)
+	}
+	return batches, nil
+}
+
+// embeddingCacheKey identifies an embedding by the content it was computed
+// from, so identical texts share an entry across requests and processes.
+func embeddingCacheKey(model EmbeddingModel, dimensions int, text string) string {
+	h := sha256.New()
+	h.Write([]byte(model))
+	h.Write([]byte{0})
+	h.Write([]byte(strconv.Itoa(dimensions)))
+	h.Write([]byte{0})
+	h.Write([]byte(text))
+	return "embedding:" + hex.EncodeToString(h.Sum(nil))
+}
+
+// encodeEmbeddingVector serializes a vector using the little-endian float32
+// layout that base64String.Decode reads.
+func encodeEmbeddingVector(v []float32) []byte {
+	const sizeOfFloat32 = 4
+	buf := make([]byte, len(v)*sizeOfFloat32)
+	for i, f := range v {
+		binary.LittleEndian.PutUint32(buf[i*sizeOfFloat32:], math.Float32bits(f))
+	}
+	return buf
+}
+
+func decodeEmbeddingVector(buf []byte) []float32 {
+	const sizeOfFloat32 = 4
+	v := make([]float32, len(buf)/sizeOfFloat32)
+	for i := range v {
+		v[i] = math.Float32frombits(binary.LittleEndian.Uint32(buf[i*sizeOfFloat32:]))
+	}
+	return v
+}
+
+// checkEmbeddingIndexes checks that data holds exactly one embedding for each
+// of the n inputs of a request.
+func checkEmbeddingIndexes(data []Embedding, n int) error {
+	if len(data) != n {
+		return fmt.Errorf("embeddings response has %d items, expected %d", len(data), n)
+	}
+	seen := make([]bool, n)
+	for _, emb := range data {
+		if emb.Index < 0 || emb.Index >= n {
+			return fmt.Errorf("embeddings response has index %d, expected 0 to %d", emb.Index, n-1)
+		}
+		if seen[emb.Index] {
+			return fmt.Errorf("embeddings response has index %d twice", emb.Index)
+		}
+		seen[emb.Index] = true
+	}
+	return nil
+}
+
+// CreateEmbeddingsBatched embeds an arbitrary number of strings. The inputs are
+// split into requests that respect the endpoint's input count and token limits,
+// the requests are sent concurrently, and the results are returned in input
+// order with Embedding.Index set to each input's position in request.Input.
+//
+// When opts.Cache is set, texts that were already embedded with the same model
+// and dimensions are served from the cache and are not sent to the API. Usage
+// only accounts for the texts that were actually sent.
+func (c *Client) CreateEmbeddingsBatched(
+	ctx context.Context,
+	request EmbeddingRequestStrings,
+	opts EmbeddingBatchOptions,
+	reqOpts ...RequestOption,
+) (res EmbeddingResponse, err error) {
+	opts = opts.withDefaults()
+	res.Object = "list"
+	res.Model = request.Model
+	res.Data = make([]Embedding, len(request.Input))
+
+	pending := make([]int, 0, len(request.Input))
+	for i, text := range request.Input {
+		if opts.Cache != nil {
+			key := embeddingCacheKey(request.Model, request.Dimensions, text)
+			if raw, ok, cacheErr := opts.Cache.Get(ctx, key); cacheErr == nil && ok {
+				res.Data[i] = Embedding{Object: "embedding", Embedding: decodeEmbeddingVector(raw), Index: i}
+				continue
+			}
+		}
+		pending = append(pending, i)
+	}
+
+	batches, err := splitEmbeddingBatches(request.Input, pending, opts)
+	if err != nil {
+		return EmbeddingResponse{}, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+		sem      = make(chan struct{}, opts.Concurrency)
+	)
+	for _, batch := range batches {
+		batch := batch
+		sem <- struct{}{}
+		if ctx.Err() != nil {
+			<-sem
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+
+			batchReq := request
+			batchReq.Input = batch.Inputs
+			batchRes, batchErr := c.CreateEmbeddings(ctx, batchReq, reqOpts...)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if batchErr == nil {
+				batchErr = checkEmbeddingIndexes(batchRes.Data, len(batch.Inputs))
+			}
+			if batchErr != nil {
+				if firstErr == nil {
+					firstErr = batchErr
+					cancel()
+				}
+				return
+			}
+			for _, emb := range batchRes.Data {
+				pos := batch.Positions[emb.Index]
+				emb.Index = pos
+				res.Data[pos] = emb
+				if opts.Cache != nil {
+					key := embeddingCacheKey(request.Model, request.Dimensions, request.Input[pos])
+					_ = opts.Cache.Set(ctx, key, encodeEmbeddingVector(emb.Embedding), opts.CacheTTL)
+				}
+			}
+			res.Usage.PromptTokens += batchRes.Usage.PromptTokens
+			res.Usage.TotalTokens += batchRes.Usage.TotalTokens
+			res.httpHeader = batchRes.httpHeader
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return EmbeddingResponse{}, firstErr
+	}
+	if err = ctx.Err(); err != nil {
+		return EmbeddingResponse{}, err
+	}
+	return res, nil
+}
@@ -0,0 +1,189 @@
+package openai_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gradientlabs-ai/go-openai"
+	"github.com/gradientlabs-ai/go-openai/internal/test/checks"
+)
+
+// handleBatchedEmbeddingEndpoint embeds each input as a one-dimensional vector
+// holding the input's length and returns the items in reverse order, so tests
+// can verify that results are reassembled by Embedding.Index.
+func handleBatchedEmbeddingEndpoint(batchSizes *[]int, mu *sync.Mutex) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req openai.EmbeddingRequestStrings
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		*batchSizes = append(*batchSizes, len(req.Input))
+		mu.Unlock()
+
+		res := openai.EmbeddingResponse{Usage: openai.Usage{PromptTokens: len(req.Input), TotalTokens: len(req.Input)}}
+		for i := len(req.Input) - 1; i >= 0; i-- {
+			res.Data = append(res.Data, openai.Embedding{
+				Object:    "embedding",
+				Embedding: []float32{float32(len(req.Input[i]))},
+				Index:     i,
+			})
+		}
+		resBytes, _ := json.Marshal(res)
+		_, _ = w.Write(resBytes)
+	}
+}
+
+func TestCreateEmbeddingsBatched(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+
+	var (
+		mu         sync.Mutex
+		batchSizes []int
+	)
+	server.RegisterHandler("/v1/embeddings", handleBatchedEmbeddingEndpoint(&batchSizes, &mu))
+
+	input := make([]string, 10)
+	for i := range input {
+		input[i] = strings.Repeat("a", i+1)
+	}
+	res, err := client.CreateEmbeddingsBatched(context.Background(), openai.EmbeddingRequestStrings{
+		Input: input,
+		Model: openai.SmallEmbedding3,
+	}, openai.EmbeddingBatchOptions{MaxInputsPerRequest: 3, Concurrency: 2})
+	checks.NoError(t, err, "CreateEmbeddingsBatched error")
+
+	if len(batchSizes) != 4 {
+		t.Fatalf("expected 4 requests, got %v", batchSizes)
+	}
+	if res.Usage.TotalTokens != 10 {
+		t.Errorf("expected usage summed across batches, got %d", res.Usage.TotalTokens)
+	}
+	for i, emb := range res.Data {
+		if emb.Index != i || emb.Embedding[0] != float32(i+1) {
+			t.Errorf("item %d out of order: index %d, embedding %v", i, emb.Index, emb.Embedding)
+		}
+	}
+}
+
+func TestCreateEmbeddingsBatchedTokenLimit(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+
+	var (
+		mu         sync.Mutex
+		batchSizes []int
+	)
+	server.RegisterHandler("/v1/embeddings", handleBatchedEmbeddingEndpoint(&batchSizes, &mu))
+
+	// Each input is 8 characters, i.e. 8 tokens by the default count.
+	input := []string{"aaaaaaaa", "bbbbbbbb", "cccccccc", "dddddddd", "eeeeeeee"}
+	_, err := client.CreateEmbeddingsBatched(context.Background(), openai.EmbeddingRequestStrings{
+		Input: input,
+		Model: openai.SmallEmbedding3,
+	}, openai.EmbeddingBatchOptions{MaxTokensPerRequest: 16, Concurrency: 1})
+	checks.NoError(t, err, "CreateEmbeddingsBatched error")
+	if len(batchSizes) != 3 || batchSizes[0] != 2 || batchSizes[2] != 1 {
+		t.Fatalf("unexpected batch sizes %v", batchSizes)
+	}
+
+	_, err = client.CreateEmbeddingsBatched(context.Background(), openai.EmbeddingRequestStrings{
+		Input: []string{strings.Repeat("a", 100)},
+		Model: openai.SmallEmbedding3,
+	}, openai.EmbeddingBatchOptions{MaxTokensPerInput: 10})
+	checks.ErrorIs(t, err, openai.ErrEmbeddingInputTooLong, "oversized input should be rejected")
+
+	// CJK text is about a token per character, not per four characters.
+	_, err = client.CreateEmbeddingsBatched(context.Background(), openai.EmbeddingRequestStrings{
+		Input: []string{"日本語のテキスト"},
+		Model: openai.SmallEmbedding3,
+	}, openai.EmbeddingBatchOptions{MaxTokensPerInput: 4})
+	checks.ErrorIs(t, err, openai.ErrEmbeddingInputTooLong, "CJK input should not be undercounted")
+}
+
+func TestCreateEmbeddingsBatchedCache(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+
+	var (
+		mu         sync.Mutex
+		batchSizes []int
+	)
+	server.RegisterHandler("/v1/embeddings", handleBatchedEmbeddingEndpoint(&batchSizes, &mu))
+
+	store, err := openai.NewMemoryCacheStore(100)
+	checks.NoError(t, err, "NewMemoryCacheStore error")
+	opts := openai.EmbeddingBatchOptions{Cache: store}
+	ctx := context.Background()
+
+	_, err = client.CreateEmbeddingsBatched(ctx, openai.EmbeddingRequestStrings{
+		Input: []string{"a", "bb"},
+		Model: openai.SmallEmbedding3,
+	}, opts)
+	checks.NoError(t, err, "CreateEmbeddingsBatched error")
+
+	res, err := client.CreateEmbeddingsBatched(ctx, openai.EmbeddingRequestStrings{
+		Input: []string{"bb", "ccc", "a"},
+		Model: openai.SmallEmbedding3,
+	}, opts)
+	checks.NoError(t, err, "CreateEmbeddingsBatched error")
+	if len(batchSizes) != 2 || batchSizes[1] != 1 {
+		t.Fatalf("expected only the new text to be sent, got batches %v", batchSizes)
+	}
+	for i, want := range []float32{2, 3, 1} {
+		if res.Data[i].Embedding[0] != want || res.Data[i].Index != i {
+			t.Errorf("item %d: got %+v, want embedding %v", i, res.Data[i], want)
+		}
+	}
+
+	// A different dimensions setting must not reuse cached vectors.
+	_, err = client.CreateEmbeddingsBatched(ctx, openai.EmbeddingRequestStrings{
+		Input:      []string{"a"},
+		Model:      openai.SmallEmbedding3,
+		Dimensions: 256,
+	}, opts)
+	checks.NoError(t, err, "CreateEmbeddingsBatched error")
+	if len(batchSizes) != 3 {
+		t.Fatalf("expected dimensions to be part of the cache key, got batches %v", batchSizes)
+	}
+}
+
+func TestCreateEmbeddingsBatchedError(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+
+	server.RegisterHandler("/v1/embeddings", func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, `{"error":{"message":"boom","type":"server_error"}}`, http.StatusInternalServerError)
+	})
+	_, err := client.CreateEmbeddingsBatched(context.Background(), openai.EmbeddingRequestStrings{
+		Input: []string{"a", "b", "c"},
+		Model: openai.SmallEmbedding3,
+	}, openai.EmbeddingBatchOptions{MaxInputsPerRequest: 1})
+	checks.HasError(t, err, "expected batch error to be returned")
+}
+
+func TestCreateEmbeddingsBatchedInvalidIndexes(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+
+	for _, data := range []string{
+		`[{"embedding": [1], "index": 0}, {"embedding": [2], "index": 2}]`,
+		`[{"embedding": [1], "index": 1}, {"embedding": [2], "index": 1}]`,
+	} {
+		data := data
+		server.RegisterHandler("/v1/embeddings", func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(`{"object": "list", "data": ` + data + `}`))
+		})
+		_, err := client.CreateEmbeddingsBatched(context.Background(), openai.EmbeddingRequestStrings{
+			Input: []string{"a", "b"},
+			Model: openai.SmallEmbedding3,
+		}, openai.EmbeddingBatchOptions{})
+		checks.HasError(t, err, "a response without an embedding for every input should fail")
+	}
+}
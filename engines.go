@@ -14,6 +14,7 @@ type Engine struct {
 	Ready  bool   `json:"ready"`
 
 	httpHeader
+	rawJSON
 }
 
 // EnginesList is a list of engines.
@@ -21,12 +22,13 @@ type EnginesList struct {
 	Engines []Engine `json:"data"`
 
 	httpHeader
+	rawJSON
 }
 
 // ListEngines Lists the currently available engines, and provides basic
 // information about each option such as the owner and availability.
-func (c *Client) ListEngines(ctx context.Context) (engines EnginesList, err error) {
-	req, err := c.newRequest(ctx, http.MethodGet, c.fullURL("/engines"))
+func (c *Client) ListEngines(ctx context.Context, opts ...RequestOption) (engines EnginesList, err error) {
+	req, err := c.newRequest(ctx, http.MethodGet, c.fullURL("/engines"), withRequestOptions(opts))
 	if err != nil {
 		return
 	}
@@ -40,9 +42,10 @@ func (c *Client) ListEngines(ctx context.Context) (engines EnginesList, err erro
 func (c *Client) GetEngine(
 	ctx context.Context,
 	engineID string,
+	opts ...RequestOption,
 ) (engine Engine, err error) {
 	urlSuffix := fmt.Sprintf("/engines/%s", engineID)
-	req, err := c.newRequest(ctx, http.MethodGet, c.fullURL(urlSuffix))
+	req, err := c.newRequest(ctx, http.MethodGet, c.fullURL(urlSuffix), withRequestOptions(opts))
 	if err != nil {
 		return
 	}
@@ -0,0 +1,224 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Error kinds reported by the API. APIError and RequestError match them with
+// errors.Is, so callers don't need to inspect codes and messages:
+//
+//	if errors.Is(err, openai.ErrContextLengthExceeded) { ... }
+var (
+	ErrContextLengthExceeded  = errors.New("context length exceeded")
+	ErrRateLimited            = errors.New("rate limited")
+	ErrQuotaExceeded          = errors.New("quota exceeded")
+	ErrInvalidAPIKey          = errors.New("invalid API key")
+	ErrModelNotFound          = errors.New("model not found")
+	ErrContentPolicyViolation = errors.New("content policy violation")
+	ErrServerOverloaded       = errors.New("server overloaded")
+)
+
+// statusServiceOverloaded is the non-standard status some providers return
+// when they are over capacity.
+const statusServiceOverloaded = 529
+
+var (
+	maxTokensPattern = regexp.MustCompile(
+		`(?i)(?:maximum context length is|context window of|limit of|maximum of) (\d+) tokens`)
+	requestedTokensPattern = regexp.MustCompile(`(?i)(?:resulted in|requested|contains|input has) (\d+) tokens`)
+	retryInPattern         = regexp.MustCompile(`(?i)try again in (\d+(?:\.\d+)?(?:ms|s|m|h)(?:\d+(?:\.\d+)?(?:ms|s))?)`)
+)
+
+// ErrorDetails holds the structured details that can be extracted from an
+// API error.
+type ErrorDetails struct {
+	// RequestedTokens and MaxTokens are parsed from context length errors.
+	// They are zero if the message doesn't state them.
+	RequestedTokens int
+	MaxTokens       int
+	// RetryAfter is how long until the rate limit resets, taken from the
+	// Retry-After and rate limit headers or from the error message, and
+	// ResetAt is when that is. Both are zero if unknown.
+	RetryAfter time.Duration
+	ResetAt    time.Time
+	// ContentFilterCategories lists the Azure content filter categories that
+	// rejected the request.
+	ContentFilterCategories []string
+}
+
+// Is reports whether the error is of the given kind, e.g.
+// ErrContextLengthExceeded.
+func (e *APIError) Is(target error) bool {
+	kind := e.kind()
+	return kind != nil && kind == target
+}
+
+// Is reports whether the status code of the error is of the given kind.
+func (e *RequestError) Is(target error) bool {
+	kind := statusErrorKind(e.HTTPStatusCode)
+	return kind != nil && kind == target
+}
+
+func (e *APIError) kind() error {
+	code := strings.ToLower(e.codeString())
+	errType := strings.ToLower(e.Type)
+	message := strings.ToLower(e.Message)
+	switch {
+	case code == "context_length_exceeded" || isContextLengthMessage(message):
+		return ErrContextLengthExceeded
+	case code == "insufficient_quota" || errType == "insufficient_quota":
+		return ErrQuotaExceeded
+	case code == "rate_limit_exceeded" || code == "429":
+		return ErrRateLimited
+	case code == "invalid_api_key" || code == "401":
+		return ErrInvalidAPIKey
+	case code == "model_not_found" || code == "deploymentnotfound" ||
+		strings.Contains(message, "model") && strings.Contains(message, "does not exist"):
+		return ErrModelNotFound
+	case code == "content_policy_violation" || code == "content_filter" ||
+		e.InnerError != nil && e.InnerError.Code == "ResponsibleAIPolicyViolation":
+		return ErrContentPolicyViolation
+	case code == "server_overloaded" || errType == "overloaded_error" || strings.Contains(message, "overloaded"):
+		return ErrServerOverloaded
+	}
+	return statusErrorKind(e.HTTPStatusCode)
+}
+
+func statusErrorKind(code int) error {
+	switch code {
+	case http.StatusTooManyRequests:
+		return ErrRateLimited
+	case http.StatusUnauthorized:
+		return ErrInvalidAPIKey
+	case http.StatusServiceUnavailable, statusServiceOverloaded:
+		return ErrServerOverloaded
+	}
+	return nil
+}
+
+func isContextLengthMessage(message string) bool {
+	return strings.Contains(message, "context length") || strings.Contains(message, "context window") ||
+		strings.Contains(message, "maximum context")
+}
+
+func (e *APIError) codeString() string {
+	switch code := e.Code.(type) {
+	case nil:
+		return ""
+	case string:
+		return code
+	default:
+		return fmt.Sprint(code)
+	}
+}
+
+// Details extracts the structured details of the error.
+func (e *APIError) Details() ErrorDetails {
+	var details ErrorDetails
+	if e.kind() == ErrContextLengthExceeded {
+		details.MaxTokens = matchInt(maxTokensPattern, e.Message)
+		details.RequestedTokens = matchInt(requestedTokensPattern, e.Message)
+	}
+	details.RetryAfter, details.ResetAt = resetFromHeader(http.Header(e.httpHeader))
+	if details.RetryAfter == 0 {
+		if match := retryInPattern.FindStringSubmatch(e.Message); match != nil {
+			if d, err := time.ParseDuration(match[1]); err == nil {
+				details.RetryAfter, details.ResetAt = d, time.Now().Add(d)
+			}
+		}
+	}
+	if e.InnerError != nil {
+		details.ContentFilterCategories = e.InnerError.ContentFilterResults.FilteredCategories()
+	}
+	return details
+}
+
+// GetErrorDetails extracts the structured details of an APIError or
+// RequestError in err's chain. It reports false for other errors.
+func GetErrorDetails(err error) (ErrorDetails, bool) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Details(), true
+	}
+	var reqErr *RequestError
+	if errors.As(err, &reqErr) {
+		var details ErrorDetails
+		details.RetryAfter, details.ResetAt = resetFromHeader(http.Header(reqErr.httpHeader))
+		return details, true
+	}
+	return ErrorDetails{}, false
+}
+
+// IsRetryable reports whether a request that failed with err may succeed if
+// sent again: rate limiting, overloaded or failing servers, timeouts, stalled
+// streams and connection errors. Exhausted quotas and context length errors
+// are not retryable, and neither are requests canceled through their
+// context, since the caller gave up on them. An exceeded context deadline is
+// a timeout and is retryable: a per-call WithTimeout gives the next attempt
+// a fresh deadline, while a retry loop should stop once its own context is
+// done.
+func IsRetryable(err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, context.Canceled):
+		return false
+	case errors.Is(err, context.DeadlineExceeded):
+		return true
+	case errors.Is(err, ErrQuotaExceeded), errors.Is(err, ErrContextLengthExceeded):
+		return false
+	case errors.Is(err, ErrRateLimited), errors.Is(err, ErrServerOverloaded), errors.Is(err, ErrStreamTimeout):
+		return true
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return isRetryableStatus(apiErr.HTTPStatusCode)
+	}
+	var reqErr *RequestError
+	if errors.As(err, &reqErr) {
+		return isRetryableStatus(reqErr.HTTPStatusCode)
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+func isRetryableStatus(code int) bool {
+	return code == http.StatusRequestTimeout || code == http.StatusConflict || code >= http.StatusInternalServerError
+}
+
+// resetFromHeader returns how long until the rate limit resets according to
+// the retry-after-ms, Retry-After or rate limit reset headers.
+func resetFromHeader(h http.Header) (time.Duration, time.Time) {
+	if h == nil {
+		return 0, time.Time{}
+	}
+	if ms, err := strconv.ParseFloat(h.Get("retry-after-ms"), 64); err == nil && ms >= 0 {
+		d := time.Duration(ms * float64(time.Millisecond))
+		return d, time.Now().Add(d)
+	}
+	if d, ok := retryAfter(h); ok {
+		return d, time.Now().Add(d)
+	}
+	hasRateLimit := h.Get("x-ratelimit-limit-requests") != "" || h.Get("x-ratelimit-limit-tokens") != ""
+	if d, ok := rateLimitReset(newRateLimitHeaders(h), hasRateLimit); ok {
+		return d, time.Now().Add(d)
+	}
+	return 0, time.Time{}
+}
+
+func matchInt(pattern *regexp.Regexp, s string) int {
+	match := pattern.FindStringSubmatch(s)
+	if match == nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(match[1])
+	return n
+}
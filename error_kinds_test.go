@@ -0,0 +1,205 @@
+package openai_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gradientlabs-ai/go-openai"
+	"github.com/gradientlabs-ai/go-openai/internal/test/checks"
+)
+
+func TestAPIErrorKinds(t *testing.T) {
+	testCases := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{
+			name: "context length code",
+			err:  &openai.APIError{Code: "context_length_exceeded", HTTPStatusCode: http.StatusBadRequest},
+			want: openai.ErrContextLengthExceeded,
+		},
+		{
+			name: "context length message",
+			err:  &openai.APIError{Message: "This model's maximum context length is 8192 tokens."},
+			want: openai.ErrContextLengthExceeded,
+		},
+		{
+			name: "quota",
+			err:  &openai.APIError{Type: "insufficient_quota", HTTPStatusCode: http.StatusTooManyRequests},
+			want: openai.ErrQuotaExceeded,
+		},
+		{
+			name: "rate limit",
+			err:  &openai.APIError{Code: "rate_limit_exceeded", HTTPStatusCode: http.StatusTooManyRequests},
+			want: openai.ErrRateLimited,
+		},
+		{
+			name: "azure numeric rate limit code",
+			err:  &openai.APIError{Code: 429},
+			want: openai.ErrRateLimited,
+		},
+		{
+			name: "invalid key",
+			err:  &openai.APIError{Code: "invalid_api_key", HTTPStatusCode: http.StatusUnauthorized},
+			want: openai.ErrInvalidAPIKey,
+		},
+		{
+			name: "model not found",
+			err:  &openai.APIError{Message: "The model `gpt-9` does not exist", HTTPStatusCode: http.StatusNotFound},
+			want: openai.ErrModelNotFound,
+		},
+		{
+			name: "azure deployment not found",
+			err:  &openai.APIError{Code: "DeploymentNotFound", HTTPStatusCode: http.StatusNotFound},
+			want: openai.ErrModelNotFound,
+		},
+		{
+			name: "azure content filter",
+			err: &openai.APIError{
+				Code:       "content_filter",
+				InnerError: &openai.InnerError{Code: "ResponsibleAIPolicyViolation"},
+			},
+			want: openai.ErrContentPolicyViolation,
+		},
+		{
+			name: "overloaded",
+			err:  &openai.APIError{Message: "The server is overloaded", HTTPStatusCode: http.StatusServiceUnavailable},
+			want: openai.ErrServerOverloaded,
+		},
+		{
+			name: "request error status",
+			err:  &openai.RequestError{HTTPStatusCode: http.StatusTooManyRequests, Err: errors.New("slow down")},
+			want: openai.ErrRateLimited,
+		},
+		{
+			name: "wrapped",
+			err:  fmt.Errorf("calling backend: %w", &openai.APIError{HTTPStatusCode: http.StatusUnauthorized}),
+			want: openai.ErrInvalidAPIKey,
+		},
+		{
+			name: "unclassified",
+			err:  &openai.APIError{Message: "bad request", HTTPStatusCode: http.StatusBadRequest},
+		},
+	}
+	kinds := []error{
+		openai.ErrContextLengthExceeded, openai.ErrRateLimited, openai.ErrQuotaExceeded, openai.ErrInvalidAPIKey,
+		openai.ErrModelNotFound, openai.ErrContentPolicyViolation, openai.ErrServerOverloaded,
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, kind := range kinds {
+				if got := errors.Is(tc.err, kind); got != (kind == tc.want) {
+					t.Errorf("errors.Is(err, %v) = %v", kind, got)
+				}
+			}
+		})
+	}
+}
+
+func TestAPIErrorDetails(t *testing.T) {
+	apiErr := &openai.APIError{
+		Code: "context_length_exceeded",
+		Message: "This model's maximum context length is 8192 tokens. However, you requested 9000 tokens " +
+			"(5000 in the messages, 4000 in the completion).",
+	}
+	details := apiErr.Details()
+	if details.MaxTokens != 8192 || details.RequestedTokens != 9000 {
+		t.Errorf("unexpected token counts %+v", details)
+	}
+
+	apiErr = &openai.APIError{
+		Code:    "rate_limit_exceeded",
+		Message: "Rate limit reached for gpt-4o. Please try again in 1.5s.",
+	}
+	details = apiErr.Details()
+	if details.RetryAfter != 1500*time.Millisecond || details.ResetAt.IsZero() {
+		t.Errorf("unexpected reset %+v", details)
+	}
+
+	var azureErr openai.APIError
+	err := azureErr.UnmarshalJSON([]byte(`{"message":"filtered","code":"content_filter","innererror":{
+		"code":"ResponsibleAIPolicyViolation",
+		"content_filter_result":{"hate":{"filtered":true,"severity":"high"},"jailbreak":{"filtered":true,"detected":true}}
+	}}`))
+	checks.NoError(t, err, "UnmarshalJSON error")
+	categories := azureErr.Details().ContentFilterCategories
+	if !reflect.DeepEqual(categories, []string{"hate", "jailbreak"}) {
+		t.Errorf("unexpected content filter categories %v", categories)
+	}
+
+	if _, ok := openai.GetErrorDetails(errors.New("other")); ok {
+		t.Error("expected no details for a plain error")
+	}
+}
+
+func TestErrorKindsFromServer(t *testing.T) {
+	client := startRequestOptionsServer(t, "/v1/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "20")
+		w.WriteHeader(http.StatusTooManyRequests)
+		fmt.Fprint(w, `{"error":{"message":"Rate limit reached","type":"requests","code":"rate_limit_exceeded"}}`)
+	})
+	_, err := client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{
+		Model:    openai.GPT4oMini,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "hi"}},
+	})
+	checks.ErrorIs(t, err, openai.ErrRateLimited, "expected a rate limit error")
+	if !openai.IsRetryable(err) {
+		t.Error("a rate limit error should be retryable")
+	}
+	details, ok := openai.GetErrorDetails(err)
+	if !ok || details.RetryAfter != 20*time.Second {
+		t.Errorf("unexpected details %+v", details)
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	testCases := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{&openai.APIError{HTTPStatusCode: http.StatusBadGateway}, true},
+		{&openai.APIError{HTTPStatusCode: http.StatusTooManyRequests, Code: "insufficient_quota"}, false},
+		{&openai.APIError{HTTPStatusCode: http.StatusBadRequest, Code: "context_length_exceeded"}, false},
+		{&openai.APIError{HTTPStatusCode: http.StatusBadRequest}, false},
+		{&openai.RequestError{HTTPStatusCode: http.StatusRequestTimeout}, true},
+		{&url.Error{Op: "Get", URL: "https://api.openai.com/v1/models", Err: context.Canceled}, false},
+		{&url.Error{Op: "Get", URL: "https://api.openai.com/v1/models", Err: context.DeadlineExceeded}, true},
+		{errors.New("other"), false},
+	}
+	for _, tc := range testCases {
+		if got := openai.IsRetryable(tc.err); got != tc.want {
+			t.Errorf("IsRetryable(%v) = %v, want %v", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestIsRetryableContextErrors(t *testing.T) {
+	client := startRequestOptionsServer(t, "/v1/models", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := client.ListModels(ctx)
+	checks.ErrorIs(t, err, context.Canceled, "expected the cancellation")
+	if openai.IsRetryable(err) {
+		t.Errorf("a request canceled by its caller should not be retryable: %v", err)
+	}
+
+	_, err = client.ListModels(context.Background(), openai.WithTimeout(10*time.Millisecond))
+	checks.ErrorIs(t, err, context.DeadlineExceeded, "expected the timeout")
+	if !openai.IsRetryable(err) {
+		t.Errorf("a request that timed out should be retryable: %v", err)
+	}
+}
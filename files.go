@@ -22,6 +22,8 @@ const (
 	PurposeFineTuneResults  PurposeType = "fine-tune-results"
 	PurposeAssistants       PurposeType = "assistants"
 	PurposeAssistantsOutput PurposeType = "assistants_output"
+	PurposeBatch            PurposeType = "batch"
+	PurposeUserData         PurposeType = "user_data"
 )
 
 // FileBytesRequest represents a file upload request.
@@ -46,6 +48,7 @@ type File struct {
 	StatusDetails string `json:"status_details"`
 
 	httpHeader
+	rawJSON
 }
 
 // FilesList is a list of files that belong to the user or organization.
@@ -53,10 +56,15 @@ type FilesList struct {
 	Files []File `json:"data"`
 
 	httpHeader
+	rawJSON
 }
 
 // CreateFileBytes uploads bytes directly to OpenAI without requiring a local file.
-func (c *Client) CreateFileBytes(ctx context.Context, request FileBytesRequest) (file File, err error) {
+func (c *Client) CreateFileBytes(
+	ctx context.Context,
+	request FileBytesRequest,
+	opts ...RequestOption,
+) (file File, err error) {
 	var b bytes.Buffer
 	reader := bytes.NewReader(request.Bytes)
 	builder := c.createFormBuilder(&b)
@@ -77,7 +85,7 @@ func (c *Client) CreateFileBytes(ctx context.Context, request FileBytesRequest)
 	}
 
 	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL("/files"),
-		withBody(&b), withContentType(builder.FormDataContentType()))
+		withBody(&b), withContentType(builder.FormDataContentType()), withRequestOptions(opts))
 	if err != nil {
 		return
 	}
@@ -88,7 +96,7 @@ func (c *Client) CreateFileBytes(ctx context.Context, request FileBytesRequest)
 
 // CreateFile uploads a jsonl file to GPT3
 // FilePath must be a local file path.
-func (c *Client) CreateFile(ctx context.Context, request FileRequest) (file File, err error) {
+func (c *Client) CreateFile(ctx context.Context, request FileRequest, opts ...RequestOption) (file File, err error) {
 	var b bytes.Buffer
 	builder := c.createFormBuilder(&b)
 
@@ -113,7 +121,7 @@ func (c *Client) CreateFile(ctx context.Context, request FileRequest) (file File
 	}
 
 	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL("/files"),
-		withBody(&b), withContentType(builder.FormDataContentType()))
+		withBody(&b), withContentType(builder.FormDataContentType()), withRequestOptions(opts))
 	if err != nil {
 		return
 	}
@@ -123,8 +131,8 @@ func (c *Client) CreateFile(ctx context.Context, request FileRequest) (file File
 }
 
 // DeleteFile deletes an existing file.
-func (c *Client) DeleteFile(ctx context.Context, fileID string) (err error) {
-	req, err := c.newRequest(ctx, http.MethodDelete, c.fullURL("/files/"+fileID))
+func (c *Client) DeleteFile(ctx context.Context, fileID string, opts ...RequestOption) (err error) {
+	req, err := c.newRequest(ctx, http.MethodDelete, c.fullURL("/files/"+fileID), withRequestOptions(opts))
 	if err != nil {
 		return
 	}
@@ -135,8 +143,8 @@ func (c *Client) DeleteFile(ctx context.Context, fileID string) (err error) {
 
 // ListFiles Lists the currently available files,
 // and provides basic information about each file such as the file name and purpose.
-func (c *Client) ListFiles(ctx context.Context) (files FilesList, err error) {
-	req, err := c.newRequest(ctx, http.MethodGet, c.fullURL("/files"))
+func (c *Client) ListFiles(ctx context.Context, opts ...RequestOption) (files FilesList, err error) {
+	req, err := c.newRequest(ctx, http.MethodGet, c.fullURL("/files"), withRequestOptions(opts))
 	if err != nil {
 		return
 	}
@@ -147,9 +155,9 @@ func (c *Client) ListFiles(ctx context.Context) (files FilesList, err error) {
 
 // GetFile Retrieves a file instance, providing basic information about the file
 // such as the file name and purpose.
-func (c *Client) GetFile(ctx context.Context, fileID string) (file File, err error) {
+func (c *Client) GetFile(ctx context.Context, fileID string, opts ...RequestOption) (file File, err error) {
 	urlSuffix := fmt.Sprintf("/files/%s", fileID)
-	req, err := c.newRequest(ctx, http.MethodGet, c.fullURL(urlSuffix))
+	req, err := c.newRequest(ctx, http.MethodGet, c.fullURL(urlSuffix), withRequestOptions(opts))
 	if err != nil {
 		return
 	}
@@ -158,9 +166,13 @@ func (c *Client) GetFile(ctx context.Context, fileID string) (file File, err err
 	return
 }
 
-func (c *Client) GetFileContent(ctx context.Context, fileID string) (content RawResponse, err error) {
+func (c *Client) GetFileContent(
+	ctx context.Context,
+	fileID string,
+	opts ...RequestOption,
+) (content RawResponse, err error) {
 	urlSuffix := fmt.Sprintf("/files/%s/content", fileID)
-	req, err := c.newRequest(ctx, http.MethodGet, c.fullURL(urlSuffix))
+	req, err := c.newRequest(ctx, http.MethodGet, c.fullURL(urlSuffix), withRequestOptions(opts))
 	if err != nil {
 		return
 	}
@@ -23,6 +23,7 @@ type FineTuningJob struct {
 	TrainedTokens   int             `json:"trained_tokens"`
 
 	httpHeader
+	rawJSON
 }
 
 type Hyperparameters struct {
@@ -43,6 +44,7 @@ type FineTuningJobEventList struct {
 	HasMore bool            `json:"has_more"`
 
 	httpHeader
+	rawJSON
 }
 
 type FineTuningJobEvent struct {
@@ -59,9 +61,10 @@ type FineTuningJobEvent struct {
 func (c *Client) CreateFineTuningJob(
 	ctx context.Context,
 	request FineTuningJobRequest,
+	opts ...RequestOption,
 ) (response FineTuningJob, err error) {
 	urlSuffix := "/fine_tuning/jobs"
-	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL(urlSuffix), withBody(request))
+	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL(urlSuffix), withBody(request), withRequestOptions(opts))
 	if err != nil {
 		return
 	}
@@ -71,8 +74,13 @@ func (c *Client) CreateFineTuningJob(
 }
 
 // CancelFineTuningJob cancel a fine tuning job.
-func (c *Client) CancelFineTuningJob(ctx context.Context, fineTuningJobID string) (response FineTuningJob, err error) {
-	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL("/fine_tuning/jobs/"+fineTuningJobID+"/cancel"))
+func (c *Client) CancelFineTuningJob(
+	ctx context.Context,
+	fineTuningJobID string,
+	opts ...RequestOption,
+) (response FineTuningJob, err error) {
+	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL("/fine_tuning/jobs/"+fineTuningJobID+"/cancel"),
+		withRequestOptions(opts))
 	if err != nil {
 		return
 	}
@@ -85,9 +93,10 @@ func (c *Client) CancelFineTuningJob(ctx context.Context, fineTuningJobID string
 func (c *Client) RetrieveFineTuningJob(
 	ctx context.Context,
 	fineTuningJobID string,
+	opts ...RequestOption,
 ) (response FineTuningJob, err error) {
 	urlSuffix := fmt.Sprintf("/fine_tuning/jobs/%s", fineTuningJobID)
-	req, err := c.newRequest(ctx, http.MethodGet, c.fullURL(urlSuffix))
+	req, err := c.newRequest(ctx, http.MethodGet, c.fullURL(urlSuffix), withRequestOptions(opts))
 	if err != nil {
 		return
 	}
@@ -99,6 +108,8 @@ func (c *Client) RetrieveFineTuningJob(
 type listFineTuningJobEventsParameters struct {
 	after *string
 	limit *int
+
+	requestOptions []RequestOption
 }
 
 type ListFineTuningJobEventsParameter func(*listFineTuningJobEventsParameters)
@@ -115,6 +126,14 @@ func ListFineTuningJobEventsWithLimit(limit int) ListFineTuningJobEventsParamete
 	}
 }
 
+// ListFineTuningJobEventsWithRequestOptions passes per-call request options,
+// since ListFineTuningJobEvents already takes variadic parameters.
+func ListFineTuningJobEventsWithRequestOptions(opts ...RequestOption) ListFineTuningJobEventsParameter {
+	return func(args *listFineTuningJobEventsParameters) {
+		args.requestOptions = append(args.requestOptions, opts...)
+	}
+}
+
 // ListFineTuningJobs list fine tuning jobs events.
 func (c *Client) ListFineTuningJobEvents(
 	ctx context.Context,
@@ -147,6 +166,7 @@ func (c *Client) ListFineTuningJobEvents(
 		ctx,
 		http.MethodGet,
 		c.fullURL("/fine_tuning/jobs/"+fineTuningJobID+"/events"+encodedValues),
+		withRequestOptions(parameters.requestOptions),
 	)
 	if err != nil {
 		return
@@ -56,6 +56,7 @@ type ImageResponse struct {
 	Data    []ImageResponseDataInner `json:"data,omitempty"`
 
 	httpHeader
+	rawJSON
 }
 
 // ImageResponseDataInner represents a response data structure for image API.
@@ -63,12 +64,21 @@ type ImageResponseDataInner struct {
 	URL           string `json:"url,omitempty"`
 	B64JSON       string `json:"b64_json,omitempty"`
 	RevisedPrompt string `json:"revised_prompt,omitempty"`
+	// ContentFilterResults and PromptFilterResults are only returned by Azure
+	// OpenAI.
+	ContentFilterResults *ContentFilterResults `json:"content_filter_results,omitempty"`
+	PromptFilterResults  *ContentFilterResults `json:"prompt_filter_results,omitempty"`
 }
 
 // CreateImage - API call to create an image. This is the main endpoint of the DALL-E API.
-func (c *Client) CreateImage(ctx context.Context, request ImageRequest) (response ImageResponse, err error) {
+func (c *Client) CreateImage(
+	ctx context.Context,
+	request ImageRequest,
+	opts ...RequestOption,
+) (response ImageResponse, err error) {
 	urlSuffix := "/images/generations"
-	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL(urlSuffix, request.Model), withBody(request))
+	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL(urlSuffix, request.Model), withBody(request),
+		withRequestOptions(opts))
 	if err != nil {
 		return
 	}
@@ -89,7 +99,11 @@ type ImageEditRequest struct {
 }
 
 // CreateEditImage - API call to create an image. This is the main endpoint of the DALL-E API.
-func (c *Client) CreateEditImage(ctx context.Context, request ImageEditRequest) (response ImageResponse, err error) {
+func (c *Client) CreateEditImage(
+	ctx context.Context,
+	request ImageEditRequest,
+	opts ...RequestOption,
+) (response ImageResponse, err error) {
 	body := &bytes.Buffer{}
 	builder := c.createFormBuilder(body)
 
@@ -133,7 +147,7 @@ func (c *Client) CreateEditImage(ctx context.Context, request ImageEditRequest)
 	}
 
 	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL("/images/edits", request.Model),
-		withBody(body), withContentType(builder.FormDataContentType()))
+		withBody(body), withContentType(builder.FormDataContentType()), withRequestOptions(opts))
 	if err != nil {
 		return
 	}
@@ -153,7 +167,11 @@ type ImageVariRequest struct {
 
 // CreateVariImage - API call to create an image variation. This is the main endpoint of the DALL-E API.
 // Use abbreviations(vari for variation) because ci-lint has a single-line length limit ...
-func (c *Client) CreateVariImage(ctx context.Context, request ImageVariRequest) (response ImageResponse, err error) {
+func (c *Client) CreateVariImage(
+	ctx context.Context,
+	request ImageVariRequest,
+	opts ...RequestOption,
+) (response ImageResponse, err error) {
 	body := &bytes.Buffer{}
 	builder := c.createFormBuilder(body)
 
@@ -184,7 +202,7 @@ func (c *Client) CreateVariImage(ctx context.Context, request ImageVariRequest)
 	}
 
 	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL("/images/variations", request.Model),
-		withBody(body), withContentType(builder.FormDataContentType()))
+		withBody(body), withContentType(builder.FormDataContentType()), withRequestOptions(opts))
 	if err != nil {
 		return
 	}
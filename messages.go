@@ -24,6 +24,7 @@ type Message struct {
 	Metadata    map[string]any   `json:"metadata"`
 
 	httpHeader
+	rawJSON
 }
 
 type MessagesList struct {
@@ -35,6 +36,7 @@ type MessagesList struct {
 	HasMore bool    `json:"has_more"`
 
 	httpHeader
+	rawJSON
 }
 
 type MessageContent struct {
@@ -65,19 +67,26 @@ type MessageFile struct {
 	MessageID string `json:"message_id"`
 
 	httpHeader
+	rawJSON
 }
 
 type MessageFilesList struct {
 	MessageFiles []MessageFile `json:"data"`
 
 	httpHeader
+	rawJSON
 }
 
 // CreateMessage creates a new message.
-func (c *Client) CreateMessage(ctx context.Context, threadID string, request MessageRequest) (msg Message, err error) {
+func (c *Client) CreateMessage(
+	ctx context.Context,
+	threadID string,
+	request MessageRequest,
+	opts ...RequestOption,
+) (msg Message, err error) {
 	urlSuffix := fmt.Sprintf("/threads/%s/%s", threadID, messagesSuffix)
 	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL(urlSuffix), withBody(request),
-		withBetaAssistantVersion(c.config.AssistantVersion))
+		withBetaAssistantVersion(c.config.AssistantVersion), withRequestOptions(opts))
 	if err != nil {
 		return
 	}
@@ -92,6 +101,7 @@ func (c *Client) ListMessage(ctx context.Context, threadID string,
 	order *string,
 	after *string,
 	before *string,
+	opts ...RequestOption,
 ) (messages MessagesList, err error) {
 	urlValues := url.Values{}
 	if limit != nil {
@@ -112,7 +122,8 @@ func (c *Client) ListMessage(ctx context.Context, threadID string,
 	}
 
 	urlSuffix := fmt.Sprintf("/threads/%s/%s%s", threadID, messagesSuffix, encodedValues)
-	req, err := c.newRequest(ctx, http.MethodGet, c.fullURL(urlSuffix), withBetaAssistantVersion(c.config.AssistantVersion))
+	req, err := c.newRequest(ctx, http.MethodGet, c.fullURL(urlSuffix),
+		withBetaAssistantVersion(c.config.AssistantVersion), withRequestOptions(opts))
 	if err != nil {
 		return
 	}
@@ -125,9 +136,11 @@ func (c *Client) ListMessage(ctx context.Context, threadID string,
 func (c *Client) RetrieveMessage(
 	ctx context.Context,
 	threadID, messageID string,
+	opts ...RequestOption,
 ) (msg Message, err error) {
 	urlSuffix := fmt.Sprintf("/threads/%s/%s/%s", threadID, messagesSuffix, messageID)
-	req, err := c.newRequest(ctx, http.MethodGet, c.fullURL(urlSuffix), withBetaAssistantVersion(c.config.AssistantVersion))
+	req, err := c.newRequest(ctx, http.MethodGet, c.fullURL(urlSuffix),
+		withBetaAssistantVersion(c.config.AssistantVersion), withRequestOptions(opts))
 	if err != nil {
 		return
 	}
@@ -141,10 +154,12 @@ func (c *Client) ModifyMessage(
 	ctx context.Context,
 	threadID, messageID string,
 	metadata map[string]string,
+	opts ...RequestOption,
 ) (msg Message, err error) {
 	urlSuffix := fmt.Sprintf("/threads/%s/%s/%s", threadID, messagesSuffix, messageID)
 	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL(urlSuffix),
-		withBody(map[string]any{"metadata": metadata}), withBetaAssistantVersion(c.config.AssistantVersion))
+		withBody(map[string]any{"metadata": metadata}), withBetaAssistantVersion(c.config.AssistantVersion),
+		withRequestOptions(opts))
 	if err != nil {
 		return
 	}
@@ -157,9 +172,11 @@ func (c *Client) ModifyMessage(
 func (c *Client) RetrieveMessageFile(
 	ctx context.Context,
 	threadID, messageID, fileID string,
+	opts ...RequestOption,
 ) (file MessageFile, err error) {
 	urlSuffix := fmt.Sprintf("/threads/%s/%s/%s/files/%s", threadID, messagesSuffix, messageID, fileID)
-	req, err := c.newRequest(ctx, http.MethodGet, c.fullURL(urlSuffix), withBetaAssistantVersion(c.config.AssistantVersion))
+	req, err := c.newRequest(ctx, http.MethodGet, c.fullURL(urlSuffix),
+		withBetaAssistantVersion(c.config.AssistantVersion), withRequestOptions(opts))
 	if err != nil {
 		return
 	}
@@ -172,9 +189,11 @@ func (c *Client) RetrieveMessageFile(
 func (c *Client) ListMessageFiles(
 	ctx context.Context,
 	threadID, messageID string,
+	opts ...RequestOption,
 ) (files MessageFilesList, err error) {
 	urlSuffix := fmt.Sprintf("/threads/%s/%s/%s/files", threadID, messagesSuffix, messageID)
-	req, err := c.newRequest(ctx, http.MethodGet, c.fullURL(urlSuffix), withBetaAssistantVersion(c.config.AssistantVersion))
+	req, err := c.newRequest(ctx, http.MethodGet, c.fullURL(urlSuffix),
+		withBetaAssistantVersion(c.config.AssistantVersion), withRequestOptions(opts))
 	if err != nil {
 		return
 	}
@@ -17,6 +17,7 @@ type Model struct {
 	Parent     string       `json:"parent"`
 
 	httpHeader
+	rawJSON
 }
 
 // Permission struct represents an OpenAPI permission.
@@ -42,6 +43,7 @@ type FineTuneModelDeleteResponse struct {
 	Deleted bool   `json:"deleted"`
 
 	httpHeader
+	rawJSON
 }
 
 // ModelsList is a list of models, including those that belong to the user or organization.
@@ -49,12 +51,13 @@ type ModelsList struct {
 	Models []Model `json:"data"`
 
 	httpHeader
+	rawJSON
 }
 
 // ListModels Lists the currently available models,
 // and provides basic information about each model such as the model id and parent.
-func (c *Client) ListModels(ctx context.Context) (models ModelsList, err error) {
-	req, err := c.newRequest(ctx, http.MethodGet, c.fullURL("/models"))
+func (c *Client) ListModels(ctx context.Context, opts ...RequestOption) (models ModelsList, err error) {
+	req, err := c.newRequest(ctx, http.MethodGet, c.fullURL("/models"), withRequestOptions(opts))
 	if err != nil {
 		return
 	}
@@ -65,9 +68,9 @@ func (c *Client) ListModels(ctx context.Context) (models ModelsList, err error)
 
 // GetModel Retrieves a model instance, providing basic information about
 // the model such as the owner and permissioning.
-func (c *Client) GetModel(ctx context.Context, modelID string) (model Model, err error) {
+func (c *Client) GetModel(ctx context.Context, modelID string, opts ...RequestOption) (model Model, err error) {
 	urlSuffix := fmt.Sprintf("/models/%s", modelID)
-	req, err := c.newRequest(ctx, http.MethodGet, c.fullURL(urlSuffix))
+	req, err := c.newRequest(ctx, http.MethodGet, c.fullURL(urlSuffix), withRequestOptions(opts))
 	if err != nil {
 		return
 	}
@@ -78,9 +81,9 @@ func (c *Client) GetModel(ctx context.Context, modelID string) (model Model, err
 
 // DeleteFineTuneModel Deletes a fine-tune model. You must have the Owner
 // role in your organization to delete a model.
-func (c *Client) DeleteFineTuneModel(ctx context.Context, modelID string) (
+func (c *Client) DeleteFineTuneModel(ctx context.Context, modelID string, opts ...RequestOption) (
 	response FineTuneModelDeleteResponse, err error) {
-	req, err := c.newRequest(ctx, http.MethodDelete, c.fullURL("/models/"+modelID))
+	req, err := c.newRequest(ctx, http.MethodDelete, c.fullURL("/models/"+modelID), withRequestOptions(opts))
 	if err != nil {
 		return
 	}
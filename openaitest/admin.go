@@ -0,0 +1,148 @@
+package openaitest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/gradientlabs-ai/go-openai"
+)
+
+// adminPrefix is where the fake is scripted over HTTP, for example by a test
+// written in another language against cmd/openai-mock. The admin endpoints
+// don't need the API key and are not recorded:
+//
+//	POST /_mock/rules     a JSON array of rules, as read by LoadRules
+//	POST /_mock/failures  a JSON array of failures
+//	GET  /_mock/requests  the recorded requests
+//	POST /_mock/reset     forget everything, as Reset does
+const adminPrefix = "/_mock"
+
+type jsonRule struct {
+	Model      string            `json:"model,omitempty"`
+	Prompt     string            `json:"prompt,omitempty"`
+	Reply      string            `json:"reply,omitempty"`
+	ToolCalls  []openai.ToolCall `json:"tool_calls,omitempty"`
+	ChunkSize  int               `json:"chunk_size,omitempty"`
+	ChunkDelay string            `json:"chunk_delay,omitempty"`
+	Times      int               `json:"times,omitempty"`
+}
+
+type jsonFailure struct {
+	Kind        FailureKind `json:"kind"`
+	Path        string      `json:"path,omitempty"`
+	Model       string      `json:"model,omitempty"`
+	Times       int         `json:"times,omitempty"`
+	RetryAfter  string      `json:"retry_after,omitempty"`
+	AfterChunks int         `json:"after_chunks,omitempty"`
+}
+
+type jsonRequest struct {
+	Method string          `json:"method"`
+	Path   string          `json:"path"`
+	Model  string          `json:"model,omitempty"`
+	Stream bool            `json:"stream,omitempty"`
+	Body   json.RawMessage `json:"body,omitempty"`
+}
+
+// LoadRules reads a JSON array of rules. Field names are snake case and
+// chunk_delay is a duration such as "20ms":
+//
+//	[{"model": "gpt-4o", "prompt": "weather", "reply": "Sunny.", "chunk_delay": "20ms"}]
+func LoadRules(r io.Reader) ([]Rule, error) {
+	var decoded []jsonRule
+	if err := json.NewDecoder(r).Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("openaitest: decoding rules: %w", err)
+	}
+	rules := make([]Rule, len(decoded))
+	for i, d := range decoded {
+		if _, err := regexp.Compile(d.Prompt); err != nil {
+			return nil, fmt.Errorf("openaitest: rule %d: %w", i, err)
+		}
+		rules[i] = Rule{
+			Model:     d.Model,
+			Prompt:    d.Prompt,
+			Reply:     d.Reply,
+			ToolCalls: d.ToolCalls,
+			ChunkSize: d.ChunkSize,
+			Times:     d.Times,
+		}
+		if d.ChunkDelay != "" {
+			delay, err := time.ParseDuration(d.ChunkDelay)
+			if err != nil {
+				return nil, fmt.Errorf("openaitest: rule %d: %w", i, err)
+			}
+			rules[i].ChunkDelay = delay
+		}
+	}
+	return rules, nil
+}
+
+func loadFailures(body []byte) ([]Failure, error) {
+	var decoded []jsonFailure
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		return nil, fmt.Errorf("openaitest: decoding failures: %w", err)
+	}
+	failures := make([]Failure, len(decoded))
+	for i, d := range decoded {
+		failures[i] = Failure{
+			Kind:        d.Kind,
+			Path:        d.Path,
+			Model:       d.Model,
+			Times:       d.Times,
+			AfterChunks: d.AfterChunks,
+		}
+		if d.RetryAfter != "" {
+			retryAfter, err := time.ParseDuration(d.RetryAfter)
+			if err != nil {
+				return nil, fmt.Errorf("openaitest: failure %d: %w", i, err)
+			}
+			failures[i].RetryAfter = retryAfter
+		}
+	}
+	return failures, nil
+}
+
+func (f *Fake) serveAdmin(w http.ResponseWriter, r *http.Request, p string, body []byte) {
+	switch {
+	case p == "/rules" && r.Method == http.MethodPost:
+		rules, err := LoadRules(bytes.NewReader(body))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_request_error", "", err.Error())
+			return
+		}
+		for _, rule := range rules {
+			f.On(rule)
+		}
+		writeJSON(w, http.StatusOK, map[string]int{"added": len(rules)})
+	case p == "/failures" && r.Method == http.MethodPost:
+		failures, err := loadFailures(body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_request_error", "", err.Error())
+			return
+		}
+		for _, failure := range failures {
+			f.Fail(failure)
+		}
+		writeJSON(w, http.StatusOK, map[string]int{"added": len(failures)})
+	case p == "/requests" && r.Method == http.MethodGet:
+		requests := f.Requests()
+		list := make([]jsonRequest, len(requests))
+		for i, request := range requests {
+			list[i] = jsonRequest{Method: request.Method, Path: request.Path, Model: request.Model, Stream: request.Stream}
+			if json.Valid(request.Body) {
+				list[i].Body = request.Body
+			}
+		}
+		writeJSON(w, http.StatusOK, list)
+	case p == "/reset" && r.Method == http.MethodPost:
+		f.Reset()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeNotFound(w, adminPrefix+p)
+	}
+}
@@ -0,0 +1,566 @@
+package openaitest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gradientlabs-ai/go-openai"
+)
+
+// state holds the objects created through the API. It is guarded by the
+// fake's mutex.
+type state struct {
+	files      map[string]*storedFile
+	assistants map[string]*openai.Assistant
+	threads    map[string]*openai.Thread
+	messages   map[string][]*openai.Message
+	runs       map[string]*storedRun
+	batches    map[string]*storedBatch
+}
+
+type storedFile struct {
+	file    openai.File
+	content []byte
+}
+
+// storedRun is a run and where it is in its life cycle. Every retrieval
+// moves it one step on: queued, in_progress, then requires_action if the
+// matching rule has tool calls, and completed once tool outputs are in.
+type storedRun struct {
+	run           openai.Run
+	prompt        string
+	toolsAnswered bool
+}
+
+func newState() *state {
+	return &state{
+		files:      make(map[string]*storedFile),
+		assistants: make(map[string]*openai.Assistant),
+		threads:    make(map[string]*openai.Thread),
+		messages:   make(map[string][]*openai.Message),
+		runs:       make(map[string]*storedRun),
+		batches:    make(map[string]*storedBatch),
+	}
+}
+
+// sortedIDs returns the keys of m ordered by creation, newest first unless
+// the request asks for ascending order.
+func sortedIDs[T any](m map[string]T, createdAt func(T) int64, r *http.Request) []string {
+	ids := make([]string, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	ascending := r.URL.Query().Get("order") == "asc"
+	sort.Slice(ids, func(i, j int) bool {
+		a, b := createdAt(m[ids[i]]), createdAt(m[ids[j]])
+		if a == b {
+			a, b = idNumber(ids[i]), idNumber(ids[j])
+		}
+		if ascending {
+			return a < b
+		}
+		return a > b
+	})
+	if limit, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && limit > 0 && limit < len(ids) {
+		ids = ids[:limit]
+	}
+	return ids
+}
+
+// idNumber returns the counter at the end of an ID made by newID.
+func idNumber(id string) int64 {
+	i := len(id)
+	for i > 0 && id[i-1] >= '0' && id[i-1] <= '9' {
+		i--
+	}
+	n, _ := strconv.ParseInt(id[i:], 10, 64)
+	return n
+}
+
+func deleted(id, object string) map[string]any {
+	return map[string]any{"id": id, "object": object, "deleted": true}
+}
+
+func (f *Fake) files(w http.ResponseWriter, r *http.Request, request RecordedRequest, rest []string) {
+	switch {
+	case len(rest) == 0 && r.Method == http.MethodPost:
+		f.uploadFile(w, r, request)
+	case len(rest) == 0 && r.Method == http.MethodGet:
+		f.mu.Lock()
+		ids := sortedIDs(f.state.files, func(s *storedFile) int64 { return s.file.CreatedAt }, r)
+		files := make([]openai.File, len(ids))
+		for i, id := range ids {
+			files[i] = f.state.files[id].file
+		}
+		f.mu.Unlock()
+		writeJSON(w, http.StatusOK, map[string]any{"object": "list", "data": files})
+	case len(rest) >= 1:
+		f.mu.Lock()
+		stored, ok := f.state.files[rest[0]]
+		if ok && r.Method == http.MethodDelete && len(rest) == 1 {
+			delete(f.state.files, rest[0])
+		}
+		f.mu.Unlock()
+		switch {
+		case !ok:
+			writeNotFound(w, request.Path)
+		case len(rest) == 2 && rest[1] == "content" && r.Method == http.MethodGet:
+			w.Header().Set("Content-Type", "application/octet-stream")
+			_, _ = w.Write(stored.content)
+		case len(rest) == 1 && r.Method == http.MethodGet:
+			writeJSON(w, http.StatusOK, stored.file)
+		case len(rest) == 1 && r.Method == http.MethodDelete:
+			writeJSON(w, http.StatusOK, deleted(rest[0], "file"))
+		default:
+			writeNotFound(w, request.Path)
+		}
+	default:
+		writeNotFound(w, request.Path)
+	}
+}
+
+func (f *Fake) uploadFile(w http.ResponseWriter, r *http.Request, request RecordedRequest) {
+	_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "", "expected a multipart form")
+		return
+	}
+	form, err := multipart.NewReader(bytes.NewReader(request.Body), params["boundary"]).ReadForm(32 << 20)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "", err.Error())
+		return
+	}
+	defer form.RemoveAll() //nolint:errcheck // best effort cleanup of temporary files
+	headers := form.File["file"]
+	if len(headers) == 0 {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "", "missing file")
+		return
+	}
+	part, err := headers[0].Open()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "", err.Error())
+		return
+	}
+	defer part.Close()
+	content, err := io.ReadAll(part)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "", err.Error())
+		return
+	}
+
+	file := openai.File{
+		ID:        f.newID("file-"),
+		Object:    "file",
+		Bytes:     len(content),
+		CreatedAt: unixNow(),
+		FileName:  headers[0].Filename,
+		Purpose:   strings.Join(form.Value["purpose"], ""),
+		Status:    "processed",
+	}
+	f.mu.Lock()
+	f.state.files[file.ID] = &storedFile{file: file, content: content}
+	f.mu.Unlock()
+	writeJSON(w, http.StatusOK, file)
+}
+
+func (f *Fake) assistants(w http.ResponseWriter, r *http.Request, request RecordedRequest, rest []string) {
+	if len(rest) == 0 {
+		switch r.Method {
+		case http.MethodPost:
+			var body openai.AssistantRequest
+			if err := request.DecodeJSON(&body); err != nil {
+				writeError(w, http.StatusBadRequest, "invalid_request_error", "", err.Error())
+				return
+			}
+			assistant := &openai.Assistant{ID: f.newID("asst_"), Object: "assistant", CreatedAt: unixNow()}
+			applyAssistantRequest(assistant, request.Body)
+			f.mu.Lock()
+			f.state.assistants[assistant.ID] = assistant
+			f.mu.Unlock()
+			writeJSON(w, http.StatusOK, assistant)
+		case http.MethodGet:
+			f.mu.Lock()
+			ids := sortedIDs(f.state.assistants, func(a *openai.Assistant) int64 { return a.CreatedAt }, r)
+			list := make([]openai.Assistant, len(ids))
+			for i, id := range ids {
+				list[i] = *f.state.assistants[id]
+			}
+			f.mu.Unlock()
+			writeJSON(w, http.StatusOK, map[string]any{"object": "list", "data": list, "has_more": false})
+		default:
+			writeNotFound(w, request.Path)
+		}
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	assistant, ok := f.state.assistants[rest[0]]
+	if !ok || len(rest) > 1 {
+		writeNotFound(w, request.Path)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, assistant)
+	case http.MethodPost:
+		applyAssistantRequest(assistant, request.Body)
+		writeJSON(w, http.StatusOK, assistant)
+	case http.MethodDelete:
+		delete(f.state.assistants, rest[0])
+		writeJSON(w, http.StatusOK, deleted(rest[0], "assistant.deleted"))
+	default:
+		writeNotFound(w, request.Path)
+	}
+}
+
+// applyAssistantRequest sets the fields present in an assistant request body.
+func applyAssistantRequest(assistant *openai.Assistant, body []byte) {
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(body, &fields) != nil {
+		return
+	}
+	update := *assistant
+	_ = json.Unmarshal(body, &update)
+	if _, ok := fields["tools"]; !ok {
+		update.Tools = assistant.Tools
+	}
+	if update.Tools == nil {
+		update.Tools = []openai.AssistantTool{}
+	}
+	*assistant = update
+}
+
+//nolint:gocognit,gocyclo // a flat routing table reads best
+func (f *Fake) threads(w http.ResponseWriter, r *http.Request, request RecordedRequest, rest []string) {
+	switch {
+	case len(rest) == 0 && r.Method == http.MethodPost:
+		var body openai.ThreadRequest
+		if err := request.DecodeJSON(&body); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_request_error", "", err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, f.createThread(body))
+	case len(rest) == 1 && rest[0] == "runs" && r.Method == http.MethodPost:
+		var body openai.CreateThreadAndRunRequest
+		if err := request.DecodeJSON(&body); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_request_error", "", err.Error())
+			return
+		}
+		thread := f.createThread(body.Thread)
+		f.createRun(w, request, thread.ID, body.RunRequest)
+	case len(rest) == 1:
+		f.thread(w, r, request, rest[0])
+	case len(rest) >= 2 && rest[1] == "messages":
+		f.threadMessages(w, r, request, rest[0], rest[2:])
+	case len(rest) == 2 && rest[1] == "runs" && r.Method == http.MethodPost:
+		var body openai.RunRequest
+		if err := request.DecodeJSON(&body); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_request_error", "", err.Error())
+			return
+		}
+		f.createRun(w, request, rest[0], body)
+	case len(rest) == 2 && rest[1] == "runs" && r.Method == http.MethodGet:
+		f.mu.Lock()
+		var runs []openai.Run
+		for _, stored := range f.state.runs {
+			if stored.run.ThreadID == rest[0] {
+				runs = append(runs, stored.run)
+			}
+		}
+		f.mu.Unlock()
+		sort.Slice(runs, func(i, j int) bool { return idNumber(runs[i].ID) > idNumber(runs[j].ID) })
+		writeJSON(w, http.StatusOK, map[string]any{"object": "list", "data": runs})
+	case len(rest) >= 3 && rest[1] == "runs":
+		f.runAction(w, r, request, rest[0], rest[2], rest[3:])
+	default:
+		writeNotFound(w, request.Path)
+	}
+}
+
+func (f *Fake) createThread(body openai.ThreadRequest) *openai.Thread {
+	thread := &openai.Thread{ID: f.newID("thread_"), Object: "thread", CreatedAt: unixNow(), Metadata: body.Metadata}
+	if thread.Metadata == nil {
+		thread.Metadata = map[string]any{}
+	}
+	f.mu.Lock()
+	f.state.threads[thread.ID] = thread
+	f.mu.Unlock()
+	for _, message := range body.Messages {
+		f.addMessage(thread.ID, string(message.Role), message.Content, message.FileIDs, message.Metadata, nil)
+	}
+	return thread
+}
+
+func (f *Fake) thread(w http.ResponseWriter, r *http.Request, request RecordedRequest, id string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	thread, ok := f.state.threads[id]
+	if !ok {
+		writeNotFound(w, request.Path)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, thread)
+	case http.MethodPost:
+		var body openai.ModifyThreadRequest
+		_ = request.DecodeJSON(&body)
+		thread.Metadata = body.Metadata
+		writeJSON(w, http.StatusOK, thread)
+	case http.MethodDelete:
+		delete(f.state.threads, id)
+		delete(f.state.messages, id)
+		writeJSON(w, http.StatusOK, deleted(id, "thread.deleted"))
+	default:
+		writeNotFound(w, request.Path)
+	}
+}
+
+// addMessage appends a message to a thread. It reports false if the thread
+// doesn't exist.
+func (f *Fake) addMessage(
+	threadID, role, content string,
+	fileIDs []string,
+	metadata map[string]any,
+	run *openai.Run,
+) (*openai.Message, bool) {
+	message := &openai.Message{
+		ID:        f.newID("msg_"),
+		Object:    "thread.message",
+		CreatedAt: int(unixNow()),
+		ThreadID:  threadID,
+		Role:      role,
+		Content: []openai.MessageContent{{
+			Type: "text",
+			Text: &openai.MessageText{Value: content, Annotations: []any{}},
+		}},
+		FileIds:  fileIDs,
+		Metadata: metadata,
+	}
+	if message.FileIds == nil {
+		message.FileIds = []string{}
+	}
+	if run != nil {
+		message.AssistantID = &run.AssistantID
+		message.RunID = &run.ID
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.state.threads[threadID]; !ok {
+		return nil, false
+	}
+	f.state.messages[threadID] = append(f.state.messages[threadID], message)
+	return message, true
+}
+
+func (f *Fake) threadMessages(
+	w http.ResponseWriter,
+	r *http.Request,
+	request RecordedRequest,
+	threadID string,
+	rest []string,
+) {
+	switch {
+	case len(rest) == 0 && r.Method == http.MethodPost:
+		var body openai.MessageRequest
+		if err := request.DecodeJSON(&body); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_request_error", "", err.Error())
+			return
+		}
+		message, ok := f.addMessage(threadID, body.Role, body.Content, body.FileIds, body.Metadata, nil)
+		if !ok {
+			writeNotFound(w, request.Path)
+			return
+		}
+		writeJSON(w, http.StatusOK, message)
+	case len(rest) == 0 && r.Method == http.MethodGet:
+		f.mu.Lock()
+		byID := make(map[string]*openai.Message)
+		for _, message := range f.state.messages[threadID] {
+			byID[message.ID] = message
+		}
+		ids := sortedIDs(byID, func(m *openai.Message) int64 { return int64(m.CreatedAt) }, r)
+		messages := make([]openai.Message, len(ids))
+		for i, id := range ids {
+			messages[i] = *byID[id]
+		}
+		f.mu.Unlock()
+		list := openai.MessagesList{Messages: messages, Object: "list"}
+		if len(ids) > 0 {
+			list.FirstID, list.LastID = &ids[0], &ids[len(ids)-1]
+		}
+		writeJSON(w, http.StatusOK, list)
+	case len(rest) == 1 && r.Method == http.MethodGet:
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		for _, message := range f.state.messages[threadID] {
+			if message.ID == rest[0] {
+				writeJSON(w, http.StatusOK, message)
+				return
+			}
+		}
+		writeNotFound(w, request.Path)
+	default:
+		writeNotFound(w, request.Path)
+	}
+}
+
+func (f *Fake) createRun(w http.ResponseWriter, request RecordedRequest, threadID string, body openai.RunRequest) {
+	f.mu.Lock()
+	assistant, ok := f.state.assistants[body.AssistantID]
+	_, threadOK := f.state.threads[threadID]
+	var prompt string
+	messages := f.state.messages[threadID]
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == openai.ChatMessageRoleUser && len(messages[i].Content) > 0 &&
+			messages[i].Content[0].Text != nil {
+			prompt = messages[i].Content[0].Text.Value
+			break
+		}
+	}
+	f.mu.Unlock()
+	if !ok || !threadOK {
+		writeNotFound(w, request.Path)
+		return
+	}
+
+	run := openai.Run{
+		ID:          f.newID("run_"),
+		Object:      "thread.run",
+		CreatedAt:   unixNow(),
+		ThreadID:    threadID,
+		AssistantID: body.AssistantID,
+		Status:      openai.RunStatusQueued,
+		Model:       assistant.Model,
+		Tools:       body.Tools,
+		FileIDS:     []string{},
+		Metadata:    body.Metadata,
+	}
+	if body.Model != "" {
+		run.Model = body.Model
+	}
+	run.Instructions = body.Instructions
+	if run.Instructions == "" && assistant.Instructions != nil {
+		run.Instructions = *assistant.Instructions
+	}
+	if run.Tools == nil {
+		run.Tools = []openai.Tool{}
+		for _, tool := range assistant.Tools {
+			run.Tools = append(run.Tools, openai.Tool{Type: openai.ToolType(tool.Type), Function: tool.Function})
+		}
+	}
+	f.mu.Lock()
+	f.state.runs[run.ID] = &storedRun{run: run, prompt: prompt}
+	f.mu.Unlock()
+	writeJSON(w, http.StatusOK, run)
+}
+
+func (f *Fake) runAction(
+	w http.ResponseWriter,
+	r *http.Request,
+	request RecordedRequest,
+	threadID, runID string,
+	rest []string,
+) {
+	f.mu.Lock()
+	stored, ok := f.state.runs[runID]
+	f.mu.Unlock()
+	if !ok || stored.run.ThreadID != threadID {
+		writeNotFound(w, request.Path)
+		return
+	}
+
+	switch {
+	case len(rest) == 0 && r.Method == http.MethodGet:
+		f.advanceRun(stored)
+	case len(rest) == 1 && rest[0] == "cancel" && r.Method == http.MethodPost:
+		f.mu.Lock()
+		switch stored.run.Status {
+		case openai.RunStatusQueued, openai.RunStatusInProgress, openai.RunStatusRequiresAction:
+			stored.run.Status = openai.RunStatusCancelling
+			stored.run.RequiredAction = nil
+		}
+		f.mu.Unlock()
+	case len(rest) == 1 && rest[0] == "submit_tool_outputs" && r.Method == http.MethodPost:
+		f.mu.Lock()
+		status := stored.run.Status
+		requiresAction := status == openai.RunStatusRequiresAction
+		if requiresAction {
+			stored.run.Status = openai.RunStatusInProgress
+			stored.run.RequiredAction = nil
+			stored.toolsAnswered = true
+		}
+		f.mu.Unlock()
+		if !requiresAction {
+			writeError(w, http.StatusBadRequest, "invalid_request_error", "",
+				"Runs in status \""+string(status)+"\" do not accept tool outputs.")
+			return
+		}
+	default:
+		writeNotFound(w, request.Path)
+		return
+	}
+	f.mu.Lock()
+	run := stored.run
+	f.mu.Unlock()
+	writeJSON(w, http.StatusOK, run)
+}
+
+// advanceRun moves a run one step through its life cycle.
+func (f *Fake) advanceRun(stored *storedRun) {
+	f.mu.Lock()
+	status := stored.run.Status
+	f.mu.Unlock()
+
+	now := unixNow()
+	switch status {
+	case openai.RunStatusQueued:
+		f.mu.Lock()
+		stored.run.Status = openai.RunStatusInProgress
+		stored.run.StartedAt = &now
+		f.mu.Unlock()
+	case openai.RunStatusCancelling:
+		f.mu.Lock()
+		stored.run.Status = openai.RunStatusCancelled
+		stored.run.CancelledAt = &now
+		f.mu.Unlock()
+	case openai.RunStatusInProgress:
+		r := f.match(stored.run.Model, stored.prompt)
+		f.mu.Lock()
+		toolsAnswered := stored.toolsAnswered
+		f.mu.Unlock()
+		if len(r.ToolCalls) > 0 && !toolsAnswered {
+			calls := r.toolCalls(f)
+			f.mu.Lock()
+			stored.run.Status = openai.RunStatusRequiresAction
+			stored.run.RequiredAction = &openai.RunRequiredAction{
+				Type:              openai.RequiredActionTypeSubmitToolOutputs,
+				SubmitToolOutputs: &openai.SubmitToolOutputs{ToolCalls: calls},
+			}
+			f.mu.Unlock()
+			return
+		}
+		tokenizer := openai.ApproximateTokenizer{}
+		f.mu.Lock()
+		run := stored.run
+		f.mu.Unlock()
+		f.addMessage(run.ThreadID, openai.ChatMessageRoleAssistant, r.Reply, nil, map[string]any{}, &run)
+		f.mu.Lock()
+		stored.run.Status = openai.RunStatusCompleted
+		stored.run.CompletedAt = &now
+		stored.run.Usage = openai.Usage{
+			PromptTokens:     tokenizer.CountTokens(stored.prompt),
+			CompletionTokens: tokenizer.CountTokens(r.Reply),
+		}
+		stored.run.Usage.TotalTokens = stored.run.Usage.PromptTokens + stored.run.Usage.CompletionTokens
+		f.mu.Unlock()
+	}
+}
@@ -0,0 +1,220 @@
+package openaitest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/gradientlabs-ai/go-openai"
+)
+
+// storedBatch is a batch and where it is in its life cycle. Like runs,
+// every retrieval moves it one step on: validating, in_progress, then
+// completed, when its requests are answered as the fake answers them
+// directly, rules included.
+type storedBatch struct {
+	batch openai.Batch
+	lines [][]byte
+}
+
+type batchLine struct {
+	CustomID string          `json:"custom_id"`
+	Method   string          `json:"method"`
+	URL      string          `json:"url"`
+	Body     json.RawMessage `json:"body"`
+}
+
+func (f *Fake) batches(w http.ResponseWriter, r *http.Request, request RecordedRequest, rest []string) {
+	switch {
+	case len(rest) == 0 && r.Method == http.MethodPost:
+		f.createBatch(w, request)
+	case len(rest) == 0 && r.Method == http.MethodGet:
+		f.mu.Lock()
+		ids := sortedIDs(f.state.batches, func(b *storedBatch) int64 { return b.batch.CreatedAt }, r)
+		list := make([]openai.Batch, len(ids))
+		for i, id := range ids {
+			list[i] = f.state.batches[id].batch
+		}
+		f.mu.Unlock()
+		writeJSON(w, http.StatusOK, map[string]any{"object": "list", "data": list, "has_more": false})
+	case len(rest) >= 1:
+		f.mu.Lock()
+		stored, ok := f.state.batches[rest[0]]
+		f.mu.Unlock()
+		switch {
+		case !ok:
+			writeNotFound(w, request.Path)
+		case len(rest) == 1 && r.Method == http.MethodGet:
+			f.advanceBatch(stored)
+		case len(rest) == 2 && rest[1] == "cancel" && r.Method == http.MethodPost:
+			f.mu.Lock()
+			if !stored.batch.Done() {
+				now := unixNow()
+				stored.batch.Status = openai.BatchStatusCancelling
+				stored.batch.CancellingAt = &now
+			}
+			f.mu.Unlock()
+		default:
+			writeNotFound(w, request.Path)
+			return
+		}
+		if ok {
+			f.mu.Lock()
+			batch := stored.batch
+			f.mu.Unlock()
+			writeJSON(w, http.StatusOK, batch)
+		}
+	default:
+		writeNotFound(w, request.Path)
+	}
+}
+
+func (f *Fake) createBatch(w http.ResponseWriter, request RecordedRequest) {
+	var body openai.CreateBatchRequest
+	if err := request.DecodeJSON(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "", err.Error())
+		return
+	}
+	f.mu.Lock()
+	input, ok := f.state.files[body.InputFileID]
+	f.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "",
+			"Could not find file "+body.InputFileID+".")
+		return
+	}
+
+	var lines [][]byte
+	scanner := bufio.NewScanner(bytes.NewReader(input.content))
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		if line := bytes.TrimSpace(scanner.Bytes()); len(line) > 0 {
+			lines = append(lines, append([]byte(nil), line...))
+		}
+	}
+	now := unixNow()
+	expires := now + 24*60*60
+	batch := openai.Batch{
+		ID:               f.newID("batch_"),
+		Object:           "batch",
+		Endpoint:         body.Endpoint,
+		InputFileID:      body.InputFileID,
+		CompletionWindow: body.CompletionWindow,
+		Status:           openai.BatchStatusValidating,
+		CreatedAt:        now,
+		ExpiresAt:        &expires,
+		RequestCounts:    openai.BatchRequestCounts{Total: len(lines)},
+		Metadata:         body.Metadata,
+	}
+	f.mu.Lock()
+	f.state.batches[batch.ID] = &storedBatch{batch: batch, lines: lines}
+	f.mu.Unlock()
+	writeJSON(w, http.StatusOK, batch)
+}
+
+// advanceBatch moves a batch one step through its life cycle.
+func (f *Fake) advanceBatch(stored *storedBatch) {
+	f.mu.Lock()
+	status := stored.batch.Status
+	f.mu.Unlock()
+
+	now := unixNow()
+	switch status {
+	case openai.BatchStatusValidating:
+		f.mu.Lock()
+		stored.batch.Status = openai.BatchStatusInProgress
+		stored.batch.InProgressAt = &now
+		f.mu.Unlock()
+	case openai.BatchStatusCancelling:
+		f.mu.Lock()
+		stored.batch.Status = openai.BatchStatusCancelled
+		stored.batch.CancelledAt = &now
+		f.mu.Unlock()
+	case openai.BatchStatusInProgress:
+		var output, errorOutput bytes.Buffer
+		var completed, failed int
+		for _, line := range stored.lines {
+			result, ok := f.runBatchLine(stored.batch.Endpoint, line)
+			if ok {
+				completed++
+				output.Write(result)
+			} else {
+				failed++
+				errorOutput.Write(result)
+			}
+		}
+		outputID := f.storeBatchFile(stored.batch.ID+"_output.jsonl", output.Bytes())
+		var errorID *string
+		if errorOutput.Len() > 0 {
+			id := f.storeBatchFile(stored.batch.ID+"_error.jsonl", errorOutput.Bytes())
+			errorID = &id
+		}
+		f.mu.Lock()
+		stored.batch.Status = openai.BatchStatusCompleted
+		stored.batch.FinalizingAt = &now
+		stored.batch.CompletedAt = &now
+		stored.batch.OutputFileID = &outputID
+		stored.batch.ErrorFileID = errorID
+		stored.batch.RequestCounts.Completed = completed
+		stored.batch.RequestCounts.Failed = failed
+		f.mu.Unlock()
+	}
+}
+
+// runBatchLine answers one request of a batch and returns its output line.
+// It reports false if the request failed.
+func (f *Fake) runBatchLine(endpoint openai.BatchEndpoint, line []byte) ([]byte, bool) {
+	var in batchLine
+	result := map[string]any{"id": f.newID("batch_req_"), "custom_id": nil, "response": nil, "error": nil}
+	if err := json.Unmarshal(line, &in); err != nil {
+		result["error"] = map[string]any{"code": "invalid_json", "message": err.Error()}
+		return encodeLine(result), false
+	}
+	result["custom_id"] = in.CustomID
+	if in.URL != string(endpoint) {
+		result["error"] = map[string]any{
+			"code":    "invalid_url",
+			"message": "The URL " + in.URL + " does not match the batch endpoint " + string(endpoint) + ".",
+		}
+		return encodeLine(result), false
+	}
+
+	r := httptest.NewRequest(in.Method, in.URL, bytes.NewReader(in.Body))
+	r.Header.Set("Content-Type", "application/json")
+	request := newRecordedRequest(r, strings.TrimPrefix(in.URL, "/v1"), in.Body)
+	recorder := httptest.NewRecorder()
+	f.route(recorder, r, request, nil)
+
+	var body any
+	_ = json.Unmarshal(recorder.Body.Bytes(), &body)
+	result["response"] = map[string]any{
+		"status_code": recorder.Code,
+		"request_id":  f.newID("req_"),
+		"body":        body,
+	}
+	return encodeLine(result), recorder.Code < http.StatusBadRequest
+}
+
+func (f *Fake) storeBatchFile(name string, content []byte) string {
+	file := openai.File{
+		ID:        f.newID("file-"),
+		Object:    "file",
+		Bytes:     len(content),
+		CreatedAt: unixNow(),
+		FileName:  name,
+		Purpose:   "batch_output",
+		Status:    "processed",
+	}
+	f.mu.Lock()
+	f.state.files[file.ID] = &storedFile{file: file, content: content}
+	f.mu.Unlock()
+	return file.ID
+}
+
+func encodeLine(v any) []byte {
+	data, _ := json.Marshal(v)
+	return append(data, '\n')
+}
@@ -0,0 +1,403 @@
+package openaitest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gradientlabs-ai/go-openai"
+)
+
+// Rule scripts the answer to chat completions, and to assistant runs, whose
+// model and last user message match. Rules are tried in the order they were
+// added and the first match answers.
+type Rule struct {
+	// Model matches the requested model. Empty matches every model.
+	Model string
+	// Prompt is a regular expression matched against the last user message.
+	// Empty matches every message.
+	Prompt string
+	// Reply is the content of the answer.
+	Reply string
+	// ToolCalls are returned instead of the reply when set. Missing IDs and
+	// types are filled in.
+	ToolCalls []openai.ToolCall
+	// ChunkSize is the number of characters per streamed chunk. Zero streams
+	// word by word.
+	ChunkSize int
+	// ChunkDelay is the pause before each streamed chunk.
+	ChunkDelay time.Duration
+	// Times is how many requests the rule answers. Zero means no limit.
+	Times int
+}
+
+type rule struct {
+	Rule
+	prompt *regexp.Regexp
+}
+
+// On adds a rule. It panics if Prompt is not a valid regular expression.
+func (f *Fake) On(r Rule) {
+	compiled := &rule{Rule: r}
+	if r.Prompt != "" {
+		compiled.prompt = regexp.MustCompile(r.Prompt)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rules = append(f.rules, compiled)
+}
+
+// match returns the rule answering model and prompt.
+func (f *Fake) match(model, prompt string) Rule {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, r := range f.rules {
+		if r.Model != "" && r.Model != model {
+			continue
+		}
+		if r.prompt != nil && !r.prompt.MatchString(prompt) {
+			continue
+		}
+		if r.Times > 0 {
+			r.Times--
+			if r.Times == 0 {
+				f.rules = append(f.rules[:i], f.rules[i+1:]...)
+			}
+		}
+		return r.Rule
+	}
+	return Rule{Reply: f.DefaultReply}
+}
+
+// toolCalls returns the rule's tool calls with IDs and types filled in.
+func (r Rule) toolCalls(f *Fake) []openai.ToolCall {
+	calls := make([]openai.ToolCall, len(r.ToolCalls))
+	for i, call := range r.ToolCalls {
+		if call.ID == "" {
+			call.ID = f.newID("call_")
+		}
+		if call.Type == "" {
+			call.Type = openai.ToolTypeFunction
+		}
+		call.Index = nil
+		calls[i] = call
+	}
+	return calls
+}
+
+func lastUserMessage(messages []openai.ChatCompletionMessage) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role != openai.ChatMessageRoleUser {
+			continue
+		}
+		if messages[i].Content != "" {
+			return messages[i].Content
+		}
+		var text []string
+		for _, part := range messages[i].MultiContent {
+			if part.Type == openai.ChatMessagePartTypeText {
+				text = append(text, part.Text)
+			}
+		}
+		return strings.Join(text, "\n")
+	}
+	return ""
+}
+
+func (f *Fake) chatCompletion(w http.ResponseWriter, request RecordedRequest, failure *Failure) {
+	chatRequest, err := request.ChatCompletionRequest()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "", err.Error())
+		return
+	}
+	r := f.match(chatRequest.Model, lastUserMessage(chatRequest.Messages))
+	calls := r.toolCalls(f)
+	tokenizer := openai.ApproximateTokenizer{}
+	usage := openai.Usage{PromptTokens: openai.CountMessageTokens(tokenizer, chatRequest.Messages)}
+	usage.CompletionTokens = tokenizer.CountTokens(r.Reply)
+	for _, call := range calls {
+		usage.CompletionTokens += tokenizer.CountTokens(call.Function.Name + call.Function.Arguments)
+	}
+	usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+
+	id := f.newID("chatcmpl-")
+	if chatRequest.Stream {
+		includeUsage := chatRequest.StreamOptions != nil && chatRequest.StreamOptions.IncludeUsage
+		f.streamChat(w, id, chatRequest.Model, r, calls, usage, includeUsage, failure)
+		return
+	}
+
+	message := openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant}
+	finishReason := openai.FinishReasonStop
+	if len(calls) > 0 {
+		message.ToolCalls = calls
+		finishReason = openai.FinishReasonToolCalls
+	} else {
+		message.Content = r.Reply
+	}
+	writeJSON(w, http.StatusOK, openai.ChatCompletionResponse{
+		ID:      id,
+		Object:  "chat.completion",
+		Created: unixNow(),
+		Model:   chatRequest.Model,
+		Choices: []openai.ChatCompletionChoice{{Message: message, FinishReason: finishReason}},
+		Usage:   usage,
+	})
+}
+
+//nolint:funlen // one pass over the chunks of a scripted stream
+func (f *Fake) streamChat(
+	w http.ResponseWriter,
+	id, model string,
+	r Rule,
+	calls []openai.ToolCall,
+	usage openai.Usage,
+	includeUsage bool,
+	failure *Failure,
+) {
+	flusher, _ := w.(http.Flusher)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	created := unixNow()
+	type delta = openai.ChatCompletionStreamChoiceDelta
+	chunk := func(d delta, finish openai.FinishReason) openai.ChatCompletionStreamResponse {
+		return openai.ChatCompletionStreamResponse{
+			ID:      id,
+			Object:  "chat.completion.chunk",
+			Created: created,
+			Model:   model,
+			Choices: []openai.ChatCompletionStreamChoice{{Delta: d, FinishReason: finish}},
+		}
+	}
+	chunks := []openai.ChatCompletionStreamResponse{
+		chunk(delta{Role: openai.ChatMessageRoleAssistant}, ""),
+	}
+	finishReason := openai.FinishReasonStop
+	if len(calls) > 0 {
+		finishReason = openai.FinishReasonToolCalls
+		for i, call := range calls {
+			index := i
+			head := call
+			head.Index = &index
+			head.Function.Arguments = ""
+			chunks = append(chunks, chunk(delta{ToolCalls: []openai.ToolCall{head}}, ""))
+			for _, part := range splitText(call.Function.Arguments, r.ChunkSize) {
+				call := openai.ToolCall{Index: &index, Function: openai.FunctionCall{Arguments: part}}
+				chunks = append(chunks, chunk(delta{ToolCalls: []openai.ToolCall{call}}, ""))
+			}
+		}
+	} else {
+		for _, part := range splitText(r.Reply, r.ChunkSize) {
+			chunks = append(chunks, chunk(delta{Content: part}, ""))
+		}
+	}
+	chunks = append(chunks, chunk(delta{}, finishReason))
+	if includeUsage {
+		chunks = append(chunks, openai.ChatCompletionStreamResponse{
+			ID: id, Object: "chat.completion.chunk", Created: created, Model: model,
+			Choices: []openai.ChatCompletionStreamChoice{}, Usage: &usage,
+		})
+	}
+
+	for i, c := range chunks {
+		if failure != nil && i == failure.AfterChunks {
+			writeStreamFailure(w, failure)
+			if flusher != nil {
+				flusher.Flush()
+			}
+			return
+		}
+		if r.ChunkDelay > 0 {
+			time.Sleep(r.ChunkDelay)
+		}
+		data, _ := json.Marshal(c)
+		fmt.Fprintf(w, "data: %s\n\n", data)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+	fmt.Fprint(w, "data: [DONE]\n\n")
+}
+
+func writeStreamFailure(w http.ResponseWriter, failure *Failure) {
+	if failure.Kind == FailMalformedChunk {
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":\n\n")
+		return
+	}
+	fmt.Fprint(w, `data: {"error":{"message":"The server had an error while processing your request.",`+
+		`"type":"server_error","param":null,"code":null}}`+"\n\n")
+}
+
+// splitText splits text into chunks of size characters, or into words
+// keeping their leading spaces if size is zero.
+func splitText(text string, size int) []string {
+	var parts []string
+	if size <= 0 {
+		start := 0
+		for i := 1; i < len(text); i++ {
+			if text[i] == ' ' && text[i-1] != ' ' {
+				parts = append(parts, text[start:i])
+				start = i
+			}
+		}
+		if start < len(text) {
+			parts = append(parts, text[start:])
+		}
+		return parts
+	}
+	for len(text) > 0 {
+		end, count := 0, 0
+		for end < len(text) && count < size {
+			_, n := utf8.DecodeRuneInString(text[end:])
+			end += n
+			count++
+		}
+		parts = append(parts, text[:end])
+		text = text[end:]
+	}
+	return parts
+}
+
+func (f *Fake) embeddings(w http.ResponseWriter, request RecordedRequest) {
+	var embeddingRequest struct {
+		Input          json.RawMessage                `json:"input"`
+		Model          string                         `json:"model"`
+		EncodingFormat openai.EmbeddingEncodingFormat `json:"encoding_format"`
+		Dimensions     int                            `json:"dimensions"`
+	}
+	if err := request.DecodeJSON(&embeddingRequest); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "", err.Error())
+		return
+	}
+	inputs, err := embeddingInputs(embeddingRequest.Input)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "", err.Error())
+		return
+	}
+	dimensions := embeddingRequest.Dimensions
+	if dimensions <= 0 {
+		dimensions = f.EmbeddingDimensions
+	}
+
+	tokenizer := openai.ApproximateTokenizer{}
+	data := make([]map[string]any, len(inputs))
+	var tokens int
+	for i, input := range inputs {
+		vector := fakeEmbedding(input, dimensions)
+		var embedding any = vector
+		if embeddingRequest.EncodingFormat == openai.EmbeddingEncodingFormatBase64 {
+			var buf bytes.Buffer
+			_ = binary.Write(&buf, binary.LittleEndian, vector)
+			embedding = base64.StdEncoding.EncodeToString(buf.Bytes())
+		}
+		data[i] = map[string]any{"object": "embedding", "index": i, "embedding": embedding}
+		tokens += tokenizer.CountTokens(input)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"object": "list",
+		"model":  embeddingRequest.Model,
+		"data":   data,
+		"usage":  openai.Usage{PromptTokens: tokens, TotalTokens: tokens},
+	})
+}
+
+// embeddingInputs returns the inputs of an embedding request, with token
+// arrays rendered as text.
+func embeddingInputs(raw json.RawMessage) ([]string, error) {
+	var single string
+	if json.Unmarshal(raw, &single) == nil {
+		return []string{single}, nil
+	}
+	var many []string
+	if json.Unmarshal(raw, &many) == nil {
+		return many, nil
+	}
+	var tokens []int
+	if json.Unmarshal(raw, &tokens) == nil {
+		return []string{fmt.Sprint(tokens)}, nil
+	}
+	var tokenLists [][]int
+	if err := json.Unmarshal(raw, &tokenLists); err != nil {
+		return nil, fmt.Errorf("unsupported embedding input: %w", err)
+	}
+	inputs := make([]string, len(tokenLists))
+	for i, list := range tokenLists {
+		inputs[i] = fmt.Sprint(list)
+	}
+	return inputs, nil
+}
+
+// fakeEmbedding returns a deterministic unit vector for text.
+func fakeEmbedding(text string, dimensions int) []float32 {
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(text))
+	seed := hash.Sum64()
+	vector := make([]float32, dimensions)
+	var norm float64
+	for i := range vector {
+		// xorshift64
+		seed ^= seed << 13
+		seed ^= seed >> 7
+		seed ^= seed << 17
+		value := float64(seed%2000)/1000 - 1
+		vector[i] = float32(value)
+		norm += value * value
+	}
+	norm = math.Sqrt(norm)
+	if norm > 0 {
+		for i := range vector {
+			vector[i] = float32(float64(vector[i]) / norm)
+		}
+	}
+	return vector
+}
+
+func (f *Fake) models(w http.ResponseWriter, rest []string) {
+	f.mu.Lock()
+	seen := make(map[string]bool)
+	var ids []string
+	for _, id := range f.Models {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	for _, r := range f.rules {
+		if r.Model != "" && !seen[r.Model] {
+			seen[r.Model] = true
+			ids = append(ids, r.Model)
+		}
+	}
+	f.mu.Unlock()
+	sort.Strings(ids)
+
+	model := func(id string) openai.Model {
+		return openai.Model{ID: id, Object: "model", OwnedBy: "openaitest", Root: id, Permission: []openai.Permission{}}
+	}
+	if len(rest) == 1 {
+		if !seen[rest[0]] {
+			writeError(w, http.StatusNotFound, "invalid_request_error", "model_not_found",
+				fmt.Sprintf("The model `%s` does not exist", rest[0]))
+			return
+		}
+		writeJSON(w, http.StatusOK, model(rest[0]))
+		return
+	}
+	models := make([]openai.Model, len(ids))
+	for i, id := range ids {
+		models[i] = model(id)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"object": "list", "data": models})
+}
@@ -0,0 +1,104 @@
+package openaitest
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+	"time"
+)
+
+// FailureKind is a kind of injected failure.
+type FailureKind string
+
+const (
+	// FailRateLimit answers with a 429 and rate limit headers.
+	FailRateLimit FailureKind = "rate_limit"
+	// FailServerError answers with a 500.
+	FailServerError FailureKind = "server_error"
+	// FailMalformedChunk sends a chunk that is not valid JSON in a stream.
+	FailMalformedChunk FailureKind = "malformed_chunk"
+	// FailMidStream sends an error event in a stream.
+	FailMidStream FailureKind = "mid_stream"
+)
+
+// Failure makes matching requests fail. Stream failures only match
+// streaming chat completions.
+type Failure struct {
+	Kind FailureKind
+	// Path matches the request path without the /v1 prefix and may use
+	// path.Match wildcards. Empty matches every path.
+	Path string
+	// Model matches the model of the request. Empty matches every model.
+	Model string
+	// Times is how many requests fail. Zero means one.
+	Times int
+	// RetryAfter is sent with rate limit failures. Zero means one second.
+	RetryAfter time.Duration
+	// AfterChunks is how many chunks a stream failure sends first.
+	AfterChunks int
+}
+
+// Fail injects a failure. Failures are matched in the order they were added.
+func (f *Fake) Fail(failure Failure) {
+	if failure.Times <= 0 {
+		failure.Times = 1
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failures = append(f.failures, &failure)
+}
+
+func (failure *Failure) streams() bool {
+	return failure.Kind == FailMalformedChunk || failure.Kind == FailMidStream
+}
+
+func (f *Fake) takeFailure(request RecordedRequest) *Failure {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, failure := range f.failures {
+		if failure.Path != "" {
+			if ok, _ := path.Match(failure.Path, request.Path); !ok {
+				continue
+			}
+		}
+		if failure.Model != "" && failure.Model != request.Model {
+			continue
+		}
+		if failure.streams() && (!request.Stream || request.Path != "/chat/completions") {
+			continue
+		}
+		failure.Times--
+		if failure.Times == 0 {
+			f.failures = append(f.failures[:i], f.failures[i+1:]...)
+		}
+		return failure
+	}
+	return nil
+}
+
+// writeResponse answers the request if the failure replaces the whole
+// response, and reports whether it did.
+func (failure *Failure) writeResponse(w http.ResponseWriter) bool {
+	switch failure.Kind {
+	case FailRateLimit:
+		retryAfter := failure.RetryAfter
+		if retryAfter <= 0 {
+			retryAfter = time.Second
+		}
+		header := w.Header()
+		header.Set("Retry-After", strconv.Itoa(int(retryAfter.Round(time.Second)/time.Second)))
+		header.Set("retry-after-ms", strconv.FormatInt(retryAfter.Milliseconds(), 10))
+		header.Set("x-ratelimit-limit-requests", "60")
+		header.Set("x-ratelimit-remaining-requests", "0")
+		header.Set("x-ratelimit-reset-requests", retryAfter.String())
+		writeError(w, http.StatusTooManyRequests, "requests", "rate_limit_exceeded",
+			fmt.Sprintf("Rate limit reached. Please try again in %s.", retryAfter))
+		return true
+	case FailServerError:
+		writeError(w, http.StatusInternalServerError, "server_error", "",
+			"The server had an error while processing your request.")
+		return true
+	}
+	return false
+}
@@ -0,0 +1,301 @@
+// Package openaitest provides an in-memory fake of the OpenAI API for
+// integration tests. It answers chat completions, streamed or not, from
+// scripted rules; keeps files, assistants, threads, runs and batches in
+// memory; can inject failures; and records every request for assertions.
+//
+//	server := openaitest.NewServer()
+//	defer server.Close()
+//	server.On(openaitest.Rule{Prompt: "weather", Reply: "Sunny."})
+//	client := server.OpenAIClient()
+//
+// The same fake runs standalone with cmd/openai-mock.
+package openaitest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gradientlabs-ai/go-openai"
+)
+
+// DefaultAPIKey is the API key the clients returned by Server use.
+const DefaultAPIKey = "openaitest-key"
+
+// TestingT is the part of testing.TB the assertions use.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// Fake is an http.Handler that imitates the OpenAI API. Paths are served
+// with or without the /v1 prefix. The zero value is not usable; create one
+// with New.
+type Fake struct {
+	// APIKey, if set, must be sent as a bearer token or api-key header.
+	APIKey string
+	// DefaultReply answers chat completions that match no rule.
+	DefaultReply string
+	// Models are listed by GET /models, together with the models of the rules.
+	Models []string
+	// EmbeddingDimensions is the size of embeddings when the request doesn't
+	// ask for one.
+	EmbeddingDimensions int
+
+	mu           sync.Mutex
+	rules        []*rule
+	failures     []*Failure
+	expectations []func(RecordedRequest) error
+	violations   []string
+	requests     []RecordedRequest
+	nextID       int
+	state        *state
+}
+
+// New creates a fake with no rules.
+func New() *Fake {
+	return &Fake{
+		DefaultReply:        "This is a mock response.",
+		Models:              []string{openai.GPT4o, openai.GPT4oMini, string(openai.SmallEmbedding3)},
+		EmbeddingDimensions: 8,
+		state:               newState(),
+	}
+}
+
+// Server is a Fake served by an httptest.Server.
+type Server struct {
+	*Fake
+	*httptest.Server
+}
+
+// NewServer starts a fake on a local port. Close it when done.
+func NewServer() *Server {
+	fake := New()
+	fake.APIKey = DefaultAPIKey
+	return &Server{Fake: fake, Server: httptest.NewServer(fake)}
+}
+
+// ClientConfig returns a client config that talks to the server.
+func (s *Server) ClientConfig() openai.ClientConfig {
+	config := openai.DefaultConfig(s.APIKey)
+	config.BaseURL = s.URL + "/v1"
+	return config
+}
+
+// OpenAIClient returns a client that talks to the server.
+func (s *Server) OpenAIClient() *openai.Client {
+	return openai.NewClientWithConfig(s.ClientConfig())
+}
+
+// RecordedRequest is a request the fake received.
+type RecordedRequest struct {
+	Method string
+	// Path is the request path without the /v1 prefix.
+	Path   string
+	Header http.Header
+	Body   []byte
+	// Model is the model named in a JSON body, if any.
+	Model string
+	// Stream is set in a JSON body that asks for streaming.
+	Stream bool
+}
+
+// DecodeJSON decodes the body into v.
+func (r RecordedRequest) DecodeJSON(v any) error {
+	return json.Unmarshal(r.Body, v)
+}
+
+// ChatCompletionRequest decodes the body as a chat completion request.
+func (r RecordedRequest) ChatCompletionRequest() (openai.ChatCompletionRequest, error) {
+	var request openai.ChatCompletionRequest
+	err := r.DecodeJSON(&request)
+	return request, err
+}
+
+// Requests returns every request received so far, oldest first.
+func (f *Fake) Requests() []RecordedRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]RecordedRequest(nil), f.requests...)
+}
+
+// RequestsTo returns the requests whose path matches pattern, which may use
+// path.Match wildcards such as "/threads/*/runs".
+func (f *Fake) RequestsTo(pattern string) []RecordedRequest {
+	var matched []RecordedRequest
+	for _, r := range f.Requests() {
+		if ok, _ := path.Match(pattern, r.Path); ok {
+			matched = append(matched, r)
+		}
+	}
+	return matched
+}
+
+// Expect registers a check run on every request. A request that fails it
+// gets a 400, and the failure is reported by AssertExpectations.
+func (f *Fake) Expect(check func(RecordedRequest) error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.expectations = append(f.expectations, check)
+}
+
+// AssertExpectations reports every request that failed an Expect check.
+func (f *Fake) AssertExpectations(t TestingT) {
+	t.Helper()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, violation := range f.violations {
+		t.Errorf("openaitest: %s", violation)
+	}
+}
+
+// AssertRequestCount reports an error unless want requests matched pattern.
+func (f *Fake) AssertRequestCount(t TestingT, pattern string, want int) {
+	t.Helper()
+	if got := len(f.RequestsTo(pattern)); got != want {
+		t.Errorf("openaitest: expected %d requests to %s, got %d", want, pattern, got)
+	}
+}
+
+// Reset forgets rules, failures, expectations, requests and stored objects.
+func (f *Fake) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rules = nil
+	f.failures = nil
+	f.expectations = nil
+	f.violations = nil
+	f.requests = nil
+	f.state = newState()
+}
+
+func (f *Fake) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "", err.Error())
+		return
+	}
+	p := strings.TrimPrefix(r.URL.Path, "/v1")
+	if strings.HasPrefix(p, adminPrefix) {
+		f.serveAdmin(w, r, strings.TrimPrefix(p, adminPrefix), body)
+		return
+	}
+	if !f.authorized(r) {
+		writeError(w, http.StatusUnauthorized, "invalid_request_error", "invalid_api_key",
+			"Incorrect API key provided.")
+		return
+	}
+
+	request := f.record(r, p, body)
+	if violation := f.check(request); violation != "" {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "", violation)
+		return
+	}
+	failure := f.takeFailure(request)
+	if failure != nil && failure.writeResponse(w) {
+		return
+	}
+	f.route(w, r, request, failure)
+}
+
+func (f *Fake) authorized(r *http.Request) bool {
+	if f.APIKey == "" {
+		return true
+	}
+	return r.Header.Get("Authorization") == "Bearer "+f.APIKey || r.Header.Get("api-key") == f.APIKey
+}
+
+func (f *Fake) record(r *http.Request, p string, body []byte) RecordedRequest {
+	request := newRecordedRequest(r, p, body)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, request)
+	return request
+}
+
+func newRecordedRequest(r *http.Request, p string, body []byte) RecordedRequest {
+	request := RecordedRequest{Method: r.Method, Path: p, Header: r.Header.Clone(), Body: body}
+	if bytes.HasPrefix(bytes.TrimSpace(body), []byte("{")) {
+		var fields struct {
+			Model  string `json:"model"`
+			Stream bool   `json:"stream"`
+		}
+		if json.Unmarshal(body, &fields) == nil {
+			request.Model, request.Stream = fields.Model, fields.Stream
+		}
+	}
+	return request
+}
+
+func (f *Fake) check(request RecordedRequest) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, check := range f.expectations {
+		if err := check(request); err != nil {
+			violation := fmt.Sprintf("%s %s: %v", request.Method, request.Path, err)
+			f.violations = append(f.violations, violation)
+			return violation
+		}
+	}
+	return ""
+}
+
+func (f *Fake) newID(prefix string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nextID++
+	return fmt.Sprintf("%s%d", prefix, f.nextID)
+}
+
+//nolint:gocyclo // a flat routing table reads best
+func (f *Fake) route(w http.ResponseWriter, r *http.Request, request RecordedRequest, failure *Failure) {
+	parts := strings.Split(strings.Trim(request.Path, "/"), "/")
+	method := r.Method
+	switch {
+	case len(parts) == 2 && parts[0] == "chat" && parts[1] == "completions" && method == http.MethodPost:
+		f.chatCompletion(w, request, failure)
+	case len(parts) == 1 && parts[0] == "embeddings" && method == http.MethodPost:
+		f.embeddings(w, request)
+	case parts[0] == "models" && method == http.MethodGet:
+		f.models(w, parts[1:])
+	case parts[0] == "files":
+		f.files(w, r, request, parts[1:])
+	case parts[0] == "assistants":
+		f.assistants(w, r, request, parts[1:])
+	case parts[0] == "threads":
+		f.threads(w, r, request, parts[1:])
+	case parts[0] == "batches":
+		f.batches(w, r, request, parts[1:])
+	default:
+		writeNotFound(w, request.Path)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, errType, code, message string) {
+	apiErr := map[string]any{"message": message, "type": errType, "param": nil, "code": nil}
+	if code != "" {
+		apiErr["code"] = code
+	}
+	writeJSON(w, status, map[string]any{"error": apiErr})
+}
+
+func writeNotFound(w http.ResponseWriter, what string) {
+	writeError(w, http.StatusNotFound, "invalid_request_error", "not_found", fmt.Sprintf("No such resource: %s", what))
+}
+
+func unixNow() int64 {
+	return time.Now().Unix()
+}
@@ -0,0 +1,511 @@
+package openaitest_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gradientlabs-ai/go-openai"
+	"github.com/gradientlabs-ai/go-openai/internal/test/checks"
+	"github.com/gradientlabs-ai/go-openai/openaitest"
+)
+
+func chatRequest(prompt string) openai.ChatCompletionRequest {
+	return openai.ChatCompletionRequest{
+		Model:    openai.GPT4o,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: prompt}},
+	}
+}
+
+func TestFakeChatCompletion(t *testing.T) {
+	server := openaitest.NewServer()
+	defer server.Close()
+	server.On(openaitest.Rule{Model: openai.GPT4o, Prompt: "(?i)weather", Reply: "Sunny."})
+	client := server.OpenAIClient()
+
+	resp, err := client.CreateChatCompletion(context.Background(), chatRequest("What's the weather?"))
+	checks.NoError(t, err)
+	if got := resp.Choices[0].Message.Content; got != "Sunny." {
+		t.Fatalf("expected the scripted reply, got %q", got)
+	}
+	if resp.Usage.TotalTokens == 0 {
+		t.Fatal("expected usage to be counted")
+	}
+
+	resp, err = client.CreateChatCompletion(context.Background(), chatRequest("Hello"))
+	checks.NoError(t, err)
+	if got := resp.Choices[0].Message.Content; got != server.DefaultReply {
+		t.Fatalf("expected the default reply, got %q", got)
+	}
+	server.AssertRequestCount(t, "/chat/completions", 2)
+}
+
+func TestFakeRuleTimes(t *testing.T) {
+	server := openaitest.NewServer()
+	defer server.Close()
+	server.On(openaitest.Rule{Reply: "first", Times: 1})
+	server.On(openaitest.Rule{Reply: "second"})
+	client := server.OpenAIClient()
+
+	for _, want := range []string{"first", "second", "second"} {
+		resp, err := client.CreateChatCompletion(context.Background(), chatRequest("hi"))
+		checks.NoError(t, err)
+		if got := resp.Choices[0].Message.Content; got != want {
+			t.Fatalf("expected %q, got %q", want, got)
+		}
+	}
+}
+
+func TestFakeStream(t *testing.T) {
+	server := openaitest.NewServer()
+	defer server.Close()
+	server.On(openaitest.Rule{Reply: "Hello there, friend.", ChunkSize: 5, ChunkDelay: time.Millisecond})
+	client := server.OpenAIClient()
+
+	request := chatRequest("hi")
+	request.StreamOptions = &openai.StreamOptions{IncludeUsage: true}
+	stream, err := client.CreateChatCompletionStream(context.Background(), request)
+	checks.NoError(t, err)
+	defer stream.Close()
+
+	var (
+		content strings.Builder
+		chunks  int
+		usage   *openai.Usage
+	)
+	for {
+		chunk, recvErr := stream.Recv()
+		if errors.Is(recvErr, io.EOF) {
+			break
+		}
+		checks.NoError(t, recvErr)
+		chunks++
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+		for _, choice := range chunk.Choices {
+			content.WriteString(choice.Delta.Content)
+		}
+	}
+	if content.String() != "Hello there, friend." {
+		t.Fatalf("unexpected content %q", content.String())
+	}
+	// A role chunk, four content chunks, a finish chunk and a usage chunk.
+	if chunks != 7 {
+		t.Fatalf("expected 7 chunks, got %d", chunks)
+	}
+	if usage == nil || usage.CompletionTokens == 0 {
+		t.Fatalf("expected a usage chunk, got %+v", usage)
+	}
+}
+
+func TestFakeToolCalls(t *testing.T) {
+	server := openaitest.NewServer()
+	defer server.Close()
+	call := openai.ToolCall{Function: openai.FunctionCall{Name: "get_weather", Arguments: `{"city":"Paris"}`}}
+	server.On(openaitest.Rule{ToolCalls: []openai.ToolCall{call}, ChunkSize: 4})
+	client := server.OpenAIClient()
+
+	resp, err := client.CreateChatCompletion(context.Background(), chatRequest("weather in Paris"))
+	checks.NoError(t, err)
+	choice := resp.Choices[0]
+	if choice.FinishReason != openai.FinishReasonToolCalls || len(choice.Message.ToolCalls) != 1 {
+		t.Fatalf("expected one tool call, got %+v", choice)
+	}
+	if got := choice.Message.ToolCalls[0]; got.ID == "" || got.Function.Name != "get_weather" {
+		t.Fatalf("unexpected tool call %+v", got)
+	}
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), chatRequest("weather in Paris"))
+	checks.NoError(t, err)
+	defer stream.Close()
+	var name, arguments string
+	for {
+		chunk, recvErr := stream.Recv()
+		if errors.Is(recvErr, io.EOF) {
+			break
+		}
+		checks.NoError(t, recvErr)
+		for _, toolCall := range chunk.Choices[0].Delta.ToolCalls {
+			name += toolCall.Function.Name
+			arguments += toolCall.Function.Arguments
+		}
+	}
+	if name != "get_weather" || arguments != `{"city":"Paris"}` {
+		t.Fatalf("unexpected streamed tool call %q %q", name, arguments)
+	}
+}
+
+func TestFakeRateLimitFailure(t *testing.T) {
+	server := openaitest.NewServer()
+	defer server.Close()
+	server.Fail(openaitest.Failure{Kind: openaitest.FailRateLimit, Path: "/chat/*", RetryAfter: 2 * time.Second})
+	client := server.OpenAIClient()
+
+	_, err := client.CreateChatCompletion(context.Background(), chatRequest("hi"))
+	checks.ErrorIs(t, err, openai.ErrRateLimited)
+	details, ok := openai.GetErrorDetails(err)
+	if !ok || details.RetryAfter != 2*time.Second {
+		t.Fatalf("expected a two second retry-after, got %+v", details)
+	}
+
+	_, err = client.CreateChatCompletion(context.Background(), chatRequest("hi"))
+	checks.NoError(t, err, "the failure should only apply once")
+}
+
+func TestFakeServerErrorFailure(t *testing.T) {
+	server := openaitest.NewServer()
+	defer server.Close()
+	server.Fail(openaitest.Failure{Kind: openaitest.FailServerError, Model: openai.GPT4o, Times: 2})
+	client := server.OpenAIClient()
+
+	for i := 0; i < 2; i++ {
+		_, err := client.CreateChatCompletion(context.Background(), chatRequest("hi"))
+		var apiErr *openai.APIError
+		if !errors.As(err, &apiErr) || apiErr.HTTPStatusCode != http.StatusInternalServerError {
+			t.Fatalf("expected a 500, got %v", err)
+		}
+	}
+	_, err := client.CreateChatCompletion(context.Background(), chatRequest("hi"))
+	checks.NoError(t, err)
+}
+
+func TestFakeStreamFailures(t *testing.T) {
+	for _, kind := range []openaitest.FailureKind{openaitest.FailMidStream, openaitest.FailMalformedChunk} {
+		t.Run(string(kind), func(t *testing.T) {
+			server := openaitest.NewServer()
+			defer server.Close()
+			server.On(openaitest.Rule{Reply: "one two three"})
+			server.Fail(openaitest.Failure{Kind: kind, AfterChunks: 2})
+			client := server.OpenAIClient()
+
+			stream, err := client.CreateChatCompletionStream(context.Background(), chatRequest("hi"))
+			checks.NoError(t, err)
+			defer stream.Close()
+			var received int
+			for {
+				_, err = stream.Recv()
+				if err != nil {
+					break
+				}
+				received++
+			}
+			if received != 2 {
+				t.Fatalf("expected 2 chunks before the failure, got %d", received)
+			}
+			if errors.Is(err, io.EOF) {
+				t.Fatal("expected the stream to fail")
+			}
+		})
+	}
+}
+
+func TestFakeEmbeddings(t *testing.T) {
+	server := openaitest.NewServer()
+	defer server.Close()
+	client := server.OpenAIClient()
+
+	request := openai.EmbeddingRequest{Input: []string{"a", "b", "a"}, Model: openai.SmallEmbedding3, Dimensions: 16}
+	resp, err := client.CreateEmbeddings(context.Background(), request)
+	checks.NoError(t, err)
+	if len(resp.Data) != 3 || len(resp.Data[0].Embedding) != 16 {
+		t.Fatalf("unexpected embeddings %+v", resp.Data)
+	}
+	if fmt.Sprint(resp.Data[0].Embedding) != fmt.Sprint(resp.Data[2].Embedding) {
+		t.Fatal("expected equal inputs to have equal embeddings")
+	}
+	if fmt.Sprint(resp.Data[0].Embedding) == fmt.Sprint(resp.Data[1].Embedding) {
+		t.Fatal("expected different inputs to have different embeddings")
+	}
+
+	request.EncodingFormat = openai.EmbeddingEncodingFormatBase64
+	encoded, err := client.CreateEmbeddings(context.Background(), request)
+	checks.NoError(t, err)
+	if fmt.Sprint(encoded.Data[0].Embedding) != fmt.Sprint(resp.Data[0].Embedding) {
+		t.Fatal("expected base64 embeddings to decode to the same vectors")
+	}
+}
+
+func TestFakeModels(t *testing.T) {
+	server := openaitest.NewServer()
+	defer server.Close()
+	server.On(openaitest.Rule{Model: "my-fine-tune", Reply: "ok"})
+	client := server.OpenAIClient()
+
+	models, err := client.ListModels(context.Background())
+	checks.NoError(t, err)
+	var found bool
+	for _, model := range models.Models {
+		found = found || model.ID == "my-fine-tune"
+	}
+	if !found {
+		t.Fatalf("expected the rule's model to be listed, got %+v", models.Models)
+	}
+	_, err = client.GetModel(context.Background(), "no-such-model")
+	checks.ErrorIs(t, err, openai.ErrModelNotFound)
+}
+
+func TestFakeFiles(t *testing.T) {
+	server := openaitest.NewServer()
+	defer server.Close()
+	client := server.OpenAIClient()
+	ctx := context.Background()
+
+	file, err := client.CreateFileBytes(ctx, openai.FileBytesRequest{
+		Name:    "notes.txt",
+		Bytes:   []byte("hello"),
+		Purpose: openai.PurposeAssistants,
+	})
+	checks.NoError(t, err)
+	if file.FileName != "notes.txt" || file.Bytes != 5 || file.Purpose != string(openai.PurposeAssistants) {
+		t.Fatalf("unexpected file %+v", file)
+	}
+
+	content, err := client.GetFileContent(ctx, file.ID)
+	checks.NoError(t, err)
+	data, err := io.ReadAll(content)
+	content.Close()
+	checks.NoError(t, err)
+	if string(data) != "hello" {
+		t.Fatalf("unexpected content %q", data)
+	}
+
+	list, err := client.ListFiles(ctx)
+	checks.NoError(t, err)
+	if len(list.Files) != 1 {
+		t.Fatalf("expected one file, got %d", len(list.Files))
+	}
+	checks.NoError(t, client.DeleteFile(ctx, file.ID))
+	_, err = client.GetFile(ctx, file.ID)
+	checks.HasError(t, err, "the file should be gone")
+}
+
+func TestFakeAssistantRun(t *testing.T) {
+	server := openaitest.NewServer()
+	defer server.Close()
+	call := openai.ToolCall{Function: openai.FunctionCall{Name: "lookup", Arguments: `{"q":"order"}`}}
+	server.On(openaitest.Rule{Prompt: "order", ToolCalls: []openai.ToolCall{call}, Reply: "It ships today."})
+	client := server.OpenAIClient()
+	ctx := context.Background()
+
+	name := "support"
+	assistant, err := client.CreateAssistant(ctx, openai.AssistantRequest{Model: openai.GPT4o, Name: &name})
+	checks.NoError(t, err)
+	thread, err := client.CreateThread(ctx, openai.ThreadRequest{
+		Messages: []openai.ThreadMessage{{Role: openai.ThreadMessageRoleUser, Content: "Where is my order?"}},
+	})
+	checks.NoError(t, err)
+	run, err := client.CreateRun(ctx, thread.ID, openai.RunRequest{AssistantID: assistant.ID})
+	checks.NoError(t, err)
+	if run.Status != openai.RunStatusQueued || run.Model != openai.GPT4o {
+		t.Fatalf("unexpected new run %+v", run)
+	}
+
+	retrieve := func(want openai.RunStatus) openai.Run {
+		t.Helper()
+		got, retrieveErr := client.RetrieveRun(ctx, thread.ID, run.ID)
+		checks.NoError(t, retrieveErr)
+		if got.Status != want {
+			t.Fatalf("expected run status %s, got %s", want, got.Status)
+		}
+		return got
+	}
+	retrieve(openai.RunStatusInProgress)
+	run = retrieve(openai.RunStatusRequiresAction)
+	calls := run.RequiredAction.SubmitToolOutputs.ToolCalls
+	if len(calls) != 1 || calls[0].Function.Name != "lookup" {
+		t.Fatalf("unexpected required action %+v", run.RequiredAction)
+	}
+
+	run, err = client.SubmitToolOutputs(ctx, thread.ID, run.ID, openai.SubmitToolOutputsRequest{
+		ToolOutputs: []openai.ToolOutput{{ToolCallID: calls[0].ID, Output: "shipped"}},
+	})
+	checks.NoError(t, err)
+	if run.Status != openai.RunStatusInProgress {
+		t.Fatalf("expected the run to resume, got %s", run.Status)
+	}
+	retrieve(openai.RunStatusCompleted)
+
+	order := "asc"
+	messages, err := client.ListMessage(ctx, thread.ID, nil, &order, nil, nil)
+	checks.NoError(t, err)
+	if len(messages.Messages) != 2 {
+		t.Fatalf("expected the question and the answer, got %d messages", len(messages.Messages))
+	}
+	answer := messages.Messages[1]
+	if answer.Role != openai.ChatMessageRoleAssistant || answer.Content[0].Text.Value != "It ships today." {
+		t.Fatalf("unexpected answer %+v", answer)
+	}
+}
+
+func TestFakeCancelRun(t *testing.T) {
+	server := openaitest.NewServer()
+	defer server.Close()
+	client := server.OpenAIClient()
+	ctx := context.Background()
+
+	assistant, err := client.CreateAssistant(ctx, openai.AssistantRequest{Model: openai.GPT4o})
+	checks.NoError(t, err)
+	thread, err := client.CreateThread(ctx, openai.ThreadRequest{})
+	checks.NoError(t, err)
+	run, err := client.CreateRun(ctx, thread.ID, openai.RunRequest{AssistantID: assistant.ID})
+	checks.NoError(t, err)
+
+	run, err = client.CancelRun(ctx, thread.ID, run.ID)
+	checks.NoError(t, err)
+	if run.Status != openai.RunStatusCancelling {
+		t.Fatalf("expected cancelling, got %s", run.Status)
+	}
+	run, err = client.RetrieveRun(ctx, thread.ID, run.ID)
+	checks.NoError(t, err)
+	if run.Status != openai.RunStatusCancelled {
+		t.Fatalf("expected cancelled, got %s", run.Status)
+	}
+}
+
+func TestFakeBatch(t *testing.T) {
+	server := openaitest.NewServer()
+	defer server.Close()
+	server.On(openaitest.Rule{Prompt: "ping", Reply: "pong"})
+	client := server.OpenAIClient()
+	ctx := context.Background()
+
+	lines := `{"custom_id": "a", "method": "POST", "url": "/v1/chat/completions",` +
+		` "body": {"model": "gpt-4o", "messages": [{"role": "user", "content": "ping"}]}}
+{"custom_id": "b", "method": "POST", "url": "/v1/embeddings", "body": {"input": "x"}}
+`
+	input, err := client.CreateFileBytes(ctx, openai.FileBytesRequest{
+		Name:    "batch.jsonl",
+		Bytes:   []byte(lines),
+		Purpose: openai.PurposeBatch,
+	})
+	checks.NoError(t, err)
+	batch, err := client.CreateBatch(ctx, openai.CreateBatchRequest{
+		InputFileID: input.ID,
+		Endpoint:    openai.BatchEndpointChatCompletions,
+	})
+	checks.NoError(t, err)
+	if batch.Status != openai.BatchStatusValidating || batch.RequestCounts.Total != 2 {
+		t.Fatalf("unexpected new batch %+v", batch)
+	}
+	for !batch.Done() {
+		batch, err = client.RetrieveBatch(ctx, batch.ID)
+		checks.NoError(t, err)
+	}
+	if batch.Status != openai.BatchStatusCompleted || batch.RequestCounts.Completed != 1 ||
+		batch.RequestCounts.Failed != 1 || batch.OutputFileID == nil || batch.ErrorFileID == nil {
+		t.Fatalf("unexpected finished batch %+v", batch)
+	}
+
+	content, err := client.GetFileContent(ctx, *batch.OutputFileID)
+	checks.NoError(t, err)
+	defer content.Close()
+	var result struct {
+		CustomID string `json:"custom_id"`
+		Response struct {
+			StatusCode int                           `json:"status_code"`
+			Body       openai.ChatCompletionResponse `json:"body"`
+		} `json:"response"`
+	}
+	checks.NoError(t, json.NewDecoder(content).Decode(&result))
+	if result.CustomID != "a" || result.Response.StatusCode != http.StatusOK ||
+		result.Response.Body.Choices[0].Message.Content != "pong" {
+		t.Fatalf("unexpected batch output %+v", result)
+	}
+	// The batch's requests are not recorded as requests to the fake.
+	server.AssertRequestCount(t, "/chat/completions", 0)
+}
+
+func TestFakeExpectations(t *testing.T) {
+	server := openaitest.NewServer()
+	defer server.Close()
+	server.Expect(func(r openaitest.RecordedRequest) error {
+		if r.Model != "" && r.Model != openai.GPT4o {
+			return fmt.Errorf("unexpected model %s", r.Model)
+		}
+		return nil
+	})
+	client := server.OpenAIClient()
+
+	_, err := client.CreateChatCompletion(context.Background(), chatRequest("hi"))
+	checks.NoError(t, err)
+	request := chatRequest("hi")
+	request.Model = openai.GPT3Dot5Turbo
+	_, err = client.CreateChatCompletion(context.Background(), request)
+	checks.HasError(t, err, "the request should fail the expectation")
+
+	recorder := &recordingT{}
+	server.AssertExpectations(recorder)
+	if len(recorder.errors) != 1 || !strings.Contains(recorder.errors[0], "unexpected model") {
+		t.Fatalf("expected one violation, got %q", recorder.errors)
+	}
+
+	recorded, err := server.Requests()[0].ChatCompletionRequest()
+	checks.NoError(t, err)
+	if recorded.Messages[0].Content != "hi" {
+		t.Fatalf("unexpected recorded request %+v", recorded)
+	}
+}
+
+func TestFakeAPIKey(t *testing.T) {
+	server := openaitest.NewServer()
+	defer server.Close()
+	config := openai.DefaultConfig("wrong")
+	config.BaseURL = server.ClientConfig().BaseURL
+
+	_, err := openai.NewClientWithConfig(config).CreateChatCompletion(context.Background(), chatRequest("hi"))
+	checks.ErrorIs(t, err, openai.ErrInvalidAPIKey)
+}
+
+func TestFakeAdmin(t *testing.T) {
+	server := openaitest.NewServer()
+	defer server.Close()
+	post := func(path, body string) {
+		t.Helper()
+		resp, err := http.Post(server.URL+"/_mock"+path, "application/json", strings.NewReader(body))
+		checks.NoError(t, err)
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			t.Fatalf("POST %s: status %d", path, resp.StatusCode)
+		}
+	}
+	post("/rules", `[{"prompt": "ping", "reply": "pong", "chunk_delay": "1ms"}]`)
+	post("/failures", `[{"kind": "rate_limit", "retry_after": "1s"}]`)
+	client := server.OpenAIClient()
+
+	_, err := client.CreateChatCompletion(context.Background(), chatRequest("ping"))
+	checks.ErrorIs(t, err, openai.ErrRateLimited)
+	resp, err := client.CreateChatCompletion(context.Background(), chatRequest("ping"))
+	checks.NoError(t, err)
+	if got := resp.Choices[0].Message.Content; got != "pong" {
+		t.Fatalf("expected the rule loaded over HTTP, got %q", got)
+	}
+
+	post("/reset", "")
+	if len(server.Requests()) != 0 {
+		t.Fatal("expected reset to forget requests")
+	}
+}
+
+func TestLoadRules(t *testing.T) {
+	_, err := openaitest.LoadRules(strings.NewReader(`[{"prompt": "("}]`))
+	checks.HasError(t, err, "an invalid prompt should fail")
+	_, err = openaitest.LoadRules(strings.NewReader(`[{"chunk_delay": "soon"}]`))
+	checks.HasError(t, err, "an invalid delay should fail")
+}
+
+type recordingT struct {
+	errors []string
+}
+
+func (r *recordingT) Helper() {}
+
+func (r *recordingT) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,262 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// HedgingPolicy configures hedged chat completion requests. A duplicate
+// request is sent each time Delay passes without a response, and the first
+// successful response wins; the others are canceled. For streams the race is
+// to the start of the response.
+type HedgingPolicy struct {
+	// Delay before each duplicate, typically the p95 latency of the call.
+	Delay time.Duration
+	// MaxHedges is the number of duplicates. Zero means 1.
+	MaxHedges int
+}
+
+// CircuitBreakerPolicy configures the breakers a Client keeps per endpoint
+// and model. Server errors, rate limiting, timeouts and connection errors
+// count as failures. While a breaker is open, calls fail with ErrCircuitOpen.
+type CircuitBreakerPolicy struct {
+	CircuitBreakerConfig
+	// OnStateChange, if set, is called on every state transition. It runs
+	// while the breaker is locked, so it must not block.
+	OnStateChange func(endpoint, model string, from, to CircuitState)
+}
+
+// PolicyMetrics counts what the hedging and circuit breaker policies did.
+type PolicyMetrics struct {
+	// HedgedRequests is the number of calls that sent at least one duplicate.
+	HedgedRequests int64
+	// HedgesSent is the number of duplicate requests sent.
+	HedgesSent int64
+	// HedgeWins is the number of calls answered by a duplicate.
+	HedgeWins int64
+	// BreakerRejections is the number of calls refused by an open breaker.
+	BreakerRejections int64
+	// BreakerTransitions counts breaker transitions by the state entered.
+	BreakerTransitions map[CircuitState]int64
+}
+
+type policyKey struct {
+	endpoint, model string
+}
+
+type clientPolicies struct {
+	hedging *HedgingPolicy
+	breaker *CircuitBreakerPolicy
+
+	mu       sync.Mutex
+	breakers map[policyKey]*circuitBreaker
+
+	hedgedRequests    int64
+	hedgesSent        int64
+	hedgeWins         int64
+	breakerRejections int64
+	transitions       [CircuitHalfOpen + 1]int64
+}
+
+func newClientPolicies(config ClientConfig) *clientPolicies {
+	return &clientPolicies{
+		hedging:  config.Hedging,
+		breaker:  config.CircuitBreaker,
+		breakers: make(map[policyKey]*circuitBreaker),
+	}
+}
+
+// PolicyMetrics returns a snapshot of the hedging and circuit breaker
+// counters.
+func (c *Client) PolicyMetrics() PolicyMetrics {
+	p := c.policies
+	metrics := PolicyMetrics{
+		HedgedRequests:     atomic.LoadInt64(&p.hedgedRequests),
+		HedgesSent:         atomic.LoadInt64(&p.hedgesSent),
+		HedgeWins:          atomic.LoadInt64(&p.hedgeWins),
+		BreakerRejections:  atomic.LoadInt64(&p.breakerRejections),
+		BreakerTransitions: make(map[CircuitState]int64, len(p.transitions)),
+	}
+	for state := range p.transitions {
+		metrics.BreakerTransitions[CircuitState(state)] = atomic.LoadInt64(&p.transitions[state])
+	}
+	return metrics
+}
+
+// BreakerState returns the state of the breaker for an endpoint, such as
+// "/chat/completions", and model.
+func (c *Client) BreakerState(endpoint, model string) CircuitState {
+	p := c.policies
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if breaker, ok := p.breakers[policyKey{endpoint, model}]; ok {
+		return breaker.State()
+	}
+	return CircuitClosed
+}
+
+func (p *clientPolicies) breakerFor(endpoint, model string) *circuitBreaker {
+	if p.breaker == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	key := policyKey{endpoint, model}
+	breaker, ok := p.breakers[key]
+	if !ok {
+		breaker = newCircuitBreaker(p.breaker.CircuitBreakerConfig, func(from, to CircuitState) {
+			atomic.AddInt64(&p.transitions[to], 1)
+			if p.breaker.OnStateChange != nil {
+				p.breaker.OnStateChange(endpoint, model, from, to)
+			}
+		})
+		p.breakers[key] = breaker
+	}
+	return breaker
+}
+
+// policyCall is a call that the client policies apply to.
+type policyCall[T any] struct {
+	endpoint string
+	model    string
+	// hedge allows the call to be hedged.
+	hedge bool
+	do    func(ctx context.Context) (T, error)
+	// keep hands the context of a winning hedged attempt to a result that
+	// outlives the call, such as a stream. Without it the context is canceled
+	// on return.
+	keep func(result T, cancel context.CancelFunc)
+	// discard releases the result of an attempt that lost the race.
+	discard func(result T)
+}
+
+func callWithPolicies[T any](ctx context.Context, p *clientPolicies, call policyCall[T]) (T, error) {
+	if p == nil {
+		return call.do(ctx)
+	}
+	breaker := p.breakerFor(call.endpoint, call.model)
+	var probe bool
+	if breaker != nil {
+		var allowed bool
+		if allowed, probe = breaker.allow(); !allowed {
+			atomic.AddInt64(&p.breakerRejections, 1)
+			var zero T
+			return zero, fmt.Errorf("%w: %s for model %s", ErrCircuitOpen, call.endpoint, call.model)
+		}
+	}
+
+	var (
+		result T
+		err    error
+	)
+	if call.hedge && p.hedging != nil && p.hedging.Delay > 0 {
+		result, err = hedgedCall(ctx, p, call)
+	} else {
+		result, err = call.do(ctx)
+	}
+
+	if breaker != nil {
+		failure, _ := classifyFailover(ctx, err)
+		switch {
+		case err == nil:
+			breaker.record(probe, callSucceeded)
+		case failure:
+			breaker.record(probe, callFailed)
+		default:
+			breaker.record(probe, callIgnored)
+		}
+	}
+	return result, err
+}
+
+type hedgeResult[T any] struct {
+	value T
+	err   error
+	index int
+}
+
+// hedgedCall runs call, starting a duplicate each time the hedging delay
+// passes without a result, and returns the first success. If every attempt
+// fails, the first error is returned.
+func hedgedCall[T any](ctx context.Context, p *clientPolicies, call policyCall[T]) (T, error) {
+	maxHedges := p.hedging.MaxHedges
+	if maxHedges <= 0 {
+		maxHedges = 1
+	}
+	results := make(chan hedgeResult[T], maxHedges+1)
+	cancels := make([]context.CancelFunc, 0, maxHedges+1)
+	launch := func() {
+		index := len(cancels)
+		attemptCtx, cancel := context.WithCancel(ctx)
+		cancels = append(cancels, cancel)
+		go func() {
+			value, err := call.do(attemptCtx)
+			results <- hedgeResult[T]{value: value, err: err, index: index}
+		}()
+	}
+
+	launch()
+	pending := 1
+	timer := time.NewTimer(p.hedging.Delay)
+	defer timer.Stop()
+	var firstErr error
+	for {
+		select {
+		case <-timer.C:
+			if len(cancels) == 1 {
+				atomic.AddInt64(&p.hedgedRequests, 1)
+			}
+			atomic.AddInt64(&p.hedgesSent, 1)
+			launch()
+			pending++
+			if len(cancels) <= maxHedges {
+				timer.Reset(p.hedging.Delay)
+			}
+		case result := <-results:
+			pending--
+			if result.err != nil {
+				cancels[result.index]()
+				if firstErr == nil {
+					firstErr = result.err
+				}
+				if pending == 0 {
+					var zero T
+					return zero, firstErr
+				}
+				continue
+			}
+
+			if result.index > 0 {
+				atomic.AddInt64(&p.hedgeWins, 1)
+			}
+			for i, cancel := range cancels {
+				if i != result.index {
+					cancel()
+				}
+			}
+			if pending > 0 {
+				go discardHedges(results, pending, call.discard)
+			}
+			if call.keep != nil {
+				call.keep(result.value, cancels[result.index])
+			} else {
+				cancels[result.index]()
+			}
+			return result.value, nil
+		}
+	}
+}
+
+// discardHedges waits for the attempts that lost the race and releases their
+// results.
+func discardHedges[T any](results <-chan hedgeResult[T], pending int, discard func(T)) {
+	for ; pending > 0; pending-- {
+		result := <-results
+		if result.err == nil && discard != nil {
+			discard(result.value)
+		}
+	}
+}
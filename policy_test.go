@@ -0,0 +1,184 @@
+package openai_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gradientlabs-ai/go-openai"
+	"github.com/gradientlabs-ai/go-openai/internal/test"
+	"github.com/gradientlabs-ai/go-openai/internal/test/checks"
+)
+
+func startPolicyTestClient(
+	t *testing.T,
+	config func(*openai.ClientConfig),
+	handler func(http.ResponseWriter, *http.Request),
+) *openai.Client {
+	t.Helper()
+	server := test.NewTestServer()
+	server.RegisterHandler("/v1/chat/completions", handler)
+	ts := server.OpenAITestServer()
+	ts.Start()
+	t.Cleanup(ts.Close)
+	clientConfig := openai.DefaultConfig(test.GetTestToken())
+	clientConfig.BaseURL = ts.URL + "/v1"
+	config(&clientConfig)
+	return openai.NewClientWithConfig(clientConfig)
+}
+
+// slowFirstHandler stalls the first request until it is canceled and answers
+// later ones straight away. It reports whether the first one was canceled.
+func slowFirstHandler(canceled chan<- struct{}) func(http.ResponseWriter, *http.Request) {
+	var calls int32
+	return func(w http.ResponseWriter, r *http.Request) {
+		call := atomic.AddInt32(&calls, 1)
+		// The server only notices a client going away once the body is read.
+		_, _ = io.Copy(io.Discard, r.Body)
+		if call == 1 {
+			select {
+			case <-r.Context().Done():
+				close(canceled)
+			case <-time.After(5 * time.Second):
+			}
+			return
+		}
+		if r.Header.Get("Accept") == "text/event-stream" {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"content\":\"call %d\"}}]}\n\ndata: [DONE]\n\n", call)
+			return
+		}
+		fmt.Fprintf(w, `{"choices":[{"message":{"role":"assistant","content":"call %d"}}]}`, call)
+	}
+}
+
+func TestHedgedChatCompletion(t *testing.T) {
+	canceled := make(chan struct{})
+	client := startPolicyTestClient(t, func(config *openai.ClientConfig) {
+		config.Hedging = &openai.HedgingPolicy{Delay: 20 * time.Millisecond}
+	}, slowFirstHandler(canceled))
+
+	resp, err := client.CreateChatCompletion(context.Background(), routerRequest("m"))
+	checks.NoError(t, err, "CreateChatCompletion error")
+	if resp.Choices[0].Message.Content != "call 2" {
+		t.Errorf("expected the hedge to win, got %q", resp.Choices[0].Message.Content)
+	}
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Error("the losing request was not canceled")
+	}
+
+	metrics := client.PolicyMetrics()
+	if metrics.HedgedRequests != 1 || metrics.HedgesSent != 1 || metrics.HedgeWins != 1 {
+		t.Errorf("unexpected metrics %+v", metrics)
+	}
+
+	// Fast responses are not hedged.
+	_, err = client.CreateChatCompletion(context.Background(), routerRequest("m"))
+	checks.NoError(t, err, "CreateChatCompletion error")
+	if client.PolicyMetrics().HedgesSent != 1 {
+		t.Error("a fast response should not be hedged")
+	}
+}
+
+func TestHedgedChatCompletionStream(t *testing.T) {
+	canceled := make(chan struct{})
+	client := startPolicyTestClient(t, func(config *openai.ClientConfig) {
+		config.Hedging = &openai.HedgingPolicy{Delay: 20 * time.Millisecond, MaxHedges: 2}
+	}, slowFirstHandler(canceled))
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), routerRequest("m"))
+	checks.NoError(t, err, "CreateChatCompletionStream error")
+	defer stream.Close()
+	chunk, err := stream.Recv()
+	checks.NoError(t, err, "Recv error")
+	if chunk.Choices[0].Delta.Content != "call 2" {
+		t.Errorf("expected the hedge to win, got %q", chunk.Choices[0].Delta.Content)
+	}
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Error("the losing request was not canceled")
+	}
+	if metrics := client.PolicyMetrics(); metrics.HedgeWins != 1 || metrics.HedgesSent != 1 {
+		t.Errorf("unexpected metrics %+v", metrics)
+	}
+}
+
+func TestClientCircuitBreaker(t *testing.T) {
+	var (
+		mu          sync.Mutex
+		status      = http.StatusInternalServerError
+		requests    int
+		transitions []string
+	)
+	client := startPolicyTestClient(t, func(config *openai.ClientConfig) {
+		config.CircuitBreaker = &openai.CircuitBreakerPolicy{
+			CircuitBreakerConfig: openai.CircuitBreakerConfig{FailureThreshold: 2, OpenTimeout: 50 * time.Millisecond},
+			OnStateChange: func(endpoint, model string, from, to openai.CircuitState) {
+				transitions = append(transitions, fmt.Sprintf("%s %s %s->%s", endpoint, model, from, to))
+			},
+		}
+	}, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		code := status
+		mu.Unlock()
+		w.WriteHeader(code)
+		if code == http.StatusOK {
+			fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"ok"}}]}`)
+			return
+		}
+		fmt.Fprint(w, serverErrorBody)
+	})
+	ctx := context.Background()
+	requestCount := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return requests
+	}
+
+	for i := 0; i < 2; i++ {
+		_, err := client.CreateChatCompletion(ctx, routerRequest("m"))
+		checks.HasError(t, err, "server error")
+	}
+	_, err := client.CreateChatCompletion(ctx, routerRequest("m"))
+	checks.ErrorIs(t, err, openai.ErrCircuitOpen, "the breaker should be open")
+	if requestCount() != 2 || client.BreakerState("/chat/completions", "m") != openai.CircuitOpen {
+		t.Errorf("expected the open breaker to refuse the call, got %d requests", requestCount())
+	}
+
+	// Breakers are per model.
+	_, err = client.CreateChatCompletion(ctx, routerRequest("other"))
+	checks.HasError(t, err, "server error")
+	if requestCount() != 3 {
+		t.Error("another model should not be affected")
+	}
+
+	mu.Lock()
+	status = http.StatusOK
+	mu.Unlock()
+	time.Sleep(60 * time.Millisecond)
+	_, err = client.CreateChatCompletion(ctx, routerRequest("m"))
+	checks.NoError(t, err, "the probe should succeed")
+
+	want := []string{
+		"/chat/completions m closed->open",
+		"/chat/completions m open->half-open",
+		"/chat/completions m half-open->closed",
+	}
+	if fmt.Sprint(transitions) != fmt.Sprint(want) {
+		t.Errorf("unexpected transitions %v", transitions)
+	}
+	metrics := client.PolicyMetrics()
+	if metrics.BreakerRejections != 1 || metrics.BreakerTransitions[openai.CircuitOpen] != 1 ||
+		metrics.BreakerTransitions[openai.CircuitClosed] != 1 {
+		t.Errorf("unexpected metrics %+v", metrics)
+	}
+}
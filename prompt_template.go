@@ -0,0 +1,604 @@
+package openai
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"text/template/parse"
+)
+
+var (
+	ErrPromptNotFound        = errors.New("prompt template not found")
+	ErrPromptExists          = errors.New("prompt template version already registered")
+	ErrPromptVariableMissing = errors.New("prompt variables not provided")
+)
+
+const (
+	// Request metadata keys RenderedPrompt.Apply records the template in.
+	PromptMetadataName    = "prompt_name"
+	PromptMetadataVersion = "prompt_version"
+
+	// File extensions read by LoadPromptRegistry.
+	PromptTemplateExt = ".prompt"
+	PromptPartialExt  = ".partial"
+)
+
+// FewShotExample is a worked example, shown to the model as a user message
+// followed by the assistant's answer.
+type FewShotExample struct {
+	Input  string
+	Output string
+}
+
+// PromptTemplate renders chat messages from a text/template. Create one with
+// ParsePromptTemplate or load a directory of them with LoadPromptRegistry.
+// It is safe for concurrent use.
+type PromptTemplate struct {
+	Name        string
+	Version     string
+	Description string
+	// Metadata holds the frontmatter keys other than name, version and
+	// description.
+	Metadata map[string]string
+
+	tmpl *template.Template
+	// marker starts every role marker in the output. It is random so that
+	// variables cannot start messages of their own.
+	marker string
+	// variables are the top-level fields the template refers to, and
+	// required those of them it uses other than as a condition.
+	variables []string
+	required  map[string]bool
+}
+
+// ParsePromptTemplate parses a prompt template. The source is a text/template
+// that may start with frontmatter: "key: value" lines between two "---"
+// lines. The name, version and description keys set the fields of the same
+// name and the others go into Metadata.
+//
+// {{role "system"}}, {{role "user"}} and so on start a new message with that
+// role; without any, the whole output is one user message. {{examples .X}}
+// renders a []FewShotExample as alternating user and assistant messages.
+// Space around each message is trimmed and empty messages are dropped, so
+// that a role inside {{if}} only adds a message when the condition holds.
+func ParsePromptTemplate(source string) (*PromptTemplate, error) {
+	return parsePromptTemplate(nil, "", source)
+}
+
+// parsePromptTemplate parses source with the templates of partials, if any,
+// available to it. defaultName names the template if its frontmatter doesn't.
+func parsePromptTemplate(partials *template.Template, defaultName, source string) (*PromptTemplate, error) {
+	frontmatter, body, err := splitFrontmatter(source)
+	if err != nil {
+		return nil, err
+	}
+	t := &PromptTemplate{Name: defaultName}
+	for key, value := range frontmatter {
+		switch key {
+		case "name":
+			t.Name = value
+		case "version":
+			t.Version = value
+		case "description":
+			t.Description = value
+		default:
+			if t.Metadata == nil {
+				t.Metadata = make(map[string]string)
+			}
+			t.Metadata[key] = value
+		}
+	}
+
+	nonce := make([]byte, 8)
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	t.marker = "\x00role-" + hex.EncodeToString(nonce) + ":"
+	t.tmpl = template.New(t.Name).Option("missingkey=error").Funcs(promptFuncs(t.marker))
+	if partials != nil {
+		for _, partial := range partials.Templates() {
+			if partial.Tree == nil {
+				continue
+			}
+			if _, err = t.tmpl.AddParseTree(partial.Name(), partial.Tree); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if _, err = t.tmpl.Parse(body); err != nil {
+		return nil, err
+	}
+
+	refs := promptReferences{all: make(map[string]bool), required: make(map[string]bool)}
+	refs.walk(t.tmpl, t.tmpl.Tree.Root, promptScope{dot: true, dollar: true, required: true}, map[string]bool{})
+	for name := range refs.all {
+		t.variables = append(t.variables, name)
+	}
+	sort.Strings(t.variables)
+	t.required = refs.required
+	return t, nil
+}
+
+// promptFuncs are the functions prompt templates can call. The partials of a
+// registry are parsed with an empty marker, as only the names matter then.
+func promptFuncs(marker string) template.FuncMap {
+	role := func(role string) (string, error) {
+		switch role {
+		case ChatMessageRoleSystem, ChatMessageRoleDeveloper, ChatMessageRoleUser, ChatMessageRoleAssistant:
+			return marker + role + "\x00", nil
+		default:
+			return "", fmt.Errorf("unsupported prompt role %q", role)
+		}
+	}
+	return template.FuncMap{
+		"role": role,
+		"examples": func(examples []FewShotExample) string {
+			var sb strings.Builder
+			for _, e := range examples {
+				sb.WriteString(marker + ChatMessageRoleUser + "\x00")
+				sb.WriteString(e.Input)
+				sb.WriteString(marker + ChatMessageRoleAssistant + "\x00")
+				sb.WriteString(e.Output)
+			}
+			return sb.String()
+		},
+	}
+}
+
+// Variables returns the names of the top-level fields or map keys the
+// template refers to.
+func (t *PromptTemplate) Variables() []string {
+	return append([]string(nil), t.variables...)
+}
+
+// Render executes the template with vars, a struct, a map with string keys or
+// a pointer to either. It returns an error wrapping ErrPromptVariableMissing
+// unless every variable the template refers to is provided: map keys must be
+// present, and struct fields used other than as an {{if}}, {{with}} or
+// {{range}} condition must be non-empty unless tagged `prompt:"optional"`.
+func (t *PromptTemplate) Render(vars any) (RenderedPrompt, error) {
+	if err := t.checkVariables(vars); err != nil {
+		return RenderedPrompt{}, err
+	}
+	var buf bytes.Buffer
+	if err := t.tmpl.Execute(&buf, vars); err != nil {
+		return RenderedPrompt{}, err
+	}
+	messages, err := t.splitMessages(buf.String())
+	if err != nil {
+		return RenderedPrompt{}, err
+	}
+	return RenderedPrompt{Name: t.Name, Version: t.Version, Messages: messages}, nil
+}
+
+func (t *PromptTemplate) checkVariables(vars any) error {
+	v := reflect.ValueOf(vars)
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			v = reflect.Value{}
+			break
+		}
+		v = v.Elem()
+	}
+
+	var missing []string
+	switch v.Kind() {
+	case reflect.Invalid:
+		missing = t.variables
+	case reflect.Struct:
+		for _, name := range t.variables {
+			field, ok := v.Type().FieldByName(name)
+			if !ok {
+				if !hasMethod(v.Type(), name) {
+					missing = append(missing, name)
+				}
+				continue
+			}
+			if !t.required[name] || field.Tag.Get("prompt") == "optional" {
+				continue
+			}
+			// A field of a nil embedded pointer is not provided either.
+			if value, err := v.FieldByIndexErr(field.Index); err != nil || isEmptyValue(value) {
+				missing = append(missing, name)
+			}
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("prompt %q: variables map must have string keys, got %s", t.Name, v.Type())
+		}
+		for _, name := range t.variables {
+			if !v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key())).IsValid() {
+				missing = append(missing, name)
+			}
+		}
+	default:
+		if len(t.variables) > 0 {
+			return fmt.Errorf("prompt %q: variables must be a struct or a map, got %s", t.Name, v.Type())
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: prompt %q needs %s", ErrPromptVariableMissing, t.Name, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// splitMessages splits the output of the template at its role markers.
+func (t *PromptTemplate) splitMessages(output string) ([]ChatCompletionMessage, error) {
+	parts := strings.Split(output, t.marker)
+	if len(parts) == 1 {
+		if content := strings.TrimSpace(output); content != "" {
+			return []ChatCompletionMessage{{Role: ChatMessageRoleUser, Content: content}}, nil
+		}
+		return nil, fmt.Errorf("prompt %q renders no messages", t.Name)
+	}
+	if strings.TrimSpace(parts[0]) != "" {
+		return nil, fmt.Errorf("prompt %q has text before its first role", t.Name)
+	}
+	var messages []ChatCompletionMessage
+	for _, part := range parts[1:] {
+		role, content, _ := strings.Cut(part, "\x00")
+		if content = strings.TrimSpace(content); content != "" {
+			messages = append(messages, ChatCompletionMessage{Role: role, Content: content})
+		}
+	}
+	if len(messages) == 0 {
+		return nil, fmt.Errorf("prompt %q renders no messages", t.Name)
+	}
+	return messages, nil
+}
+
+// RenderedPrompt is the output of a PromptTemplate and the template it came
+// from.
+type RenderedPrompt struct {
+	Name     string
+	Version  string
+	Messages []ChatCompletionMessage
+}
+
+// Apply appends the messages to request and records the name and version of
+// the template in its Metadata, so that stored completions can be traced back
+// to the prompt that produced them.
+func (p RenderedPrompt) Apply(request *ChatCompletionRequest) {
+	request.Messages = append(request.Messages, p.Messages...)
+	if p.Name == "" && p.Version == "" {
+		return
+	}
+	if request.Metadata == nil {
+		request.Metadata = make(map[string]string)
+	}
+	if p.Name != "" {
+		request.Metadata[PromptMetadataName] = p.Name
+	}
+	if p.Version != "" {
+		request.Metadata[PromptMetadataVersion] = p.Version
+	}
+}
+
+// TypedPrompt is a PromptTemplate bound to the type of its variables, so that
+// a template referring to a field T lacks fails when it is bound, typically at
+// start-up, rather than when it is first rendered.
+type TypedPrompt[T any] struct {
+	Template *PromptTemplate
+}
+
+// NewTypedPrompt binds t to T, which must be a struct, a map with string keys
+// or a pointer to either, and checks that a struct T has every variable t
+// refers to.
+func NewTypedPrompt[T any](t *PromptTemplate) (TypedPrompt[T], error) {
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	switch {
+	case typ.Kind() == reflect.Struct:
+		var unknown []string
+		for _, name := range t.variables {
+			if _, ok := typ.FieldByName(name); !ok && !hasMethod(typ, name) {
+				unknown = append(unknown, name)
+			}
+		}
+		if len(unknown) > 0 {
+			return TypedPrompt[T]{}, fmt.Errorf("prompt %q uses %s, which %s does not have",
+				t.Name, strings.Join(unknown, ", "), typ)
+		}
+	case typ.Kind() == reflect.Map && typ.Key().Kind() == reflect.String:
+	default:
+		return TypedPrompt[T]{}, fmt.Errorf("prompt %q: variables must be a struct or a map, got %s", t.Name, typ)
+	}
+	return TypedPrompt[T]{Template: t}, nil
+}
+
+// Render renders the template with vars.
+func (p TypedPrompt[T]) Render(vars T) (RenderedPrompt, error) {
+	return p.Template.Render(vars)
+}
+
+// PromptRegistry holds prompt templates by name and version. It is safe for
+// concurrent use.
+type PromptRegistry struct {
+	mu sync.RWMutex
+	// prompts are sorted by version, oldest first.
+	prompts map[string][]*PromptTemplate
+}
+
+func NewPromptRegistry() *PromptRegistry {
+	return &PromptRegistry{prompts: make(map[string][]*PromptTemplate)}
+}
+
+// LoadPromptRegistry parses every .prompt file in fsys into a new registry.
+// A prompt without a name in its frontmatter is named after its file. Every
+// .partial file is available to all prompts as {{template "name" .}}, where
+// name is its file name without the extension, along with any templates it
+// {{define}}s.
+func LoadPromptRegistry(fsys fs.FS) (*PromptRegistry, error) {
+	var prompts, partialFiles []string
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		switch path.Ext(p) {
+		case PromptTemplateExt:
+			prompts = append(prompts, p)
+		case PromptPartialExt:
+			partialFiles = append(partialFiles, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	partials := template.New("").Funcs(promptFuncs(""))
+	for _, p := range partialFiles {
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = partials.New(strings.TrimSuffix(path.Base(p), PromptPartialExt)).Parse(string(data)); err != nil {
+			return nil, fmt.Errorf("%s: %w", p, err)
+		}
+	}
+
+	registry := NewPromptRegistry()
+	for _, p := range prompts {
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return nil, err
+		}
+		t, err := parsePromptTemplate(partials, strings.TrimSuffix(path.Base(p), PromptTemplateExt), string(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", p, err)
+		}
+		if err = registry.Register(t); err != nil {
+			return nil, fmt.Errorf("%s: %w", p, err)
+		}
+	}
+	return registry, nil
+}
+
+// Register adds a template. It returns ErrPromptExists if the registry already
+// has that version of the template.
+func (r *PromptRegistry) Register(t *PromptTemplate) error {
+	if t.Name == "" {
+		return errors.New("prompt template has no name")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	versions := r.prompts[t.Name]
+	i := sort.Search(len(versions), func(i int) bool { return compareVersions(versions[i].Version, t.Version) >= 0 })
+	if i < len(versions) && versions[i].Version == t.Version {
+		return fmt.Errorf("%w: %s version %q", ErrPromptExists, t.Name, t.Version)
+	}
+	versions = append(versions, nil)
+	copy(versions[i+1:], versions[i:])
+	versions[i] = t
+	r.prompts[t.Name] = versions
+	return nil
+}
+
+// Get returns a version of a template, or its latest version if version is
+// empty. Versions compare as dot-separated numbers, so 1.10 is later than 1.9.
+func (r *PromptRegistry) Get(name, version string) (*PromptTemplate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	versions := r.prompts[name]
+	if version == "" && len(versions) > 0 {
+		return versions[len(versions)-1], nil
+	}
+	for _, t := range versions {
+		if t.Version == version {
+			return t, nil
+		}
+	}
+	if version == "" {
+		return nil, fmt.Errorf("%w: %s", ErrPromptNotFound, name)
+	}
+	return nil, fmt.Errorf("%w: %s version %q", ErrPromptNotFound, name, version)
+}
+
+// Versions returns the versions of a template, oldest first.
+func (r *PromptRegistry) Versions(name string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	versions := make([]string, len(r.prompts[name]))
+	for i, t := range r.prompts[name] {
+		versions[i] = t.Version
+	}
+	return versions
+}
+
+// compareVersions compares versions such as "2", "1.10" or "v3" part by part,
+// numerically where both parts are numbers.
+func compareVersions(a, b string) int {
+	as := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	bs := strings.Split(strings.TrimPrefix(b, "v"), ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, aErr := strconv.Atoi(as[i])
+		bn, bErr := strconv.Atoi(bs[i])
+		switch {
+		case aErr == nil && bErr == nil && an != bn:
+			if an < bn {
+				return -1
+			}
+			return 1
+		case (aErr != nil || bErr != nil) && as[i] != bs[i]:
+			return strings.Compare(as[i], bs[i])
+		}
+	}
+	return len(as) - len(bs)
+}
+
+// splitFrontmatter separates the frontmatter of a template from its body.
+func splitFrontmatter(source string) (map[string]string, string, error) {
+	first, rest, _ := strings.Cut(source, "\n")
+	if strings.TrimSpace(first) != "---" {
+		return nil, source, nil
+	}
+	frontmatter := make(map[string]string)
+	for {
+		var (
+			line string
+			more bool
+		)
+		line, rest, more = strings.Cut(rest, "\n")
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "---":
+			return frontmatter, rest, nil
+		case !more:
+			return nil, "", errors.New("prompt frontmatter is not closed with ---")
+		case line == "" || strings.HasPrefix(line, "#"):
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, "", fmt.Errorf("invalid prompt frontmatter line %q", line)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		frontmatter[strings.TrimSpace(key)] = value
+	}
+}
+
+// promptScope is what a node of a template can see: whether dot and $ are
+// the variables passed to Render, and whether a field used here must be set.
+type promptScope struct {
+	dot      bool
+	dollar   bool
+	required bool
+}
+
+type promptReferences struct {
+	all      map[string]bool
+	required map[string]bool
+}
+
+// walk collects the variables a template refers to, following the partials
+// it passes its variables to.
+func (refs promptReferences) walk(tmpl *template.Template, node parse.Node, scope promptScope,
+	visiting map[string]bool) {
+	add := func(name string) {
+		refs.all[name] = true
+		if scope.required {
+			refs.required[name] = true
+		}
+	}
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			refs.walk(tmpl, child, scope, visiting)
+		}
+	case *parse.ActionNode:
+		refs.walk(tmpl, n.Pipe, scope, visiting)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			refs.walk(tmpl, cmd, scope, visiting)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			refs.walk(tmpl, arg, scope, visiting)
+		}
+	case *parse.ChainNode:
+		refs.walk(tmpl, n.Node, scope, visiting)
+	case *parse.FieldNode:
+		if scope.dot {
+			add(n.Ident[0])
+		}
+	case *parse.VariableNode:
+		if scope.dollar && n.Ident[0] == "$" && len(n.Ident) > 1 {
+			add(n.Ident[1])
+		}
+	case *parse.IfNode:
+		refs.walkBranch(tmpl, &n.BranchNode, scope, scope.dot, visiting)
+	case *parse.WithNode:
+		refs.walkBranch(tmpl, &n.BranchNode, scope, false, visiting)
+	case *parse.RangeNode:
+		refs.walkBranch(tmpl, &n.BranchNode, scope, false, visiting)
+	case *parse.TemplateNode:
+		refs.walk(tmpl, n.Pipe, scope, visiting)
+		partial := tmpl.Lookup(n.Name)
+		if partial == nil || partial.Tree == nil || visiting[n.Name] || !scope.dot || !isDotPipe(n.Pipe) {
+			return
+		}
+		visiting[n.Name] = true
+		refs.walk(tmpl, partial.Tree.Root, scope, visiting)
+		delete(visiting, n.Name)
+	}
+}
+
+// walkBranch walks an {{if}}, {{with}} or {{range}}, whose condition does not
+// make its fields required and whose body sees dot as bodyDot.
+func (refs promptReferences) walkBranch(tmpl *template.Template, n *parse.BranchNode, scope promptScope,
+	bodyDot bool, visiting map[string]bool) {
+	condition := scope
+	condition.required = false
+	refs.walk(tmpl, n.Pipe, condition, visiting)
+	body := scope
+	body.dot = bodyDot
+	refs.walk(tmpl, n.List, body, visiting)
+	refs.walk(tmpl, n.ElseList, scope, visiting)
+}
+
+func isDotPipe(pipe *parse.PipeNode) bool {
+	if pipe == nil || len(pipe.Cmds) != 1 || len(pipe.Cmds[0].Args) != 1 {
+		return false
+	}
+	_, ok := pipe.Cmds[0].Args[0].(*parse.DotNode)
+	return ok
+}
+
+func hasMethod(typ reflect.Type, name string) bool {
+	if _, ok := typ.MethodByName(name); ok {
+		return true
+	}
+	_, ok := reflect.PointerTo(typ).MethodByName(name)
+	return ok
+}
+
+// isEmptyValue reports whether v is the zero value or an empty slice or map.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	default:
+		return v.IsZero()
+	}
+}
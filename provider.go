@@ -0,0 +1,299 @@
+package openai
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// ProviderProfile describes how an OpenAI-compatible backend differs from the
+// OpenAI API. Set ClientConfig.Provider, or build a config with
+// DefaultProviderConfig, to have the client adapt requests and responses.
+type ProviderProfile struct {
+	Name string
+	// BaseURL is the provider's API base URL.
+	BaseURL string
+
+	// AuthHeader is the header carrying the API key. Empty uses
+	// "Authorization: Bearer <key>".
+	AuthHeader string
+	// AuthScheme prefixes the key in AuthHeader, e.g. "Bearer". Empty sends the
+	// bare key.
+	AuthScheme string
+
+	// FieldRenames renames top-level fields of chat completion and completion
+	// requests, e.g. max_completion_tokens to max_tokens. A dotted target
+	// nests the value, so "reasoning.effort" sends {"reasoning": {"effort": ...}}.
+	FieldRenames map[string]string
+	// UnsupportedParams lists top-level fields removed from chat completion
+	// and completion requests before sending. Other endpoints, such as
+	// batches and assistants, are sent unchanged, as fields like metadata
+	// mean something else there.
+	UnsupportedParams []string
+	// ExtraBodyField, if set, sends ChatCompletionRequest.ExtraBody nested
+	// under this request field instead of merged into the top level.
+	ExtraBodyField string
+
+	// ReasoningField is the message field in which the provider returns
+	// reasoning text: "reasoning" or "reasoning_content". The client copies
+	// "reasoning" into ChatCompletionMessage.ReasoningContent and the stream
+	// delta's ReasoningContent, so callers can always read ReasoningContent.
+	ReasoningField string
+
+	// ParseError decodes error bodies in the provider's format. It returns nil
+	// for bodies it does not recognize, which then go through the default
+	// OpenAI error parsing.
+	ParseError func(body []byte) *APIError
+}
+
+const reasoningFieldReasoning = "reasoning"
+
+// Profiles for popular OpenAI-compatible servers.
+var (
+	ProviderOpenAI = ProviderProfile{
+		Name:    "openai",
+		BaseURL: openaiAPIURLv1,
+	}
+
+	// ProviderGemini targets the Gemini API's OpenAI compatibility layer.
+	// https://ai.google.dev/gemini-api/docs/openai
+	ProviderGemini = ProviderProfile{
+		Name:    "gemini",
+		BaseURL: "https://generativelanguage.googleapis.com/v1beta/openai",
+		FieldRenames: map[string]string{
+			"max_completion_tokens": "max_tokens",
+		},
+		UnsupportedParams: []string{
+			"logit_bias", "service_tier", "store", "metadata", "prediction",
+		},
+		// Gemini reads its own options, such as google.thinking_config,
+		// from a literal extra_body field.
+		ExtraBodyField: "extra_body",
+		ReasoningField: "reasoning_content",
+		ParseError:     parseGoogleError,
+	}
+
+	// ProviderGroq targets GroqCloud.
+	// https://console.groq.com/docs/openai
+	ProviderGroq = ProviderProfile{
+		Name:    "groq",
+		BaseURL: "https://api.groq.com/openai/v1",
+		UnsupportedParams: []string{
+			"logit_bias", "logprobs", "top_logprobs", "store", "metadata", "prediction",
+		},
+		ReasoningField: reasoningFieldReasoning,
+	}
+
+	// ProviderCerebras targets Cerebras Inference.
+	// https://inference-docs.cerebras.ai/resources/openai
+	ProviderCerebras = ProviderProfile{
+		Name:    "cerebras",
+		BaseURL: "https://api.cerebras.ai/v1",
+		UnsupportedParams: []string{
+			"frequency_penalty", "presence_penalty", "logit_bias", "service_tier", "store", "metadata", "prediction",
+		},
+		ReasoningField: reasoningFieldReasoning,
+	}
+
+	// ProviderOllama targets a local Ollama server's OpenAI compatible API.
+	// https://github.com/ollama/ollama/blob/main/docs/openai.md
+	ProviderOllama = ProviderProfile{
+		Name:    "ollama",
+		BaseURL: "http://localhost:11434/v1",
+		FieldRenames: map[string]string{
+			"max_completion_tokens": "max_tokens",
+		},
+		UnsupportedParams: []string{
+			"logit_bias", "service_tier", "store", "metadata", "prediction", "parallel_tool_calls",
+		},
+		ReasoningField: reasoningFieldReasoning,
+		ParseError:     parseStringError,
+	}
+
+	// ProviderVLLM targets a vLLM OpenAI compatible server.
+	// https://docs.vllm.ai/en/latest/serving/openai_compatible_server.html
+	ProviderVLLM = ProviderProfile{
+		Name:    "vllm",
+		BaseURL: "http://localhost:8000/v1",
+		UnsupportedParams: []string{
+			"service_tier", "store", "metadata", "prediction",
+		},
+		ReasoningField: "reasoning_content",
+		ParseError:     parseTopLevelError,
+	}
+
+	// ProviderOpenRouter targets OpenRouter, which takes the reasoning effort
+	// as reasoning.effort.
+	// https://openrouter.ai/docs/api-reference/overview
+	ProviderOpenRouter = ProviderProfile{
+		Name:    "openrouter",
+		BaseURL: "https://openrouter.ai/api/v1",
+		FieldRenames: map[string]string{
+			"reasoning_effort": "reasoning.effort",
+		},
+		ReasoningField: reasoningFieldReasoning,
+	}
+)
+
+// DefaultProviderConfig returns a config for an OpenAI-compatible provider.
+func DefaultProviderConfig(profile ProviderProfile, apiKey string) ClientConfig {
+	config := DefaultConfig(apiKey)
+	config.BaseURL = profile.BaseURL
+	config.Provider = &profile
+	return config
+}
+
+func (p *ProviderProfile) authHeaderValue(token string) string {
+	if p.AuthScheme == "" {
+		return token
+	}
+	return p.AuthScheme + " " + token
+}
+
+// rewritesRequests reports whether FieldRenames and UnsupportedParams apply to
+// a request to url.
+func (p *ProviderProfile) rewritesRequests(url string) bool {
+	if len(p.FieldRenames) == 0 && len(p.UnsupportedParams) == 0 {
+		return false
+	}
+	// Both chat completions and completions end in /completions.
+	path, _, _ := strings.Cut(url, "?")
+	return strings.HasSuffix(path, "/completions")
+}
+
+// nestExtraBody moves the ExtraBody of a chat completion request under
+// ExtraBodyField. An ExtraBody that already holds just that field is left
+// as it is.
+func (p *ProviderProfile) nestExtraBody(body any) any {
+	request, ok := body.(ChatCompletionRequest)
+	if !ok || p.ExtraBodyField == "" || len(request.ExtraBody) == 0 {
+		return body
+	}
+	if _, nested := request.ExtraBody[p.ExtraBodyField]; nested && len(request.ExtraBody) == 1 {
+		return body
+	}
+	request.ExtraBody = map[string]any{p.ExtraBodyField: request.ExtraBody}
+	return request
+}
+
+// rewriteRequestBody applies FieldRenames and UnsupportedParams to a JSON
+// request body. Bodies that are not JSON objects are returned unchanged.
+func (p *ProviderProfile) rewriteRequestBody(body any) (any, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err = json.Unmarshal(data, &fields); err != nil {
+		return body, nil //nolint:nilerr // not an object, nothing to rewrite
+	}
+
+	for _, param := range p.UnsupportedParams {
+		delete(fields, param)
+	}
+	for from, to := range p.FieldRenames {
+		value, ok := fields[from]
+		if !ok {
+			continue
+		}
+		delete(fields, from)
+		if err = setNestedField(fields, strings.Split(to, "."), value); err != nil {
+			return nil, err
+		}
+	}
+
+	data, err = json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(data), nil
+}
+
+// setNestedField sets fields[path[0]][path[1]]... to value, creating objects
+// along the way and merging into existing ones.
+func setNestedField(fields map[string]json.RawMessage, path []string, value json.RawMessage) error {
+	if len(path) == 1 {
+		fields[path[0]] = value
+		return nil
+	}
+	child := map[string]json.RawMessage{}
+	if existing, ok := fields[path[0]]; ok {
+		if err := json.Unmarshal(existing, &child); err != nil {
+			return err
+		}
+	}
+	if err := setNestedField(child, path[1:], value); err != nil {
+		return err
+	}
+	data, err := json.Marshal(child)
+	if err != nil {
+		return err
+	}
+	fields[path[0]] = data
+	return nil
+}
+
+func (p *ProviderProfile) normalizeMessageReasoning(m *ChatCompletionMessage) {
+	if p.ReasoningField == reasoningFieldReasoning && m.ReasoningContent == "" {
+		m.ReasoningContent = m.Reasoning
+	}
+}
+
+func (p *ProviderProfile) normalizeDeltaReasoning(d *ChatCompletionStreamChoiceDelta) {
+	if p.ReasoningField == reasoningFieldReasoning && d.ReasoningContent == "" {
+		d.ReasoningContent = d.Reasoning
+	}
+}
+
+// parseGoogleError decodes Google API errors, which may be wrapped in an array
+// and carry a status string: [{"error": {"code": 400, "message": "...",
+// "status": "INVALID_ARGUMENT"}}].
+func parseGoogleError(body []byte) *APIError {
+	type googleError struct {
+		Error *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+			Status  string `json:"status"`
+		} `json:"error"`
+	}
+	var wrapped []googleError
+	if err := json.Unmarshal(body, &wrapped); err != nil || len(wrapped) == 0 {
+		var single googleError
+		if err = json.Unmarshal(body, &single); err != nil {
+			return nil
+		}
+		wrapped = []googleError{single}
+	}
+	e := wrapped[0].Error
+	if e == nil || e.Message == "" {
+		return nil
+	}
+	return &APIError{Code: e.Code, Message: e.Message, Type: e.Status}
+}
+
+// parseStringError decodes errors whose error field is a bare string:
+// {"error": "model not found"}.
+func parseStringError(body []byte) *APIError {
+	var resp struct {
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil || resp.Error == "" {
+		return nil
+	}
+	return &APIError{Message: resp.Error}
+}
+
+// parseTopLevelError decodes errors that are not wrapped in an error field:
+// {"object": "error", "message": "...", "type": "BadRequestError", "code": 400}.
+func parseTopLevelError(body []byte) *APIError {
+	var resp struct {
+		Object string `json:"object"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil || resp.Object != "error" {
+		return nil
+	}
+	var apiErr APIError
+	if err := json.Unmarshal(body, &apiErr); err != nil {
+		return nil
+	}
+	return &apiErr
+}
@@ -0,0 +1,285 @@
+package openai_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gradientlabs-ai/go-openai"
+	"github.com/gradientlabs-ai/go-openai/internal/test"
+	"github.com/gradientlabs-ai/go-openai/internal/test/checks"
+)
+
+// providerContracts pairs each built-in profile with the error its error
+// fixture must decode to. Fixtures live in testdata/providers/<name>; they are
+// hand-written from each provider's documentation, not recorded traffic.
+var providerContracts = []struct {
+	profile openai.ProviderProfile
+	errMsg  string
+	errType string
+}{
+	{
+		openai.ProviderGemini,
+		`Invalid JSON payload received. Unknown name "foo": Cannot find field.`,
+		"INVALID_ARGUMENT",
+	},
+	{
+		openai.ProviderGroq,
+		"The model `test-model` does not exist or you do not have access to it.",
+		"invalid_request_error",
+	},
+	{
+		openai.ProviderCerebras,
+		"Model test-model does not exist or you do not have access to it.",
+		"not_found_error",
+	},
+	{
+		openai.ProviderOllama,
+		`model "test-model" not found, try pulling it first`,
+		"",
+	},
+	{
+		openai.ProviderVLLM,
+		"The model `test-model` does not exist.",
+		"NotFoundError",
+	},
+	{
+		openai.ProviderOpenRouter,
+		"test-model is not a valid model ID",
+		"",
+	},
+}
+
+// providerContractRequest sets every parameter that some profile renames or
+// strips.
+func providerContractRequest() openai.ChatCompletionRequest {
+	return openai.ChatCompletionRequest{
+		Model:               "test-model",
+		Messages:            []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hello!"}},
+		MaxCompletionTokens: 64,
+		FrequencyPenalty:    0.5,
+		LogitBias:           map[string]int{"50256": -100},
+		LogProbs:            true,
+		TopLogProbs:         2,
+		ReasoningEffort:     "low",
+		ServiceTier:         openai.ServiceTierDefault,
+	}
+}
+
+func readFixture(t *testing.T, dir, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	checks.NoError(t, err, "reading fixture")
+	return data
+}
+
+// checkJSONEqual compares two JSON documents, ignoring the listed top-level
+// keys of actual.
+func checkJSONEqual(t *testing.T, expected, actual []byte, ignore ...string) error {
+	t.Helper()
+	var want, got map[string]any
+	if err := json.Unmarshal(expected, &want); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(actual, &got); err != nil {
+		return err
+	}
+	for _, key := range ignore {
+		delete(got, key)
+	}
+	if !reflect.DeepEqual(want, got) {
+		return errors.New("request body does not match the fixture:\n" + string(actual))
+	}
+	return nil
+}
+
+func TestProviderContracts(t *testing.T) {
+	for _, contract := range providerContracts {
+		contract := contract
+		t.Run(contract.profile.Name, func(t *testing.T) {
+			dir := filepath.Join("testdata", "providers", contract.profile.Name)
+			expectedRequest := readFixture(t, dir, "chat_request.json")
+
+			server := test.NewTestServer()
+			server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				var request struct {
+					Model  string `json:"model"`
+					Stream bool   `json:"stream"`
+				}
+				_ = json.Unmarshal(body, &request)
+				if request.Model == "missing-model" {
+					w.WriteHeader(http.StatusBadRequest)
+					_, _ = w.Write(readFixture(t, dir, "error.json"))
+					return
+				}
+				if err := checkJSONEqual(t, expectedRequest, body, "stream"); err != nil {
+					http.Error(w, err.Error(), http.StatusTeapot)
+					return
+				}
+				if request.Stream {
+					w.Header().Set("Content-Type", "text/event-stream")
+					_, _ = w.Write(readFixture(t, dir, "chat_stream.txt"))
+					return
+				}
+				_, _ = w.Write(readFixture(t, dir, "chat_response.json"))
+			})
+			ts := server.OpenAITestServer()
+			ts.Start()
+			defer ts.Close()
+
+			config := openai.DefaultProviderConfig(contract.profile, test.GetTestToken())
+			config.BaseURL = ts.URL + "/v1"
+			client := openai.NewClientWithConfig(config)
+			ctx := context.Background()
+
+			resp, err := client.CreateChatCompletion(ctx, providerContractRequest())
+			checks.NoError(t, err, "CreateChatCompletion error")
+			message := resp.Choices[0].Message
+			if message.Content != "Hi there!" || message.ReasoningContent != "Greeting." {
+				t.Errorf("unexpected message %+v", message)
+			}
+
+			stream, err := client.CreateChatCompletionStream(ctx, providerContractRequest())
+			checks.NoError(t, err, "CreateChatCompletionStream error")
+			defer stream.Close()
+			var content, reasoning strings.Builder
+			for {
+				chunk, recvErr := stream.Recv()
+				if errors.Is(recvErr, io.EOF) {
+					break
+				}
+				checks.NoError(t, recvErr, "Recv error")
+				content.WriteString(chunk.Choices[0].Delta.Content)
+				reasoning.WriteString(chunk.Choices[0].Delta.ReasoningContent)
+			}
+			if content.String() != "Hi there!" || reasoning.String() != "Greeting." {
+				t.Errorf("unexpected stream content %q and reasoning %q", content.String(), reasoning.String())
+			}
+
+			request := providerContractRequest()
+			request.Model = "missing-model"
+			_, err = client.CreateChatCompletion(ctx, request)
+			var apiErr *openai.APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("expected an APIError, got %v", err)
+			}
+			if apiErr.Message != contract.errMsg || apiErr.Type != contract.errType ||
+				apiErr.HTTPStatusCode != http.StatusBadRequest {
+				t.Errorf("unexpected error %+v", apiErr)
+			}
+		})
+	}
+}
+
+func TestProviderAuthHeader(t *testing.T) {
+	server := test.NewTestServer()
+	server.RegisterHandler("/v1/models", handleListModelsEndpoint)
+	ts := server.OpenAITestServer()
+	ts.Start()
+	defer ts.Close()
+
+	// The test server also accepts the key in an api-key header.
+	profile := openai.ProviderProfile{Name: "custom", BaseURL: ts.URL + "/v1", AuthHeader: "api-key"}
+	client := openai.NewClientWithConfig(openai.DefaultProviderConfig(profile, test.GetTestToken()))
+	_, err := client.ListModels(context.Background())
+	checks.NoError(t, err, "ListModels error")
+
+	profile.AuthScheme = "Token"
+	client = openai.NewClientWithConfig(openai.DefaultProviderConfig(profile, test.GetTestToken()))
+	_, err = client.ListModels(context.Background())
+	checks.HasError(t, err, "the scheme should prefix the key")
+}
+
+func TestProviderExtraBodyNesting(t *testing.T) {
+	var body map[string]any
+	server := test.NewTestServer()
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		body = nil
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		_, _ = w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "Hi"}}]}`))
+	})
+	ts := server.OpenAITestServer()
+	ts.Start()
+	defer ts.Close()
+
+	thinking := map[string]any{"thinking_config": map[string]any{"include_thoughts": true}}
+	request := providerContractRequest()
+	request.ExtraBody = map[string]any{"google": thinking}
+	send := func(config openai.ClientConfig) {
+		config.BaseURL = ts.URL + "/v1"
+		_, err := openai.NewClientWithConfig(config).CreateChatCompletion(context.Background(), request)
+		checks.NoError(t, err, "CreateChatCompletion error")
+	}
+
+	// Gemini reads ExtraBody from extra_body, as it was sent before it was
+	// merged into the top level.
+	send(openai.DefaultProviderConfig(openai.ProviderGemini, test.GetTestToken()))
+	extra, _ := body["extra_body"].(map[string]any)
+	if _, ok := body["google"]; ok || !reflect.DeepEqual(extra["google"], thinking) {
+		t.Errorf("expected Gemini to get extra_body.google, got %v", body)
+	}
+
+	// Other clients get ExtraBody at the top level.
+	send(openai.DefaultConfig(test.GetTestToken()))
+	if _, ok := body["extra_body"]; ok || !reflect.DeepEqual(body["google"], thinking) {
+		t.Errorf("expected google at the top level, got %v", body)
+	}
+
+	// An ExtraBody that is already nested is not nested again.
+	request.ExtraBody = map[string]any{"extra_body": map[string]any{"google": thinking}}
+	send(openai.DefaultProviderConfig(openai.ProviderGemini, test.GetTestToken()))
+	extra, _ = body["extra_body"].(map[string]any)
+	if !reflect.DeepEqual(extra["google"], thinking) {
+		t.Errorf("expected a nested ExtraBody to be kept, got %v", body)
+	}
+}
+
+func TestProviderRewritesOnlyCompletions(t *testing.T) {
+	bodies := map[string]map[string]any{}
+	server := test.NewTestServer()
+	record := func(response string) func(http.ResponseWriter, *http.Request) {
+		return func(w http.ResponseWriter, r *http.Request) {
+			var body map[string]any
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			bodies[r.URL.Path] = body
+			_, _ = w.Write([]byte(response))
+		}
+	}
+	server.RegisterHandler("/v1/chat/completions", record(`{"choices": [{"message": {"content": "Hi"}}]}`))
+	server.RegisterHandler("/v1/batches", record(`{"id": "batch_1", "object": "batch"}`))
+	ts := server.OpenAITestServer()
+	ts.Start()
+	defer ts.Close()
+
+	config := openai.DefaultProviderConfig(openai.ProviderGemini, test.GetTestToken())
+	config.BaseURL = ts.URL + "/v1"
+	client := openai.NewClientWithConfig(config)
+	ctx := context.Background()
+
+	request := providerContractRequest()
+	request.Metadata = map[string]string{"team": "search"}
+	_, err := client.CreateChatCompletion(ctx, request)
+	checks.NoError(t, err, "CreateChatCompletion error")
+	if _, ok := bodies["/v1/chat/completions"]["metadata"]; ok {
+		t.Error("expected metadata to be removed from the chat completion request")
+	}
+
+	_, err = client.CreateBatch(ctx, openai.CreateBatchRequest{
+		InputFileID:      "file-1",
+		Endpoint:         openai.BatchEndpointChatCompletions,
+		CompletionWindow: "24h",
+		Metadata:         map[string]any{"team": "search"},
+	})
+	checks.NoError(t, err, "CreateBatch error")
+	if metadata, _ := bodies["/v1/batches"]["metadata"].(map[string]any); metadata["team"] != "search" {
+		t.Errorf("expected the batch metadata to be sent, got %v", bodies["/v1/batches"])
+	}
+}
@@ -0,0 +1,375 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var ErrRAGNoQuery = errors.New("chat completion request has no user message to retrieve context for")
+
+const (
+	defaultRAGTopK             = 4
+	defaultRAGMaxContextTokens = 2000
+	defaultRAGChunkTokens      = 400
+	defaultRAGChunkOverlap     = 50
+
+	// Metadata keys used to keep chunk provenance in the VectorIndex.
+	ragMetadataDocumentID = "rag.document_id"
+	ragMetadataChunkIndex = "rag.chunk_index"
+	ragMetadataStart      = "rag.start"
+	ragMetadataEnd        = "rag.end"
+	ragMetadataText       = "rag.text"
+)
+
+// RAGDocument is a document ingested into a RAGPipeline.
+type RAGDocument struct {
+	ID       string
+	Text     string
+	Metadata map[string]string
+}
+
+// RAGSource is a retrieved chunk of a document. Start and End are byte offsets
+// into the original document text.
+type RAGSource struct {
+	DocumentID string
+	ChunkIndex int
+	Text       string
+	Start      int
+	End        int
+	Score      float32
+	Metadata   map[string]string
+}
+
+// Retriever finds the sources most relevant to a query.
+type Retriever interface {
+	Retrieve(ctx context.Context, query string, k int) ([]RAGSource, error)
+}
+
+// RAGPromptTemplate renders the system prompt that injects the retrieved
+// sources. Sources are numbered from 1 in the order given, which is the order
+// RAGResponse.Sources reports them in.
+type RAGPromptTemplate func(sources []RAGSource) string
+
+// DefaultRAGPromptTemplate asks the model to answer from the numbered sources
+// and to cite them as [n].
+func DefaultRAGPromptTemplate(sources []RAGSource) string {
+	var sb strings.Builder
+	sb.WriteString("Answer the user's question using only the sources below. ")
+	sb.WriteString("Cite the sources you use as [n]. ")
+	sb.WriteString("If the sources do not contain the answer, say that you don't know.\n\nSources:")
+	for i, s := range sources {
+		fmt.Fprintf(&sb, "\n\n[%d] (%s)\n%s", i+1, s.DocumentID, s.Text)
+	}
+	return sb.String()
+}
+
+// VectorRetriever embeds the query with CreateEmbeddings and searches a
+// VectorIndex populated by RAGPipeline.Ingest.
+type VectorRetriever struct {
+	Client     *Client
+	Index      *VectorIndex
+	Model      EmbeddingModel
+	Dimensions int
+	// Filter optionally restricts which chunks may be returned.
+	Filter VectorFilter
+}
+
+func (r *VectorRetriever) Retrieve(ctx context.Context, query string, k int) ([]RAGSource, error) {
+	res, err := r.Client.CreateEmbeddings(ctx, EmbeddingRequestStrings{
+		Input:      []string{query},
+		Model:      r.Model,
+		Dimensions: r.Dimensions,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Data) == 0 {
+		return nil, nil
+	}
+
+	results, err := r.Index.Search(res.Data[0].Embedding, k, r.Filter)
+	if err != nil {
+		return nil, err
+	}
+	sources := make([]RAGSource, len(results))
+	for i, result := range results {
+		sources[i] = ragSourceFromRecord(result.VectorRecord, result.Score)
+	}
+	return sources, nil
+}
+
+func ragSourceFromRecord(record VectorRecord, score float32) RAGSource {
+	source := RAGSource{
+		DocumentID: record.Metadata[ragMetadataDocumentID],
+		Text:       record.Metadata[ragMetadataText],
+		Score:      score,
+		Metadata:   make(map[string]string),
+	}
+	source.ChunkIndex, _ = strconv.Atoi(record.Metadata[ragMetadataChunkIndex])
+	source.Start, _ = strconv.Atoi(record.Metadata[ragMetadataStart])
+	source.End, _ = strconv.Atoi(record.Metadata[ragMetadataEnd])
+	for k, v := range record.Metadata {
+		if !strings.HasPrefix(k, "rag.") {
+			source.Metadata[k] = v
+		}
+	}
+	return source
+}
+
+// RAGConfig configures a RAGPipeline. Zero values select defaults.
+type RAGConfig struct {
+	// EmbeddingModel is used for both ingestion and queries. Defaults to
+	// SmallEmbedding3.
+	EmbeddingModel EmbeddingModel
+	// Dimensions optionally shortens the embeddings.
+	Dimensions int
+	// Chunker splits documents on ingestion. Defaults to a TokenWindowChunker
+	// with 400-token chunks and a 50-token overlap.
+	Chunker Chunker
+	// Index stores the chunk embeddings. Defaults to a new cosine VectorIndex.
+	Index *VectorIndex
+	// Retriever finds sources for a query. Defaults to a VectorRetriever over
+	// Index.
+	Retriever Retriever
+	// Template renders the retrieved sources into a system prompt. Defaults to
+	// DefaultRAGPromptTemplate.
+	Template RAGPromptTemplate
+	// Tokenizer is used for token budgeting. Defaults to ApproximateTokenizer.
+	Tokenizer Tokenizer
+	// TopK is the number of sources retrieved per question. Defaults to 4.
+	TopK int
+	// MaxContextTokens caps the size of the rendered sources prompt. Sources
+	// that do not fit are dropped, lowest ranked first. Defaults to 2000.
+	MaxContextTokens int
+	// MaxPromptTokens optionally caps the estimated size of the whole prompt,
+	// including the caller's messages. Zero means no limit.
+	MaxPromptTokens int
+	// EmbeddingBatch controls how documents are embedded on ingestion.
+	EmbeddingBatch EmbeddingBatchOptions
+}
+
+// RAGPipeline implements retrieval-augmented chat: documents are chunked and
+// embedded into an index, and each chat request is answered with the most
+// relevant chunks injected into the system prompt.
+type RAGPipeline struct {
+	client *Client
+	config RAGConfig
+}
+
+// RAGResponse is the chat completion produced by a RAGPipeline together with
+// the sources that were injected into the prompt, in citation order.
+type RAGResponse struct {
+	ChatCompletionResponse
+
+	Sources []RAGSource
+}
+
+// Answer returns the content of the first choice.
+func (r RAGResponse) Answer() string {
+	if len(r.Choices) == 0 {
+		return ""
+	}
+	return r.Choices[0].Message.Content
+}
+
+// NewRAGPipeline creates a RAGPipeline that uses client for embeddings and
+// chat completions.
+func NewRAGPipeline(client *Client, config RAGConfig) (*RAGPipeline, error) {
+	if config.EmbeddingModel == "" {
+		config.EmbeddingModel = SmallEmbedding3
+	}
+	if config.Chunker == nil {
+		config.Chunker = TokenWindowChunker{
+			MaxTokens: defaultRAGChunkTokens,
+			Overlap:   defaultRAGChunkOverlap,
+			Tokenizer: config.Tokenizer,
+		}
+	}
+	if config.Index == nil {
+		index, err := NewVectorIndex(VectorIndexOptions{Dimensions: config.Dimensions})
+		if err != nil {
+			return nil, err
+		}
+		config.Index = index
+	}
+	if config.Retriever == nil {
+		config.Retriever = &VectorRetriever{
+			Client:     client,
+			Index:      config.Index,
+			Model:      config.EmbeddingModel,
+			Dimensions: config.Dimensions,
+		}
+	}
+	if config.Template == nil {
+		config.Template = DefaultRAGPromptTemplate
+	}
+	if config.Tokenizer == nil {
+		config.Tokenizer = ApproximateTokenizer{}
+	}
+	if config.TopK <= 0 {
+		config.TopK = defaultRAGTopK
+	}
+	if config.MaxContextTokens <= 0 {
+		config.MaxContextTokens = defaultRAGMaxContextTokens
+	}
+	if config.EmbeddingBatch.Tokenizer == nil {
+		config.EmbeddingBatch.Tokenizer = config.Tokenizer
+	}
+	return &RAGPipeline{client: client, config: config}, nil
+}
+
+// Index returns the vector index the pipeline ingests into.
+func (p *RAGPipeline) Index() *VectorIndex {
+	return p.config.Index
+}
+
+// Ingest chunks and embeds documents and adds the chunks to the index.
+// Re-ingesting a document with the same ID replaces its previous chunks.
+func (p *RAGPipeline) Ingest(ctx context.Context, documents ...RAGDocument) error {
+	var (
+		texts   []string
+		records []VectorRecord
+	)
+	for _, doc := range documents {
+		chunks, err := p.config.Chunker.Chunk(doc.Text)
+		if err != nil {
+			return fmt.Errorf("chunking document %q: %w", doc.ID, err)
+		}
+		for i, chunk := range chunks {
+			metadata := copyMetadata(doc.Metadata)
+			if metadata == nil {
+				metadata = make(map[string]string)
+			}
+			for k, v := range chunk.Metadata {
+				metadata[k] = v
+			}
+			metadata[ragMetadataDocumentID] = doc.ID
+			metadata[ragMetadataChunkIndex] = strconv.Itoa(i)
+			metadata[ragMetadataStart] = strconv.Itoa(chunk.Start)
+			metadata[ragMetadataEnd] = strconv.Itoa(chunk.End)
+			metadata[ragMetadataText] = chunk.Text
+			texts = append(texts, chunk.Text)
+			records = append(records, VectorRecord{ID: fmt.Sprintf("%s#%d", doc.ID, i), Metadata: metadata})
+		}
+	}
+	if len(texts) == 0 {
+		return nil
+	}
+
+	res, err := p.client.CreateEmbeddingsBatched(ctx, EmbeddingRequestStrings{
+		Input:      texts,
+		Model:      p.config.EmbeddingModel,
+		Dimensions: p.config.Dimensions,
+	}, p.config.EmbeddingBatch)
+	if err != nil {
+		return err
+	}
+	for i := range records {
+		records[i].Vector = res.Data[i].Embedding
+	}
+	for _, doc := range documents {
+		p.deleteDocumentChunks(doc.ID)
+	}
+	return p.config.Index.Add(records...)
+}
+
+func (p *RAGPipeline) deleteDocumentChunks(documentID string) {
+	for i := 0; ; i++ {
+		if !p.config.Index.Delete(fmt.Sprintf("%s#%d", documentID, i)) {
+			return
+		}
+	}
+}
+
+// Retrieve returns the sources the pipeline would use for query.
+func (p *RAGPipeline) Retrieve(ctx context.Context, query string) ([]RAGSource, error) {
+	return p.config.Retriever.Retrieve(ctx, query, p.config.TopK)
+}
+
+// CreateChatCompletion answers request with retrieved context. The last user
+// message is used as the retrieval query, and the selected sources are
+// rendered with the template into a system prompt. If the request already
+// starts with a system or developer message, the sources are appended to it.
+func (p *RAGPipeline) CreateChatCompletion(
+	ctx context.Context,
+	request ChatCompletionRequest,
+) (response RAGResponse, err error) {
+	query := lastUserMessageText(request.Messages)
+	if query == "" {
+		err = ErrRAGNoQuery
+		return
+	}
+
+	candidates, err := p.Retrieve(ctx, query)
+	if err != nil {
+		return
+	}
+
+	sources, messages := p.assemblePrompt(request.Messages, candidates)
+	request.Messages = messages
+	response.Sources = sources
+	response.ChatCompletionResponse, err = p.client.CreateChatCompletion(ctx, request)
+	return
+}
+
+// assemblePrompt greedily selects the highest ranked candidates that fit the
+// token budgets and returns them with the messages to send.
+func (p *RAGPipeline) assemblePrompt(
+	original []ChatCompletionMessage,
+	candidates []RAGSource,
+) ([]RAGSource, []ChatCompletionMessage) {
+	var selected []RAGSource
+	messages := injectRAGPrompt(original, p.config.Template(nil))
+	for _, candidate := range candidates {
+		trial := append(append([]RAGSource(nil), selected...), candidate)
+		prompt := p.config.Template(trial)
+		if p.config.Tokenizer.CountTokens(prompt) > p.config.MaxContextTokens {
+			continue
+		}
+		trialMessages := injectRAGPrompt(original, prompt)
+		if p.config.MaxPromptTokens > 0 &&
+			CountMessageTokens(p.config.Tokenizer, trialMessages) > p.config.MaxPromptTokens {
+			continue
+		}
+		selected, messages = trial, trialMessages
+	}
+	return selected, messages
+}
+
+// injectRAGPrompt returns a copy of messages with prompt added to the leading
+// system or developer message, or prepended as a new system message.
+func injectRAGPrompt(messages []ChatCompletionMessage, prompt string) []ChatCompletionMessage {
+	out := make([]ChatCompletionMessage, 0, len(messages)+1)
+	if len(messages) > 0 && messages[0].MultiContent == nil &&
+		(messages[0].Role == ChatMessageRoleSystem || messages[0].Role == ChatMessageRoleDeveloper) {
+		first := messages[0]
+		first.Content = first.Content + "\n\n" + prompt
+		out = append(out, first)
+		return append(out, messages[1:]...)
+	}
+	out = append(out, ChatCompletionMessage{Role: ChatMessageRoleSystem, Content: prompt})
+	return append(out, messages...)
+}
+
+func lastUserMessageText(messages []ChatCompletionMessage) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		m := messages[i]
+		if m.Role != ChatMessageRoleUser {
+			continue
+		}
+		if m.Content != "" {
+			return m.Content
+		}
+		var parts []string
+		for _, part := range m.MultiContent {
+			if part.Type == ChatMessagePartTypeText {
+				parts = append(parts, part.Text)
+			}
+		}
+		return strings.Join(parts, "\n")
+	}
+	return ""
+}
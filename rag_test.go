@@ -0,0 +1,180 @@
+package openai_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gradientlabs-ai/go-openai"
+	"github.com/gradientlabs-ai/go-openai/internal/test"
+	"github.com/gradientlabs-ai/go-openai/internal/test/checks"
+)
+
+// keywordEmbedding maps text onto a tiny vector space keyed by topic, so that
+// retrieval results in tests are predictable.
+func keywordEmbedding(text string) []float32 {
+	text = strings.ToLower(text)
+	return []float32{
+		float32(strings.Count(text, "cat")),
+		float32(strings.Count(text, "dog")),
+		float32(strings.Count(text, "bird")) + 0.01,
+	}
+}
+
+func registerRAGHandlers(server *test.ServerTest, chatRequests *[]openai.ChatCompletionRequest) {
+	server.RegisterHandler("/v1/embeddings", func(w http.ResponseWriter, r *http.Request) {
+		var req openai.EmbeddingRequestStrings
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		res := openai.EmbeddingResponse{}
+		for i, text := range req.Input {
+			res.Data = append(res.Data, openai.Embedding{Embedding: keywordEmbedding(text), Index: i})
+		}
+		resBytes, _ := json.Marshal(res)
+		_, _ = w.Write(resBytes)
+	})
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		var req openai.ChatCompletionRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		*chatRequests = append(*chatRequests, req)
+		resBytes, _ := json.Marshal(openai.ChatCompletionResponse{
+			Choices: []openai.ChatCompletionChoice{{Message: openai.ChatCompletionMessage{
+				Role:    openai.ChatMessageRoleAssistant,
+				Content: "Cats purr [1].",
+			}}},
+		})
+		_, _ = w.Write(resBytes)
+	})
+}
+
+func TestRAGPipeline(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	var chatRequests []openai.ChatCompletionRequest
+	registerRAGHandlers(server, &chatRequests)
+
+	pipeline, err := openai.NewRAGPipeline(client, openai.RAGConfig{TopK: 2})
+	checks.NoError(t, err, "NewRAGPipeline error")
+
+	ctx := context.Background()
+	err = pipeline.Ingest(ctx,
+		openai.RAGDocument{
+			ID:       "cats",
+			Text:     "The cat purrs when the cat is happy.",
+			Metadata: map[string]string{"lang": "en"},
+		},
+		openai.RAGDocument{ID: "dogs", Text: "A dog barks at the mail carrier."},
+		openai.RAGDocument{ID: "birds", Text: "Birds sing at dawn."},
+	)
+	checks.NoError(t, err, "Ingest error")
+	if pipeline.Index().Len() != 3 {
+		t.Fatalf("expected 3 chunks, got %d", pipeline.Index().Len())
+	}
+
+	res, err := pipeline.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: openai.GPT4oMini,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: "You are a pet expert."},
+			{Role: openai.ChatMessageRoleUser, Content: "Why does my cat purr?"},
+		},
+	})
+	checks.NoError(t, err, "CreateChatCompletion error")
+
+	if res.Answer() != "Cats purr [1]." {
+		t.Errorf("unexpected answer %q", res.Answer())
+	}
+	if len(res.Sources) != 2 || res.Sources[0].DocumentID != "cats" {
+		t.Fatalf("unexpected sources %+v", res.Sources)
+	}
+	source := res.Sources[0]
+	if source.Text != "The cat purrs when the cat is happy." || source.Start != 0 || source.End != len(source.Text) {
+		t.Errorf("unexpected source chunk %+v", source)
+	}
+	if source.Metadata["lang"] != "en" {
+		t.Errorf("document metadata not propagated: %+v", source.Metadata)
+	}
+
+	sent := chatRequests[0].Messages
+	if len(sent) != 2 || sent[0].Role != openai.ChatMessageRoleSystem {
+		t.Fatalf("unexpected messages sent %+v", sent)
+	}
+	if !strings.HasPrefix(sent[0].Content, "You are a pet expert.") ||
+		!strings.Contains(sent[0].Content, "[1] (cats)\nThe cat purrs") {
+		t.Errorf("sources not injected into the system prompt: %q", sent[0].Content)
+	}
+}
+
+func TestRAGPipelineTokenBudget(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	var chatRequests []openai.ChatCompletionRequest
+	registerRAGHandlers(server, &chatRequests)
+
+	template := func(sources []openai.RAGSource) string {
+		var parts []string
+		for _, s := range sources {
+			parts = append(parts, s.Text)
+		}
+		return strings.Join(parts, "\n")
+	}
+	pipeline, err := openai.NewRAGPipeline(client, openai.RAGConfig{
+		TopK:             3,
+		Template:         template,
+		Tokenizer:        wordTokenizer{},
+		MaxContextTokens: 7,
+	})
+	checks.NoError(t, err, "NewRAGPipeline error")
+
+	ctx := context.Background()
+	checks.NoError(t, pipeline.Ingest(ctx,
+		openai.RAGDocument{ID: "a", Text: "cat cat cat facts"},
+		openai.RAGDocument{ID: "b", Text: "cat and dog facts here"},
+		openai.RAGDocument{ID: "c", Text: "cat"},
+	), "Ingest error")
+
+	res, err := pipeline.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model:    openai.GPT4oMini,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "cat"}},
+	})
+	checks.NoError(t, err, "CreateChatCompletion error")
+
+	var ids []string
+	for _, s := range res.Sources {
+		ids = append(ids, s.DocumentID)
+	}
+	// Adding "b" would take the prompt to 10 words, over the budget of 7.
+	if strings.Join(ids, ",") != "c,a" {
+		t.Errorf("unexpected sources within budget: %v", ids)
+	}
+	if sent := chatRequests[0].Messages; len(sent) != 2 || sent[0].Content != "cat\ncat cat cat facts" {
+		t.Errorf("unexpected prompt %+v", sent)
+	}
+}
+
+func TestRAGPipelineReingest(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	var chatRequests []openai.ChatCompletionRequest
+	registerRAGHandlers(server, &chatRequests)
+
+	pipeline, err := openai.NewRAGPipeline(client, openai.RAGConfig{
+		Chunker: openai.TokenWindowChunker{MaxTokens: 1, Tokenizer: wordTokenizer{}},
+	})
+	checks.NoError(t, err, "NewRAGPipeline error")
+
+	ctx := context.Background()
+	checks.NoError(t, pipeline.Ingest(ctx, openai.RAGDocument{ID: "doc", Text: "one two three four"}))
+	if pipeline.Index().Len() != 4 {
+		t.Fatalf("expected 4 chunks, got %d", pipeline.Index().Len())
+	}
+	checks.NoError(t, pipeline.Ingest(ctx, openai.RAGDocument{ID: "doc", Text: "one"}))
+	if pipeline.Index().Len() != 1 {
+		t.Fatalf("expected re-ingest to replace old chunks, got %d", pipeline.Index().Len())
+	}
+
+	_, err = pipeline.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleSystem, Content: "hi"}},
+	})
+	checks.ErrorIs(t, err, openai.ErrRAGNoQuery, "a request without a user message should fail")
+}
@@ -0,0 +1,244 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+)
+
+// IdempotencyKeyHeader is the header set by WithIdempotencyKey.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// RequestOption customizes a single API call. Every Client method accepts
+// options as trailing arguments:
+//
+//	resp, err := client.CreateChatCompletion(ctx, req,
+//		openai.WithExtraBody(map[string]any{"top_k": 40}),
+//		openai.WithTimeout(30*time.Second),
+//	)
+type RequestOption func(*requestOptions)
+
+// WithExtraBody merges fields into the top level of the request body,
+// overriding fields of the same name. JSON bodies get the values as JSON;
+// multipart bodies get them as form fields, with strings sent verbatim and
+// other values JSON encoded.
+func WithExtraBody(fields map[string]any) RequestOption {
+	return func(args *requestOptions) {
+		if args.extraBody == nil {
+			args.extraBody = make(map[string]any, len(fields))
+		}
+		for k, v := range fields {
+			args.extraBody[k] = v
+		}
+	}
+}
+
+// WithExtraHeader sets a header on the request. It is applied after the
+// client's own headers, so it can override them.
+func WithExtraHeader(key, value string) RequestOption {
+	return func(args *requestOptions) {
+		args.extraHeader.Set(key, value)
+	}
+}
+
+// WithExtraQuery adds a query parameter to the request URL.
+func WithExtraQuery(key, value string) RequestOption {
+	return func(args *requestOptions) {
+		if args.extraQuery == nil {
+			args.extraQuery = make(url.Values)
+		}
+		args.extraQuery.Add(key, value)
+	}
+}
+
+// WithTimeout bounds the call, including reading a streamed response, to d.
+func WithTimeout(d time.Duration) RequestOption {
+	return func(args *requestOptions) {
+		args.timeout = d
+	}
+}
+
+// WithIdempotencyKey sends key in the Idempotency-Key header so that a
+// retried request is not applied twice by servers that support it.
+func WithIdempotencyKey(key string) RequestOption {
+	return func(args *requestOptions) {
+		args.extraHeader.Set(IdempotencyKeyHeader, key)
+	}
+}
+
+func withRequestOptions(opts []RequestOption) requestOption {
+	return func(args *requestOptions) {
+		for _, opt := range opts {
+			opt(args)
+		}
+	}
+}
+
+type requestCancelKey struct{}
+
+// withTimeout returns ctx bounded by d. Its CancelFunc is kept in the context
+// rather than called when newRequest returns, since the caller may still be
+// reading the response body then; releaseRequest calls it once the response
+// is done with.
+func withTimeout(ctx context.Context, d time.Duration) context.Context {
+	if d <= 0 {
+		return ctx
+	}
+	ctx, cancel := context.WithTimeout(ctx, d)
+	return context.WithValue(ctx, requestCancelKey{}, cancel)
+}
+
+// releaseRequest releases the timeout withTimeout put on ctx, if any.
+func releaseRequest(ctx context.Context) {
+	if cancel, ok := ctx.Value(requestCancelKey{}).(context.CancelFunc); ok {
+		cancel()
+	}
+}
+
+// releasingBody releases the timeout of a request once its response body is
+// closed: after decoding, or when a raw response or stream is closed.
+type releasingBody struct {
+	io.ReadCloser
+	ctx context.Context
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	releaseRequest(b.ctx)
+	return err
+}
+
+// applyExtraBody merges args.extraBody into the request body.
+func (args *requestOptions) applyExtraBody() error {
+	if len(args.extraBody) == 0 || args.body == nil {
+		return nil
+	}
+	reader, isReader := args.body.(io.Reader)
+	if !isReader {
+		data, err := json.Marshal(args.body)
+		if err != nil {
+			return err
+		}
+		data, err = mergeJSONFields(data, args.extraBody)
+		if err != nil {
+			return err
+		}
+		args.body = json.RawMessage(data)
+		return nil
+	}
+	mediaType, params, err := mime.ParseMediaType(args.header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/form-data" {
+		return nil //nolint:nilerr // only form bodies can take extra fields
+	}
+	body, err := mergeFormFields(reader, params["boundary"], args.extraBody)
+	if err != nil {
+		return err
+	}
+	args.body = body
+	return nil
+}
+
+// applyExtras sets the extra headers and query parameters on req.
+func (args *requestOptions) applyExtras(req *http.Request) {
+	for key, values := range args.extraHeader {
+		req.Header[key] = values
+	}
+	if len(args.extraQuery) == 0 {
+		return
+	}
+	query := req.URL.Query()
+	for key, values := range args.extraQuery {
+		for _, value := range values {
+			query.Add(key, value)
+		}
+	}
+	req.URL.RawQuery = query.Encode()
+}
+
+// mergeJSONFields sets fields on the JSON object data.
+func mergeJSONFields(data []byte, fields map[string]any) ([]byte, error) {
+	if len(fields) == 0 {
+		return data, nil
+	}
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, fmt.Errorf("extra body fields need a JSON object body: %w", err)
+	}
+	for key, value := range fields {
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("encoding extra body field %q: %w", key, err)
+		}
+		object[key] = raw
+	}
+	return json.Marshal(object)
+}
+
+// mergeFormFields rewrites a multipart form, replacing or adding fields. The
+// boundary is kept so the request's Content-Type stays valid.
+func mergeFormFields(body io.Reader, boundary string, fields map[string]any) (io.Reader, error) {
+	var out bytes.Buffer
+	writer := multipart.NewWriter(&out)
+	if err := writer.SetBoundary(boundary); err != nil {
+		return nil, err
+	}
+
+	reader := multipart.NewReader(body, boundary)
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if _, replaced := fields[part.FormName()]; replaced {
+			continue
+		}
+		dst, err := writer.CreatePart(part.Header)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = io.Copy(dst, part); err != nil {
+			return nil, err
+		}
+	}
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := fields[key]
+		text, ok := value.(string)
+		if !ok {
+			raw, err := json.Marshal(value)
+			if err != nil {
+				return nil, fmt.Errorf("encoding extra body field %q: %w", key, err)
+			}
+			// A value that encodes as a JSON string is sent unquoted and
+			// unescaped; anything else is sent as its JSON text.
+			text = string(raw)
+			var unquoted string
+			if json.Unmarshal(raw, &unquoted) == nil {
+				text = unquoted
+			}
+		}
+		if err := writer.WriteField(key, text); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
@@ -0,0 +1,201 @@
+package openai_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/gradientlabs-ai/go-openai"
+	"github.com/gradientlabs-ai/go-openai/internal/test"
+	"github.com/gradientlabs-ai/go-openai/internal/test/checks"
+)
+
+// startRequestOptionsServer serves handler at path and returns a client for it.
+func startRequestOptionsServer(
+	t *testing.T,
+	path string,
+	handler func(http.ResponseWriter, *http.Request),
+) *openai.Client {
+	t.Helper()
+	server := test.NewTestServer()
+	server.RegisterHandler(path, handler)
+	ts := server.OpenAITestServer()
+	ts.Start()
+	t.Cleanup(ts.Close)
+	config := openai.DefaultConfig(test.GetTestToken())
+	config.BaseURL = ts.URL + "/v1"
+	return openai.NewClientWithConfig(config)
+}
+
+func checkExtras(r *http.Request) error {
+	if got := r.Header.Get("X-Trace"); got != "abc" {
+		return fmt.Errorf("unexpected X-Trace header %q", got)
+	}
+	if got := r.Header.Get(openai.IdempotencyKeyHeader); got != "req-1" {
+		return fmt.Errorf("unexpected idempotency key %q", got)
+	}
+	if got := r.URL.Query().Get("tenant"); got != "t1" {
+		return fmt.Errorf("unexpected tenant query %q", got)
+	}
+	return nil
+}
+
+var extraOptions = []openai.RequestOption{
+	openai.WithExtraHeader("X-Trace", "abc"),
+	openai.WithIdempotencyKey("req-1"),
+	openai.WithExtraQuery("tenant", "t1"),
+}
+
+func TestRequestOptionsJSON(t *testing.T) {
+	client := startRequestOptionsServer(t, "/v1/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		if err := checkExtras(r); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if _, ok := body["extra_body"]; ok || body["top_k"] != float64(40) || body["repetition_penalty"] != 1.1 ||
+			body["model"] != "override-model" {
+			http.Error(w, fmt.Sprintf("extra body not merged: %v", body), http.StatusBadRequest)
+			return
+		}
+		if body["stream"] == true {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"ok\"}}]}\n\ndata: [DONE]\n\n")
+			return
+		}
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"ok"}}]}`)
+	})
+
+	request := openai.ChatCompletionRequest{
+		Model:     openai.GPT4oMini,
+		Messages:  []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hello!"}},
+		ExtraBody: map[string]any{"top_k": 40},
+	}
+	opts := append([]openai.RequestOption{
+		openai.WithExtraBody(map[string]any{"repetition_penalty": 1.1, "model": "override-model"}),
+	}, extraOptions...)
+
+	_, err := client.CreateChatCompletion(context.Background(), request, opts...)
+	checks.NoError(t, err, "CreateChatCompletion error")
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), request, opts...)
+	checks.NoError(t, err, "CreateChatCompletionStream error")
+	defer stream.Close()
+	resp, err := stream.Recv()
+	checks.NoError(t, err, "Recv error")
+	if resp.Choices[0].Delta.Content != "ok" {
+		t.Errorf("unexpected chunk %+v", resp)
+	}
+}
+
+// formLabel is not a plain string, so WithExtraBody encodes it as JSON.
+type formLabel string
+
+func TestRequestOptionsMultipart(t *testing.T) {
+	client := startRequestOptionsServer(t, "/v1/files", func(w http.ResponseWriter, r *http.Request) {
+		if err := checkExtras(r); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		content, _ := io.ReadAll(file)
+		if string(content) != "hello" || r.FormValue("purpose") != "batch" ||
+			r.FormValue("expires_after[seconds]") != "3600" || r.FormValue("note") != "extra" ||
+			r.FormValue("label") != "say \"hi\"\nto café" {
+			http.Error(w, fmt.Sprintf("unexpected form %v", r.MultipartForm.Value), http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, `{"id":"file-1","object":"file","purpose":"batch"}`)
+	})
+
+	opts := append([]openai.RequestOption{
+		openai.WithExtraBody(map[string]any{
+			"purpose":                "batch",
+			"expires_after[seconds]": 3600,
+			"note":                   "extra",
+			"label":                  formLabel("say \"hi\"\nto café"),
+		}),
+	}, extraOptions...)
+	file, err := client.CreateFileBytes(context.Background(), openai.FileBytesRequest{
+		Name:    "data.jsonl",
+		Bytes:   []byte("hello"),
+		Purpose: openai.PurposeFineTune,
+	}, opts...)
+	checks.NoError(t, err, "CreateFileBytes error")
+	if file.ID != "file-1" {
+		t.Errorf("unexpected file %+v", file)
+	}
+}
+
+func TestRequestOptionsTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	client := startRequestOptionsServer(t, "/v1/models", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	})
+
+	_, err := client.ListModels(context.Background(), openai.WithTimeout(20*time.Millisecond))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected a deadline error, got %v", err)
+	}
+}
+
+func TestRequestOptionsTimeoutReleasedWithResponse(t *testing.T) {
+	client := startRequestOptionsServer(t, "/v1/models", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `{"object":"list","data":[]}`)
+	})
+	ctx := context.Background()
+	_, err := client.ListModels(ctx, openai.WithTimeout(time.Hour))
+	checks.NoError(t, err, "ListModels error")
+
+	// A finished call must not leave anything waiting for its timeout.
+	before := runtime.NumGoroutine()
+	for i := 0; i < 50; i++ {
+		_, err = client.ListModels(ctx, openai.WithTimeout(time.Hour))
+		checks.NoError(t, err, "ListModels error")
+	}
+	if after := runtime.NumGoroutine(); after >= before+50 {
+		t.Fatalf("expected calls with a timeout not to leave goroutines behind, got %d then %d", before, after)
+	}
+}
+
+func TestListFineTuningJobEventsRequestOptions(t *testing.T) {
+	client := startRequestOptionsServer(t, "/v1/fine_tuning/jobs/ftjob-1/events",
+		func(w http.ResponseWriter, r *http.Request) {
+			if err := checkExtras(r); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if r.URL.Query().Get("limit") != "5" {
+				http.Error(w, "missing limit", http.StatusBadRequest)
+				return
+			}
+			fmt.Fprint(w, `{"object":"list","data":[]}`)
+		})
+
+	_, err := client.ListFineTuningJobEvents(context.Background(), "ftjob-1",
+		openai.ListFineTuningJobEventsWithLimit(5),
+		openai.ListFineTuningJobEventsWithRequestOptions(extraOptions...))
+	checks.NoError(t, err, "ListFineTuningJobEvents error")
+}
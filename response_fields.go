@@ -0,0 +1,208 @@
+package openai
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrExtensionFieldNotFound is returned by DecodeExtensionField when the
+// response has no field at the requested path.
+var ErrExtensionFieldNotFound = errors.New("extension field not found")
+
+// RawJSONResponse is implemented by every JSON response type, including
+// stream chunks. It gives access to fields this library does not model yet.
+type RawJSONResponse interface {
+	// RawJSON returns the response body as received.
+	RawJSON() json.RawMessage
+	// UnknownFields returns the top-level fields of the response that have no
+	// matching struct field, keyed by their JSON name.
+	UnknownFields() map[string]json.RawMessage
+}
+
+// rawJSON is embedded in response types to keep the body they were decoded
+// from.
+type rawJSON struct {
+	raw     json.RawMessage
+	unknown *unknownJSON
+}
+
+// unknownJSON finds the unknown fields of a response the first time they are
+// asked for, since decoding the body again for every response and stream
+// chunk would cost more than the few callers that read them.
+type unknownJSON struct {
+	once   sync.Once
+	t      reflect.Type
+	fields map[string]json.RawMessage
+}
+
+func (r *rawJSON) RawJSON() json.RawMessage {
+	return r.raw
+}
+
+func (r *rawJSON) UnknownFields() map[string]json.RawMessage {
+	if r.unknown == nil {
+		return nil
+	}
+	r.unknown.once.Do(func() {
+		r.unknown.fields = unknownJSONFields(r.raw, r.unknown.t)
+	})
+	return r.unknown.fields
+}
+
+func (r *rawJSON) setRawJSON(data []byte, t reflect.Type) {
+	r.raw = data
+	r.unknown = &unknownJSON{t: t}
+}
+
+type rawJSONSetter interface {
+	setRawJSON(data []byte, t reflect.Type)
+}
+
+// unmarshalResponse decodes data into v and, if v embeds rawJSON, records the
+// raw body so that its unknown fields can be found later.
+func unmarshalResponse(data []byte, v any, unmarshal func([]byte, any) error) error {
+	if err := unmarshal(data, v); err != nil {
+		return err
+	}
+	setter, ok := v.(rawJSONSetter)
+	if !ok {
+		return nil
+	}
+	setter.setRawJSON(data, reflect.TypeOf(v))
+	return nil
+}
+
+// unknownJSONFields returns the top-level fields of the JSON object data that
+// encoding/json would not decode into a value of type t.
+func unknownJSONFields(data []byte, t reflect.Type) map[string]json.RawMessage {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil
+	}
+	known := knownJSONFields(t)
+	var unknown map[string]json.RawMessage
+	for key, value := range object {
+		if known[strings.ToLower(key)] {
+			continue
+		}
+		if unknown == nil {
+			unknown = make(map[string]json.RawMessage)
+		}
+		unknown[key] = value
+	}
+	return unknown
+}
+
+var knownJSONFieldsCache sync.Map // reflect.Type -> map[string]bool
+
+// knownJSONFields returns the lower-cased JSON names of the fields of struct
+// type t, following embedded structs the way encoding/json does.
+func knownJSONFields(t reflect.Type) map[string]bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if cached, ok := knownJSONFieldsCache.Load(t); ok {
+		return cached.(map[string]bool)
+	}
+	fields := make(map[string]bool)
+	if t.Kind() == reflect.Struct {
+		collectJSONFields(t, fields)
+	}
+	knownJSONFieldsCache.Store(t, fields)
+	return fields
+}
+
+func collectJSONFields(t reflect.Type, fields map[string]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				collectJSONFields(embedded, fields)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[strings.ToLower(name)] = true
+	}
+}
+
+// DecodeExtensionField decodes the field at path in the raw response JSON
+// into T. Path elements are object keys, or indices for arrays, so a field the
+// library does not model yet can be read with e.g.
+//
+//	citations, err := openai.DecodeExtensionField[[]string](&resp, "citations")
+//	reasoning, err := openai.DecodeExtensionField[string](&resp, "choices", "0", "message", "reasoning")
+//
+// It returns ErrExtensionFieldNotFound if the path does not exist.
+func DecodeExtensionField[T any](resp RawJSONResponse, path ...string) (T, error) {
+	var value T
+	current := resp.RawJSON()
+	if len(path) == 1 {
+		// Unknown fields are already split out, skip decoding the whole body.
+		if raw, ok := resp.UnknownFields()[path[0]]; ok {
+			current, path = raw, nil
+		}
+	}
+	for i, key := range path {
+		next, err := jsonChild(current, key)
+		if err != nil {
+			return value, fmt.Errorf("%w: %s", err, strings.Join(path[:i+1], "."))
+		}
+		current = next
+	}
+	if current == nil {
+		return value, ErrExtensionFieldNotFound
+	}
+	if err := json.Unmarshal(current, &value); err != nil {
+		return value, fmt.Errorf("decoding extension field %s: %w", strings.Join(path, "."), err)
+	}
+	return value, nil
+}
+
+// jsonChild returns the member key of a JSON object, or the element at index
+// key of a JSON array.
+func jsonChild(data json.RawMessage, key string) (json.RawMessage, error) {
+	trimmed := strings.TrimSpace(string(data))
+	if strings.HasPrefix(trimmed, "[") {
+		index, err := strconv.Atoi(key)
+		if err != nil {
+			return nil, ErrExtensionFieldNotFound
+		}
+		var elements []json.RawMessage
+		if err = json.Unmarshal(data, &elements); err != nil {
+			return nil, err
+		}
+		if index < 0 || index >= len(elements) {
+			return nil, ErrExtensionFieldNotFound
+		}
+		return elements[index], nil
+	}
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, ErrExtensionFieldNotFound
+	}
+	child, ok := object[key]
+	if !ok {
+		return nil, ErrExtensionFieldNotFound
+	}
+	return child, nil
+}
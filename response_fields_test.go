@@ -0,0 +1,105 @@
+package openai_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/gradientlabs-ai/go-openai"
+	"github.com/gradientlabs-ai/go-openai/internal/test/checks"
+)
+
+const extendedChatResponse = `{
+	"id": "chatcmpl-1",
+	"object": "chat.completion",
+	"model": "sonar",
+	"choices": [{"index": 0, "message": {"role": "assistant", "content": "Hi",
+		"annotations_v2": [{"kind": "url", "url": "https://example.com"}]}}],
+	"usage": {"prompt_tokens": 1, "completion_tokens": 1, "total_tokens": 2},
+	"citations": ["https://example.com"],
+	"search_results": {"count": 1}
+}`
+
+func TestResponseUnknownFields(t *testing.T) {
+	client := startRequestOptionsServer(t, "/v1/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, extendedChatResponse)
+	})
+	resp, err := client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{
+		Model:    openai.GPT4oMini,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hello!"}},
+	})
+	checks.NoError(t, err, "CreateChatCompletion error")
+
+	if string(resp.RawJSON()) != extendedChatResponse {
+		t.Errorf("unexpected raw JSON %s", resp.RawJSON())
+	}
+	unknown := resp.UnknownFields()
+	if len(unknown) != 2 || unknown["citations"] == nil || unknown["search_results"] == nil {
+		t.Errorf("unexpected unknown fields %v", unknown)
+	}
+
+	citations, err := openai.DecodeExtensionField[[]string](&resp, "citations")
+	checks.NoError(t, err, "DecodeExtensionField error")
+	if len(citations) != 1 || citations[0] != "https://example.com" {
+		t.Errorf("unexpected citations %v", citations)
+	}
+
+	type annotation struct {
+		Kind string `json:"kind"`
+		URL  string `json:"url"`
+	}
+	annotations, err := openai.DecodeExtensionField[[]annotation](&resp, "choices", "0", "message", "annotations_v2")
+	checks.NoError(t, err, "DecodeExtensionField error")
+	if len(annotations) != 1 || annotations[0].Kind != "url" {
+		t.Errorf("unexpected annotations %v", annotations)
+	}
+
+	_, err = openai.DecodeExtensionField[string](&resp, "choices", "1", "message")
+	checks.ErrorIs(t, err, openai.ErrExtensionFieldNotFound, "out of range index")
+	_, err = openai.DecodeExtensionField[string](&resp, "missing")
+	checks.ErrorIs(t, err, openai.ErrExtensionFieldNotFound, "missing field")
+	_, err = openai.DecodeExtensionField[string](&resp, "citations")
+	checks.HasError(t, err, "type mismatch")
+}
+
+func TestStreamResponseUnknownFields(t *testing.T) {
+	client := startRequestOptionsServer(t, "/v1/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, `data: {"id":"1","choices":[{"index":0,"delta":{"content":"Hi"}}],"x_groq":{"id":"req_1"}}`+"\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	})
+	stream, err := client.CreateChatCompletionStream(context.Background(), openai.ChatCompletionRequest{
+		Model:    openai.GPT4oMini,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hello!"}},
+	})
+	checks.NoError(t, err, "CreateChatCompletionStream error")
+	defer stream.Close()
+
+	chunk, err := stream.Recv()
+	checks.NoError(t, err, "Recv error")
+	id, err := openai.DecodeExtensionField[string](&chunk, "x_groq", "id")
+	checks.NoError(t, err, "DecodeExtensionField error")
+	if id != "req_1" || len(chunk.UnknownFields()) != 1 {
+		t.Errorf("unexpected extension id %q, unknown fields %v", id, chunk.UnknownFields())
+	}
+
+	_, err = stream.Recv()
+	if !errors.Is(err, io.EOF) {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+}
+
+func TestListResponseUnknownFields(t *testing.T) {
+	client := startRequestOptionsServer(t, "/v1/models", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"object":"list","data":[{"id":"m1","object":"model"}],"next_page":"abc"}`)
+	})
+	models, err := client.ListModels(context.Background())
+	checks.NoError(t, err, "ListModels error")
+	// ModelsList does not model the object field either.
+	if _, ok := models.UnknownFields()["next_page"]; !ok || len(models.UnknownFields()) != 2 {
+		t.Errorf("unexpected unknown fields %v", models.UnknownFields())
+	}
+}
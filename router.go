@@ -0,0 +1,395 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	ErrRouterNoBackends      = errors.New("router needs at least one backend")
+	ErrRouterUnknownBackend  = errors.New("route target refers to an unknown backend")
+	ErrRouterDuplicateTarget = errors.New("route lists the same backend and model twice")
+)
+
+// RouterStrategy decides the order in which a Router tries the targets of a
+// route.
+type RouterStrategy int
+
+const (
+	// RouterStrategyPriority tries targets in the order they are listed.
+	RouterStrategyPriority RouterStrategy = iota
+	// RouterStrategyWeightedRoundRobin spreads requests over the targets in
+	// proportion to their weights. The remaining targets are fallbacks in
+	// listed order.
+	RouterStrategyWeightedRoundRobin
+	// RouterStrategyLatency tries the target with the lowest recent latency
+	// first. Targets without samples are tried before measured ones so that
+	// every target gets measured.
+	RouterStrategyLatency
+)
+
+// RouterBackend is a client the router can send requests to.
+type RouterBackend struct {
+	Name   string
+	Client *Client
+}
+
+// RouteTarget is one place a request can be sent to.
+type RouteTarget struct {
+	// Backend is the name of a RouterBackend.
+	Backend string
+	// Model replaces the requested model. Empty keeps it, which lets Azure
+	// backends map it to a deployment as usual.
+	Model string
+	// Weight is used by RouterStrategyWeightedRoundRobin. Zero means 1.
+	Weight int
+}
+
+// RouteRule lists the targets for a requested model.
+type RouteRule struct {
+	Targets []RouteTarget
+	// ContextOverflow targets, e.g. a model with a larger context window,
+	// are tried in order once a target rejects the request for exceeding its
+	// context length.
+	ContextOverflow []RouteTarget
+}
+
+// RouteAttempt describes one attempt made by a Router.
+type RouteAttempt struct {
+	Backend string
+	Model   string
+	Latency time.Duration
+	// Err is nil if the attempt succeeded.
+	Err error
+}
+
+// RouterConfig configures a Router.
+type RouterConfig struct {
+	Backends []RouterBackend
+	// Rules maps requested models to their routes. Models without a rule are
+	// sent to every backend in order, unchanged.
+	Rules    map[string]RouteRule
+	Strategy RouterStrategy
+
+	// CircuitBreaker configures the breaker kept for each backend. Backends
+	// with an open breaker are skipped.
+	CircuitBreaker CircuitBreakerConfig
+	// AttemptTimeout bounds each attempt so that a hanging backend fails
+	// over. For streams it bounds the time until the response starts. Zero
+	// means no per-attempt timeout.
+	AttemptTimeout time.Duration
+
+	// OnAttempt, if set, is called after every attempt.
+	OnAttempt func(RouteAttempt)
+}
+
+// Router sends chat completions to several clients, failing over to the next
+// target on server errors (5xx), rate limiting (429), timeouts and connection
+// errors, and to the ContextOverflow targets when a request is too long for a
+// model. Other errors, such as invalid requests, are returned as they are.
+type Router struct {
+	config   RouterConfig
+	clients  map[string]*Client
+	breakers map[string]*circuitBreaker
+	defaults RouteRule
+
+	mu        sync.Mutex
+	latencies map[routeKey]time.Duration
+	wrrWeight map[string][]int
+}
+
+// NewRouter checks config and returns a Router.
+func NewRouter(config RouterConfig) (*Router, error) {
+	if len(config.Backends) == 0 {
+		return nil, ErrRouterNoBackends
+	}
+	r := &Router{
+		config:    config,
+		clients:   make(map[string]*Client, len(config.Backends)),
+		breakers:  make(map[string]*circuitBreaker, len(config.Backends)),
+		latencies: make(map[routeKey]time.Duration),
+		wrrWeight: make(map[string][]int),
+	}
+	for _, backend := range config.Backends {
+		r.clients[backend.Name] = backend.Client
+		r.breakers[backend.Name] = newCircuitBreaker(config.CircuitBreaker, nil)
+		r.defaults.Targets = append(r.defaults.Targets, RouteTarget{Backend: backend.Name})
+	}
+	for model, rule := range config.Rules {
+		for _, targets := range [][]RouteTarget{rule.Targets, rule.ContextOverflow} {
+			seen := make(map[routeKey]bool, len(targets))
+			for _, target := range targets {
+				if _, ok := r.clients[target.Backend]; !ok {
+					return nil, fmt.Errorf("%w: %q in the route for %q", ErrRouterUnknownBackend, target.Backend, model)
+				}
+				key := routeKey{target.Backend, target.Model}
+				if seen[key] {
+					return nil, fmt.Errorf("%w: %q in the route for %q", ErrRouterDuplicateTarget, target.Backend, model)
+				}
+				seen[key] = true
+			}
+		}
+	}
+	return r, nil
+}
+
+// BreakerState returns the state of a backend's circuit breaker.
+func (r *Router) BreakerState(backend string) CircuitState {
+	breaker, ok := r.breakers[backend]
+	if !ok {
+		return CircuitClosed
+	}
+	return breaker.State()
+}
+
+// CreateChatCompletion sends request to the first target of its route that
+// succeeds.
+func (r *Router) CreateChatCompletion(
+	ctx context.Context,
+	request ChatCompletionRequest,
+	opts ...RequestOption,
+) (response ChatCompletionResponse, err error) {
+	_, err = r.route(ctx, request.Model,
+		func(ctx context.Context, client *Client, model string) (*ChatCompletionStream, error) {
+			req := request
+			req.Model = model
+			var attemptErr error
+			response, attemptErr = client.CreateChatCompletion(ctx, req, opts...)
+			return nil, attemptErr
+		})
+	return
+}
+
+// CreateChatCompletionStream opens a stream on the first target of its route
+// that accepts the request. Failover happens only until the stream is open;
+// errors while reading it are returned by Recv.
+func (r *Router) CreateChatCompletionStream(
+	ctx context.Context,
+	request ChatCompletionRequest,
+	opts ...RequestOption,
+) (stream *ChatCompletionStream, err error) {
+	return r.route(ctx, request.Model,
+		func(ctx context.Context, client *Client, model string) (*ChatCompletionStream, error) {
+			req := request
+			req.Model = model
+			return client.CreateChatCompletionStream(ctx, req, opts...)
+		})
+}
+
+// routeAttemptFunc makes one attempt. Stream attempts return the open stream,
+// which then owns the attempt's context.
+type routeAttemptFunc func(ctx context.Context, client *Client, model string) (*ChatCompletionStream, error)
+
+func (r *Router) route(ctx context.Context, model string, attempt routeAttemptFunc) (*ChatCompletionStream, error) {
+	rule, ok := r.config.Rules[model]
+	if !ok {
+		rule = r.defaults
+	}
+
+	var (
+		lastErr  error
+		switched bool
+	)
+	targets := r.order(model, rule.Targets)
+	for i := 0; i < len(targets); i++ {
+		target := targets[i]
+		breaker := r.breakers[target.Backend]
+		allowed, probe := breaker.allow()
+		if !allowed {
+			continue
+		}
+		if target.Model == "" {
+			target.Model = model
+		}
+
+		stream, err := r.attempt(ctx, target, attempt)
+		failover, overflow := classifyFailover(ctx, err)
+		switch {
+		case err == nil:
+			breaker.record(probe, callSucceeded)
+			return stream, nil
+		case failover:
+			breaker.record(probe, callFailed)
+		default:
+			breaker.record(probe, callIgnored)
+		}
+		lastErr = err
+		if overflow && !switched {
+			if len(rule.ContextOverflow) == 0 {
+				// The other regular targets would reject the request the
+				// same way.
+				return nil, err
+			}
+			// Switch to the overflow targets, once.
+			targets, switched, i = rule.ContextOverflow, true, -1
+			continue
+		}
+		if !failover && !overflow {
+			return nil, err
+		}
+	}
+	if lastErr == nil {
+		return nil, ErrCircuitOpen
+	}
+	return nil, lastErr
+}
+
+func (r *Router) attempt(
+	ctx context.Context,
+	target RouteTarget,
+	attempt routeAttemptFunc,
+) (*ChatCompletionStream, error) {
+	attemptCtx, cancel := context.WithCancel(ctx)
+	var timer *time.Timer
+	if r.config.AttemptTimeout > 0 {
+		timer = time.AfterFunc(r.config.AttemptTimeout, cancel)
+	}
+
+	start := time.Now()
+	stream, err := attempt(attemptCtx, r.clients[target.Backend], target.Model)
+	latency := time.Since(start)
+	if timer != nil && !timer.Stop() && (err != nil || stream != nil) {
+		// The attempt ran out of time. A stream that opened just before is
+		// unusable, since its context is gone.
+		if stream != nil {
+			_ = stream.Close()
+			stream = nil
+		}
+		if err == nil {
+			err = context.DeadlineExceeded
+		} else {
+			err = fmt.Errorf("%w: %v", context.DeadlineExceeded, err)
+		}
+	}
+	if stream != nil {
+		stream.cancel = cancel
+	} else {
+		cancel()
+	}
+
+	if err == nil {
+		r.observeLatency(target, latency)
+	}
+	if r.config.OnAttempt != nil {
+		r.config.OnAttempt(RouteAttempt{Backend: target.Backend, Model: target.Model, Latency: latency, Err: err})
+	}
+	return stream, err
+}
+
+// classifyFailover reports whether err should fail over to the next target,
+// and whether it is a context length error.
+func classifyFailover(ctx context.Context, err error) (failover, overflow bool) {
+	if err == nil || ctx.Err() != nil {
+		return false, false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		if errors.Is(apiErr, ErrContextLengthExceeded) {
+			return false, true
+		}
+		return isFailoverStatus(apiErr.HTTPStatusCode), false
+	}
+	var reqErr *RequestError
+	if errors.As(err, &reqErr) {
+		return isFailoverStatus(reqErr.HTTPStatusCode), false
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return true, false
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr), false
+}
+
+func isFailoverStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code == http.StatusRequestTimeout ||
+		code >= http.StatusInternalServerError
+}
+
+// order returns the targets in the order the strategy wants them tried.
+func (r *Router) order(model string, targets []RouteTarget) []RouteTarget {
+	ordered := append([]RouteTarget(nil), targets...)
+	if len(ordered) == 0 {
+		return ordered
+	}
+	switch r.config.Strategy {
+	case RouterStrategyWeightedRoundRobin:
+		first := r.nextWeighted(model, targets)
+		ordered = append(ordered[:0], targets[first])
+		ordered = append(ordered, targets[:first]...)
+		ordered = append(ordered, targets[first+1:]...)
+	case RouterStrategyLatency:
+		r.mu.Lock()
+		latency := make([]time.Duration, len(ordered))
+		for i, target := range ordered {
+			if target.Model == "" {
+				target.Model = model
+			}
+			latency[i] = r.latencies[routeKey{target.Backend, target.Model}]
+		}
+		r.mu.Unlock()
+		index := make([]int, len(ordered))
+		for i := range index {
+			index[i] = i
+		}
+		sort.SliceStable(index, func(a, b int) bool { return latency[index[a]] < latency[index[b]] })
+		byLatency := make([]RouteTarget, len(ordered))
+		for i, j := range index {
+			byLatency[i] = ordered[j]
+		}
+		ordered = byLatency
+	case RouterStrategyPriority:
+	}
+	return ordered
+}
+
+// nextWeighted picks a target with smooth weighted round-robin and returns
+// its index.
+func (r *Router) nextWeighted(model string, targets []RouteTarget) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	current := r.wrrWeight[model]
+	if len(current) != len(targets) {
+		current = make([]int, len(targets))
+		r.wrrWeight[model] = current
+	}
+	best, total := 0, 0
+	for i, target := range targets {
+		weight := target.Weight
+		if weight <= 0 {
+			weight = 1
+		}
+		total += weight
+		current[i] += weight
+		if current[i] > current[best] {
+			best = i
+		}
+	}
+	current[best] -= total
+	return best
+}
+
+// latencySmoothing is the weight of the newest sample in the moving average.
+const latencySmoothing = 0.3
+
+type routeKey struct {
+	backend, model string
+}
+
+// observeLatency folds latency into an exponentially weighted moving average.
+func (r *Router) observeLatency(target RouteTarget, latency time.Duration) {
+	key := routeKey{target.Backend, target.Model}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	previous, ok := r.latencies[key]
+	if !ok {
+		r.latencies[key] = latency
+		return
+	}
+	r.latencies[key] = time.Duration(latencySmoothing*float64(latency) + (1-latencySmoothing)*float64(previous))
+}
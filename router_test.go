@@ -0,0 +1,292 @@
+package openai_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gradientlabs-ai/go-openai"
+	"github.com/gradientlabs-ai/go-openai/internal/test/checks"
+)
+
+// routerBackend is a fake chat backend whose behavior tests can change.
+type routerBackend struct {
+	mu     sync.Mutex
+	status int
+	body   string
+	delay  time.Duration
+	models []string
+}
+
+func (b *routerBackend) set(status int, body string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.status, b.body = status, body
+}
+
+func (b *routerBackend) requests() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string(nil), b.models...)
+}
+
+func (b *routerBackend) start(t *testing.T, name string) openai.RouterBackend {
+	client := startRequestOptionsServer(t, "/v1/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		var req openai.ChatCompletionRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		b.mu.Lock()
+		b.models = append(b.models, req.Model)
+		status, body, delay := b.status, b.body, b.delay
+		b.mu.Unlock()
+
+		if delay > 0 {
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		if status != 0 && status != http.StatusOK {
+			w.WriteHeader(status)
+			fmt.Fprint(w, body)
+			return
+		}
+		if req.Stream {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprintf(w, "data: {\"model\":%q,\"choices\":[{\"delta\":{\"content\":%q}}]}\n\n", req.Model, name)
+			fmt.Fprint(w, "data: [DONE]\n\n")
+			return
+		}
+		fmt.Fprintf(w, `{"model":%q,"choices":[{"message":{"role":"assistant","content":%q}}]}`, req.Model, name)
+	})
+	return openai.RouterBackend{Name: name, Client: client}
+}
+
+const serverErrorBody = `{"error":{"message":"overloaded","type":"server_error"}}`
+
+func routerRequest(model string) openai.ChatCompletionRequest {
+	return openai.ChatCompletionRequest{
+		Model:    model,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hello!"}},
+	}
+}
+
+func TestRouterFailover(t *testing.T) {
+	primary, fallback := &routerBackend{}, &routerBackend{}
+	var attempts []openai.RouteAttempt
+	router, err := openai.NewRouter(openai.RouterConfig{
+		Backends: []openai.RouterBackend{primary.start(t, "primary"), fallback.start(t, "fallback")},
+		Rules: map[string]openai.RouteRule{
+			"gpt-4o": {Targets: []openai.RouteTarget{{Backend: "primary"}, {Backend: "fallback", Model: "llama-3"}}},
+		},
+		OnAttempt: func(a openai.RouteAttempt) { attempts = append(attempts, a) },
+	})
+	checks.NoError(t, err, "NewRouter error")
+	ctx := context.Background()
+
+	primary.set(http.StatusServiceUnavailable, serverErrorBody)
+	resp, err := router.CreateChatCompletion(ctx, routerRequest("gpt-4o"))
+	checks.NoError(t, err, "CreateChatCompletion error")
+	if resp.Choices[0].Message.Content != "fallback" || resp.Model != "llama-3" {
+		t.Errorf("expected the fallback with the substituted model, got %+v", resp)
+	}
+	if len(attempts) != 2 || attempts[0].Err == nil || attempts[1].Err != nil || attempts[1].Model != "llama-3" {
+		t.Errorf("unexpected attempts %+v", attempts)
+	}
+
+	primary.set(http.StatusTooManyRequests, `{"error":{"message":"slow down","type":"requests"}}`)
+	stream, err := router.CreateChatCompletionStream(ctx, routerRequest("gpt-4o"))
+	checks.NoError(t, err, "CreateChatCompletionStream error")
+	chunk, err := stream.Recv()
+	checks.NoError(t, err, "Recv error")
+	stream.Close()
+	if chunk.Choices[0].Delta.Content != "fallback" {
+		t.Errorf("expected the stream to fail over, got %+v", chunk)
+	}
+
+	// Invalid requests are not retried elsewhere.
+	primary.set(http.StatusBadRequest, `{"error":{"message":"bad","type":"invalid_request_error"}}`)
+	fallbackCalls := len(fallback.requests())
+	_, err = router.CreateChatCompletion(ctx, routerRequest("gpt-4o"))
+	var apiErr *openai.APIError
+	if !errors.As(err, &apiErr) || apiErr.HTTPStatusCode != http.StatusBadRequest {
+		t.Errorf("expected the 400 to be returned, got %v", err)
+	}
+	if len(fallback.requests()) != fallbackCalls {
+		t.Error("a 400 should not fail over")
+	}
+
+	// Models without a rule go to every backend in order, unchanged.
+	primary.set(http.StatusInternalServerError, serverErrorBody)
+	resp, err = router.CreateChatCompletion(ctx, routerRequest("other-model"))
+	checks.NoError(t, err, "CreateChatCompletion error")
+	if resp.Model != "other-model" {
+		t.Errorf("unexpected model %q", resp.Model)
+	}
+}
+
+func TestRouterContextOverflow(t *testing.T) {
+	small, large := &routerBackend{}, &routerBackend{}
+	router, err := openai.NewRouter(openai.RouterConfig{
+		Backends: []openai.RouterBackend{small.start(t, "small"), large.start(t, "large")},
+		Rules: map[string]openai.RouteRule{
+			"gpt-4o-mini": {
+				Targets:         []openai.RouteTarget{{Backend: "small"}},
+				ContextOverflow: []openai.RouteTarget{{Backend: "large", Model: "gpt-4.1"}},
+			},
+		},
+		CircuitBreaker: openai.CircuitBreakerConfig{FailureThreshold: 1},
+	})
+	checks.NoError(t, err, "NewRouter error")
+
+	small.set(http.StatusBadRequest, `{"error":{"message":"This model's maximum context length is 128000 tokens.",`+
+		`"type":"invalid_request_error","code":"context_length_exceeded"}}`)
+	resp, err := router.CreateChatCompletion(context.Background(), routerRequest("gpt-4o-mini"))
+	checks.NoError(t, err, "CreateChatCompletion error")
+	if resp.Model != "gpt-4.1" {
+		t.Errorf("expected the overflow model, got %q", resp.Model)
+	}
+	if router.BreakerState("small") != openai.CircuitClosed {
+		t.Error("context length errors should not count against the backend")
+	}
+}
+
+func TestRouterContextOverflowWithoutTargets(t *testing.T) {
+	first, second := &routerBackend{}, &routerBackend{}
+	router, err := openai.NewRouter(openai.RouterConfig{
+		Backends: []openai.RouterBackend{first.start(t, "first"), second.start(t, "second")},
+	})
+	checks.NoError(t, err, "NewRouter error")
+
+	first.set(http.StatusBadRequest, `{"error":{"message":"This model's maximum context length is 128000 tokens.",`+
+		`"type":"invalid_request_error","code":"context_length_exceeded"}}`)
+	_, err = router.CreateChatCompletion(context.Background(), routerRequest("m"))
+	checks.ErrorIs(t, err, openai.ErrContextLengthExceeded, "context length exceeded")
+	if n := len(second.requests()); n != 0 {
+		t.Errorf("expected no attempt on the other target, got %d", n)
+	}
+}
+
+func TestRouterCircuitBreaker(t *testing.T) {
+	flaky, stable := &routerBackend{}, &routerBackend{}
+	router, err := openai.NewRouter(openai.RouterConfig{
+		Backends:       []openai.RouterBackend{flaky.start(t, "flaky"), stable.start(t, "stable")},
+		CircuitBreaker: openai.CircuitBreakerConfig{FailureThreshold: 2, OpenTimeout: 50 * time.Millisecond},
+	})
+	checks.NoError(t, err, "NewRouter error")
+	ctx := context.Background()
+
+	flaky.set(http.StatusBadGateway, serverErrorBody)
+	for i := 0; i < 3; i++ {
+		_, err = router.CreateChatCompletion(ctx, routerRequest("m"))
+		checks.NoError(t, err, "CreateChatCompletion error")
+	}
+	if n := len(flaky.requests()); n != 2 {
+		t.Errorf("expected the breaker to open after 2 failures, got %d requests", n)
+	}
+	if state := router.BreakerState("flaky"); state != openai.CircuitOpen {
+		t.Fatalf("expected an open breaker, got %s", state)
+	}
+
+	flaky.set(http.StatusOK, "")
+	time.Sleep(60 * time.Millisecond)
+	resp, err := router.CreateChatCompletion(ctx, routerRequest("m"))
+	checks.NoError(t, err, "CreateChatCompletion error")
+	if resp.Choices[0].Message.Content != "flaky" || router.BreakerState("flaky") != openai.CircuitClosed {
+		t.Errorf("expected a successful probe to close the breaker, got %s", router.BreakerState("flaky"))
+	}
+
+	// With every breaker open, the router refuses the call.
+	flaky.set(http.StatusBadGateway, serverErrorBody)
+	stable.set(http.StatusBadGateway, serverErrorBody)
+	for i := 0; i < 2; i++ {
+		_, _ = router.CreateChatCompletion(ctx, routerRequest("m"))
+	}
+	_, err = router.CreateChatCompletion(ctx, routerRequest("m"))
+	checks.ErrorIs(t, err, openai.ErrCircuitOpen, "every breaker is open")
+}
+
+func TestRouterWeightedRoundRobin(t *testing.T) {
+	a, b := &routerBackend{}, &routerBackend{}
+	router, err := openai.NewRouter(openai.RouterConfig{
+		Backends: []openai.RouterBackend{a.start(t, "a"), b.start(t, "b")},
+		Rules: map[string]openai.RouteRule{
+			"m": {Targets: []openai.RouteTarget{{Backend: "a", Weight: 3}, {Backend: "b", Weight: 1}}},
+		},
+		Strategy: openai.RouterStrategyWeightedRoundRobin,
+	})
+	checks.NoError(t, err, "NewRouter error")
+	for i := 0; i < 8; i++ {
+		_, err = router.CreateChatCompletion(context.Background(), routerRequest("m"))
+		checks.NoError(t, err, "CreateChatCompletion error")
+	}
+	if len(a.requests()) != 6 || len(b.requests()) != 2 {
+		t.Errorf("expected a 3:1 split, got %d and %d", len(a.requests()), len(b.requests()))
+	}
+}
+
+func TestRouterLatency(t *testing.T) {
+	slow, fast := &routerBackend{delay: 30 * time.Millisecond}, &routerBackend{}
+	router, err := openai.NewRouter(openai.RouterConfig{
+		Backends: []openai.RouterBackend{slow.start(t, "slow"), fast.start(t, "fast")},
+		Strategy: openai.RouterStrategyLatency,
+	})
+	checks.NoError(t, err, "NewRouter error")
+
+	// Unmeasured backends are tried first, so the first two calls measure
+	// both; after that the fast one wins.
+	for i := 0; i < 4; i++ {
+		resp, routeErr := router.CreateChatCompletion(context.Background(), routerRequest("m"))
+		checks.NoError(t, routeErr, "CreateChatCompletion error")
+		if i > 0 && resp.Choices[0].Message.Content != "fast" {
+			t.Errorf("expected the fast backend, got %q", resp.Choices[0].Message.Content)
+		}
+	}
+}
+
+func TestRouterAttemptTimeout(t *testing.T) {
+	hanging, healthy := &routerBackend{delay: time.Second}, &routerBackend{}
+	router, err := openai.NewRouter(openai.RouterConfig{
+		Backends:       []openai.RouterBackend{hanging.start(t, "hanging"), healthy.start(t, "healthy")},
+		AttemptTimeout: 30 * time.Millisecond,
+	})
+	checks.NoError(t, err, "NewRouter error")
+
+	resp, err := router.CreateChatCompletion(context.Background(), routerRequest("m"))
+	checks.NoError(t, err, "CreateChatCompletion error")
+	if resp.Choices[0].Message.Content != "healthy" {
+		t.Errorf("expected the healthy backend, got %q", resp.Choices[0].Message.Content)
+	}
+
+	stream, err := router.CreateChatCompletionStream(context.Background(), routerRequest("m"))
+	checks.NoError(t, err, "CreateChatCompletionStream error")
+	defer stream.Close()
+	chunk, err := stream.Recv()
+	checks.NoError(t, err, "Recv error")
+	if chunk.Choices[0].Delta.Content != "healthy" {
+		t.Errorf("expected the healthy backend, got %+v", chunk)
+	}
+}
+
+func TestNewRouterValidation(t *testing.T) {
+	_, err := openai.NewRouter(openai.RouterConfig{})
+	checks.ErrorIs(t, err, openai.ErrRouterNoBackends, "no backends")
+
+	backends := []openai.RouterBackend{{Name: "a", Client: openai.NewClient("key")}}
+	_, err = openai.NewRouter(openai.RouterConfig{
+		Backends: backends,
+		Rules:    map[string]openai.RouteRule{"m": {Targets: []openai.RouteTarget{{Backend: "b"}}}},
+	})
+	checks.ErrorIs(t, err, openai.ErrRouterUnknownBackend, "unknown backend")
+
+	_, err = openai.NewRouter(openai.RouterConfig{
+		Backends: backends,
+		Rules:    map[string]openai.RouteRule{"m": {Targets: []openai.RouteTarget{{Backend: "a"}, {Backend: "a"}}}},
+	})
+	checks.ErrorIs(t, err, openai.ErrRouterDuplicateTarget, "duplicate target")
+}
@@ -39,6 +39,7 @@ type Run struct {
 	TruncationStrategy *ThreadTruncationStrategy `json:"truncation_strategy,omitempty"`
 
 	httpHeader
+	rawJSON
 }
 
 type RunStatus string
@@ -134,6 +135,7 @@ type RunList struct {
 	Runs []Run `json:"data"`
 
 	httpHeader
+	rawJSON
 }
 
 type SubmitToolOutputsRequest struct {
@@ -168,6 +170,7 @@ type RunStep struct {
 	Metadata    map[string]any `json:"metadata"`
 
 	httpHeader
+	rawJSON
 }
 
 type RunStepStatus string
@@ -206,6 +209,7 @@ type RunStepList struct {
 	HasMore bool   `json:"has_more"`
 
 	httpHeader
+	rawJSON
 }
 
 type Pagination struct {
@@ -220,6 +224,7 @@ func (c *Client) CreateRun(
 	ctx context.Context,
 	threadID string,
 	request RunRequest,
+	opts ...RequestOption,
 ) (response Run, err error) {
 	urlSuffix := fmt.Sprintf("/threads/%s/runs", threadID)
 	req, err := c.newRequest(
@@ -227,7 +232,7 @@ func (c *Client) CreateRun(
 		http.MethodPost,
 		c.fullURL(urlSuffix),
 		withBody(request),
-		withBetaAssistantVersion(c.config.AssistantVersion))
+		withBetaAssistantVersion(c.config.AssistantVersion), withRequestOptions(opts))
 	if err != nil {
 		return
 	}
@@ -241,13 +246,14 @@ func (c *Client) RetrieveRun(
 	ctx context.Context,
 	threadID string,
 	runID string,
+	opts ...RequestOption,
 ) (response Run, err error) {
 	urlSuffix := fmt.Sprintf("/threads/%s/runs/%s", threadID, runID)
 	req, err := c.newRequest(
 		ctx,
 		http.MethodGet,
 		c.fullURL(urlSuffix),
-		withBetaAssistantVersion(c.config.AssistantVersion))
+		withBetaAssistantVersion(c.config.AssistantVersion), withRequestOptions(opts))
 	if err != nil {
 		return
 	}
@@ -262,6 +268,7 @@ func (c *Client) ModifyRun(
 	threadID string,
 	runID string,
 	request RunModifyRequest,
+	opts ...RequestOption,
 ) (response Run, err error) {
 	urlSuffix := fmt.Sprintf("/threads/%s/runs/%s", threadID, runID)
 	req, err := c.newRequest(
@@ -269,7 +276,7 @@ func (c *Client) ModifyRun(
 		http.MethodPost,
 		c.fullURL(urlSuffix),
 		withBody(request),
-		withBetaAssistantVersion(c.config.AssistantVersion))
+		withBetaAssistantVersion(c.config.AssistantVersion), withRequestOptions(opts))
 	if err != nil {
 		return
 	}
@@ -283,6 +290,7 @@ func (c *Client) ListRuns(
 	ctx context.Context,
 	threadID string,
 	pagination Pagination,
+	opts ...RequestOption,
 ) (response RunList, err error) {
 	urlValues := url.Values{}
 	if pagination.Limit != nil {
@@ -308,7 +316,7 @@ func (c *Client) ListRuns(
 		ctx,
 		http.MethodGet,
 		c.fullURL(urlSuffix),
-		withBetaAssistantVersion(c.config.AssistantVersion))
+		withBetaAssistantVersion(c.config.AssistantVersion), withRequestOptions(opts))
 	if err != nil {
 		return
 	}
@@ -322,14 +330,14 @@ func (c *Client) SubmitToolOutputs(
 	ctx context.Context,
 	threadID string,
 	runID string,
-	request SubmitToolOutputsRequest) (response Run, err error) {
+	request SubmitToolOutputsRequest, opts ...RequestOption) (response Run, err error) {
 	urlSuffix := fmt.Sprintf("/threads/%s/runs/%s/submit_tool_outputs", threadID, runID)
 	req, err := c.newRequest(
 		ctx,
 		http.MethodPost,
 		c.fullURL(urlSuffix),
 		withBody(request),
-		withBetaAssistantVersion(c.config.AssistantVersion))
+		withBetaAssistantVersion(c.config.AssistantVersion), withRequestOptions(opts))
 	if err != nil {
 		return
 	}
@@ -342,13 +350,13 @@ func (c *Client) SubmitToolOutputs(
 func (c *Client) CancelRun(
 	ctx context.Context,
 	threadID string,
-	runID string) (response Run, err error) {
+	runID string, opts ...RequestOption) (response Run, err error) {
 	urlSuffix := fmt.Sprintf("/threads/%s/runs/%s/cancel", threadID, runID)
 	req, err := c.newRequest(
 		ctx,
 		http.MethodPost,
 		c.fullURL(urlSuffix),
-		withBetaAssistantVersion(c.config.AssistantVersion))
+		withBetaAssistantVersion(c.config.AssistantVersion), withRequestOptions(opts))
 	if err != nil {
 		return
 	}
@@ -360,14 +368,14 @@ func (c *Client) CancelRun(
 // CreateThreadAndRun submits tool outputs.
 func (c *Client) CreateThreadAndRun(
 	ctx context.Context,
-	request CreateThreadAndRunRequest) (response Run, err error) {
+	request CreateThreadAndRunRequest, opts ...RequestOption) (response Run, err error) {
 	urlSuffix := "/threads/runs"
 	req, err := c.newRequest(
 		ctx,
 		http.MethodPost,
 		c.fullURL(urlSuffix),
 		withBody(request),
-		withBetaAssistantVersion(c.config.AssistantVersion))
+		withBetaAssistantVersion(c.config.AssistantVersion), withRequestOptions(opts))
 	if err != nil {
 		return
 	}
@@ -382,13 +390,14 @@ func (c *Client) RetrieveRunStep(
 	threadID string,
 	runID string,
 	stepID string,
+	opts ...RequestOption,
 ) (response RunStep, err error) {
 	urlSuffix := fmt.Sprintf("/threads/%s/runs/%s/steps/%s", threadID, runID, stepID)
 	req, err := c.newRequest(
 		ctx,
 		http.MethodGet,
 		c.fullURL(urlSuffix),
-		withBetaAssistantVersion(c.config.AssistantVersion))
+		withBetaAssistantVersion(c.config.AssistantVersion), withRequestOptions(opts))
 	if err != nil {
 		return
 	}
@@ -403,6 +412,7 @@ func (c *Client) ListRunSteps(
 	threadID string,
 	runID string,
 	pagination Pagination,
+	opts ...RequestOption,
 ) (response RunStepList, err error) {
 	urlValues := url.Values{}
 	if pagination.Limit != nil {
@@ -428,7 +438,7 @@ func (c *Client) ListRunSteps(
 		ctx,
 		http.MethodGet,
 		c.fullURL(urlSuffix),
-		withBetaAssistantVersion(c.config.AssistantVersion))
+		withBetaAssistantVersion(c.config.AssistantVersion), withRequestOptions(opts))
 	if err != nil {
 		return
 	}
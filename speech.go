@@ -12,6 +12,8 @@ const (
 	TTSModel1      SpeechModel = "tts-1"
 	TTSModel1HD    SpeechModel = "tts-1-hd"
 	TTSModelCanary SpeechModel = "canary-tts"
+
+	TTSModelGPT4oMini SpeechModel = "gpt-4o-mini-tts"
 )
 
 type SpeechVoice string
@@ -23,6 +25,13 @@ const (
 	VoiceOnyx    SpeechVoice = "onyx"
 	VoiceNova    SpeechVoice = "nova"
 	VoiceShimmer SpeechVoice = "shimmer"
+	VoiceAsh     SpeechVoice = "ash"
+	VoiceBallad  SpeechVoice = "ballad"
+	VoiceCoral   SpeechVoice = "coral"
+	VoiceSage    SpeechVoice = "sage"
+	VoiceVerse   SpeechVoice = "verse"
+	VoiceMarin   SpeechVoice = "marin"
+	VoiceCedar   SpeechVoice = "cedar"
 )
 
 type SpeechResponseFormat string
@@ -36,9 +45,23 @@ const (
 	SpeechResponseFormatPcm  SpeechResponseFormat = "pcm"
 )
 
+// SpeechPCMSampleRate is the sample rate of pcm speech, which is 16-bit
+// little-endian mono.
+const SpeechPCMSampleRate = 24000
+
+// SpeechStreamFormat is how speech is streamed: as the raw audio, or as
+// server-sent events carrying base64-encoded chunks of it.
+type SpeechStreamFormat string
+
+const (
+	SpeechStreamFormatAudio SpeechStreamFormat = "audio"
+	SpeechStreamFormatSSE   SpeechStreamFormat = "sse"
+)
+
 var (
-	ErrInvalidSpeechModel = errors.New("invalid speech model")
-	ErrInvalidVoice       = errors.New("invalid voice")
+	ErrInvalidSpeechModel      = errors.New("invalid speech model")
+	ErrInvalidVoice            = errors.New("invalid voice")
+	ErrSpeechOptionUnsupported = errors.New("speech model does not support this option")
 )
 
 type CreateSpeechRequest struct {
@@ -47,37 +70,25 @@ type CreateSpeechRequest struct {
 	Voice          SpeechVoice          `json:"voice"`
 	ResponseFormat SpeechResponseFormat `json:"response_format,omitempty"` // Optional, default to mp3
 	Speed          float64              `json:"speed,omitempty"`           // Optional, default to 1.0
+	// Instructions control the voice, e.g. its tone, accent or pace. Only some models support them.
+	Instructions string `json:"instructions,omitempty"`
+	// StreamFormat is set by CreateSpeechStream. CreateSpeech always streams the raw audio, which can be read
+	// from the response as it arrives.
+	StreamFormat SpeechStreamFormat `json:"stream_format,omitempty"`
 }
 
-func contains[T comparable](s []T, e T) bool {
-	for _, v := range s {
-		if v == e {
-			return true
-		}
-	}
-	return false
-}
-
-func isValidSpeechModel(model SpeechModel) bool {
-	return contains([]SpeechModel{TTSModel1, TTSModel1HD, TTSModelCanary}, model)
-}
-
-func isValidVoice(voice SpeechVoice) bool {
-	return contains([]SpeechVoice{VoiceAlloy, VoiceEcho, VoiceFable, VoiceOnyx, VoiceNova, VoiceShimmer}, voice)
-}
-
-func (c *Client) CreateSpeech(ctx context.Context, request CreateSpeechRequest) (response RawResponse, err error) {
-	if !isValidSpeechModel(request.Model) {
-		err = ErrInvalidSpeechModel
-		return
-	}
-	if !isValidVoice(request.Voice) {
-		err = ErrInvalidVoice
+func (c *Client) CreateSpeech(
+	ctx context.Context,
+	request CreateSpeechRequest,
+	opts ...RequestOption,
+) (response RawResponse, err error) {
+	if err = c.speechModels().Validate(request); err != nil {
 		return
 	}
 	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL("/audio/speech", string(request.Model)),
 		withBody(request),
 		withContentType("application/json"),
+		withRequestOptions(opts),
 	)
 	if err != nil {
 		return
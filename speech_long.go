@@ -0,0 +1,179 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+const (
+	// maxSpeechInputLength is the maximum number of characters the speech
+	// endpoint accepts in one request.
+	maxSpeechInputLength         = 4096
+	defaultSpeechSegmentLength   = 1000
+	defaultLongSpeechConcurrency = 4
+)
+
+var ErrSpeechFormatNotJoinable = errors.New("speech in this format cannot be joined from segments")
+
+// LongSpeechOptions controls how CreateLongSpeech splits and synthesizes a
+// long text.
+type LongSpeechOptions struct {
+	// MaxSegmentLength caps the number of characters synthesized in one
+	// request. It defaults to 1000, as shorter segments start playing sooner,
+	// and cannot exceed the endpoint's limit of 4096.
+	MaxSegmentLength int
+	// Concurrency bounds the number of segments synthesized, or finished but
+	// not yet written, at once.
+	Concurrency int
+}
+
+func (o LongSpeechOptions) withDefaults() LongSpeechOptions {
+	if o.MaxSegmentLength <= 0 {
+		o.MaxSegmentLength = defaultSpeechSegmentLength
+	}
+	if o.MaxSegmentLength > maxSpeechInputLength {
+		o.MaxSegmentLength = maxSpeechInputLength
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = defaultLongSpeechConcurrency
+	}
+	return o
+}
+
+// speechSegment is the outcome of synthesizing one segment of a long text.
+type speechSegment struct {
+	audio []byte
+	err   error
+}
+
+// CreateLongSpeech synthesizes text of any length by splitting the input at
+// sentence boundaries into segments, synthesizing them concurrently, and
+// writing their audio to w in order as soon as each segment and those before
+// it are done. The first error cancels the segments still in flight.
+//
+// Segments of mp3, aac and pcm audio are joined as they are. mp3 and aac are
+// streams of frames that players decode back to back, but each mp3 segment
+// may start with its own ID3 or Xing header, which can make players report
+// the wrong duration. wav audio is requested as pcm and written with a single
+// header once all the segments are done. opus and flac fail with
+// ErrSpeechFormatNotJoinable: each segment is a whole Ogg or FLAC file, and
+// most decoders stop at the end of the first.
+func (c *Client) CreateLongSpeech(
+	ctx context.Context,
+	w io.Writer,
+	request CreateSpeechRequest,
+	options LongSpeechOptions,
+	opts ...RequestOption,
+) error {
+	options = options.withDefaults()
+	switch request.ResponseFormat {
+	case SpeechResponseFormatOpus, SpeechResponseFormatFlac:
+		return fmt.Errorf("%w: %s", ErrSpeechFormatNotJoinable, request.ResponseFormat)
+	case SpeechResponseFormatWav:
+		var pcm bytes.Buffer
+		request.ResponseFormat = SpeechResponseFormatPcm
+		if err := c.CreateLongSpeech(ctx, &pcm, request, options, opts...); err != nil {
+			return err
+		}
+		return WriteWAV(w, pcm.Bytes(), SpeechPCMSampleRate)
+	}
+	request.StreamFormat = ""
+	if err := c.speechModels().Validate(request); err != nil {
+		return err
+	}
+	segments, err := splitSpeechInput(request.Input, options.MaxSegmentLength)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	// A slot is taken before a segment is synthesized and given back once it
+	// is written, so that finished segments waiting for an earlier one count
+	// towards Concurrency too.
+	slots := make(chan struct{}, options.Concurrency)
+	results := make([]chan speechSegment, len(segments))
+	for i := range results {
+		results[i] = make(chan speechSegment, 1)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i, segment := range segments {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			segmentRequest := request
+			segmentRequest.Input = segment
+			result := results[i]
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				audio, segmentErr := c.synthesizeSpeech(ctx, segmentRequest, opts)
+				result <- speechSegment{audio: audio, err: segmentErr}
+			}()
+		}
+	}()
+
+	for i := range segments {
+		var segment speechSegment
+		select {
+		case segment = <-results[i]:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if segment.err != nil {
+			return fmt.Errorf("segment %d of %d: %w", i+1, len(segments), segment.err)
+		}
+		if _, err = w.Write(segment.audio); err != nil {
+			return err
+		}
+		<-slots
+	}
+	return nil
+}
+
+// synthesizeSpeech returns the audio of one request.
+func (c *Client) synthesizeSpeech(
+	ctx context.Context,
+	request CreateSpeechRequest,
+	opts []RequestOption,
+) ([]byte, error) {
+	response, err := c.CreateSpeech(ctx, request, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Close()
+	return io.ReadAll(response)
+}
+
+// splitSpeechInput splits text into segments of at most maxLength characters,
+// preferring to end them at sentence boundaries.
+func splitSpeechInput(text string, maxLength int) ([]string, error) {
+	chunks, err := SentenceChunker{
+		MaxTokens: maxLength,
+		Tokenizer: ApproximateTokenizer{CharsPerToken: 1},
+	}.Chunk(text)
+	if err != nil {
+		return nil, err
+	}
+	segments := make([]string, 0, len(chunks))
+	for _, chunk := range chunks {
+		if segment := strings.TrimSpace(chunk.Text); segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return segments, nil
+}
@@ -0,0 +1,121 @@
+package openai
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// SpeechModelCapabilities describes what a text-to-speech model supports.
+type SpeechModelCapabilities struct {
+	Voices []SpeechVoice
+	// ResponseFormats are the audio formats the model returns. Empty allows
+	// them all.
+	ResponseFormats []SpeechResponseFormat
+	// Instructions reports whether the model takes instructions on how to
+	// speak.
+	Instructions bool
+	// SSE reports whether the model can stream server-sent events.
+	SSE bool
+}
+
+// SpeechModelRegistry holds the capabilities of text-to-speech models, which
+// speech requests are checked against before they are sent. It is safe for
+// concurrent use.
+type SpeechModelRegistry struct {
+	mu     sync.RWMutex
+	models map[SpeechModel]SpeechModelCapabilities
+}
+
+// DefaultSpeechModels are the OpenAI text-to-speech models. Register newer
+// models or voices here, or give a client its own registry with
+// ClientConfig.SpeechModels, e.g. for Azure deployment names.
+var DefaultSpeechModels = defaultSpeechModels()
+
+func defaultSpeechModels() *SpeechModelRegistry {
+	classicVoices := []SpeechVoice{
+		VoiceAlloy, VoiceAsh, VoiceCoral, VoiceEcho, VoiceFable, VoiceOnyx, VoiceNova, VoiceSage, VoiceShimmer,
+	}
+	r := NewSpeechModelRegistry()
+	r.Register(TTSModel1, SpeechModelCapabilities{Voices: classicVoices})
+	r.Register(TTSModel1HD, SpeechModelCapabilities{Voices: classicVoices})
+	r.Register(TTSModelCanary, SpeechModelCapabilities{
+		Voices: []SpeechVoice{VoiceAlloy, VoiceEcho, VoiceFable, VoiceOnyx, VoiceNova, VoiceShimmer},
+	})
+	r.Register(TTSModelGPT4oMini, SpeechModelCapabilities{
+		Voices:       append(classicVoices, VoiceBallad, VoiceVerse, VoiceMarin, VoiceCedar),
+		Instructions: true,
+		SSE:          true,
+	})
+	return r
+}
+
+// NewSpeechModelRegistry returns an empty registry.
+func NewSpeechModelRegistry() *SpeechModelRegistry {
+	return &SpeechModelRegistry{models: make(map[SpeechModel]SpeechModelCapabilities)}
+}
+
+// Register adds a model or replaces its capabilities.
+func (r *SpeechModelRegistry) Register(model SpeechModel, capabilities SpeechModelCapabilities) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.models[model] = capabilities
+}
+
+// Capabilities returns the capabilities of a model, and reports false if it
+// is not registered.
+func (r *SpeechModelRegistry) Capabilities(model SpeechModel) (SpeechModelCapabilities, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	capabilities, ok := r.models[model]
+	return capabilities, ok
+}
+
+// Models returns the registered models in name order.
+func (r *SpeechModelRegistry) Models() []SpeechModel {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	models := make([]SpeechModel, 0, len(r.models))
+	for model := range r.models {
+		models = append(models, model)
+	}
+	sort.Slice(models, func(i, j int) bool { return models[i] < models[j] })
+	return models
+}
+
+// Validate checks that the model of a request is registered and supports the
+// voice and options it asks for. Errors wrap ErrInvalidSpeechModel,
+// ErrInvalidVoice or ErrSpeechOptionUnsupported.
+func (r *SpeechModelRegistry) Validate(request CreateSpeechRequest) error {
+	capabilities, ok := r.Capabilities(request.Model)
+	switch {
+	case !ok:
+		return fmt.Errorf("%w: %s", ErrInvalidSpeechModel, request.Model)
+	case !contains(capabilities.Voices, request.Voice):
+		return fmt.Errorf("%w: %s has no voice %q", ErrInvalidVoice, request.Model, request.Voice)
+	case request.ResponseFormat != "" && len(capabilities.ResponseFormats) > 0 &&
+		!contains(capabilities.ResponseFormats, request.ResponseFormat):
+		return fmt.Errorf("%w: %s cannot return %s", ErrSpeechOptionUnsupported, request.Model, request.ResponseFormat)
+	case request.Instructions != "" && !capabilities.Instructions:
+		return fmt.Errorf("%w: %s does not take instructions", ErrSpeechOptionUnsupported, request.Model)
+	case request.StreamFormat == SpeechStreamFormatSSE && !capabilities.SSE:
+		return fmt.Errorf("%w: %s cannot stream server-sent events", ErrSpeechOptionUnsupported, request.Model)
+	}
+	return nil
+}
+
+func (c *Client) speechModels() *SpeechModelRegistry {
+	if c.config.SpeechModels != nil {
+		return c.config.SpeechModels
+	}
+	return DefaultSpeechModels
+}
+
+func contains[T comparable](s []T, e T) bool {
+	for _, v := range s {
+		if v == e {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+package openai
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+)
+
+// Types of SpeechStreamEvent.
+const (
+	SpeechStreamEventAudioDelta = "speech.audio.delta"
+	SpeechStreamEventAudioDone  = "speech.audio.done"
+)
+
+// SpeechUsage is the number of tokens used to generate speech, reported by
+// the last event of a speech stream.
+type SpeechUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+	TotalTokens  int `json:"total_tokens"`
+}
+
+// SpeechStreamEvent is an event of a speech stream: a chunk of audio, or the
+// end of the audio with the usage.
+type SpeechStreamEvent struct {
+	Type string `json:"type"`
+	// Audio is the base64-encoded chunk of a speech.audio.delta event.
+	Audio string       `json:"audio,omitempty"`
+	Usage *SpeechUsage `json:"usage,omitempty"`
+}
+
+// AudioBytes decodes the chunk of audio.
+func (e SpeechStreamEvent) AudioBytes() ([]byte, error) {
+	return base64.StdEncoding.DecodeString(e.Audio)
+}
+
+// SpeechStream is speech streamed as server-sent events. Either receive the
+// events with Recv, or read the decoded audio with Read; do not mix the two.
+type SpeechStream struct {
+	*streamReader[SpeechStreamEvent]
+
+	pending []byte
+	usage   *SpeechUsage
+}
+
+// CreateSpeechStream generates speech and streams it as server-sent events,
+// which carry the usage once the audio is done. Only some models support
+// this; CreateSpeech streams the raw audio with any model, and can be read as
+// it arrives, e.g. to play pcm or opus audio with little delay.
+func (c *Client) CreateSpeechStream(
+	ctx context.Context,
+	request CreateSpeechRequest,
+	opts ...RequestOption,
+) (stream *SpeechStream, err error) {
+	request.StreamFormat = SpeechStreamFormatSSE
+	if err = c.speechModels().Validate(request); err != nil {
+		return
+	}
+	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL("/audio/speech", string(request.Model)),
+		withBody(request), withRequestOptions(opts))
+	if err != nil {
+		return
+	}
+
+	resp, err := sendRequestStream[SpeechStreamEvent](c, req)
+	if err != nil {
+		return
+	}
+	resp.timer.timeouts, _ = c.streamOptions(opts)
+	stream = &SpeechStream{
+		streamReader: resp,
+	}
+	return
+}
+
+// Recv returns the next event, or io.EOF after the last one.
+func (stream *SpeechStream) Recv() (event SpeechStreamEvent, err error) {
+	event, err = stream.streamReader.Recv()
+	if err == nil && event.Usage != nil {
+		stream.usage = event.Usage
+	}
+	return
+}
+
+// Read reads the decoded audio.
+func (stream *SpeechStream) Read(p []byte) (int, error) {
+	for len(stream.pending) == 0 {
+		event, err := stream.Recv()
+		if err != nil {
+			return 0, err
+		}
+		if event.Audio == "" {
+			continue
+		}
+		if stream.pending, err = event.AudioBytes(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, stream.pending)
+	stream.pending = stream.pending[n:]
+	return n, nil
+}
+
+// Usage returns the usage, which is known once the stream has been read to
+// the end, or nil.
+func (stream *SpeechStream) Usage() *SpeechUsage {
+	return stream.usage
+}
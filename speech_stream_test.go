@@ -0,0 +1,127 @@
+package openai_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gradientlabs-ai/go-openai"
+	"github.com/gradientlabs-ai/go-openai/internal/test/checks"
+)
+
+func TestCreateSpeechStream(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	var request map[string]any
+	server.RegisterHandler("/v1/audio/speech", func(w http.ResponseWriter, r *http.Request) {
+		checks.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, event := range []string{
+			`{"type": "speech.audio.delta", "audio": "AQID"}`,
+			`{"type": "speech.audio.delta", "audio": "BAU="}`,
+			`{"type": "speech.audio.done", "usage": {"input_tokens": 4, "output_tokens": 10, "total_tokens": 14}}`,
+		} {
+			w.Write([]byte("event: " + strings.Split(event, `"`)[3] + "\ndata: " + event + "\n\n"))
+		}
+	})
+
+	stream, err := client.CreateSpeechStream(context.Background(), openai.CreateSpeechRequest{
+		Model:          openai.TTSModelGPT4oMini,
+		Input:          "Hello!",
+		Voice:          openai.VoiceCoral,
+		ResponseFormat: openai.SpeechResponseFormatPcm,
+		Instructions:   "Speak cheerfully.",
+	})
+	checks.NoError(t, err)
+	defer stream.Close()
+
+	audio, err := io.ReadAll(stream)
+	checks.NoError(t, err)
+	if !bytes.Equal(audio, []byte{1, 2, 3, 4, 5}) {
+		t.Fatalf("unexpected audio %v", audio)
+	}
+	if usage := stream.Usage(); usage == nil || usage.TotalTokens != 14 {
+		t.Fatalf("unexpected usage %+v", usage)
+	}
+	if request["stream_format"] != "sse" || request["instructions"] != "Speak cheerfully." {
+		t.Fatalf("unexpected request %v", request)
+	}
+}
+
+func TestCreateLongSpeech(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	var (
+		mu       sync.Mutex
+		formats  []string
+		inFlight int
+		maxIn    int
+	)
+	server.RegisterHandler("/v1/audio/speech", func(w http.ResponseWriter, r *http.Request) {
+		var request openai.CreateSpeechRequest
+		checks.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		mu.Lock()
+		formats = append(formats, string(request.ResponseFormat))
+		inFlight++
+		if inFlight > maxIn {
+			maxIn = inFlight
+		}
+		mu.Unlock()
+		// The first segment finishes last, so the audio must be put back in order.
+		if strings.HasPrefix(request.Input, "One") {
+			time.Sleep(30 * time.Millisecond)
+		}
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		w.Write([]byte("[" + request.Input + "]"))
+	})
+	ctx := context.Background()
+	request := openai.CreateSpeechRequest{
+		Model: openai.TTSModel1,
+		Input: "One. Two two. Three three. Four four four. Five five five.",
+		Voice: openai.VoiceAlloy,
+	}
+	options := openai.LongSpeechOptions{MaxSegmentLength: 16, Concurrency: 2}
+
+	var out bytes.Buffer
+	checks.NoError(t, client.CreateLongSpeech(ctx, &out, request, options))
+	if out.String() != "[One. Two two.][Three three.][Four four four.][Five five five.]" {
+		t.Fatalf("unexpected audio %q", out.String())
+	}
+	if maxIn > 2 {
+		t.Fatalf("expected at most 2 segments at once, got %d", maxIn)
+	}
+
+	out.Reset()
+	request.ResponseFormat = openai.SpeechResponseFormatWav
+	checks.NoError(t, client.CreateLongSpeech(ctx, &out, request, options))
+	if !bytes.HasPrefix(out.Bytes(), []byte("RIFF")) || !bytes.HasSuffix(out.Bytes(), []byte("[Five five five.]")) {
+		t.Fatalf("expected a single WAV file, got %q", out.String())
+	}
+	if formats[len(formats)-1] != string(openai.SpeechResponseFormatPcm) {
+		t.Fatalf("expected segments of wav audio to be requested as pcm, got %v", formats)
+	}
+
+	for _, format := range []openai.SpeechResponseFormat{openai.SpeechResponseFormatOpus, openai.SpeechResponseFormatFlac} {
+		request.ResponseFormat = format
+		checks.ErrorIs(t, client.CreateLongSpeech(ctx, &out, request, options), openai.ErrSpeechFormatNotJoinable)
+	}
+
+	request.ResponseFormat = ""
+	request.Input = strings.Repeat("Word. ", 20)
+	server.RegisterHandler("/v1/audio/speech", func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, `{"error": {"message": "boom"}}`, http.StatusInternalServerError)
+	})
+	err := client.CreateLongSpeech(ctx, io.Discard, request, options)
+	checks.HasError(t, err, "a failed segment must fail the speech")
+	if !strings.Contains(err.Error(), "segment 1 of") {
+		t.Fatalf("expected the error to name the segment, got %v", err)
+	}
+}
@@ -92,7 +92,7 @@ func TestSpeechIntegration(t *testing.T) {
 		checks.NoError(t, err, "ReadAll error")
 
 		// save buf to file as mp3
-		err = os.WriteFile("test.mp3", buf, 0644)
+		err = os.WriteFile(filepath.Join(t.TempDir(), "test.mp3"), buf, 0644)
 		checks.NoError(t, err, "Create error")
 	})
 	t.Run("invalid model", func(t *testing.T) {
@@ -113,3 +113,55 @@ func TestSpeechIntegration(t *testing.T) {
 		checks.ErrorIs(t, err, openai.ErrInvalidVoice, "CreateSpeech error")
 	})
 }
+
+func TestSpeechModelRegistry(t *testing.T) {
+	ctx := context.Background()
+	client := openai.NewClient("token")
+	_, err := client.CreateSpeech(ctx, openai.CreateSpeechRequest{
+		Model:        openai.TTSModel1,
+		Input:        "Hello!",
+		Voice:        openai.VoiceAlloy,
+		Instructions: "Speak cheerfully.",
+	})
+	checks.ErrorIs(t, err, openai.ErrSpeechOptionUnsupported)
+	_, err = client.CreateSpeechStream(ctx, openai.CreateSpeechRequest{
+		Model: openai.TTSModel1HD,
+		Input: "Hello!",
+		Voice: openai.VoiceAlloy,
+	})
+	checks.ErrorIs(t, err, openai.ErrSpeechOptionUnsupported)
+	err = openai.DefaultSpeechModels.Validate(openai.CreateSpeechRequest{
+		Model: openai.TTSModel1,
+		Voice: openai.VoiceMarin,
+	})
+	checks.ErrorIs(t, err, openai.ErrInvalidVoice)
+	checks.NoError(t, openai.DefaultSpeechModels.Validate(openai.CreateSpeechRequest{
+		Model:        openai.TTSModelGPT4oMini,
+		Voice:        openai.VoiceMarin,
+		Instructions: "Speak cheerfully.",
+	}))
+
+	registry := openai.NewSpeechModelRegistry()
+	registry.Register("my-deployment", openai.SpeechModelCapabilities{
+		Voices:          []openai.SpeechVoice{"custom"},
+		ResponseFormats: []openai.SpeechResponseFormat{openai.SpeechResponseFormatMp3},
+	})
+	config := openai.DefaultConfig("token")
+	config.SpeechModels = registry
+	_, err = openai.NewClientWithConfig(config).CreateSpeech(ctx, openai.CreateSpeechRequest{
+		Model: openai.TTSModel1,
+		Input: "Hello!",
+		Voice: openai.VoiceAlloy,
+	})
+	checks.ErrorIs(t, err, openai.ErrInvalidSpeechModel)
+	err = registry.Validate(openai.CreateSpeechRequest{
+		Model:          "my-deployment",
+		Voice:          "custom",
+		ResponseFormat: openai.SpeechResponseFormatOpus,
+	})
+	checks.ErrorIs(t, err, openai.ErrSpeechOptionUnsupported)
+	checks.NoError(t, registry.Validate(openai.CreateSpeechRequest{Model: "my-deployment", Voice: "custom"}))
+	if models := registry.Models(); len(models) != 1 || models[0] != "my-deployment" {
+		t.Fatalf("unexpected models %v", models)
+	}
+}
@@ -20,6 +20,7 @@ type CompletionStream struct {
 func (c *Client) CreateCompletionStream(
 	ctx context.Context,
 	request CompletionRequest,
+	opts ...RequestOption,
 ) (stream *CompletionStream, err error) {
 	urlSuffix := "/completions"
 	if !checkEndpointSupportsModel(urlSuffix, request.Model) {
@@ -33,7 +34,7 @@ func (c *Client) CreateCompletionStream(
 	}
 
 	request.Stream = true
-	req, err := c.newRequest(ctx, "POST", c.fullURL(urlSuffix, request.Model), withBody(request))
+	req, err := c.newRequest(ctx, "POST", c.fullURL(urlSuffix, request.Model), withBody(request), withRequestOptions(opts))
 	if err != nil {
 		return nil, err
 	}
@@ -42,6 +43,7 @@ func (c *Client) CreateCompletionStream(
 	if err != nil {
 		return
 	}
+	resp.timer.timeouts, _ = c.streamOptions(opts)
 	stream = &CompletionStream{
 		streamReader: resp,
 	}
@@ -0,0 +1,302 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrStreamSubscriberTooSlow = errors.New("stream subscriber fell behind")
+	ErrStreamTeeStarted        = errors.New("stream tee already started")
+)
+
+// StreamReceiver is a stream of chunks, such as a *ChatCompletionStream or a
+// *CompletionStream.
+type StreamReceiver[T any] interface {
+	Recv() (T, error)
+	Close() error
+}
+
+// BackpressurePolicy decides what a StreamTee does when a subscriber's
+// buffer is full.
+type BackpressurePolicy int
+
+const (
+	// BackpressureBlock makes the stream wait for the subscriber, so the
+	// slowest blocking subscriber sets the pace for everyone.
+	BackpressureBlock BackpressurePolicy = iota
+	// BackpressureDisconnect ends the subscription with
+	// ErrStreamSubscriberTooSlow, leaving the other subscribers unaffected.
+	BackpressureDisconnect
+)
+
+// StreamSubscription receives the chunks of a stream on C. C is closed when
+// the stream ends, after which Err returns nil if the stream completed and
+// the error that ended it otherwise.
+type StreamSubscription[T any] struct {
+	C <-chan T
+
+	ch       chan T
+	policy   BackpressurePolicy
+	canceled chan struct{}
+	once     sync.Once
+	// onLeave tells the tee that the subscription no longer wants chunks.
+	onLeave   func()
+	leaveOnce sync.Once
+
+	mu  sync.Mutex
+	err error
+}
+
+func newStreamSubscription[T any](buffer int, policy BackpressurePolicy) *StreamSubscription[T] {
+	ch := make(chan T, buffer)
+	return &StreamSubscription[T]{C: ch, ch: ch, policy: policy, canceled: make(chan struct{})}
+}
+
+// Err returns the error that ended the stream. It is only meaningful once C
+// is closed.
+func (s *StreamSubscription[T]) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Cancel ends the subscription. The stream is closed once no subscription
+// is left, even while it waits for the next chunk.
+func (s *StreamSubscription[T]) Cancel() {
+	s.once.Do(func() { close(s.canceled) })
+	s.leave()
+}
+
+func (s *StreamSubscription[T]) leave() {
+	if s.onLeave != nil {
+		s.leaveOnce.Do(s.onLeave)
+	}
+}
+
+func (s *StreamSubscription[T]) finish(err error) {
+	s.mu.Lock()
+	s.err = err
+	s.mu.Unlock()
+	close(s.ch)
+}
+
+// StreamTee multicasts one stream to several subscribers, e.g. a UI, a
+// logger and an accumulator. Subscribe to it before calling Start.
+type StreamTee[T any] struct {
+	stream StreamReceiver[T]
+
+	mu          sync.Mutex
+	subscribers []*StreamSubscription[T]
+	started     bool
+	// live counts the subscriptions that have not been canceled or dropped.
+	live int
+}
+
+// NewStreamTee creates a tee over stream. The tee closes the stream when it
+// ends.
+func NewStreamTee[T any](stream StreamReceiver[T]) *StreamTee[T] {
+	return &StreamTee[T]{stream: stream}
+}
+
+// Subscribe adds a subscriber with room for buffer chunks. Subscribing after
+// Start returns a subscription that fails with ErrStreamTeeStarted.
+func (t *StreamTee[T]) Subscribe(buffer int, policy BackpressurePolicy) *StreamSubscription[T] {
+	sub := newStreamSubscription[T](buffer, policy)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.started {
+		sub.finish(ErrStreamTeeStarted)
+		return sub
+	}
+	sub.onLeave = t.leave
+	t.subscribers = append(t.subscribers, sub)
+	t.live++
+	return sub
+}
+
+// leave closes the stream once the last subscription has left, so that a
+// Recv waiting on a stalled stream returns.
+func (t *StreamTee[T]) leave() {
+	t.mu.Lock()
+	t.live--
+	closeNow := t.started && t.live == 0
+	t.mu.Unlock()
+	if closeNow {
+		_ = t.stream.Close()
+	}
+}
+
+// abandoned reports whether every subscription has left.
+func (t *StreamTee[T]) abandoned() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.live == 0
+}
+
+// Start reads the stream in a goroutine until it ends, every subscription
+// is canceled or ctx is done. Canceling ctx closes the stream, which aborts
+// the HTTP request.
+func (t *StreamTee[T]) Start(ctx context.Context) {
+	t.mu.Lock()
+	t.started = true
+	subscribers := t.subscribers
+	closeNow := t.live == 0
+	t.mu.Unlock()
+	go t.run(ctx, subscribers)
+	if closeNow {
+		_ = t.stream.Close()
+	}
+}
+
+func (t *StreamTee[T]) run(ctx context.Context, active []*StreamSubscription[T]) {
+	stop := closeOnDone(ctx, t.stream)
+	var err error
+	for len(active) > 0 {
+		var chunk T
+		chunk, err = t.stream.Recv()
+		if err != nil {
+			switch {
+			case ctx.Err() != nil:
+				err = ctx.Err()
+			case t.abandoned():
+				err = context.Canceled
+			case errors.Is(err, io.EOF):
+				err = nil
+			}
+			break
+		}
+		active = t.deliver(ctx, active, chunk)
+	}
+	stop()
+	// The stream is closed before the subscribers hear that it ended.
+	_ = t.stream.Close()
+	for _, sub := range active {
+		sub.finish(err)
+	}
+}
+
+// deliver sends chunk to the subscribers and returns the ones still active.
+func (t *StreamTee[T]) deliver(
+	ctx context.Context,
+	subscribers []*StreamSubscription[T],
+	chunk T,
+) []*StreamSubscription[T] {
+	active := subscribers[:0]
+	for _, sub := range subscribers {
+		var err error
+		if sub.policy == BackpressureDisconnect {
+			select {
+			case sub.ch <- chunk:
+			case <-sub.canceled:
+				err = context.Canceled
+			default:
+				err = ErrStreamSubscriberTooSlow
+			}
+		} else {
+			select {
+			case sub.ch <- chunk:
+			case <-sub.canceled:
+				err = context.Canceled
+			case <-ctx.Done():
+				err = ctx.Err()
+			}
+		}
+		if err != nil {
+			sub.finish(err)
+			sub.leave()
+			continue
+		}
+		active = append(active, sub)
+	}
+	return active
+}
+
+// StreamChannel delivers the chunks of stream on a channel, reading ahead up
+// to buffer chunks. The stream is closed when it ends or ctx is done.
+func StreamChannel[T any](ctx context.Context, stream StreamReceiver[T], buffer int) *StreamSubscription[T] {
+	tee := NewStreamTee(stream)
+	sub := tee.Subscribe(buffer, BackpressureBlock)
+	tee.Start(ctx)
+	return sub
+}
+
+// closeOnDone closes closer if ctx is done before stop is called.
+func closeOnDone(ctx context.Context, closer io.Closer) (stop func()) {
+	stopped := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = closer.Close()
+		case <-stopped:
+		}
+	}()
+	return func() { close(stopped) }
+}
+
+// streamTextReader reads the text content of a stream.
+type streamTextReader[T any] struct {
+	stream  StreamReceiver[T]
+	text    func(T) string
+	pending string
+	err     error
+}
+
+func (r *streamTextReader[T]) Read(p []byte) (int, error) {
+	for r.pending == "" {
+		if r.err != nil {
+			return 0, r.err
+		}
+		chunk, err := r.stream.Recv()
+		if err != nil {
+			r.err = err
+			continue
+		}
+		r.pending = r.text(chunk)
+	}
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
+
+func (r *streamTextReader[T]) Close() error {
+	return r.stream.Close()
+}
+
+// TextReader returns a reader of the content of the first choice, so the
+// answer can be copied to an http.ResponseWriter or any other io.Writer as
+// it arrives. Closing the reader closes the stream.
+func (stream *ChatCompletionStream) TextReader() io.ReadCloser {
+	return &streamTextReader[ChatCompletionStreamResponse]{
+		stream: stream,
+		text: func(chunk ChatCompletionStreamResponse) string {
+			var text strings.Builder
+			for _, choice := range chunk.Choices {
+				if choice.Index == 0 {
+					text.WriteString(choice.Delta.Content)
+				}
+			}
+			return text.String()
+		},
+	}
+}
+
+// TextReader returns a reader of the text of the first choice. Closing the
+// reader closes the stream.
+func (stream *CompletionStream) TextReader() io.ReadCloser {
+	return &streamTextReader[CompletionResponse]{
+		stream: stream,
+		text: func(chunk CompletionResponse) string {
+			var text strings.Builder
+			for _, choice := range chunk.Choices {
+				if choice.Index == 0 {
+					text.WriteString(choice.Text)
+				}
+			}
+			return text.String()
+		},
+	}
+}
@@ -0,0 +1,185 @@
+package openai_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gradientlabs-ai/go-openai"
+	"github.com/gradientlabs-ai/go-openai/internal/test/checks"
+)
+
+func completeStreamHandler(chunks ...string) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, chunk := range chunks {
+			fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"content\":%q}}]}\n\n", chunk)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}
+}
+
+// sliceStream is a StreamReceiver over fixed chunks.
+type sliceStream struct {
+	chunks []int
+	closed chan struct{}
+	once   sync.Once
+}
+
+func newSliceStream(chunks ...int) *sliceStream {
+	return &sliceStream{chunks: chunks, closed: make(chan struct{})}
+}
+
+func (s *sliceStream) Recv() (int, error) {
+	if len(s.chunks) == 0 {
+		return 0, io.EOF
+	}
+	chunk := s.chunks[0]
+	s.chunks = s.chunks[1:]
+	return chunk, nil
+}
+
+func (s *sliceStream) Close() error {
+	s.once.Do(func() { close(s.closed) })
+	return nil
+}
+
+func (s *sliceStream) waitClosed(t *testing.T) {
+	t.Helper()
+	select {
+	case <-s.closed:
+	case <-time.After(time.Second):
+		t.Error("expected the upstream to be closed")
+	}
+}
+
+func TestStreamChannel(t *testing.T) {
+	client := startStreamTestClient(t, func(*openai.ClientConfig) {}, completeStreamHandler("a", "b", "c"))
+	stream, err := client.CreateChatCompletionStream(context.Background(), routerRequest("m"))
+	checks.NoError(t, err, "CreateChatCompletionStream error")
+
+	sub := openai.StreamChannel[openai.ChatCompletionStreamResponse](context.Background(), stream, 1)
+	var content string
+	for chunk := range sub.C {
+		content += chunk.Choices[0].Delta.Content
+	}
+	checks.NoError(t, sub.Err(), "stream error")
+	if content != "abc" {
+		t.Errorf("unexpected content %q", content)
+	}
+}
+
+func TestStreamChannelCancel(t *testing.T) {
+	client := startStreamTestClient(t, func(*openai.ClientConfig) {}, stallingStreamHandler("a"))
+	stream, err := client.CreateChatCompletionStream(context.Background(), routerRequest("m"))
+	checks.NoError(t, err, "CreateChatCompletionStream error")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sub := openai.StreamChannel[openai.ChatCompletionStreamResponse](ctx, stream, 0)
+	<-sub.C
+	cancel()
+	select {
+	case _, ok := <-sub.C:
+		if ok {
+			t.Fatal("expected no more chunks")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("canceling the context did not end the stream")
+	}
+	checks.ErrorIs(t, sub.Err(), context.Canceled, "expected the cancellation")
+}
+
+func TestStreamTextReader(t *testing.T) {
+	client := startStreamTestClient(t, func(*openai.ClientConfig) {}, completeStreamHandler("Hello", ", ", "world"))
+	stream, err := client.CreateChatCompletionStream(context.Background(), routerRequest("m"))
+	checks.NoError(t, err, "CreateChatCompletionStream error")
+
+	reader := stream.TextReader()
+	defer reader.Close()
+	text, err := io.ReadAll(reader)
+	checks.NoError(t, err, "ReadAll error")
+	if string(text) != "Hello, world" {
+		t.Errorf("unexpected text %q", text)
+	}
+}
+
+func TestStreamTee(t *testing.T) {
+	upstream := newSliceStream(1, 2, 3)
+	tee := openai.NewStreamTee[int](upstream)
+	ui := tee.Subscribe(0, openai.BackpressureBlock)
+	logger := tee.Subscribe(3, openai.BackpressureBlock)
+	slow := tee.Subscribe(0, openai.BackpressureDisconnect)
+	tee.Start(context.Background())
+
+	late := tee.Subscribe(1, openai.BackpressureBlock)
+	if _, ok := <-late.C; ok || !errors.Is(late.Err(), openai.ErrStreamTeeStarted) {
+		t.Errorf("expected a late subscription to fail, got %v", late.Err())
+	}
+
+	var sum int
+	for chunk := range ui.C {
+		sum += chunk
+	}
+	checks.NoError(t, ui.Err(), "ui error")
+	if sum != 6 {
+		t.Errorf("expected every chunk, got a sum of %d", sum)
+	}
+	var logged int
+	for range logger.C {
+		logged++
+	}
+	if logged != 3 {
+		t.Errorf("expected the logger to get 3 chunks, got %d", logged)
+	}
+	for range slow.C {
+	}
+	checks.ErrorIs(t, slow.Err(), openai.ErrStreamSubscriberTooSlow, "expected the slow subscriber to be dropped")
+	upstream.waitClosed(t)
+}
+
+func TestStreamTeeCancelAll(t *testing.T) {
+	upstream := newSliceStream(1, 2, 3)
+	tee := openai.NewStreamTee[int](upstream)
+	sub := tee.Subscribe(0, openai.BackpressureBlock)
+	tee.Start(context.Background())
+
+	<-sub.C
+	sub.Cancel()
+	for range sub.C {
+	}
+	checks.ErrorIs(t, sub.Err(), context.Canceled, "expected the cancellation")
+	upstream.waitClosed(t)
+}
+
+func TestStreamTeeCancelClosesStalledStream(t *testing.T) {
+	disconnected := make(chan struct{})
+	stall := stallingStreamHandler("a")
+	client := startStreamTestClient(t, func(*openai.ClientConfig) {}, func(w http.ResponseWriter, r *http.Request) {
+		stall(w, r)
+		if r.Context().Err() != nil {
+			close(disconnected)
+		}
+	})
+	stream, err := client.CreateChatCompletionStream(context.Background(), routerRequest("m"))
+	checks.NoError(t, err, "CreateChatCompletionStream error")
+
+	tee := openai.NewStreamTee[openai.ChatCompletionStreamResponse](stream)
+	sub := tee.Subscribe(0, openai.BackpressureBlock)
+	tee.Start(context.Background())
+	<-sub.C
+	// The tee is now waiting for a chunk that never comes.
+	sub.Cancel()
+	select {
+	case <-disconnected:
+	case <-time.After(2 * time.Second):
+		t.Fatal("canceling the last subscription did not close the HTTP body")
+	}
+	for range sub.C {
+	}
+	checks.ErrorIs(t, sub.Err(), context.Canceled, "expected the cancellation")
+}
@@ -0,0 +1,282 @@
+package openai
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+)
+
+// Server-sent event names used by StreamProxy besides the default message
+// event, which carries the chunks.
+const (
+	StreamProxyEventToolCalls = "tool_calls"
+	StreamProxyEventUsage     = "usage"
+	StreamProxyEventError     = "error"
+)
+
+// StreamProxyToolCalls is the data of a tool_calls event: the assembled tool
+// calls of the choice with the given index.
+type StreamProxyToolCalls struct {
+	Index     int        `json:"index"`
+	ToolCalls []ToolCall `json:"tool_calls"`
+}
+
+// StreamProxy is an http.Handler that runs a chat completion stream and
+// relays it to the client as server-sent events in the OpenAI format: one
+// data event per chunk, then data: [DONE]. When the answer has tool calls,
+// the assembled calls of each choice follow as a tool_calls event holding a
+// StreamProxyToolCalls, in choice index order; usage follows as a usage
+// event if IncludeUsage is set; a failure part way ends the stream with an
+// error event. The upstream request is canceled when the client disconnects.
+type StreamProxy struct {
+	client  *Client
+	request func(r *http.Request) (ChatCompletionRequest, error)
+
+	// Transform, if set, is called on every chunk before it is sent, e.g. to
+	// redact content. Returning false drops the chunk. The tool_calls event
+	// is assembled from the transformed chunks.
+	Transform func(chunk *ChatCompletionStreamResponse) bool
+	// IncludeUsage requests token usage and sends it as a final usage event.
+	IncludeUsage bool
+	// Options are applied to every upstream call.
+	Options []RequestOption
+}
+
+// NewStreamProxy creates a StreamProxy that sends the same request for every
+// incoming request.
+func NewStreamProxy(client *Client, request ChatCompletionRequest) *StreamProxy {
+	return NewStreamProxyFunc(client, func(*http.Request) (ChatCompletionRequest, error) {
+		return request, nil
+	})
+}
+
+// NewStreamProxyFunc creates a StreamProxy that builds the chat completion
+// request from the incoming request. If build fails, the client gets a 400
+// with the error message.
+func NewStreamProxyFunc(
+	client *Client,
+	build func(r *http.Request) (ChatCompletionRequest, error),
+) *StreamProxy {
+	return &StreamProxy{client: client, request: build}
+}
+
+func (p *StreamProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	request, err := p.request(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if p.IncludeUsage {
+		request.StreamOptions = &StreamOptions{IncludeUsage: true}
+	}
+
+	// The stream uses the incoming request's context, so a client that goes
+	// away cancels it.
+	stream, err := p.client.CreateChatCompletionStream(r.Context(), request, p.Options...)
+	if err != nil {
+		status := http.StatusBadGateway
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.HTTPStatusCode >= 400 && apiErr.HTTPStatusCode < 500 {
+			status = apiErr.HTTPStatusCode
+		}
+		writeJSONError(w, status, err)
+		return
+	}
+	defer stream.Close()
+
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	sse := &sseWriter{w: w, flusher: flusher}
+	var (
+		toolCalls choiceToolCalls
+		usage     *Usage
+	)
+	for {
+		chunk, recvErr := stream.Recv()
+		if errors.Is(recvErr, io.EOF) {
+			break
+		}
+		if recvErr != nil {
+			if r.Context().Err() == nil {
+				sse.event(StreamProxyEventError, ErrorResponse{Error: streamProxyError(recvErr)})
+			}
+			return
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+		if p.IncludeUsage && len(chunk.Choices) == 0 && chunk.Usage != nil {
+			// The usage chunk is sent as a usage event instead.
+			continue
+		}
+		if p.Transform != nil && !p.Transform(&chunk) {
+			continue
+		}
+		for _, choice := range chunk.Choices {
+			toolCalls.add(choice.Index, choice.Delta.ToolCalls)
+		}
+		if !sse.event("", chunk) {
+			return
+		}
+	}
+
+	for _, calls := range toolCalls.calls() {
+		if !sse.event(StreamProxyEventToolCalls, calls) {
+			return
+		}
+	}
+	if p.IncludeUsage && usage != nil && !sse.event(StreamProxyEventUsage, usage) {
+		return
+	}
+	sse.done()
+}
+
+// streamProxyError returns the error sent to the client. Only API errors are
+// passed on; anything else may describe the proxy's own network.
+func streamProxyError(err error) *APIError {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return &APIError{Code: apiErr.Code, Message: apiErr.Message, Type: apiErr.Type}
+	}
+	if errors.Is(err, ErrStreamTimeout) {
+		return &APIError{Message: "the upstream stream timed out", Type: "server_error"}
+	}
+	return &APIError{Message: "the upstream stream failed", Type: "server_error"}
+}
+
+func writeJSONError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(ErrorResponse{Error: streamProxyError(err)})
+}
+
+// sseWriter writes server-sent events and flushes each one. It stops at the
+// first write error, which means the client went away.
+type sseWriter struct {
+	w       io.Writer
+	flusher http.Flusher
+	err     error
+}
+
+func (s *sseWriter) event(name string, v any) bool {
+	data, err := json.Marshal(v)
+	if err != nil {
+		s.err = err
+		return false
+	}
+	return s.write(name, data)
+}
+
+func (s *sseWriter) done() bool {
+	return s.write("", []byte("[DONE]"))
+}
+
+func (s *sseWriter) write(name string, data []byte) bool {
+	if s.err != nil {
+		return false
+	}
+	if name != "" {
+		_, s.err = fmt.Fprintf(s.w, "event: %s\n", name)
+	}
+	if s.err == nil {
+		_, s.err = fmt.Fprintf(s.w, "data: %s\n\n", data)
+	}
+	if s.err != nil {
+		return false
+	}
+	s.flusher.Flush()
+	return true
+}
+
+// choiceToolCalls assembles the tool calls of each choice of a stream.
+type choiceToolCalls struct {
+	byChoice map[int]*toolCallBuilder
+}
+
+func (c *choiceToolCalls) add(choice int, deltas []ToolCall) {
+	if len(deltas) == 0 {
+		return
+	}
+	if c.byChoice == nil {
+		c.byChoice = make(map[int]*toolCallBuilder)
+	}
+	builder, ok := c.byChoice[choice]
+	if !ok {
+		builder = &toolCallBuilder{}
+		c.byChoice[choice] = builder
+	}
+	builder.add(deltas)
+}
+
+// calls returns the assembled calls of each choice that has any, in choice
+// index order.
+func (c *choiceToolCalls) calls() []StreamProxyToolCalls {
+	choices := make([]int, 0, len(c.byChoice))
+	for choice := range c.byChoice {
+		choices = append(choices, choice)
+	}
+	sort.Ints(choices)
+	calls := make([]StreamProxyToolCalls, 0, len(choices))
+	for _, choice := range choices {
+		calls = append(calls, StreamProxyToolCalls{Index: choice, ToolCalls: c.byChoice[choice].calls()})
+	}
+	return calls
+}
+
+// toolCallBuilder assembles the tool calls of one choice from their deltas.
+type toolCallBuilder struct {
+	byIndex map[int]*ToolCall
+}
+
+func (b *toolCallBuilder) add(deltas []ToolCall) {
+	for _, delta := range deltas {
+		index := 0
+		if delta.Index != nil {
+			index = *delta.Index
+		}
+		if b.byIndex == nil {
+			b.byIndex = make(map[int]*ToolCall)
+		}
+		call, ok := b.byIndex[index]
+		if !ok {
+			call = &ToolCall{}
+			b.byIndex[index] = call
+		}
+		if delta.ID != "" {
+			call.ID = delta.ID
+		}
+		if delta.Type != "" {
+			call.Type = delta.Type
+		}
+		call.Function.Name += delta.Function.Name
+		call.Function.Arguments += delta.Function.Arguments
+	}
+}
+
+// calls returns the assembled calls in index order.
+func (b *toolCallBuilder) calls() []ToolCall {
+	indexes := make([]int, 0, len(b.byIndex))
+	for index := range b.byIndex {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	calls := make([]ToolCall, 0, len(indexes))
+	for _, index := range indexes {
+		calls = append(calls, *b.byIndex[index])
+	}
+	return calls
+}
@@ -0,0 +1,222 @@
+package openai_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gradientlabs-ai/go-openai"
+	"github.com/gradientlabs-ai/go-openai/internal/test/checks"
+)
+
+type sseEvent struct {
+	name string
+	data string
+}
+
+func readSSEEvents(t *testing.T, body io.Reader) []sseEvent {
+	t.Helper()
+	var (
+		events  []sseEvent
+		current sseEvent
+	)
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			current.name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			current.data = strings.TrimPrefix(line, "data: ")
+		case line == "":
+			events = append(events, current)
+			current = sseEvent{}
+		}
+	}
+	checks.NoError(t, scanner.Err(), "reading events")
+	return events
+}
+
+func TestStreamProxy(t *testing.T) {
+	client := startStreamTestClient(t, func(*openai.ClientConfig) {}, func(w http.ResponseWriter, r *http.Request) {
+		var request openai.ChatCompletionRequest
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		if request.StreamOptions == nil || !request.StreamOptions.IncludeUsage {
+			http.Error(w, "expected include_usage", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, chunk := range []string{
+			`{"choices":[{"delta":{"content":"my secret"}}]}`,
+			`{"choices":[{"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function",` +
+				`"function":{"name":"lookup","arguments":"{\"q\":"}}]}}]}`,
+			`{"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"x\"}"}}]},` +
+				`"finish_reason":"tool_calls"}]}`,
+			`{"choices":[],"usage":{"prompt_tokens":3,"completion_tokens":5,"total_tokens":8}}`,
+		} {
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	})
+
+	proxy := openai.NewStreamProxy(client, routerRequest("m"))
+	proxy.IncludeUsage = true
+	proxy.Transform = func(chunk *openai.ChatCompletionStreamResponse) bool {
+		for i := range chunk.Choices {
+			chunk.Choices[i].Delta.Content = strings.ReplaceAll(chunk.Choices[i].Delta.Content, "secret", "[redacted]")
+		}
+		return true
+	}
+	server := httptest.NewServer(proxy)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	checks.NoError(t, err, "GET error")
+	defer resp.Body.Close()
+	if resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("unexpected content type %q", resp.Header.Get("Content-Type"))
+	}
+	events := readSSEEvents(t, resp.Body)
+	if len(events) != 6 {
+		t.Fatalf("expected 6 events, got %+v", events)
+	}
+	if !strings.Contains(events[0].data, "my [redacted]") {
+		t.Errorf("expected the content to be redacted, got %s", events[0].data)
+	}
+	var calls openai.StreamProxyToolCalls
+	checks.NoError(t, json.Unmarshal([]byte(events[3].data), &calls), "decoding tool calls")
+	if events[3].name != openai.StreamProxyEventToolCalls || calls.Index != 0 || len(calls.ToolCalls) != 1 ||
+		calls.ToolCalls[0].ID != "call_1" || calls.ToolCalls[0].Function.Arguments != `{"q":"x"}` {
+		t.Errorf("unexpected tool calls event %+v", events[3])
+	}
+	var usage openai.Usage
+	checks.NoError(t, json.Unmarshal([]byte(events[4].data), &usage), "decoding usage")
+	if events[4].name != openai.StreamProxyEventUsage || usage.TotalTokens != 8 {
+		t.Errorf("unexpected usage event %+v", events[4])
+	}
+	if events[5].data != "[DONE]" {
+		t.Errorf("expected the stream to end with [DONE], got %+v", events[5])
+	}
+}
+
+func TestStreamProxyToolCallsPerChoice(t *testing.T) {
+	client := startStreamTestClient(t, func(*openai.ClientConfig) {}, func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, chunk := range []string{
+			`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_a","type":"function",` +
+				`"function":{"name":"lookup","arguments":"{\"q\":"}}]}},` +
+				`{"index":1,"delta":{"tool_calls":[{"index":0,"id":"call_b","type":"function",` +
+				`"function":{"name":"search","arguments":"{\"s\":"}}]}}]}`,
+			`{"choices":[{"index":1,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"y\"}"}}]}},` +
+				`{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"x\"}"}}]}}]}`,
+		} {
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	})
+	server := httptest.NewServer(openai.NewStreamProxy(client, routerRequest("m")))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	checks.NoError(t, err, "GET error")
+	defer resp.Body.Close()
+	events := readSSEEvents(t, resp.Body)
+	if len(events) != 5 {
+		t.Fatalf("expected 5 events, got %+v", events)
+	}
+	// The calls of the two choices share tool call index 0 but stay apart.
+	for i, want := range []openai.FunctionCall{
+		{Name: "lookup", Arguments: `{"q":"x"}`},
+		{Name: "search", Arguments: `{"s":"y"}`},
+	} {
+		event := events[2+i]
+		var calls openai.StreamProxyToolCalls
+		checks.NoError(t, json.Unmarshal([]byte(event.data), &calls), "decoding tool calls")
+		if event.name != openai.StreamProxyEventToolCalls || calls.Index != i ||
+			len(calls.ToolCalls) != 1 || calls.ToolCalls[0].Function != want {
+			t.Errorf("unexpected tool calls event %d: %+v", i, event)
+		}
+	}
+
+	// With tool calls on the second choice only, the event names that choice.
+	client = startStreamTestClient(t, func(*openai.ClientConfig) {}, func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, `data: {"choices":[{"index":0,"delta":{"content":"hi"}},`+
+			`{"index":1,"delta":{"tool_calls":[{"index":0,"id":"call_b","type":"function",`+
+			`"function":{"name":"search","arguments":"{}"}}]}}]}`+"\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	})
+	second := httptest.NewServer(openai.NewStreamProxy(client, routerRequest("m")))
+	defer second.Close()
+	resp, err = http.Get(second.URL)
+	checks.NoError(t, err, "GET error")
+	defer resp.Body.Close()
+	events = readSSEEvents(t, resp.Body)
+	var calls openai.StreamProxyToolCalls
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %+v", events)
+	}
+	checks.NoError(t, json.Unmarshal([]byte(events[1].data), &calls), "decoding tool calls")
+	if events[1].name != openai.StreamProxyEventToolCalls || calls.Index != 1 ||
+		len(calls.ToolCalls) != 1 || calls.ToolCalls[0].ID != "call_b" {
+		t.Errorf("unexpected tool calls event %+v", events[1])
+	}
+}
+
+func TestStreamProxyClientDisconnect(t *testing.T) {
+	canceled := make(chan struct{})
+	stall := stallingStreamHandler("a")
+	client := startStreamTestClient(t, func(*openai.ClientConfig) {}, func(w http.ResponseWriter, r *http.Request) {
+		stall(w, r)
+		if r.Context().Err() != nil {
+			close(canceled)
+		}
+	})
+	server := httptest.NewServer(openai.NewStreamProxy(client, routerRequest("m")))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	resp, err := http.DefaultClient.Do(req)
+	checks.NoError(t, err, "GET error")
+	defer resp.Body.Close()
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	checks.NoError(t, err, "reading the first event")
+	if !strings.HasPrefix(line, "data: ") {
+		t.Fatalf("unexpected first line %q", line)
+	}
+	cancel()
+	select {
+	case <-canceled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("the upstream request was not canceled")
+	}
+}
+
+func TestStreamProxyUpstreamError(t *testing.T) {
+	client := startStreamTestClient(t, func(*openai.ClientConfig) {}, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error":{"message":"bad model","type":"invalid_request_error"}}`)
+	})
+	server := httptest.NewServer(openai.NewStreamProxyFunc(client,
+		func(r *http.Request) (openai.ChatCompletionRequest, error) {
+			return routerRequest(r.URL.Query().Get("model")), nil
+		}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "?model=nope")
+	checks.NoError(t, err, "GET error")
+	defer resp.Body.Close()
+	var body openai.ErrorResponse
+	checks.NoError(t, json.NewDecoder(resp.Body).Decode(&body), "decoding the error")
+	if resp.StatusCode != http.StatusBadRequest || body.Error == nil || body.Error.Message != "bad model" {
+		t.Errorf("unexpected response %d %+v", resp.StatusCode, body.Error)
+	}
+}
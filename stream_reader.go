@@ -11,12 +11,13 @@ import (
 )
 
 var (
-	headerData  = []byte("data: ")
-	errorPrefix = []byte(`data: {"error":`)
+	headerData    = []byte("data: ")
+	errorPrefix   = []byte(`data: {"error":`)
+	commentPrefix = []byte(":")
 )
 
 type streamable interface {
-	ChatCompletionStreamResponse | CompletionResponse
+	ChatCompletionStreamResponse | CompletionResponse | SpeechStreamEvent | TranscriptionStreamEvent
 }
 
 type streamReader[T streamable] struct {
@@ -27,6 +28,7 @@ type streamReader[T streamable] struct {
 	response       *http.Response
 	errAccumulator utils.ErrorAccumulator
 	unmarshaler    utils.Unmarshaler
+	timer          readTimer
 
 	httpHeader
 }
@@ -37,7 +39,14 @@ func (stream *streamReader[T]) Recv() (response T, err error) {
 		return
 	}
 
+	timer := stream.timer.start(stream.abort)
 	response, err = stream.processLines()
+	if timeoutErr := stream.timer.stop(timer, err); timeoutErr != nil {
+		return *new(T), timeoutErr
+	}
+	if err == nil {
+		stream.timer.received = true
+	}
 	return
 }
 
@@ -59,6 +68,10 @@ func (stream *streamReader[T]) processLines() (T, error) {
 		}
 
 		noSpaceLine := bytes.TrimSpace(rawLine)
+		if bytes.HasPrefix(noSpaceLine, commentPrefix) {
+			// Comments are heartbeats that keep the connection open.
+			continue
+		}
 		if bytes.HasPrefix(noSpaceLine, errorPrefix) {
 			hasErrorPrefix = true
 		}
@@ -85,7 +98,7 @@ func (stream *streamReader[T]) processLines() (T, error) {
 		}
 
 		var response T
-		unmarshalErr := stream.unmarshaler.Unmarshal(noPrefixLine, &response)
+		unmarshalErr := unmarshalResponse(noPrefixLine, &response, stream.unmarshaler.Unmarshal)
 		if unmarshalErr != nil {
 			return *new(T), unmarshalErr
 		}
@@ -108,6 +121,11 @@ func (stream *streamReader[T]) unmarshalError() (errResp *ErrorResponse) {
 	return
 }
 
+// abort closes the connection of a stalled stream.
+func (stream *streamReader[T]) abort() {
+	_ = stream.Close()
+}
+
 func (stream *streamReader[T]) Close() error {
 	return stream.response.Body.Close()
 }
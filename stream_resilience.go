@@ -0,0 +1,210 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// ErrStreamTimeout is matched by every *StreamTimeoutError.
+var ErrStreamTimeout = errors.New("stream timed out")
+
+// StreamTimeoutError is returned by Recv when a stream waits longer than its
+// first token or idle timeout for the next chunk. The stream is closed.
+type StreamTimeoutError struct {
+	// FirstToken is true if no chunk had arrived yet.
+	FirstToken bool
+	Timeout    time.Duration
+}
+
+func (e *StreamTimeoutError) Error() string {
+	if e.FirstToken {
+		return fmt.Sprintf("%s: no first chunk after %s", ErrStreamTimeout, e.Timeout)
+	}
+	return fmt.Sprintf("%s: no chunk for %s", ErrStreamTimeout, e.Timeout)
+}
+
+func (e *StreamTimeoutError) Unwrap() error {
+	return ErrStreamTimeout
+}
+
+// WithStreamTimeouts overrides the client's StreamFirstTokenTimeout and
+// StreamIdleTimeout for one streaming call. Zero disables a timeout.
+func WithStreamTimeouts(firstToken, idle time.Duration) RequestOption {
+	return func(args *requestOptions) {
+		args.streamTimeouts = &streamTimeouts{firstToken: firstToken, idle: idle}
+	}
+}
+
+// WithStreamFallback makes a chat completion stream that is cut off part way,
+// by a stream timeout or a broken connection, finish with a non-streaming
+// request. The content received so far is sent back as a partial assistant
+// message for the model to continue from, and the rest of the answer arrives
+// as one final chunk. Streams with tool calls or several choices are not
+// retried.
+//
+// Resuming from the last event ID is not offered: chat completion streams
+// carry no event IDs, and this package has no Responses or Assistants
+// streaming endpoint whose events could be resumed.
+func WithStreamFallback() RequestOption {
+	return func(args *requestOptions) {
+		args.streamFallback = true
+	}
+}
+
+type streamTimeouts struct {
+	firstToken time.Duration
+	idle       time.Duration
+}
+
+// streamOptions returns the stream settings of a call.
+func (c *Client) streamOptions(opts []RequestOption) (streamTimeouts, bool) {
+	args := &requestOptions{header: make(http.Header), extraHeader: make(http.Header)}
+	withRequestOptions(opts)(args)
+	timeouts := streamTimeouts{firstToken: c.config.StreamFirstTokenTimeout, idle: c.config.StreamIdleTimeout}
+	if args.streamTimeouts != nil {
+		timeouts = *args.streamTimeouts
+	}
+	return timeouts, args.streamFallback
+}
+
+// readTimer closes the response body of a stream that waits too long for
+// its next chunk.
+type readTimer struct {
+	timeouts streamTimeouts
+	// started is when the first token timeout started counting.
+	started  time.Time
+	received bool
+	timedOut int32
+}
+
+// start arms the timer for the next chunk. It returns nil if there is no
+// timeout to enforce.
+func (t *readTimer) start(abort func()) *time.Timer {
+	timeout := t.timeouts.idle
+	wait := timeout
+	if !t.received {
+		timeout = t.timeouts.firstToken
+		wait = timeout - time.Since(t.started)
+	}
+	if timeout <= 0 {
+		return nil
+	}
+	return time.AfterFunc(wait, func() {
+		atomic.StoreInt32(&t.timedOut, 1)
+		abort()
+	})
+}
+
+// stop disarms timer and returns the timeout error if the read failed
+// because a timer fired. A chunk that was read before the body was closed is
+// still returned; the timeout is reported by the next read.
+func (t *readTimer) stop(timer *time.Timer, readErr error) error {
+	if timer != nil {
+		timer.Stop()
+	}
+	if readErr == nil || atomic.LoadInt32(&t.timedOut) == 0 {
+		return nil
+	}
+	if t.received {
+		return &StreamTimeoutError{Timeout: t.timeouts.idle}
+	}
+	return &StreamTimeoutError{FirstToken: true, Timeout: t.timeouts.firstToken}
+}
+
+// streamFallback finishes an interrupted chat completion stream without
+// streaming.
+type streamFallback struct {
+	ctx     context.Context
+	client  *Client
+	request ChatCompletionRequest
+	opts    []RequestOption
+
+	content   strings.Builder
+	toolCalls bool
+	finished  bool
+	// interruption is the error the fallback replaced.
+	interruption error
+}
+
+// observe records a chunk the caller received.
+func (f *streamFallback) observe(response ChatCompletionStreamResponse) {
+	for _, choice := range response.Choices {
+		f.content.WriteString(choice.Delta.Content)
+		if len(choice.Delta.ToolCalls) > 0 || choice.Delta.FunctionCall != nil {
+			f.toolCalls = true
+		}
+		if choice.FinishReason != "" {
+			f.finished = true
+		}
+	}
+}
+
+// applies reports whether the fallback should replace err.
+func (f *streamFallback) applies(err error, streamFinished bool) bool {
+	if f.interruption != nil || f.toolCalls || f.finished || f.request.N > 1 || f.ctx.Err() != nil {
+		return false
+	}
+	if errors.Is(err, io.EOF) {
+		// The body ended without [DONE] or a finish reason.
+		return !streamFinished
+	}
+	var netErr net.Error
+	return errors.Is(err, ErrStreamTimeout) || errors.Is(err, io.ErrUnexpectedEOF) || errors.As(err, &netErr)
+}
+
+// complete requests the rest of the answer and returns it as a chunk.
+func (f *streamFallback) complete(interruption error) (ChatCompletionStreamResponse, error) {
+	f.interruption = interruption
+	request := f.request
+	request.Stream = false
+	request.StreamOptions = nil
+	if partial := f.content.String(); partial != "" {
+		request.Messages = append(append([]ChatCompletionMessage(nil), request.Messages...), ChatCompletionMessage{
+			Role:    ChatMessageRoleAssistant,
+			Content: partial,
+		})
+	}
+	resp, err := f.client.CreateChatCompletion(f.ctx, request, f.opts...)
+	if err != nil {
+		return ChatCompletionStreamResponse{}, fmt.Errorf("%w (non-streaming fallback failed: %v)", interruption, err)
+	}
+
+	chunk := ChatCompletionStreamResponse{
+		ID:                resp.ID,
+		Object:            "chat.completion.chunk",
+		Created:           resp.Created,
+		Model:             resp.Model,
+		SystemFingerprint: resp.SystemFingerprint,
+		Usage:             &resp.Usage,
+	}
+	for _, choice := range resp.Choices {
+		chunk.Choices = append(chunk.Choices, ChatCompletionStreamChoice{
+			Index: choice.Index,
+			Delta: ChatCompletionStreamChoiceDelta{
+				Content:          choice.Message.Content,
+				ToolCalls:        choice.Message.ToolCalls,
+				ReasoningContent: choice.Message.ReasoningContent,
+				Reasoning:        choice.Message.Reasoning,
+			},
+			FinishReason:         choice.FinishReason,
+			ContentFilterResults: choice.ContentFilterResults,
+		})
+	}
+	return chunk, nil
+}
+
+// Interruption returns the error that cut the stream off if it was finished
+// by WithStreamFallback, and nil otherwise.
+func (stream *ChatCompletionStream) Interruption() error {
+	if stream.fallback == nil {
+		return nil
+	}
+	return stream.fallback.interruption
+}
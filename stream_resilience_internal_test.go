@@ -0,0 +1,27 @@
+package openai
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestReadTimerKeepsChunkReadBeforeTimeout(t *testing.T) {
+	aborted := make(chan struct{})
+	timer := &readTimer{timeouts: streamTimeouts{idle: time.Millisecond}, received: true}
+	armed := timer.start(func() { close(aborted) })
+	<-aborted
+
+	// The chunk was read before the body was closed, so it is not lost.
+	if err := timer.stop(armed, nil); err != nil {
+		t.Fatalf("expected a successful read to be returned, got %v", err)
+	}
+
+	// The read after it fails on the closed body and reports the timeout.
+	armed = timer.start(func() {})
+	var timeoutErr *StreamTimeoutError
+	if err := timer.stop(armed, io.ErrUnexpectedEOF); !errors.As(err, &timeoutErr) {
+		t.Fatalf("expected a StreamTimeoutError, got %v", err)
+	}
+}
@@ -0,0 +1,154 @@
+package openai_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gradientlabs-ai/go-openai"
+	"github.com/gradientlabs-ai/go-openai/internal/test"
+	"github.com/gradientlabs-ai/go-openai/internal/test/checks"
+)
+
+// stallingStreamHandler sends chunks and then stalls until the client goes
+// away.
+func stallingStreamHandler(chunks ...string) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		for _, chunk := range chunks {
+			fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"content\":%q}}]}\n\n", chunk)
+		}
+		w.(http.Flusher).Flush()
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+func startStreamTestClient(
+	t *testing.T,
+	config func(*openai.ClientConfig),
+	handler func(http.ResponseWriter, *http.Request),
+) *openai.Client {
+	t.Helper()
+	server := test.NewTestServer()
+	server.RegisterHandler("/v1/chat/completions", handler)
+	ts := server.OpenAITestServer()
+	ts.Start()
+	t.Cleanup(ts.Close)
+	clientConfig := openai.DefaultConfig(test.GetTestToken())
+	clientConfig.BaseURL = ts.URL + "/v1"
+	config(&clientConfig)
+	return openai.NewClientWithConfig(clientConfig)
+}
+
+func TestStreamFirstTokenTimeout(t *testing.T) {
+	client := startStreamTestClient(t, func(config *openai.ClientConfig) {
+		config.StreamFirstTokenTimeout = 50 * time.Millisecond
+	}, stallingStreamHandler())
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), routerRequest("m"))
+	checks.NoError(t, err, "CreateChatCompletionStream error")
+	defer stream.Close()
+
+	start := time.Now()
+	_, err = stream.Recv()
+	var timeoutErr *openai.StreamTimeoutError
+	if !errors.As(err, &timeoutErr) || !timeoutErr.FirstToken {
+		t.Fatalf("expected a first token timeout, got %v", err)
+	}
+	checks.ErrorIs(t, err, openai.ErrStreamTimeout, "expected ErrStreamTimeout")
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Recv took %s", elapsed)
+	}
+}
+
+func TestStreamIdleTimeout(t *testing.T) {
+	client := startStreamTestClient(t, func(*openai.ClientConfig) {}, stallingStreamHandler("Hello"))
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), routerRequest("m"),
+		openai.WithStreamTimeouts(time.Second, 50*time.Millisecond))
+	checks.NoError(t, err, "CreateChatCompletionStream error")
+	defer stream.Close()
+
+	_, err = stream.Recv()
+	checks.NoError(t, err, "first Recv error")
+	_, err = stream.Recv()
+	var timeoutErr *openai.StreamTimeoutError
+	if !errors.As(err, &timeoutErr) || timeoutErr.FirstToken || timeoutErr.Timeout != 50*time.Millisecond {
+		t.Fatalf("expected an idle timeout, got %v", err)
+	}
+	if !openai.IsRetryable(err) {
+		t.Error("a stream timeout should be retryable")
+	}
+}
+
+func TestStreamHeartbeats(t *testing.T) {
+	client := startStreamTestClient(t, func(config *openai.ClientConfig) {
+		config.EmptyMessagesLimit = 2
+	}, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, strings.Repeat(": keep-alive\n", 10))
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"hi\"}}]}\n\ndata: [DONE]\n\n")
+	})
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), routerRequest("m"))
+	checks.NoError(t, err, "CreateChatCompletionStream error")
+	defer stream.Close()
+	resp, err := stream.Recv()
+	checks.NoError(t, err, "heartbeats should be skipped")
+	if resp.Choices[0].Delta.Content != "hi" {
+		t.Errorf("unexpected content %q", resp.Choices[0].Delta.Content)
+	}
+}
+
+func TestStreamFallback(t *testing.T) {
+	stream := stallingStreamHandler("Hello", ",")
+	client := startStreamTestClient(t, func(config *openai.ClientConfig) {
+		config.StreamIdleTimeout = 50 * time.Millisecond
+	}, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") == "text/event-stream" {
+			stream(w, r)
+			return
+		}
+		var request openai.ChatCompletionRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Stream {
+			http.Error(w, "expected a non-streaming request", http.StatusBadRequest)
+			return
+		}
+		last := request.Messages[len(request.Messages)-1]
+		if last.Role != openai.ChatMessageRoleAssistant || last.Content != "Hello," {
+			http.Error(w, "expected the partial answer", http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, `{"id":"x","choices":[{"message":{"role":"assistant","content":" world"},"finish_reason":"stop"}]}`)
+	})
+
+	chatStream, err := client.CreateChatCompletionStream(context.Background(), routerRequest("m"),
+		openai.WithStreamFallback())
+	checks.NoError(t, err, "CreateChatCompletionStream error")
+	defer chatStream.Close()
+
+	var content strings.Builder
+	for {
+		resp, recvErr := chatStream.Recv()
+		if errors.Is(recvErr, io.EOF) {
+			break
+		}
+		checks.NoError(t, recvErr, "Recv error")
+		content.WriteString(resp.Choices[0].Delta.Content)
+	}
+	if content.String() != "Hello, world" {
+		t.Errorf("unexpected content %q", content.String())
+	}
+	checks.ErrorIs(t, chatStream.Interruption(), openai.ErrStreamTimeout, "expected the timeout to be recorded")
+}
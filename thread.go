@@ -16,6 +16,7 @@ type Thread struct {
 	Metadata  map[string]any `json:"metadata"`
 
 	httpHeader
+	rawJSON
 }
 
 type ThreadRequest struct {
@@ -46,12 +47,17 @@ type ThreadDeleteResponse struct {
 	Deleted bool   `json:"deleted"`
 
 	httpHeader
+	rawJSON
 }
 
 // CreateThread creates a new thread.
-func (c *Client) CreateThread(ctx context.Context, request ThreadRequest) (response Thread, err error) {
+func (c *Client) CreateThread(
+	ctx context.Context,
+	request ThreadRequest,
+	opts ...RequestOption,
+) (response Thread, err error) {
 	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL(threadsSuffix), withBody(request),
-		withBetaAssistantVersion(c.config.AssistantVersion))
+		withBetaAssistantVersion(c.config.AssistantVersion), withRequestOptions(opts))
 	if err != nil {
 		return
 	}
@@ -61,10 +67,14 @@ func (c *Client) CreateThread(ctx context.Context, request ThreadRequest) (respo
 }
 
 // RetrieveThread retrieves a thread.
-func (c *Client) RetrieveThread(ctx context.Context, threadID string) (response Thread, err error) {
+func (c *Client) RetrieveThread(
+	ctx context.Context,
+	threadID string,
+	opts ...RequestOption,
+) (response Thread, err error) {
 	urlSuffix := threadsSuffix + "/" + threadID
 	req, err := c.newRequest(ctx, http.MethodGet, c.fullURL(urlSuffix),
-		withBetaAssistantVersion(c.config.AssistantVersion))
+		withBetaAssistantVersion(c.config.AssistantVersion), withRequestOptions(opts))
 	if err != nil {
 		return
 	}
@@ -78,10 +88,11 @@ func (c *Client) ModifyThread(
 	ctx context.Context,
 	threadID string,
 	request ModifyThreadRequest,
+	opts ...RequestOption,
 ) (response Thread, err error) {
 	urlSuffix := threadsSuffix + "/" + threadID
 	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL(urlSuffix), withBody(request),
-		withBetaAssistantVersion(c.config.AssistantVersion))
+		withBetaAssistantVersion(c.config.AssistantVersion), withRequestOptions(opts))
 	if err != nil {
 		return
 	}
@@ -94,10 +105,11 @@ func (c *Client) ModifyThread(
 func (c *Client) DeleteThread(
 	ctx context.Context,
 	threadID string,
+	opts ...RequestOption,
 ) (response ThreadDeleteResponse, err error) {
 	urlSuffix := threadsSuffix + "/" + threadID
 	req, err := c.newRequest(ctx, http.MethodDelete, c.fullURL(urlSuffix),
-		withBetaAssistantVersion(c.config.AssistantVersion))
+		withBetaAssistantVersion(c.config.AssistantVersion), withRequestOptions(opts))
 	if err != nil {
 		return
 	}
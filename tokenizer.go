@@ -0,0 +1,66 @@
+package openai
+
+import (
+	"math"
+	"unicode/utf8"
+)
+
+// Tokenizer counts the tokens a piece of text consumes. It is used by helpers
+// that need to keep requests within model token limits. Plug in an exact BPE
+// tokenizer (e.g. a tiktoken port) when precise budgeting matters.
+type Tokenizer interface {
+	CountTokens(text string) int
+}
+
+const defaultCharsPerToken = 4
+
+// ApproximateTokenizer estimates token counts using OpenAI's rule of thumb of
+// roughly four characters per token for English text:
+// https://help.openai.com/en/articles/4936856-what-are-tokens-and-how-to-count-them
+type ApproximateTokenizer struct {
+	// CharsPerToken overrides the default ratio of four characters per token.
+	CharsPerToken float64
+}
+
+func (t ApproximateTokenizer) CountTokens(text string) int {
+	if text == "" {
+		return 0
+	}
+	ratio := t.CharsPerToken
+	if ratio <= 0 {
+		ratio = defaultCharsPerToken
+	}
+	return int(math.Ceil(float64(utf8.RuneCountInString(text)) / ratio))
+}
+
+const (
+	// tokensPerMessage and tokensPerName are the per-message overheads of the
+	// chat format. See:
+	// https://github.com/openai/openai-cookbook/blob/main/examples/How_to_count_tokens_with_tiktoken.ipynb
+	tokensPerMessage = 3
+	tokensPerName    = 1
+	// tokensPerReply accounts for the assistant reply priming added by the API.
+	tokensPerReply = 3
+)
+
+// CountMessageTokens estimates the prompt tokens consumed by messages in a chat
+// completion request, including the chat format overhead. Only text content is
+// counted; images and files are ignored.
+func CountMessageTokens(t Tokenizer, messages []ChatCompletionMessage) int {
+	total := tokensPerReply
+	for _, m := range messages {
+		total += tokensPerMessage
+		total += t.CountTokens(m.Role)
+		total += t.CountTokens(m.Content)
+		for _, part := range m.MultiContent {
+			total += t.CountTokens(part.Text)
+		}
+		if m.Name != "" {
+			total += t.CountTokens(m.Name) + tokensPerName
+		}
+		for _, call := range m.ToolCalls {
+			total += t.CountTokens(call.Function.Name) + t.CountTokens(call.Function.Arguments)
+		}
+	}
+	return total
+}
@@ -0,0 +1,49 @@
+package openai_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gradientlabs-ai/go-openai"
+)
+
+// wordTokenizer counts whitespace-separated words, which keeps token
+// arithmetic in tests easy to follow.
+type wordTokenizer struct{}
+
+func (wordTokenizer) CountTokens(text string) int {
+	return len(strings.Fields(text))
+}
+
+func TestApproximateTokenizer(t *testing.T) {
+	cases := []struct {
+		name      string
+		tokenizer openai.ApproximateTokenizer
+		text      string
+		expected  int
+	}{
+		{name: "empty", text: "", expected: 0},
+		{name: "short text rounds up", text: "hi", expected: 1},
+		{name: "four chars per token", text: "The food was delicious", expected: 6},
+		{name: "counts runes not bytes", text: "日本語のテキスト", expected: 2},
+		{name: "custom ratio", tokenizer: openai.ApproximateTokenizer{CharsPerToken: 2}, text: "abcdef", expected: 3},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := c.tokenizer.CountTokens(c.text); got != c.expected {
+				t.Errorf("CountTokens(%q) = %d, expected %d", c.text, got, c.expected)
+			}
+		})
+	}
+}
+
+func TestCountMessageTokens(t *testing.T) {
+	messages := []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleSystem, Content: "be brief"},
+		{Role: openai.ChatMessageRoleUser, Name: "bob", Content: "hello there friend"},
+	}
+	// 3 reply priming + 2*3 per message + roles (1 + 1) + contents (2 + 3) + name (1 + 1).
+	if got := openai.CountMessageTokens(wordTokenizer{}, messages); got != 18 {
+		t.Errorf("CountMessageTokens = %d, expected 18", got)
+	}
+}
@@ -0,0 +1,120 @@
+package openai
+
+import (
+	"errors"
+	"math"
+)
+
+var ErrInvalidVectorDimensions = errors.New("invalid vector dimensions")
+
+// CosineSimilarity returns the cosine of the angle between a and b, in the
+// range [-1, 1]. It returns zero when either vector has zero magnitude.
+func CosineSimilarity(a, b []float32) (float32, error) {
+	if len(a) != len(b) {
+		return 0, ErrVectorLengthMismatch
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0, nil
+	}
+	return float32(dot / (math.Sqrt(normA) * math.Sqrt(normB))), nil
+}
+
+// L2Distance returns the Euclidean distance between a and b.
+func L2Distance(a, b []float32) (float32, error) {
+	if len(a) != len(b) {
+		return 0, ErrVectorLengthMismatch
+	}
+	return float32(math.Sqrt(float64(squaredL2(a, b)))), nil
+}
+
+// NormalizeVector returns a copy of v scaled to unit length. A zero vector is
+// returned unchanged.
+func NormalizeVector(v []float32) []float32 {
+	out := make([]float32, len(v))
+	copy(out, v)
+	norm := vectorNorm(v)
+	if norm == 0 {
+		return out
+	}
+	for i := range out {
+		out[i] = float32(float64(out[i]) / norm)
+	}
+	return out
+}
+
+// TruncateVector shortens v to its first dimensions components and
+// re-normalizes the result. This is the Matryoshka-style shortening supported by
+// the text-embedding-3 models, and yields the same vectors the API returns when
+// EmbeddingRequest.Dimensions is set.
+func TruncateVector(v []float32, dimensions int) ([]float32, error) {
+	if dimensions <= 0 || dimensions > len(v) {
+		return nil, ErrInvalidVectorDimensions
+	}
+	return NormalizeVector(v[:dimensions]), nil
+}
+
+// CosineSimilarity calculates the cosine similarity between the embedding
+// vector and another embedding vector. Both vectors must have the same length;
+// otherwise, an ErrVectorLengthMismatch is returned.
+func (e *Embedding) CosineSimilarity(other *Embedding) (float32, error) {
+	return CosineSimilarity(e.Embedding, other.Embedding)
+}
+
+// L2Distance calculates the Euclidean distance between the embedding vector
+// and another embedding vector. Both vectors must have the same length;
+// otherwise, an ErrVectorLengthMismatch is returned.
+func (e *Embedding) L2Distance(other *Embedding) (float32, error) {
+	return L2Distance(e.Embedding, other.Embedding)
+}
+
+// Normalized returns a copy of the embedding with its vector scaled to unit
+// length. OpenAI embeddings are already normalized, so this is mainly useful
+// for vectors that were truncated or produced elsewhere.
+func (e *Embedding) Normalized() Embedding {
+	out := *e
+	out.Embedding = NormalizeVector(e.Embedding)
+	return out
+}
+
+// Truncated returns a copy of the embedding shortened to dimensions components
+// and re-normalized. See TruncateVector.
+func (e *Embedding) Truncated(dimensions int) (Embedding, error) {
+	v, err := TruncateVector(e.Embedding, dimensions)
+	if err != nil {
+		return Embedding{}, err
+	}
+	out := *e
+	out.Embedding = v
+	return out, nil
+}
+
+func dotProduct(a, b []float32) float32 {
+	var dot float32
+	for i := range a {
+		dot += a[i] * b[i]
+	}
+	return dot
+}
+
+func squaredL2(a, b []float32) float32 {
+	var sum float32
+	for i := range a {
+		d := a[i] - b[i]
+		sum += d * d
+	}
+	return sum
+}
+
+func vectorNorm(v []float32) float64 {
+	var sum float64
+	for _, f := range v {
+		sum += float64(f) * float64(f)
+	}
+	return math.Sqrt(sum)
+}
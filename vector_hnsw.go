@@ -0,0 +1,243 @@
+package openai
+
+import (
+	"container/heap"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+const (
+	defaultHNSWM              = 16
+	minHNSWM                  = 2
+	defaultHNSWEfConstruction = 200
+	defaultHNSWEfSearch       = 64
+	hnswRandomSeed            = 42
+)
+
+// HNSWOptions tunes the hierarchical navigable small world graph used by
+// VectorIndex when Algorithm is VectorSearchHNSW. Zero values select defaults
+// that work well for OpenAI embedding sizes.
+type HNSWOptions struct {
+	// M is the number of neighbors kept per node on upper layers. Layer zero
+	// keeps 2*M neighbors. Defaults to 16; it must be at least 2, since layer
+	// heights are drawn with a factor of 1/ln(M).
+	M int
+	// EfConstruction is the candidate list size used while inserting. Larger
+	// values build a better graph at the cost of slower inserts. Defaults to 200.
+	EfConstruction int
+	// EfSearch is the candidate list size used while searching. Larger values
+	// improve recall at the cost of latency. Defaults to 64.
+	EfSearch int
+}
+
+func (o HNSWOptions) withDefaults() HNSWOptions {
+	if o.M <= 0 {
+		o.M = defaultHNSWM
+	}
+	if o.EfConstruction <= 0 {
+		o.EfConstruction = defaultHNSWEfConstruction
+	}
+	if o.EfSearch <= 0 {
+		o.EfSearch = defaultHNSWEfSearch
+	}
+	return o
+}
+
+type hnswCandidate struct {
+	slot     int
+	distance float32
+}
+
+// hnswHeap is a binary heap of candidates ordered by distance. It is a min-heap
+// unless farthestFirst is set.
+type hnswHeap struct {
+	items         []hnswCandidate
+	farthestFirst bool
+}
+
+func (h *hnswHeap) Len() int { return len(h.items) }
+func (h *hnswHeap) Less(i, j int) bool {
+	if h.farthestFirst {
+		return h.items[i].distance > h.items[j].distance
+	}
+	return h.items[i].distance < h.items[j].distance
+}
+func (h *hnswHeap) Swap(i, j int)       { h.items[i], h.items[j] = h.items[j], h.items[i] }
+func (h *hnswHeap) Push(x any)          { h.items = append(h.items, x.(hnswCandidate)) } //nolint:forcetypeassert
+func (h *hnswHeap) peek() hnswCandidate { return h.items[0] }
+func (h *hnswHeap) Pop() any {
+	last := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return last
+}
+
+// hnswGraph is an approximate nearest neighbor graph over the slots of a
+// VectorIndex. See Malkov & Yashunin, "Efficient and robust approximate nearest
+// neighbor search using Hierarchical Navigable Small World graphs".
+type hnswGraph struct {
+	options   HNSWOptions
+	levelMult float64
+	rng       *rand.Rand
+
+	// neighbors[slot][level] lists the neighbor slots of a node on a layer.
+	neighbors [][][]int
+	entry     int
+	maxLevel  int
+
+	vector   func(slot int) []float32
+	distance func(a, b []float32) float32
+}
+
+func newHNSWGraph(
+	options HNSWOptions,
+	vector func(slot int) []float32,
+	distance func(a, b []float32) float32,
+) *hnswGraph {
+	options = options.withDefaults()
+	return &hnswGraph{
+		options:   options,
+		levelMult: 1 / math.Log(float64(options.M)),
+		rng:       rand.New(rand.NewSource(hnswRandomSeed)), //nolint:gosec // not used for security
+		entry:     -1,
+		vector:    vector,
+		distance:  distance,
+	}
+}
+
+func (g *hnswGraph) maxConnections(level int) int {
+	if level == 0 {
+		return 2 * g.options.M
+	}
+	return g.options.M
+}
+
+// insert links slot into the graph. Slots must be inserted in increasing order.
+func (g *hnswGraph) insert(slot int) {
+	level := int(-math.Log(1-g.rng.Float64()) * g.levelMult)
+	g.neighbors = append(g.neighbors, make([][]int, level+1))
+
+	if g.entry < 0 {
+		g.entry, g.maxLevel = slot, level
+		return
+	}
+
+	query := g.vector(slot)
+	entry := hnswCandidate{slot: g.entry, distance: g.distance(query, g.vector(g.entry))}
+	for l := g.maxLevel; l > level; l-- {
+		entry = g.greedyClosest(query, entry, l)
+	}
+
+	top := level
+	if g.maxLevel < top {
+		top = g.maxLevel
+	}
+	entries := []hnswCandidate{entry}
+	for l := top; l >= 0; l-- {
+		candidates := g.searchLayer(query, entries, g.options.EfConstruction, l)
+		selected := candidates
+		if len(selected) > g.options.M {
+			selected = selected[:g.options.M]
+		}
+		links := make([]int, len(selected))
+		for i, c := range selected {
+			links[i] = c.slot
+			g.link(c.slot, slot, l)
+		}
+		g.neighbors[slot][l] = links
+		entries = candidates
+	}
+
+	if level > g.maxLevel {
+		g.entry, g.maxLevel = slot, level
+	}
+}
+
+// link adds to as a neighbor of from on level, pruning from's neighbor list
+// back to the closest maxConnections nodes when it overflows.
+func (g *hnswGraph) link(from, to, level int) {
+	links := append(g.neighbors[from][level], to)
+	if limit := g.maxConnections(level); len(links) > limit {
+		base := g.vector(from)
+		candidates := make([]hnswCandidate, len(links))
+		for i, n := range links {
+			candidates[i] = hnswCandidate{slot: n, distance: g.distance(base, g.vector(n))}
+		}
+		sort.Slice(candidates, func(i, j int) bool { return candidates[i].distance < candidates[j].distance })
+		links = links[:0]
+		for _, c := range candidates[:limit] {
+			links = append(links, c.slot)
+		}
+	}
+	g.neighbors[from][level] = links
+}
+
+func (g *hnswGraph) greedyClosest(query []float32, entry hnswCandidate, level int) hnswCandidate {
+	for changed := true; changed; {
+		changed = false
+		for _, n := range g.neighbors[entry.slot][level] {
+			if d := g.distance(query, g.vector(n)); d < entry.distance {
+				entry, changed = hnswCandidate{slot: n, distance: d}, true
+			}
+		}
+	}
+	return entry
+}
+
+// searchLayer returns up to ef candidates closest to query on level, sorted by
+// increasing distance.
+func (g *hnswGraph) searchLayer(query []float32, entries []hnswCandidate, ef, level int) []hnswCandidate {
+	visited := make(map[int]struct{}, ef*2)
+	candidates := &hnswHeap{}
+	results := &hnswHeap{farthestFirst: true}
+	for _, e := range entries {
+		if _, ok := visited[e.slot]; ok {
+			continue
+		}
+		visited[e.slot] = struct{}{}
+		heap.Push(candidates, e)
+		heap.Push(results, e)
+		if results.Len() > ef {
+			heap.Pop(results)
+		}
+	}
+
+	for candidates.Len() > 0 {
+		current, _ := heap.Pop(candidates).(hnswCandidate)
+		if results.Len() >= ef && current.distance > results.peek().distance {
+			break
+		}
+		for _, n := range g.neighbors[current.slot][level] {
+			if _, ok := visited[n]; ok {
+				continue
+			}
+			visited[n] = struct{}{}
+			d := g.distance(query, g.vector(n))
+			if results.Len() < ef || d < results.peek().distance {
+				heap.Push(candidates, hnswCandidate{slot: n, distance: d})
+				heap.Push(results, hnswCandidate{slot: n, distance: d})
+				if results.Len() > ef {
+					heap.Pop(results)
+				}
+			}
+		}
+	}
+
+	sorted := make([]hnswCandidate, results.Len())
+	for i := len(sorted) - 1; i >= 0; i-- {
+		sorted[i], _ = heap.Pop(results).(hnswCandidate)
+	}
+	return sorted
+}
+
+// search returns up to ef approximate nearest neighbors of query.
+func (g *hnswGraph) search(query []float32, ef int) []hnswCandidate {
+	if g.entry < 0 {
+		return nil
+	}
+	entry := hnswCandidate{slot: g.entry, distance: g.distance(query, g.vector(g.entry))}
+	for l := g.maxLevel; l > 0; l-- {
+		entry = g.greedyClosest(query, entry, l)
+	}
+	return g.searchLayer(query, []hnswCandidate{entry}, ef, 0)
+}
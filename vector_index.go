@@ -0,0 +1,572 @@
+package openai
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"sync"
+)
+
+var (
+	ErrVectorIndexEmptyID          = errors.New("vector record ID must not be empty")
+	ErrVectorIndexInvalidK         = errors.New("top-k must be greater than zero")
+	ErrVectorIndexInvalidFormat    = errors.New("invalid vector index file")
+	ErrVectorIndexUnknownMetric    = errors.New("unknown vector metric")
+	ErrVectorIndexUnknownAlgorithm = errors.New("unknown vector search algorithm")
+)
+
+// VectorMetric selects how VectorIndex compares vectors.
+type VectorMetric string
+
+const (
+	VectorMetricCosine     VectorMetric = "cosine"
+	VectorMetricDotProduct VectorMetric = "dot_product"
+	VectorMetricL2         VectorMetric = "l2"
+)
+
+// VectorSearchAlgorithm selects how VectorIndex finds nearest neighbors.
+type VectorSearchAlgorithm string
+
+const (
+	// VectorSearchBruteForce scans every record. It is exact and is the best
+	// choice for up to tens of thousands of vectors.
+	VectorSearchBruteForce VectorSearchAlgorithm = "brute_force"
+	// VectorSearchHNSW searches an approximate nearest neighbor graph, trading a
+	// little recall for sub-linear query time on large indexes.
+	VectorSearchHNSW VectorSearchAlgorithm = "hnsw"
+)
+
+// VectorIndexOptions configures a VectorIndex.
+type VectorIndexOptions struct {
+	// Dimensions is the length of every vector. When zero it is taken from the
+	// first record added.
+	Dimensions int
+	// Metric defaults to VectorMetricCosine.
+	Metric VectorMetric
+	// Algorithm defaults to VectorSearchBruteForce.
+	Algorithm VectorSearchAlgorithm
+	// HNSW tunes the graph when Algorithm is VectorSearchHNSW.
+	HNSW HNSWOptions
+}
+
+// VectorRecord is a vector stored in a VectorIndex together with an ID and
+// free-form metadata that search filters can match on.
+type VectorRecord struct {
+	ID       string
+	Vector   []float32
+	Metadata map[string]string
+}
+
+// VectorSearchResult is a record returned by VectorIndex.Search.
+type VectorSearchResult struct {
+	VectorRecord
+	// Score is the cosine similarity or dot product for those metrics, and the
+	// Euclidean distance for VectorMetricL2. Results are always ordered from
+	// the closest match to the farthest.
+	Score float32
+}
+
+// VectorFilter reports whether a record with the given metadata may be
+// returned by a search.
+type VectorFilter func(metadata map[string]string) bool
+
+// MetadataEquals returns a VectorFilter matching records whose metadata has
+// key set to value.
+func MetadataEquals(key, value string) VectorFilter {
+	return func(metadata map[string]string) bool {
+		v, ok := metadata[key]
+		return ok && v == value
+	}
+}
+
+type vectorSlot struct {
+	record  VectorRecord
+	vector  []float32 // normalized copy for VectorMetricCosine
+	deleted bool
+}
+
+// VectorIndex is a thread-safe in-memory vector store with top-k similarity
+// search. It is intended for small services that need retrieval without an
+// external vector database.
+type VectorIndex struct {
+	mu      sync.RWMutex
+	options VectorIndexOptions
+	slots   []vectorSlot
+	ids     map[string]int
+	graph   *hnswGraph
+	deleted int
+}
+
+// NewVectorIndex creates an empty VectorIndex.
+func NewVectorIndex(options VectorIndexOptions) (*VectorIndex, error) {
+	if options.Metric == "" {
+		options.Metric = VectorMetricCosine
+	}
+	if options.Algorithm == "" {
+		options.Algorithm = VectorSearchBruteForce
+	}
+	if options.Dimensions < 0 {
+		return nil, ErrInvalidVectorDimensions
+	}
+	switch options.Metric {
+	case VectorMetricCosine, VectorMetricDotProduct, VectorMetricL2:
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrVectorIndexUnknownMetric, options.Metric)
+	}
+
+	ix := &VectorIndex{options: options, ids: make(map[string]int)}
+	switch options.Algorithm {
+	case VectorSearchBruteForce:
+	case VectorSearchHNSW:
+		ix.options.HNSW = options.HNSW.withDefaults()
+		if ix.options.HNSW.M < minHNSWM {
+			return nil, fmt.Errorf("%w: HNSW M must be at least %d, got %d",
+				ErrVectorIndexInvalidFormat, minHNSWM, ix.options.HNSW.M)
+		}
+		ix.graph = newHNSWGraph(ix.options.HNSW, func(slot int) []float32 {
+			return ix.slots[slot].vector
+		}, ix.distance)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrVectorIndexUnknownAlgorithm, options.Algorithm)
+	}
+	return ix, nil
+}
+
+// distance returns a value that is smaller the closer a and b are, so that
+// all metrics can share the same search code.
+func (ix *VectorIndex) distance(a, b []float32) float32 {
+	switch ix.options.Metric {
+	case VectorMetricL2:
+		return squaredL2(a, b)
+	case VectorMetricCosine:
+		return 1 - dotProduct(a, b)
+	default:
+		return -dotProduct(a, b)
+	}
+}
+
+// score converts an internal distance into the public VectorSearchResult.Score.
+func (ix *VectorIndex) score(distance float32) float32 {
+	switch ix.options.Metric {
+	case VectorMetricL2:
+		return float32(math.Sqrt(float64(distance)))
+	case VectorMetricCosine:
+		return 1 - distance
+	default:
+		return -distance
+	}
+}
+
+// Options returns the options the index was created with, with defaults filled in.
+func (ix *VectorIndex) Options() VectorIndexOptions {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return ix.options
+}
+
+// Len returns the number of records in the index.
+func (ix *VectorIndex) Len() int {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return len(ix.ids)
+}
+
+// Add inserts records into the index, replacing any existing records with the
+// same IDs. Vectors are copied, so callers may reuse their slices.
+func (ix *VectorIndex) Add(records ...VectorRecord) error {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	// The whole batch is checked before anything is stored, so a failed Add
+	// leaves the index, including a size not yet fixed, as it was.
+	dimensions := ix.options.Dimensions
+	for _, record := range records {
+		if record.ID == "" {
+			return ErrVectorIndexEmptyID
+		}
+		if dimensions == 0 {
+			if len(record.Vector) == 0 {
+				return ErrInvalidVectorDimensions
+			}
+			dimensions = len(record.Vector)
+		}
+		if len(record.Vector) != dimensions {
+			return fmt.Errorf("%w: record %q has %d dimensions, index has %d",
+				ErrVectorLengthMismatch, record.ID, len(record.Vector), dimensions)
+		}
+	}
+	ix.options.Dimensions = dimensions
+
+	for _, record := range records {
+		if slot, ok := ix.ids[record.ID]; ok {
+			ix.slots[slot].deleted = true
+			ix.deleted++
+		}
+		stored := VectorRecord{
+			ID:       record.ID,
+			Vector:   append([]float32(nil), record.Vector...),
+			Metadata: copyMetadata(record.Metadata),
+		}
+		vector := stored.Vector
+		if ix.options.Metric == VectorMetricCosine {
+			vector = NormalizeVector(stored.Vector)
+		}
+		ix.slots = append(ix.slots, vectorSlot{record: stored, vector: vector})
+		slot := len(ix.slots) - 1
+		ix.ids[record.ID] = slot
+		if ix.graph != nil {
+			ix.graph.insert(slot)
+		}
+	}
+	ix.compact()
+	return nil
+}
+
+// AddEmbedding inserts an embedding returned by CreateEmbeddings under id.
+func (ix *VectorIndex) AddEmbedding(id string, embedding Embedding, metadata map[string]string) error {
+	return ix.Add(VectorRecord{ID: id, Vector: embedding.Embedding, Metadata: metadata})
+}
+
+// Delete removes the record with the given ID and reports whether it existed.
+func (ix *VectorIndex) Delete(id string) bool {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	slot, ok := ix.ids[id]
+	if !ok {
+		return false
+	}
+	// Deleted slots stay in the HNSW graph as routing nodes; they are skipped
+	// in results and dropped when the index is saved and loaded again.
+	ix.slots[slot].deleted = true
+	ix.deleted++
+	delete(ix.ids, id)
+	ix.compact()
+	return true
+}
+
+// compactThreshold is the minimum number of deleted slots before compact
+// rebuilds the index.
+const compactThreshold = 64
+
+// compact drops deleted slots once they outnumber live records, rebuilding the
+// HNSW graph if there is one. Callers must hold the write lock.
+func (ix *VectorIndex) compact() {
+	if ix.deleted < compactThreshold || ix.deleted < len(ix.ids) {
+		return
+	}
+	live := make([]vectorSlot, 0, len(ix.ids))
+	for _, s := range ix.slots {
+		if !s.deleted {
+			live = append(live, s)
+		}
+	}
+	ix.slots = live
+	ix.deleted = 0
+	if ix.graph != nil {
+		ix.graph = newHNSWGraph(ix.options.HNSW, ix.graph.vector, ix.graph.distance)
+	}
+	for slot, s := range ix.slots {
+		ix.ids[s.record.ID] = slot
+		if ix.graph != nil {
+			ix.graph.insert(slot)
+		}
+	}
+}
+
+// Get returns the record stored under id.
+func (ix *VectorIndex) Get(id string) (VectorRecord, bool) {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	slot, ok := ix.ids[id]
+	if !ok {
+		return VectorRecord{}, false
+	}
+	return ix.slots[slot].record, true
+}
+
+// Search returns the k records closest to query that match filter, ordered
+// from the closest. A nil filter matches every record.
+func (ix *VectorIndex) Search(query []float32, k int, filter VectorFilter) ([]VectorSearchResult, error) {
+	if k <= 0 {
+		return nil, ErrVectorIndexInvalidK
+	}
+
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	if len(ix.ids) == 0 {
+		return nil, nil
+	}
+	if len(query) != ix.options.Dimensions {
+		return nil, ErrVectorLengthMismatch
+	}
+	if ix.options.Metric == VectorMetricCosine {
+		query = NormalizeVector(query)
+	}
+
+	matches := func(slot int) bool {
+		s := ix.slots[slot]
+		return !s.deleted && (filter == nil || filter(s.record.Metadata))
+	}
+
+	var candidates []hnswCandidate
+	if ix.graph != nil {
+		ef := ix.options.HNSW.EfSearch
+		if ef < k {
+			ef = k
+		}
+		for _, c := range ix.graph.search(query, ef) {
+			if matches(c.slot) {
+				candidates = append(candidates, c)
+			}
+		}
+	}
+	// Fall back to an exact scan when the graph could not supply k matches,
+	// which happens for selective filters or heavily deleted indexes.
+	if len(candidates) < k && len(candidates) < len(ix.ids) {
+		candidates = candidates[:0]
+		for slot := range ix.slots {
+			if matches(slot) {
+				candidates = append(candidates, hnswCandidate{slot: slot, distance: ix.distance(query, ix.slots[slot].vector)})
+			}
+		}
+		sort.Slice(candidates, func(i, j int) bool { return candidates[i].distance < candidates[j].distance })
+	}
+
+	if len(candidates) > k {
+		candidates = candidates[:k]
+	}
+	results := make([]VectorSearchResult, len(candidates))
+	for i, c := range candidates {
+		results[i] = VectorSearchResult{VectorRecord: ix.slots[c.slot].record, Score: ix.score(c.distance)}
+	}
+	return results, nil
+}
+
+func copyMetadata(metadata map[string]string) map[string]string {
+	if metadata == nil {
+		return nil
+	}
+	out := make(map[string]string, len(metadata))
+	for k, v := range metadata {
+		out[k] = v
+	}
+	return out
+}
+
+// vectorIndexMagic identifies files written by VectorIndex.WriteTo.
+var vectorIndexMagic = [4]byte{'O', 'A', 'V', 'I'}
+
+const (
+	vectorIndexFormatVersion = 1
+	// maxVectorIndexStringLength guards against allocating huge buffers when
+	// reading a corrupt file.
+	maxVectorIndexStringLength = 1 << 24
+	// maxVectorIndexDimensions is well above the size of any embedding, and
+	// likewise guards the per-record vector buffer.
+	maxVectorIndexDimensions = 1 << 16
+	// maxVectorIndexPrealloc caps the capacity allocated up front from the
+	// counts in a file; larger collections grow as they are read.
+	maxVectorIndexPrealloc = 1 << 10
+)
+
+// WriteTo serializes the index in a compact little-endian binary format. Each
+// vector is stored as consecutive little-endian float32 values, the same layout
+// the API uses for base64-encoded embeddings, so a stored vector can be
+// base64-encoded and read back with EncodingFormat base64 semantics. The HNSW
+// graph is not stored; it is rebuilt when the index is read.
+func (ix *VectorIndex) WriteTo(w io.Writer) (int64, error) {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	bw := &countingWriter{w: bufio.NewWriter(w)}
+	bw.write(vectorIndexMagic[:])
+	bw.uint32(vectorIndexFormatVersion)
+	bw.string(string(ix.options.Metric))
+	bw.string(string(ix.options.Algorithm))
+	bw.uint32(uint32(ix.options.Dimensions))
+	bw.uint32(uint32(ix.options.HNSW.M))
+	bw.uint32(uint32(ix.options.HNSW.EfConstruction))
+	bw.uint32(uint32(ix.options.HNSW.EfSearch))
+	bw.uint32(uint32(len(ix.ids)))
+
+	for _, s := range ix.slots {
+		if s.deleted {
+			continue
+		}
+		bw.string(s.record.ID)
+		keys := make([]string, 0, len(s.record.Metadata))
+		for k := range s.record.Metadata {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		bw.uint32(uint32(len(keys)))
+		for _, k := range keys {
+			bw.string(k)
+			bw.string(s.record.Metadata[k])
+		}
+		bw.write(encodeEmbeddingVector(s.record.Vector))
+	}
+	if bw.err == nil {
+		bw.err = bw.w.Flush()
+	}
+	return bw.n, bw.err
+}
+
+// ReadVectorIndex reads an index previously written with VectorIndex.WriteTo.
+func ReadVectorIndex(r io.Reader) (*VectorIndex, error) {
+	br := &byteReader{r: bufio.NewReader(r)}
+	var magic [4]byte
+	br.read(magic[:])
+	if br.err == nil && magic != vectorIndexMagic {
+		return nil, ErrVectorIndexInvalidFormat
+	}
+	if version := br.uint32(); br.err == nil && version != vectorIndexFormatVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrVectorIndexInvalidFormat, version)
+	}
+	options := VectorIndexOptions{
+		Metric:     VectorMetric(br.string()),
+		Algorithm:  VectorSearchAlgorithm(br.string()),
+		Dimensions: int(br.uint32()),
+		HNSW: HNSWOptions{
+			M:              int(br.uint32()),
+			EfConstruction: int(br.uint32()),
+			EfSearch:       int(br.uint32()),
+		},
+	}
+	count := int(br.uint32())
+	if br.err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrVectorIndexInvalidFormat, br.err)
+	}
+	if options.Dimensions > maxVectorIndexDimensions {
+		return nil, fmt.Errorf("%w: %d dimensions exceeds limit", ErrVectorIndexInvalidFormat, options.Dimensions)
+	}
+	// WriteTo stores M after defaults, so an HNSW index never has M below 2.
+	if options.Algorithm == VectorSearchHNSW && options.HNSW.M < minHNSWM {
+		return nil, fmt.Errorf("%w: HNSW M of %d", ErrVectorIndexInvalidFormat, options.HNSW.M)
+	}
+
+	ix, err := NewVectorIndex(options)
+	if err != nil {
+		return nil, err
+	}
+	const sizeOfFloat32 = 4
+	records := make([]VectorRecord, 0, minInt(count, maxVectorIndexPrealloc))
+	for i := 0; i < count && br.err == nil; i++ {
+		record := VectorRecord{ID: br.string()}
+		if n := int(br.uint32()); n > 0 {
+			record.Metadata = make(map[string]string, minInt(n, maxVectorIndexPrealloc))
+			for j := 0; j < n && br.err == nil; j++ {
+				k := br.string()
+				record.Metadata[k] = br.string()
+			}
+		}
+		raw := make([]byte, options.Dimensions*sizeOfFloat32)
+		br.read(raw)
+		record.Vector = decodeEmbeddingVector(raw)
+		records = append(records, record)
+	}
+	if br.err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrVectorIndexInvalidFormat, br.err)
+	}
+	if err = ix.Add(records...); err != nil {
+		return nil, err
+	}
+	return ix, nil
+}
+
+// SaveFile writes the index to path, replacing any existing file.
+func (ix *VectorIndex) SaveFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err = ix.WriteTo(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// LoadVectorIndexFile reads an index written with VectorIndex.SaveFile.
+func LoadVectorIndexFile(path string) (*VectorIndex, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadVectorIndex(f)
+}
+
+// countingWriter writes little-endian values and remembers the first error.
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (cw *countingWriter) write(p []byte) {
+	if cw.err != nil {
+		return
+	}
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	cw.err = err
+}
+
+func (cw *countingWriter) uint32(v uint32) {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], v)
+	cw.write(buf[:])
+}
+
+func (cw *countingWriter) string(s string) {
+	cw.uint32(uint32(len(s)))
+	cw.write([]byte(s))
+}
+
+// byteReader reads little-endian values and remembers the first error.
+type byteReader struct {
+	r   *bufio.Reader
+	err error
+}
+
+func (br *byteReader) read(p []byte) {
+	if br.err != nil {
+		return
+	}
+	_, br.err = io.ReadFull(br.r, p)
+}
+
+func (br *byteReader) uint32() uint32 {
+	var buf [4]byte
+	br.read(buf[:])
+	return binary.LittleEndian.Uint32(buf[:])
+}
+
+func (br *byteReader) string() string {
+	n := br.uint32()
+	if br.err == nil && n > maxVectorIndexStringLength {
+		br.err = fmt.Errorf("string of %d bytes exceeds limit", n)
+	}
+	if br.err != nil {
+		return ""
+	}
+	buf := make([]byte, n)
+	br.read(buf)
+	return string(buf)
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,326 @@
+package openai_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/gradientlabs-ai/go-openai"
+	"github.com/gradientlabs-ai/go-openai/internal/test"
+	"github.com/gradientlabs-ai/go-openai/internal/test/checks"
+)
+
+func randomVectors(n, dims int, seed int64) [][]float32 {
+	rng := rand.New(rand.NewSource(seed)) //nolint:gosec // deterministic test data
+	vectors := make([][]float32, n)
+	for i := range vectors {
+		vectors[i] = make([]float32, dims)
+		for j := range vectors[i] {
+			vectors[i][j] = rng.Float32()*2 - 1
+		}
+	}
+	return vectors
+}
+
+func TestVectorIndexBruteForceSearch(t *testing.T) {
+	ix, err := openai.NewVectorIndex(openai.VectorIndexOptions{})
+	checks.NoError(t, err, "NewVectorIndex error")
+
+	checks.NoError(t, ix.Add(
+		openai.VectorRecord{ID: "x", Vector: []float32{1, 0}, Metadata: map[string]string{"lang": "en"}},
+		openai.VectorRecord{ID: "y", Vector: []float32{0, 1}, Metadata: map[string]string{"lang": "fr"}},
+		openai.VectorRecord{ID: "xy", Vector: []float32{1, 1}, Metadata: map[string]string{"lang": "fr"}},
+	))
+
+	results, err := ix.Search([]float32{1, 0.1}, 2, nil)
+	checks.NoError(t, err, "Search error")
+	if len(results) != 2 || results[0].ID != "x" || results[1].ID != "xy" {
+		t.Fatalf("unexpected results %+v", results)
+	}
+	if results[0].Score <= results[1].Score {
+		t.Errorf("cosine scores should decrease, got %v then %v", results[0].Score, results[1].Score)
+	}
+
+	results, err = ix.Search([]float32{1, 0.1}, 5, openai.MetadataEquals("lang", "fr"))
+	checks.NoError(t, err, "Search error")
+	if len(results) != 2 || results[0].ID != "xy" || results[1].ID != "y" {
+		t.Fatalf("unexpected filtered results %+v", results)
+	}
+
+	_, err = ix.Search([]float32{1}, 1, nil)
+	checks.ErrorIs(t, err, openai.ErrVectorLengthMismatch, "query dimensions must match")
+	_, err = ix.Search([]float32{1, 0}, 0, nil)
+	checks.ErrorIs(t, err, openai.ErrVectorIndexInvalidK, "k must be positive")
+	err = ix.Add(openai.VectorRecord{ID: "z", Vector: []float32{1, 2, 3}})
+	checks.ErrorIs(t, err, openai.ErrVectorLengthMismatch, "record dimensions must match")
+	err = ix.Add(openai.VectorRecord{Vector: []float32{1, 2}})
+	checks.ErrorIs(t, err, openai.ErrVectorIndexEmptyID, "record ID is required")
+}
+
+func TestVectorIndexMetrics(t *testing.T) {
+	for _, metric := range []openai.VectorMetric{openai.VectorMetricDotProduct, openai.VectorMetricL2} {
+		t.Run(string(metric), func(t *testing.T) {
+			ix, err := openai.NewVectorIndex(openai.VectorIndexOptions{Metric: metric})
+			checks.NoError(t, err, "NewVectorIndex error")
+			checks.NoError(t, ix.Add(
+				openai.VectorRecord{ID: "near", Vector: []float32{1, 1}},
+				openai.VectorRecord{ID: "far", Vector: []float32{-3, -4}},
+			))
+			results, err := ix.Search([]float32{1, 1}, 2, nil)
+			checks.NoError(t, err, "Search error")
+			if results[0].ID != "near" {
+				t.Fatalf("unexpected order %+v", results)
+			}
+			l2Mismatch := !almostEqual(results[0].Score, 0) || !almostEqual(results[1].Score, 6.4031243)
+			if metric == openai.VectorMetricL2 && l2Mismatch {
+				t.Errorf("unexpected L2 scores %+v", results)
+			}
+			if metric == openai.VectorMetricDotProduct && !almostEqual(results[0].Score, 2) {
+				t.Errorf("unexpected dot product score %+v", results)
+			}
+		})
+	}
+
+	_, err := openai.NewVectorIndex(openai.VectorIndexOptions{Metric: "manhattan"})
+	checks.ErrorIs(t, err, openai.ErrVectorIndexUnknownMetric, "unknown metric should be rejected")
+	_, err = openai.NewVectorIndex(openai.VectorIndexOptions{Algorithm: "annoy"})
+	checks.ErrorIs(t, err, openai.ErrVectorIndexUnknownAlgorithm, "unknown algorithm should be rejected")
+}
+
+func TestVectorIndexDeleteAndReplace(t *testing.T) {
+	for _, algorithm := range []openai.VectorSearchAlgorithm{openai.VectorSearchBruteForce, openai.VectorSearchHNSW} {
+		t.Run(string(algorithm), func(t *testing.T) {
+			ix, err := openai.NewVectorIndex(openai.VectorIndexOptions{Algorithm: algorithm})
+			checks.NoError(t, err, "NewVectorIndex error")
+			checks.NoError(t, ix.Add(
+				openai.VectorRecord{ID: "a", Vector: []float32{1, 0}},
+				openai.VectorRecord{ID: "b", Vector: []float32{0, 1}},
+			))
+
+			if !ix.Delete("a") || ix.Delete("a") {
+				t.Fatal("Delete should report whether the record existed")
+			}
+			results, err := ix.Search([]float32{1, 0}, 2, nil)
+			checks.NoError(t, err, "Search error")
+			if len(results) != 1 || results[0].ID != "b" {
+				t.Fatalf("deleted record returned: %+v", results)
+			}
+
+			checks.NoError(t, ix.Add(openai.VectorRecord{ID: "b", Vector: []float32{1, 0}}))
+			if ix.Len() != 1 {
+				t.Fatalf("expected replace to keep 1 record, got %d", ix.Len())
+			}
+			record, ok := ix.Get("b")
+			if !ok || record.Vector[0] != 1 {
+				t.Fatalf("expected replaced vector, got %+v", record)
+			}
+
+			// Churn enough records to trigger compaction.
+			for i := 0; i < 200; i++ {
+				checks.NoError(t, ix.Add(openai.VectorRecord{ID: "b", Vector: []float32{float32(i), 1}}))
+			}
+			if ix.Len() != 1 {
+				t.Fatalf("expected 1 record after churn, got %d", ix.Len())
+			}
+			results, err = ix.Search([]float32{1, 0}, 1, nil)
+			checks.NoError(t, err, "Search error")
+			if len(results) != 1 || results[0].Vector[0] != 199 {
+				t.Fatalf("unexpected results after compaction: %+v", results)
+			}
+		})
+	}
+}
+
+func TestVectorIndexHNSWRecall(t *testing.T) {
+	const (
+		dims    = 32
+		n       = 2000
+		queries = 50
+		k       = 10
+	)
+	exact, err := openai.NewVectorIndex(openai.VectorIndexOptions{})
+	checks.NoError(t, err, "NewVectorIndex error")
+	approx, err := openai.NewVectorIndex(openai.VectorIndexOptions{Algorithm: openai.VectorSearchHNSW})
+	checks.NoError(t, err, "NewVectorIndex error")
+
+	records := make([]openai.VectorRecord, n)
+	for i, v := range randomVectors(n, dims, 1) {
+		records[i] = openai.VectorRecord{ID: fmt.Sprint(i), Vector: v}
+	}
+	checks.NoError(t, exact.Add(records...))
+	checks.NoError(t, approx.Add(records...))
+
+	var hits int
+	for _, q := range randomVectors(queries, dims, 2) {
+		want, _ := exact.Search(q, k, nil)
+		got, _ := approx.Search(q, k, nil)
+		wantIDs := make(map[string]bool, k)
+		for _, r := range want {
+			wantIDs[r.ID] = true
+		}
+		for _, r := range got {
+			if wantIDs[r.ID] {
+				hits++
+			}
+		}
+	}
+	if recall := float64(hits) / float64(queries*k); recall < 0.9 {
+		t.Errorf("HNSW recall too low: %.2f", recall)
+	}
+}
+
+func TestVectorIndexPersistence(t *testing.T) {
+	dir, cleanup := test.CreateTestDirectory(t)
+	defer cleanup()
+
+	ix, err := openai.NewVectorIndex(openai.VectorIndexOptions{
+		Algorithm: openai.VectorSearchHNSW,
+		HNSW:      openai.HNSWOptions{M: 8},
+	})
+	checks.NoError(t, err, "NewVectorIndex error")
+	checks.NoError(t, ix.Add(
+		openai.VectorRecord{ID: "a", Vector: []float32{0.25, -1.5, 3}, Metadata: map[string]string{"doc": "1"}},
+		openai.VectorRecord{ID: "b", Vector: []float32{1, 2, 3}},
+		openai.VectorRecord{ID: "gone", Vector: []float32{1, 1, 1}},
+	))
+	ix.Delete("gone")
+
+	path := filepath.Join(dir, "index.bin")
+	checks.NoError(t, ix.SaveFile(path), "SaveFile error")
+	loaded, err := openai.LoadVectorIndexFile(path)
+	checks.NoError(t, err, "LoadVectorIndexFile error")
+
+	if loaded.Len() != 2 {
+		t.Fatalf("expected 2 records, got %d", loaded.Len())
+	}
+	options := loaded.Options()
+	if options.Algorithm != openai.VectorSearchHNSW || options.HNSW.M != 8 || options.Dimensions != 3 {
+		t.Errorf("options not restored: %+v", options)
+	}
+	record, ok := loaded.Get("a")
+	if !ok || record.Metadata["doc"] != "1" || record.Vector[0] != 0.25 || record.Vector[1] != -1.5 {
+		t.Errorf("record not restored: %+v", record)
+	}
+	results, err := loaded.Search([]float32{1, 2, 3}, 1, nil)
+	checks.NoError(t, err, "Search error")
+	if len(results) != 1 || results[0].ID != "b" {
+		t.Errorf("unexpected results after load: %+v", results)
+	}
+
+	_, err = openai.ReadVectorIndex(bytes.NewReader([]byte("not an index")))
+	checks.ErrorIs(t, err, openai.ErrVectorIndexInvalidFormat, "garbage input should be rejected")
+}
+
+func TestReadVectorIndexRejectsHugeHeaders(t *testing.T) {
+	header := func(dimensions, count, metadata uint32) []byte {
+		var buf bytes.Buffer
+		buf.WriteString("OAVI")
+		for _, v := range []any{
+			uint32(1), uint32(6), []byte("cosine"), uint32(11), []byte("brute_force"),
+			dimensions, uint32(0), uint32(0), uint32(0), count,
+			uint32(1), []byte("a"), metadata,
+		} {
+			_ = binary.Write(&buf, binary.LittleEndian, v)
+		}
+		return buf.Bytes()
+	}
+
+	_, err := openai.ReadVectorIndex(bytes.NewReader(header(1<<30, 1, 0)))
+	checks.ErrorIs(t, err, openai.ErrVectorIndexInvalidFormat, "huge dimensions should be rejected")
+	// A huge record or metadata count must fail at the end of the input, not
+	// allocate for records that are not there.
+	_, err = openai.ReadVectorIndex(bytes.NewReader(header(3, 1<<31, 1<<31)))
+	checks.ErrorIs(t, err, openai.ErrVectorIndexInvalidFormat, "truncated records should be rejected")
+}
+
+func TestVectorIndexRejectsHNSWMBelowTwo(t *testing.T) {
+	_, err := openai.NewVectorIndex(openai.VectorIndexOptions{
+		Algorithm: openai.VectorSearchHNSW,
+		HNSW:      openai.HNSWOptions{M: 1},
+	})
+	checks.ErrorIs(t, err, openai.ErrVectorIndexInvalidFormat, "M of 1 should be rejected")
+
+	var buf bytes.Buffer
+	buf.WriteString("OAVI")
+	for _, v := range []any{
+		uint32(1), uint32(6), []byte("cosine"), uint32(4), []byte("hnsw"),
+		uint32(2), uint32(1), uint32(200), uint32(64), uint32(1),
+		uint32(1), []byte("a"), uint32(0), []float32{1, 0},
+	} {
+		_ = binary.Write(&buf, binary.LittleEndian, v)
+	}
+	_, err = openai.ReadVectorIndex(&buf)
+	checks.ErrorIs(t, err, openai.ErrVectorIndexInvalidFormat, "a file with M of 1 should be rejected")
+}
+
+func TestVectorIndexFailedAddLeavesIndexUnchanged(t *testing.T) {
+	ix, err := openai.NewVectorIndex(openai.VectorIndexOptions{})
+	checks.NoError(t, err, "NewVectorIndex error")
+	err = ix.Add(
+		openai.VectorRecord{ID: "a", Vector: []float32{1, 0, 0}},
+		openai.VectorRecord{ID: "b", Vector: []float32{1, 0}},
+	)
+	checks.ErrorIs(t, err, openai.ErrVectorLengthMismatch, "mismatched batch should be rejected")
+
+	// The failed batch must not have fixed the index at 3 dimensions.
+	checks.NoError(t, ix.Add(openai.VectorRecord{ID: "c", Vector: []float32{0, 1}}))
+	if ix.Len() != 1 {
+		t.Fatalf("expected only the later record, got %d", ix.Len())
+	}
+}
+
+func TestVectorIndexBinaryLayoutMatchesBase64Embeddings(t *testing.T) {
+	vector := []float32{0.5, -0.25, 1e-3}
+	ix, err := openai.NewVectorIndex(openai.VectorIndexOptions{})
+	checks.NoError(t, err, "NewVectorIndex error")
+	checks.NoError(t, ix.Add(openai.VectorRecord{ID: "a", Vector: vector}))
+
+	var buf bytes.Buffer
+	_, err = ix.WriteTo(&buf)
+	checks.NoError(t, err, "WriteTo error")
+
+	// The vector is the last thing written for a single record.
+	raw := buf.Bytes()[buf.Len()-len(vector)*4:]
+	payload, _ := json.Marshal(map[string]any{
+		"data": []map[string]any{{"embedding": base64.StdEncoding.EncodeToString(raw)}},
+	})
+	var res openai.EmbeddingResponseBase64
+	checks.NoError(t, json.Unmarshal(payload, &res), "Unmarshal error")
+	decoded, err := res.ToEmbeddingResponse()
+	checks.NoError(t, err, "ToEmbeddingResponse error")
+	for i, f := range decoded.Data[0].Embedding {
+		if f != vector[i] {
+			t.Fatalf("decoded %v, expected %v", decoded.Data[0].Embedding, vector)
+		}
+	}
+}
+
+func TestVectorIndexConcurrentAccess(t *testing.T) {
+	ix, err := openai.NewVectorIndex(openai.VectorIndexOptions{Algorithm: openai.VectorSearchHNSW})
+	checks.NoError(t, err, "NewVectorIndex error")
+	vectors := randomVectors(200, 8, 3)
+
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		w := w
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := w; i < len(vectors); i += 4 {
+				checks.NoError(t, ix.AddEmbedding(fmt.Sprint(i), openai.Embedding{Embedding: vectors[i]}, nil))
+				_, searchErr := ix.Search(vectors[i], 3, nil)
+				checks.NoError(t, searchErr, "Search error")
+			}
+		}()
+	}
+	wg.Wait()
+	if ix.Len() != len(vectors) {
+		t.Errorf("expected %d records, got %d", len(vectors), ix.Len())
+	}
+}
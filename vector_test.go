@@ -0,0 +1,108 @@
+package openai_test
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/gradientlabs-ai/go-openai"
+	"github.com/gradientlabs-ai/go-openai/internal/test/checks"
+)
+
+func almostEqual(a, b float32) bool {
+	return math.Abs(float64(a-b)) < 1e-5
+}
+
+func TestCosineSimilarity(t *testing.T) {
+	cases := []struct {
+		name     string
+		a, b     []float32
+		expected float32
+	}{
+		{name: "identical", a: []float32{1, 2, 3}, b: []float32{1, 2, 3}, expected: 1},
+		{name: "scaled", a: []float32{1, 2, 3}, b: []float32{2, 4, 6}, expected: 1},
+		{name: "orthogonal", a: []float32{1, 0}, b: []float32{0, 1}, expected: 0},
+		{name: "opposite", a: []float32{1, 0}, b: []float32{-1, 0}, expected: -1},
+		{name: "zero vector", a: []float32{0, 0}, b: []float32{1, 0}, expected: 0},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := openai.CosineSimilarity(c.a, c.b)
+			checks.NoError(t, err, "CosineSimilarity error")
+			if !almostEqual(got, c.expected) {
+				t.Errorf("CosineSimilarity = %v, expected %v", got, c.expected)
+			}
+		})
+	}
+
+	_, err := openai.CosineSimilarity([]float32{1}, []float32{1, 2})
+	checks.ErrorIs(t, err, openai.ErrVectorLengthMismatch, "length mismatch should be reported")
+}
+
+func TestL2Distance(t *testing.T) {
+	got, err := openai.L2Distance([]float32{0, 0}, []float32{3, 4})
+	checks.NoError(t, err, "L2Distance error")
+	if !almostEqual(got, 5) {
+		t.Errorf("L2Distance = %v, expected 5", got)
+	}
+
+	_, err = openai.L2Distance([]float32{1}, []float32{1, 2})
+	checks.ErrorIs(t, err, openai.ErrVectorLengthMismatch, "length mismatch should be reported")
+}
+
+func TestNormalizeAndTruncateVector(t *testing.T) {
+	v := []float32{3, 4}
+	n := openai.NormalizeVector(v)
+	if !almostEqual(n[0], 0.6) || !almostEqual(n[1], 0.8) {
+		t.Errorf("NormalizeVector = %v", n)
+	}
+	if v[0] != 3 {
+		t.Error("NormalizeVector must not modify its input")
+	}
+	if z := openai.NormalizeVector([]float32{0, 0}); z[0] != 0 || z[1] != 0 {
+		t.Errorf("zero vector should stay zero, got %v", z)
+	}
+
+	truncated, err := openai.TruncateVector([]float32{3, 4, 12}, 2)
+	checks.NoError(t, err, "TruncateVector error")
+	if len(truncated) != 2 || !almostEqual(truncated[0], 0.6) || !almostEqual(truncated[1], 0.8) {
+		t.Errorf("TruncateVector = %v", truncated)
+	}
+
+	for _, dims := range []int{0, -1, 4} {
+		_, err = openai.TruncateVector([]float32{1, 2, 3}, dims)
+		if !errors.Is(err, openai.ErrInvalidVectorDimensions) {
+			t.Errorf("TruncateVector(%d) should fail with ErrInvalidVectorDimensions, got %v", dims, err)
+		}
+	}
+}
+
+func TestEmbeddingVectorMethods(t *testing.T) {
+	a := openai.Embedding{Index: 7, Embedding: []float32{3, 4, 0}}
+	b := openai.Embedding{Embedding: []float32{3, 4, 5}}
+
+	sim, err := a.CosineSimilarity(&b)
+	checks.NoError(t, err, "CosineSimilarity error")
+	if !almostEqual(sim, float32(25/(5*math.Sqrt(50)))) {
+		t.Errorf("CosineSimilarity = %v", sim)
+	}
+
+	dist, err := a.L2Distance(&b)
+	checks.NoError(t, err, "L2Distance error")
+	if !almostEqual(dist, 5) {
+		t.Errorf("L2Distance = %v", dist)
+	}
+
+	normalized := a.Normalized()
+	if normalized.Index != 7 || !almostEqual(normalized.Embedding[0], 0.6) {
+		t.Errorf("Normalized = %+v", normalized)
+	}
+
+	truncated, err := b.Truncated(2)
+	checks.NoError(t, err, "Truncated error")
+	if len(truncated.Embedding) != 2 || !almostEqual(truncated.Embedding[1], 0.8) {
+		t.Errorf("Truncated = %+v", truncated)
+	}
+	_, err = b.Truncated(10)
+	checks.ErrorIs(t, err, openai.ErrInvalidVectorDimensions, "truncating beyond the vector length should fail")
+}